@@ -25,10 +25,13 @@ var (
 			"collectionFields":  {Type: "map[json]"},
 			"collectionFilters": {Type: "map[json]"},
 			"collectionMethods": {Type: "array[string]"},
+			"dryRun":            {Type: "boolean", Nullable: true},
 			"pluralName":        {Type: "string"},
 			"resourceActions":   {Type: "map[json]"},
 			"resourceFields":    {Type: "map[json]"},
 			"resourceMethods":   {Type: "array[string]"},
+			"shortNames":        {Type: "array[string]", Nullable: true},
+			"summaryFields":     {Type: "array[string]", Nullable: true},
 			"version":           {Type: "map[json]"},
 		},
 		Formatter: SchemaFormatter,
@@ -41,11 +44,17 @@ var (
 		ResourceMethods:   []string{},
 		CollectionMethods: []string{},
 		ResourceFields: map[string]types.Field{
-			"code":      {Type: "string"},
-			"detail":    {Type: "string", Nullable: true},
-			"message":   {Type: "string", Nullable: true},
-			"fieldName": {Type: "string", Nullable: true},
-			"status":    {Type: "int"},
+			"code":        {Type: "string"},
+			"current":     {Type: "json", Nullable: true},
+			"detail":      {Type: "string", Nullable: true},
+			"message":     {Type: "string", Nullable: true},
+			"fieldName":   {Type: "string", Nullable: true},
+			"fieldNames":  {Type: "array[string]", Nullable: true},
+			"fingerprint": {Type: "string", Nullable: true},
+			"requestId":   {Type: "string", Nullable: true},
+			"retryAfter":  {Type: "int", Nullable: true},
+			"retryable":   {Type: "boolean", Nullable: true},
+			"status":      {Type: "int"},
 		},
 	}
 
@@ -62,6 +71,18 @@ var (
 		},
 	}
 
+	DryRun = types.Schema{
+		ID:                "dryRun",
+		Version:           Version,
+		ResourceMethods:   []string{},
+		CollectionMethods: []string{},
+		ResourceFields: map[string]types.Field{
+			"object":     {Type: "json"},
+			"changes":    {Type: "array[json]"},
+			"dependents": {Type: "array[json]"},
+		},
+	}
+
 	APIRoot = types.Schema{
 		ID:                "apiRoot",
 		Version:           Version,
@@ -79,6 +100,7 @@ var (
 		AddSchema(Schema).
 		AddSchema(Error).
 		AddSchema(Collection).
+		AddSchema(DryRun).
 		AddSchema(APIRoot)
 )
 
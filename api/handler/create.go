@@ -19,12 +19,20 @@ func CreateHandler(apiContext *types.APIContext, next types.RequestHandler) erro
 	if store == nil {
 		return httperror.NewAPIError(httperror.NotFound, "no store found")
 	}
+	if err := checkDryRun(apiContext); err != nil {
+		return err
+	}
 
 	data, err = store.Create(apiContext, apiContext.Schema, data)
 	if err != nil {
 		return err
 	}
 
+	if apiContext.DryRun {
+		writeDryRun(apiContext, nil, data)
+		return nil
+	}
+
 	apiContext.WriteResponse(http.StatusCreated, data)
 	return nil
 }
@@ -12,12 +12,33 @@ func DeleteHandler(request *types.APIContext, next types.RequestHandler) error {
 	if store == nil {
 		return httperror.NewAPIError(httperror.NotFound, "no store found")
 	}
+	if err := checkDryRun(request); err != nil {
+		return err
+	}
 
 	obj, err := store.Delete(request, request.Schema, request.ID)
 	if err != nil {
 		return err
 	}
 
+	if request.DryRun {
+		var dependents []interface{}
+		for _, dependent := range types.DryRunDependents.Value(request) {
+			dependents = append(dependents, map[string]interface{}{
+				"type":   dependent.Type,
+				"id":     dependent.ID,
+				"field":  dependent.Field,
+				"action": dependent.Action,
+			})
+		}
+		request.WriteResponse(http.StatusOK, map[string]interface{}{
+			"type":       "/meta/schemas/dryRun",
+			"object":     obj,
+			"dependents": dependents,
+		})
+		return nil
+	}
+
 	if obj == nil {
 		request.WriteResponse(http.StatusNoContent, nil)
 	} else {
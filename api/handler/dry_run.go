@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+)
+
+// ignoredChanges are set by the backing store on every write.
+var ignoredChanges = map[string]bool{
+	"resourceVersion":     true,
+	"metadata.generation": true,
+}
+
+func checkDryRun(apiContext *types.APIContext) error {
+	if apiContext.DryRun && !apiContext.Schema.DryRun {
+		return httperror.NewAPIError(httperror.InvalidOption, "dry run is not supported by "+apiContext.Schema.ID)
+	}
+	return nil
+}
+
+// writeDryRun responds with the object a dry run resulted in and its changes from existing, nil for creates.
+func writeDryRun(apiContext *types.APIContext, existing, result map[string]interface{}) {
+	changes := diff("", existing, result, []interface{}{})
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].(map[string]interface{})["field"].(string) < changes[j].(map[string]interface{})["field"].(string)
+	})
+
+	apiContext.WriteResponse(http.StatusOK, map[string]interface{}{
+		"type":    "/meta/schemas/dryRun",
+		"object":  result,
+		"changes": changes,
+	})
+}
+
+// diff appends the fields added, removed or replaced from old to new to changes, as the dot separated path of the
+// field, the operation and the old and new values.
+func diff(prefix string, old, new map[string]interface{}, changes []interface{}) []interface{} {
+	for key, newValue := range new {
+		field := strings.TrimPrefix(prefix+"."+key, ".")
+		if ignoredChanges[field] {
+			continue
+		}
+		oldValue, ok := old[key]
+		if !ok {
+			changes = append(changes, change(field, "add", nil, newValue))
+			continue
+		}
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			changes = diff(field, oldMap, newMap, changes)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, change(field, "replace", oldValue, newValue))
+		}
+	}
+	for key, oldValue := range old {
+		field := strings.TrimPrefix(prefix+"."+key, ".")
+		if _, ok := new[key]; !ok && !ignoredChanges[field] {
+			changes = append(changes, change(field, "remove", oldValue, nil))
+		}
+	}
+	return changes
+}
+
+func change(field, op string, old, new interface{}) map[string]interface{} {
+	result := map[string]interface{}{
+		"field": field,
+		"op":    op,
+	}
+	if old != nil {
+		result["old"] = old
+	}
+	if new != nil {
+		result["new"] = new
+	}
+	return result
+}
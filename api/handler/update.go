@@ -2,9 +2,11 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
 )
 
 func UpdateHandler(apiContext *types.APIContext, next types.RequestHandler) error {
@@ -18,11 +20,67 @@ func UpdateHandler(apiContext *types.APIContext, next types.RequestHandler) erro
 		return httperror.NewAPIError(httperror.NotFound, "no store found")
 	}
 
+	// the resourceVersion a client read back only locks the update when asked for, by If-Match or the schema
+	if version := ifMatch(apiContext); version != "" {
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		data["resourceVersion"] = version
+	} else if !apiContext.Schema.RequireVersion {
+		delete(data, "resourceVersion")
+	}
+	if apiContext.Schema.RequireVersion && convert.IsAPIObjectEmpty(data["resourceVersion"]) {
+		return httperror.NewFieldAPIError(httperror.MissingRequired, "resourceVersion", "the version of the resource being updated is required")
+	}
+	if err := checkDryRun(apiContext); err != nil {
+		return err
+	}
+
+	var existing map[string]interface{}
+	if apiContext.DryRun {
+		if existing, err = store.ByID(apiContext, apiContext.Schema, apiContext.ID); err != nil {
+			return err
+		}
+	}
+
 	data, err = store.Update(apiContext, apiContext.Schema, data, apiContext.ID)
+	if httperror.IsConflict(err) {
+		return withCurrent(apiContext, store, err)
+	}
 	if err != nil {
 		return err
 	}
 
+	if apiContext.DryRun {
+		writeDryRun(apiContext, existing, data)
+		return nil
+	}
+
 	apiContext.WriteResponse(http.StatusOK, data)
 	return nil
 }
+
+func ifMatch(apiContext *types.APIContext) string {
+	if apiContext.Request == nil {
+		return ""
+	}
+	return strings.Trim(apiContext.Request.Header.Get("If-Match"), `"`)
+}
+
+// withCurrent adds the current state of the resource to a Conflict error, if the store didn't.
+func withCurrent(apiContext *types.APIContext, store types.Store, err error) error {
+	apiError := err.(*httperror.APIError)
+	if apiError.Current != nil {
+		return err
+	}
+	current, getErr := store.ByID(apiContext, apiContext.Schema, apiContext.ID)
+	if getErr != nil {
+		return err
+	}
+	return &httperror.APIError{
+		Code:    apiError.Code,
+		Message: apiError.Message,
+		Cause:   apiError.Cause,
+		Current: current,
+	}
+}
@@ -26,6 +26,12 @@ func ParseAndValidateBody(apiContext *types.APIContext, create bool) (map[string
 	op := builder.Create
 	if !create {
 		op = builder.Update
+		// rules are checked on the object resulting from the update
+		if len(apiContext.Schema.Rules) > 0 && apiContext.Schema.Store != nil {
+			if b.Existing, err = apiContext.Schema.Store.ByID(apiContext, apiContext.Schema, apiContext.ID); err != nil {
+				return nil, err
+			}
+		}
 	}
 	if apiContext.Schema.InputFormatter != nil {
 		err = apiContext.Schema.InputFormatter(apiContext, apiContext.Schema, data, create)
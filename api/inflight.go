@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+)
+
+// schemaKey identifies a schema and the copies served for it by profiles.
+type schemaKey struct {
+	version string
+	id      string
+}
+
+func keyOf(schema *types.Schema) schemaKey {
+	return schemaKey{
+		version: schema.Version.Path,
+		id:      schema.ID,
+	}
+}
+
+// inflight counts the requests being served by each schema, so that removed and replaced schemas can be drained.
+// Requests are numbered so that draining waits for the requests started before it, not for the new ones.
+type inflight struct {
+	lock    sync.Mutex
+	next    uint64
+	active  map[schemaKey]map[uint64]bool
+	waiters map[schemaKey][]drainWaiter
+}
+
+type drainWaiter struct {
+	upTo    uint64
+	drained chan struct{}
+}
+
+func (i *inflight) start(schema *types.Schema) func() {
+	key := keyOf(schema)
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.active == nil {
+		i.active = map[schemaKey]map[uint64]bool{}
+		i.waiters = map[schemaKey][]drainWaiter{}
+	}
+	i.next++
+	seq := i.next
+	if i.active[key] == nil {
+		i.active[key] = map[uint64]bool{}
+	}
+	i.active[key][seq] = true
+
+	return func() {
+		i.lock.Lock()
+		defer i.lock.Unlock()
+		delete(i.active[key], seq)
+		if len(i.active[key]) == 0 {
+			delete(i.active, key)
+		}
+
+		var waiting []drainWaiter
+		for _, waiter := range i.waiters[key] {
+			if i.drained(key, waiter.upTo) {
+				close(waiter.drained)
+			} else {
+				waiting = append(waiting, waiter)
+			}
+		}
+		if len(waiting) == 0 {
+			delete(i.waiters, key)
+		} else {
+			i.waiters[key] = waiting
+		}
+	}
+}
+
+// drained returns whether the requests of key up to upTo completed.
+func (i *inflight) drained(key schemaKey, upTo uint64) bool {
+	for seq := range i.active[key] {
+		if seq <= upTo {
+			return false
+		}
+	}
+	return true
+}
+
+// wait returns once the requests schema was serving, through any copy, complete, or with the error of ctx.
+func (i *inflight) wait(ctx context.Context, schema *types.Schema) error {
+	key := keyOf(schema)
+
+	i.lock.Lock()
+	if i.drained(key, i.next) {
+		i.lock.Unlock()
+		return nil
+	}
+	c := make(chan struct{})
+	i.waiters[key] = append(i.waiters[key], drainWaiter{upTo: i.next, drained: c})
+	i.lock.Unlock()
+
+	select {
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("requests to schema %s did not complete: %w", schema.ID, ctx.Err())
+	}
+}
+
+// startRequest counts the request as served by its schema until done is called. Requests parsed before their
+// schema was replaced are served by the replacement, those parsed before it was removed fail.
+func (s *Server) startRequest(apiRequest *types.APIContext) (func(), error) {
+	for {
+		done := s.root().inflight.start(apiRequest.Schema)
+		schemas := s.currentSchemas(apiRequest)
+		current := schemas.Schema(&apiRequest.Schema.Version, apiRequest.Schema.ID)
+		if current == apiRequest.Schema {
+			return done, nil
+		}
+		done()
+		if current == nil {
+			return nil, httperror.NewAPIError(httperror.NotFound, "schema "+apiRequest.Schema.ID+" was removed")
+		}
+		apiRequest.Schemas = schemas
+		apiRequest.Schema = current
+	}
+}
+
+// RemoveSchema stops serving the schema id of version, new requests get a NotFound error, and waits until the
+// requests it was serving complete or ctx is done. Its store can be closed once it returns without error.
+func (s *Server) RemoveSchema(ctx context.Context, version *types.APIVersion, id string) error {
+	schema := s.Schemas.Schema(version, id)
+	if schema == nil {
+		return fmt.Errorf("schema %s not found in %s", id, version.Path)
+	}
+	s.Schemas.RemoveSchema(*schema)
+	return s.root().inflight.wait(ctx, schema)
+}
+
+// ReplaceSchema serves schema in place of the schema with the same ID and version, and waits until the requests
+// the replaced schema was serving complete or ctx is done. New requests are served by schema right away.
+func (s *Server) ReplaceSchema(ctx context.Context, schema types.Schema) error {
+	if existing := s.Schemas.ReplaceSchema(schema); existing != nil {
+		if err := s.Schemas.Err(); err != nil {
+			return err
+		}
+		return s.root().inflight.wait(ctx, existing)
+	}
+	return s.Schemas.Err()
+}
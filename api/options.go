@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/slice"
+)
+
+// handleOptions answers an OPTIONS request with the methods the caller may use on the URL, as allowed by the
+// schema and the access control.
+func handleOptions(rw http.ResponseWriter, apiContext *types.APIContext) error {
+	rw.Header().Set("Allow", strings.Join(append(allowedMethods(apiContext), http.MethodOptions), ", "))
+	rw.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func allowedMethods(apiContext *types.APIContext) []string {
+	schema := apiContext.Schema
+
+	methods := []string{http.MethodGet}
+	switch {
+	case apiContext.Link != "":
+		if subResource, ok := schema.SubResources[apiContext.Link]; ok && len(subResource.Methods) > 0 {
+			methods = subResource.Methods
+		}
+	case apiContext.ID == "":
+		methods = append(append([]string{}, schema.CollectionMethods...), http.MethodPost)
+	default:
+		methods = append(append([]string{}, schema.ResourceMethods...), http.MethodPost)
+	}
+
+	var result []string
+	for _, method := range methods {
+		if !slice.ContainsString(result, method) && canDo(apiContext, method) {
+			result = append(result, method)
+		}
+	}
+	return result
+}
+
+func canDo(apiContext *types.APIContext, method string) bool {
+	ac, schema := apiContext.AccessControl, apiContext.Schema
+	switch method {
+	case http.MethodGet:
+		if apiContext.ID == "" {
+			return ac.CanList(apiContext, schema) == nil
+		}
+		return ac.CanGet(apiContext, schema) == nil
+	case http.MethodPost:
+		if apiContext.Link != "" {
+			return ac.CanUpdate(apiContext, nil, schema) == nil
+		}
+		actions := schema.ResourceActions
+		if apiContext.ID == "" {
+			if slice.ContainsString(schema.CollectionMethods, http.MethodPost) && ac.CanCreate(apiContext, schema) == nil {
+				return true
+			}
+			actions = schema.CollectionActions
+		}
+		actionAccess, ok := ac.(types.ActionAccessControl)
+		if !ok {
+			return len(actions) > 0
+		}
+		for name := range actions {
+			if actionAccess.CanAction(apiContext, nil, schema, name) == nil {
+				return true
+			}
+		}
+		return false
+	case http.MethodDelete:
+		return ac.CanDelete(apiContext, nil, schema) == nil
+	default:
+		return ac.CanUpdate(apiContext, nil, schema) == nil
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+)
+
+// ForProfile returns a new server exposing the schemas of s selected by profile, with the handlers, stores and
+// access control of s, to be served on another listen address or path prefix. The new server shares the read-only
+// mode and middlewares of s. A custom Parser isn't copied.
+func (s *Server) ForProfile(profile types.Profile) (*Server, error) {
+	server := NewAPIServer()
+	server.parent = s
+	server.IgnoreBuiltin = s.IgnoreBuiltin
+	server.Resolver = s.Resolver
+	server.SubContextAttributeProvider = s.SubContextAttributeProvider
+	server.ResponseWriters = s.ResponseWriters
+	server.QueryFilter = s.QueryFilter
+	server.StoreWrapper = s.StoreWrapper
+	server.URLParser = s.URLParser
+	server.Defaults = s.Defaults
+	server.AccessControl = s.AccessControl
+	server.Authenticator = s.Authenticator
+	server.RoleResolver = s.RoleResolver
+	server.ReferenceValidator = s.ReferenceValidator
+	server.ProfileResolver = s.ProfileResolver
+	return server, server.AddSchemas(s.Schemas.Profile(profile))
+}
+
+// root returns the server s was made from by ForProfile, s if none.
+func (s *Server) root() *Server {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+// requestProfile is the profile resolved for a request, see applyProfile.
+var requestProfile = types.NewContextKey[*types.Profile]("profile")
+
+// profileCache keeps the schemas of the profiles resolved for requests until a schema of the server is added,
+// replaced or removed.
+type profileCache struct {
+	lock       sync.Mutex
+	generation uint64
+	schemas    map[string]*types.Schemas
+}
+
+func (p *profileCache) get(schemas *types.Schemas, profile types.Profile) *types.Schemas {
+	generation := schemas.Generation()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.generation != generation || p.schemas == nil {
+		p.generation = generation
+		p.schemas = map[string]*types.Schemas{}
+	}
+	if result, ok := p.schemas[profile.Name]; ok {
+		return result
+	}
+	result := schemas.Profile(profile)
+	p.schemas[profile.Name] = result
+	return result
+}
+
+// applyProfile serves the request the schemas of the profile of its tenant, if the server resolves one.
+func (s *Server) applyProfile(apiRequest *types.APIContext) error {
+	if s.ProfileResolver == nil {
+		return nil
+	}
+	profile := s.ProfileResolver(apiRequest)
+	if profile == nil {
+		return nil
+	}
+
+	requestProfile.Set(apiRequest, profile)
+	apiRequest.Schemas = s.profiles.get(s.Schemas, *profile)
+	if apiRequest.Schema == nil {
+		return nil
+	}
+	schema := apiRequest.Schemas.Schema(apiRequest.Version, apiRequest.Schema.ID)
+	if schema == nil {
+		return httperror.NewAPIError(httperror.NotFound, "failed to find schema "+apiRequest.Schema.ID)
+	}
+	apiRequest.Schema = schema
+	return nil
+}
+
+// currentSchemas returns the schemas serving apiRequest now, those of its profile if it has one.
+func (s *Server) currentSchemas(apiRequest *types.APIContext) *types.Schemas {
+	if profile := requestProfile.Value(apiRequest); profile != nil {
+		return s.profiles.get(s.Schemas, *profile)
+	}
+	return apiRequest.Schemas
+}
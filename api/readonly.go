@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ReadOnlyStatus is the read-only mode of the server, the messages explain the mode to the rejected clients.
+type ReadOnlyStatus struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	// Schemas are the messages of the schemas in read-only mode by schema ID
+	Schemas map[string]string `json:"schemas,omitempty"`
+	// Standby is the message of the standby mode, see SetStandby, empty when not standing by
+	Standby string `json:"standby,omitempty"`
+}
+
+type readOnly struct {
+	lock    sync.RWMutex
+	enabled bool
+	message string
+	schemas map[string]string
+	standby string
+}
+
+// check returns an error for the mutating requests while apiContext's schema is read only.
+func (r *readOnly) check(apiContext *types.APIContext) error {
+	switch apiContext.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.enabled {
+		return httperror.NewAPIError(httperror.ReadOnly, readOnlyMessage("API is read only", r.message))
+	}
+	if r.standby != "" {
+		return httperror.NewAPIError(httperror.ReadOnly, readOnlyMessage("API is read only", r.standby))
+	}
+	if message, ok := r.schemas[apiContext.Schema.ID]; ok {
+		return httperror.NewAPIError(httperror.ReadOnly, readOnlyMessage(apiContext.Schema.ID+" is read only", message))
+	}
+	return nil
+}
+
+func readOnlyMessage(prefix, message string) string {
+	if message == "" {
+		return prefix
+	}
+	return prefix + ": " + message
+}
+
+// SetReadOnly rejects the create, update, delete and action requests of every schema while enabled, reads and
+// watches are still served.
+func (s *Server) SetReadOnly(enabled bool, message string) {
+	r := &s.root().readOnly
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.enabled = enabled
+	r.message = message
+	logrus.Infof("API read-only mode enabled: %v", enabled)
+}
+
+// SetStandby rejects the mutating requests of every schema while the server stands by, with message, until called
+// with an empty message. Standing by is independent of SetReadOnly, so leaving standby keeps the read-only mode set
+// by operators.
+func (s *Server) SetStandby(message string) {
+	r := &s.root().readOnly
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.standby = message
+	logrus.Infof("API standby mode enabled: %v", message != "")
+}
+
+// SetSchemaReadOnly rejects the mutating requests of the schema with schemaID while enabled.
+func (s *Server) SetSchemaReadOnly(schemaID string, enabled bool, message string) {
+	r := &s.root().readOnly
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !enabled {
+		delete(r.schemas, schemaID)
+		return
+	}
+	if r.schemas == nil {
+		r.schemas = map[string]string{}
+	}
+	r.schemas[schemaID] = message
+}
+
+// ReadOnly returns the read-only mode of the server.
+func (s *Server) ReadOnly() ReadOnlyStatus {
+	r := &s.root().readOnly
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	status := ReadOnlyStatus{
+		Enabled: r.enabled,
+		Message: r.message,
+		Standby: r.standby,
+	}
+	if len(r.schemas) > 0 {
+		status.Schemas = map[string]string{}
+		for schemaID, message := range r.schemas {
+			status.Schemas[schemaID] = message
+		}
+	}
+	return status
+}
+
+func (s *Server) setReadOnlyStatus(status ReadOnlyStatus) {
+	r := &s.root().readOnly
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.enabled = status.Enabled
+	r.message = status.Message
+	r.schemas = map[string]string{}
+	for schemaID, message := range status.Schemas {
+		r.schemas[schemaID] = message
+	}
+	logrus.Infof("API read-only mode enabled: %v, read-only schemas: %d", status.Enabled, len(status.Schemas))
+}
+
+// ReadOnlyHandler serves ReadOnly as JSON on GET and replaces it with the ReadOnlyStatus of the body on PUT, except
+// for the standby mode, it is meant to be mounted on an internal endpoint.
+func (s *Server) ReadOnlyHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var status ReadOnlyStatus
+			if err := json.NewDecoder(req.Body).Decode(&status); err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.setReadOnlyStatus(status)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(s.ReadOnly())
+	})
+}
+
+// ToggleReadOnlyOnSignal switches the server-wide read-only mode on and off each time one of signals is received,
+// until ctx is done.
+func (s *Server) ToggleReadOnlyOnSignal(ctx context.Context, signals ...os.Signal) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+	go func() {
+		defer signal.Stop(c)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c:
+				status := s.ReadOnly()
+				s.SetReadOnly(!status.Enabled, status.Message)
+			}
+		}
+	}()
+}
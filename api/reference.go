@@ -0,0 +1,48 @@
+package api
+
+import (
+	"github.com/rancher/norman/types"
+)
+
+// NewReferenceValidator returns a validator reading referenced objects from the store of their schema with the
+// access of the request. Set it as the ReferenceValidator of a Server to reject creates and updates setting a
+// reference field to an object that doesn't exist. Resource actions are then also validated against the actions
+// the formatter of the schema adds to the object.
+func NewReferenceValidator(apiContext *types.APIContext) types.ReferenceValidator {
+	return &referenceValidator{
+		apiContext: apiContext,
+	}
+}
+
+type referenceValidator struct {
+	apiContext *types.APIContext
+}
+
+func (r *referenceValidator) Validate(resourceType, resourceID string) bool {
+	return r.Lookup(resourceType, resourceID) != nil
+}
+
+func (r *referenceValidator) Lookup(resourceType, resourceID string) *types.RawResource {
+	schema := r.apiContext.Schemas.Schema(r.apiContext.Version, resourceType)
+	if schema == nil || schema.Store == nil || schema.CanGet(r.apiContext) != nil {
+		return nil
+	}
+
+	data, err := schema.Store.ByID(r.apiContext, schema, resourceID)
+	if err != nil || data == nil {
+		return nil
+	}
+
+	resource := &types.RawResource{
+		ID:      resourceID,
+		Type:    schema.ID,
+		Schema:  schema,
+		Links:   map[string]string{},
+		Actions: map[string]string{},
+		Values:  data,
+	}
+	if schema.Formatter != nil {
+		schema.Formatter(r.apiContext, resource)
+	}
+	return resource
+}
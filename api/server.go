@@ -1,8 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
-	"runtime/debug"
+	"strings"
 	"sync"
 
 	"github.com/rancher/norman/api/access"
@@ -15,7 +16,7 @@ import (
 	"github.com/rancher/norman/parse"
 	"github.com/rancher/norman/store/wrapper"
 	"github.com/rancher/norman/types"
-	"github.com/sirupsen/logrus"
+	"github.com/rancher/norman/types/slice"
 )
 
 type StoreWrapper func(types.Store) types.Store
@@ -35,6 +36,21 @@ type Server struct {
 	URLParser                   parse.URLParser
 	Defaults                    Defaults
 	AccessControl               types.AccessControl
+	Authenticator               types.Authenticator
+	RoleResolver                types.RoleResolver
+	// ReferenceValidator, if set, returns the validator of the references set by a request, see NewReferenceValidator
+	ReferenceValidator func(apiContext *types.APIContext) types.ReferenceValidator
+	// ProfileResolver, if set, returns the profile of the schemas served to the tenant or group of the user of a
+	// request, nil to serve every schema. Profiles set the defaults and hidden fields of a tenant, and their
+	// schemas are cached by Name.
+	ProfileResolver func(apiContext *types.APIContext) *types.Profile
+
+	inflight inflight
+	profiles profileCache
+	stats    usageStats
+	readOnly readOnly
+	// parent is the server a profile server was made from, which holds the read-only mode and middlewares
+	parent *Server
 }
 
 type Defaults struct {
@@ -106,6 +122,9 @@ func (s *Server) parser(rw http.ResponseWriter, req *http.Request) (*types.APICo
 	}
 
 	ctx.AccessControl = s.AccessControl
+	if s.ReferenceValidator != nil && ctx.ReferenceValidator == nil {
+		ctx.ReferenceValidator = s.ReferenceValidator(ctx)
+	}
 
 	return ctx, err
 }
@@ -127,6 +146,7 @@ func (s *Server) AddSchemas(schemas *types.Schemas) error {
 	for _, schema := range schemas.Schemas() {
 		s.Schemas.AddSchema(*schema)
 	}
+	s.Schemas.UseMiddlewares(schemas)
 
 	return s.Schemas.Err()
 }
@@ -164,34 +184,87 @@ func (s *Server) setupDefaults(schema *types.Schema) {
 		schema.ErrorHandler = s.Defaults.ErrorHandler
 	}
 
-	if schema.Store != nil && s.StoreWrapper != nil {
-		schema.Store = s.StoreWrapper(schema.Store)
+	if _, ok := schema.Store.(*statsStore); schema.Store != nil && !ok {
+		if s.StoreWrapper != nil {
+			schema.Store = s.StoreWrapper(schema.Store)
+		}
+		schema.Store = &statsStore{
+			Store: schema.Store,
+			stats: &s.stats,
+		}
 	}
 }
 
 func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	defer func() {
-		if err := recover(); err != nil && err != http.ErrAbortHandler {
-			logrus.Error("Panic serving api request: \n" + string(debug.Stack()))
-			rw.WriteHeader(http.StatusInternalServerError)
-		}
-	}()
+	requestID := parse.RequestID(req)
+	req.Header.Set(types.RequestIDHeader, requestID)
+	rw.Header().Set(types.RequestIDHeader, requestID)
+
+	// the panics of the parser and the error handlers, which handle can not answer through the error handler of
+	// the schema, are answered with a server error too
+	ehandler.Recovery(http.HandlerFunc(s.serve)).ServeHTTP(rw, req)
+}
 
+func (s *Server) serve(rw http.ResponseWriter, req *http.Request) {
 	if apiResponse, err := s.handle(rw, req); err != nil {
 		s.handleError(apiResponse, err)
 	}
 }
 
-func (s *Server) handle(rw http.ResponseWriter, req *http.Request) (*types.APIContext, error) {
-	apiRequest, err := s.Parser(rw, req)
+// impersonate replaces the impersonation headers sent by the client, which the stores forward to the apiserver, with
+// the authenticated user. Anonymous requests impersonate the Kubernetes anonymous user.
+func impersonate(req *http.Request, user *types.User) {
+	for header := range req.Header {
+		if strings.HasPrefix(header, "Impersonate-") {
+			req.Header.Del(header)
+		}
+	}
+	if user == nil {
+		user = &types.User{Name: "system:anonymous", Groups: []string{"system:unauthenticated"}}
+	}
+	req.Header.Set("Impersonate-User", user.Name)
+	for _, group := range user.Groups {
+		req.Header.Add("Impersonate-Group", group)
+	}
+}
+
+func (s *Server) handle(rw http.ResponseWriter, req *http.Request) (apiRequest *types.APIContext, err error) {
+	apiRequest, err = s.Parser(rw, req)
 	if err != nil {
 		return apiRequest, err
 	}
 
+	// panics are answered with an error through the error handler of the schema, like returned errors
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			err = httperror.Recovered(recovered, apiRequest.RequestID)
+		}
+	}()
+
+	if s.Authenticator != nil {
+		user, err := s.Authenticator.Authenticate(req)
+		if err != nil {
+			return apiRequest, err
+		}
+		apiRequest.User = user
+		impersonate(req, user)
+	}
+	apiRequest.RoleResolver = s.RoleResolver
+	if err := s.applyProfile(apiRequest); err != nil {
+		return apiRequest, err
+	}
+
 	if err := CheckCSRF(apiRequest); err != nil {
 		return apiRequest, err
 	}
 
+	if err := parse.ValidateScope(apiRequest); err != nil {
+		return apiRequest, err
+	}
+
 	action, err := ValidateAction(apiRequest)
 	if err != nil {
 		return apiRequest, err
@@ -200,6 +273,52 @@ func (s *Server) handle(rw http.ResponseWriter, req *http.Request) (*types.APICo
 	if apiRequest.Schema == nil {
 		return apiRequest, nil
 	}
+	if err := s.root().readOnly.check(apiRequest); err != nil {
+		return apiRequest, err
+	}
+	done, err := s.startRequest(apiRequest)
+	if err != nil {
+		return apiRequest, err
+	}
+	defer done()
+	s.stats.request(apiRequest)
+	if apiRequest.ResponseWriter != nil {
+		apiRequest.ResponseWriter = &statsResponseWriter{
+			ResponseWriter: apiRequest.ResponseWriter,
+			stats:          &s.stats,
+		}
+	}
+
+	if apiRequest.Method == http.MethodGet && apiRequest.Schema.ID == builtin.Schema.ID {
+		// the schemas are served in the negotiated format, so the same schemas have an ETag per format
+		etag := `"` + apiRequest.Schemas.Hash() + "-" + apiRequest.ResponseFormat + `"`
+		rw.Header().Set("ETag", etag)
+		if req.Header.Get("If-None-Match") == etag {
+			rw.WriteHeader(http.StatusNotModified)
+			return apiRequest, nil
+		}
+	}
+
+	if apiRequest.Method == http.MethodOptions {
+		return apiRequest, handleOptions(rw, apiRequest)
+	}
+
+	if timeout := apiRequest.Schema.Timeout(apiRequest.Method); timeout > 0 {
+		return apiRequest, handleWithTimeout(apiRequest, timeout, func(apiRequest *types.APIContext) error {
+			return s.root().Schemas.Handle(apiRequest, func() error {
+				return s.dispatch(apiRequest, action)
+			})
+		})
+	}
+	return apiRequest, s.root().Schemas.Handle(apiRequest, func() error {
+		return s.dispatch(apiRequest, action)
+	})
+}
+
+func (s *Server) dispatch(apiRequest *types.APIContext, action *types.Action) error {
+	if subResource, ok := apiRequest.Schema.SubResources[apiRequest.Link]; ok && apiRequest.ID != "" {
+		return handleSubResource(subResource, apiRequest)
+	}
 
 	if action == nil && apiRequest.Type != "" {
 		var handler types.RequestHandler
@@ -209,30 +328,30 @@ func (s *Server) handle(rw http.ResponseWriter, req *http.Request) (*types.APICo
 			case http.MethodGet:
 				if apiRequest.ID == "" {
 					if err := apiRequest.AccessControl.CanList(apiRequest, apiRequest.Schema); err != nil {
-						return apiRequest, err
+						return err
 					}
 				} else {
 					if err := apiRequest.AccessControl.CanGet(apiRequest, apiRequest.Schema); err != nil {
-						return apiRequest, err
+						return err
 					}
 				}
 				handler = apiRequest.Schema.ListHandler
 				nextHandler = s.Defaults.ListHandler
 			case http.MethodPost:
 				if err := apiRequest.AccessControl.CanCreate(apiRequest, apiRequest.Schema); err != nil {
-					return apiRequest, err
+					return err
 				}
 				handler = apiRequest.Schema.CreateHandler
 				nextHandler = s.Defaults.CreateHandler
 			case http.MethodPut:
 				if err := apiRequest.AccessControl.CanUpdate(apiRequest, nil, apiRequest.Schema); err != nil {
-					return apiRequest, err
+					return err
 				}
 				handler = apiRequest.Schema.UpdateHandler
 				nextHandler = s.Defaults.UpdateHandler
 			case http.MethodDelete:
 				if err := apiRequest.AccessControl.CanDelete(apiRequest, nil, apiRequest.Schema); err != nil {
-					return apiRequest, err
+					return err
 				}
 				handler = apiRequest.Schema.DeleteHandler
 				nextHandler = s.Defaults.DeleteHandler
@@ -243,15 +362,15 @@ func (s *Server) handle(rw http.ResponseWriter, req *http.Request) (*types.APICo
 		}
 
 		if handler == nil {
-			return apiRequest, httperror.NewAPIError(httperror.NotFound, "")
+			return httperror.NewAPIError(httperror.NotFound, "")
 		}
 
-		return apiRequest, handler(apiRequest, nextHandler)
+		return handler(apiRequest, nextHandler)
 	} else if action != nil {
-		return apiRequest, handleAction(action, apiRequest)
+		return handleAction(action, apiRequest)
 	}
 
-	return apiRequest, nil
+	return nil
 }
 
 func handleAction(action *types.Action, context *types.APIContext) error {
@@ -263,6 +382,30 @@ func handleAction(action *types.Action, context *types.APIContext) error {
 	return context.Schema.ActionHandler(context.Action, action, context)
 }
 
+func handleSubResource(subResource types.SubResource, context *types.APIContext) error {
+	methods := subResource.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet}
+	}
+	if !slice.ContainsString(methods, context.Method) {
+		return httperror.NewAPIError(httperror.MethodNotAllowed, fmt.Sprintf("%s is not allowed on %s", context.Method, context.Link))
+	}
+
+	verb := http.MethodGet
+	if context.Method != http.MethodGet {
+		verb = http.MethodPut
+	}
+	if err := context.AccessControl.CanDo(context.Schema.Version.Group, context.Schema.PluralName+"/"+context.Link, verb,
+		context, nil, context.Schema); err != nil {
+		return err
+	}
+
+	if err := access.ByID(context, context.Version, context.Type, context.ID, nil); err != nil {
+		return err
+	}
+	return subResource.Handler(context, nil)
+}
+
 func (s *Server) handleError(apiRequest *types.APIContext, err error) {
 	if apiRequest.Schema == nil {
 		s.Defaults.ErrorHandler(apiRequest, err)
@@ -1,16 +1,24 @@
 package api_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rancher/norman/api"
 	"github.com/rancher/norman/api/builtin"
 	"github.com/rancher/norman/api/writer"
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/httperror"
+	ehandler "github.com/rancher/norman/httperror/handler"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -135,3 +143,877 @@ func sendTestRequest(url, cssURL, jssURL, apiUIVersion string) (string, error) {
 func stringGetter(val string) writer.StringGetter {
 	return func() string { return val }
 }
+
+func TestServeRequestID(t *testing.T) {
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(builtin.Schemas))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/meta/missing", nil)
+	req.Header.Set("X-Request-Id", "test-request-id")
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Equal(t, "test-request-id", resp.Header().Get("X-Request-Id"))
+	require.Contains(t, resp.Body.String(), `"requestId":"test-request-id"`)
+
+	req = httptest.NewRequest(http.MethodGet, "http://localhost/meta/missing", nil)
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Len(t, resp.Header().Get("X-Request-Id"), 32)
+}
+
+func TestServeSchemasNotModified(t *testing.T) {
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(builtin.Schemas))
+
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/schemas", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	etag := resp.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Equal(t, `"`+resp.Header().Get("X-Api-Schemas-Hash")+`-json"`, etag)
+	require.Equal(t, "Accept", resp.Header().Get("Vary"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/meta/schemas", nil)
+	req.Header.Set("If-None-Match", etag)
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusNotModified, resp.Code)
+	require.Empty(t, resp.Body.String())
+
+	// the YAML schemas don't match the ETag of the JSON schemas
+	req = httptest.NewRequest(http.MethodGet, "http://localhost/meta/schemas", nil)
+	req.Header.Set("If-None-Match", etag)
+	req.Header.Set("Accept", "application/yaml")
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NotEqual(t, etag, resp.Header().Get("ETag"))
+}
+
+func TestServeProblemDetails(t *testing.T) {
+	srv := api.NewAPIServer()
+	srv.Defaults.ErrorHandler = ehandler.ProblemErrorHandler
+	require.NoError(t, srv.AddSchemas(builtin.Schemas))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/meta/missing", nil)
+	req.Header.Set("X-Request-Id", "test-request-id")
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	require.Equal(t, ehandler.ProblemContentType, resp.Header().Get("Content-Type"))
+
+	problem := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+	require.Equal(t, "urn:norman:error:NotFound", problem["type"])
+	require.Equal(t, "NotFound", problem["title"])
+	require.Equal(t, float64(http.StatusNotFound), problem["status"])
+	require.Equal(t, "/meta/missing", problem["instance"])
+	require.Equal(t, "test-request-id", problem["requestId"])
+}
+
+type Widget struct {
+	types.Resource
+}
+
+type widgetStore struct {
+	empty.Store
+}
+
+func (w *widgetStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	if id != "one" {
+		return nil, httperror.NewAPIError(httperror.NotFound, "no such widget "+id)
+	}
+	return map[string]interface{}{"id": id, "type": "widget"}, nil
+}
+
+func TestServeSubResource(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, Widget{}, func(schema *types.Schema) {
+		schema.Store = &widgetStore{}
+		schema.SubResources = map[string]types.SubResource{
+			"logs": {
+				Handler: func(apiContext *types.APIContext, next types.RequestHandler) error {
+					apiContext.Response.Write([]byte("log of " + apiContext.ID))
+					return nil
+				},
+			},
+		}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one/logs", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "log of one", resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Contains(t, resp.Body.String(), `"logs":"http://localhost/meta/widgets/one/logs"`)
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://localhost/meta/widgets/one/logs", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/two/logs", nil))
+	require.Equal(t, http.StatusNotFound, resp.Code)
+
+	srv.AccessControl = &denyDo{resource: "widgets/logs"}
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NotContains(t, resp.Body.String(), `"logs"`)
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one/logs", nil))
+	require.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+type denyDo struct {
+	authorization.AllAccess
+	resource string
+}
+
+func (d *denyDo) CanDo(apiGroup, resource, verb string, apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema) error {
+	if resource == d.resource {
+		return httperror.NewAPIError(httperror.PermissionDenied, "can not "+verb+" "+resource)
+	}
+	return d.AllAccess.CanDo(apiGroup, resource, verb, apiContext, obj, schema)
+}
+
+func TestServeMiddlewares(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, Widget{}, func(schema *types.Schema) {
+		schema.Store = &widgetStore{}
+	})
+	schemas.Use("deny", 0, func(apiContext *types.APIContext, next func() error) error {
+		if apiContext.Request.Header.Get("X-Deny") != "" {
+			return httperror.NewAPIError(httperror.PermissionDenied, "denied")
+		}
+		return next()
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one", nil)
+	req.Header.Set("X-Deny", "true")
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestServeOptions(t *testing.T) {
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(builtin.Schemas))
+
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodOptions, "http://localhost/meta/schemas", nil))
+	require.Equal(t, http.StatusNoContent, resp.Code)
+	require.Equal(t, "GET, OPTIONS", resp.Header().Get("Allow"))
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodOptions, "http://localhost/meta/schemas/schema", nil))
+	require.Equal(t, http.StatusNoContent, resp.Code)
+	require.Equal(t, "GET, OPTIONS", resp.Header().Get("Allow"))
+}
+
+type VersionedWidget struct {
+	types.Resource
+	ResourceVersion string `json:"resourceVersion"`
+	Version         string `json:"version"`
+}
+
+type versionedWidgetStore struct {
+	empty.Store
+}
+
+func (v *versionedWidgetStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": id, "type": schema.ID, "resourceVersion": "2"}, nil
+}
+
+func (v *versionedWidgetStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	if version, ok := data["resourceVersion"]; ok && version != "2" {
+		return nil, httperror.NewAPIError(httperror.Conflict, "out of date")
+	}
+	return v.ByID(apiContext, schema, id)
+}
+
+type LockedWidget struct {
+	VersionedWidget
+}
+
+func TestServeUpdateVersion(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, VersionedWidget{}, func(schema *types.Schema) {
+		schema.Store = &versionedWidgetStore{}
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
+	})
+	schemas.MustImportAndCustomize(&builtin.Version, LockedWidget{}, func(schema *types.Schema) {
+		schema.Store = &versionedWidgetStore{}
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
+		schema.RequireVersion = true
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	put := func(path, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "http://localhost/meta/"+path+"/one", strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// the version a client sends back doesn't lock the update unless asked for
+	resp := put("versionedwidgets", `{"resourceVersion":"1","version":"mine"}`, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = put("versionedwidgets", `{}`, `"1"`)
+	require.Equal(t, http.StatusConflict, resp.Code)
+	var conflict map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &conflict))
+	require.Equal(t, "2", conflict["current"].(map[string]interface{})["resourceVersion"])
+
+	resp = put("versionedwidgets", `{}`, `"2"`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = put("lockedwidgets", `{}`, "")
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	require.Contains(t, resp.Body.String(), `"fieldName":"resourceVersion"`)
+
+	resp = put("lockedwidgets", `{"resourceVersion":"1"}`, "")
+	require.Equal(t, http.StatusConflict, resp.Code)
+
+	resp = put("lockedwidgets", `{"resourceVersion":"2"}`, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+type SlowWidget struct {
+	types.Resource
+}
+
+type slowWidgetStore struct {
+	empty.Store
+}
+
+func (s *slowWidgetStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	if _, ok := apiContext.Request.Context().Deadline(); !ok {
+		return nil, fmt.Errorf("no deadline")
+	}
+	if _, ok := apiContext.Response.(http.Flusher); id == "stream" && !ok {
+		return nil, fmt.Errorf("response can not be flushed")
+	}
+	if id == "abort" {
+		panic(http.ErrAbortHandler)
+	}
+	if id == "slow" {
+		<-apiContext.Request.Context().Done()
+		time.Sleep(50 * time.Millisecond)
+	}
+	return map[string]interface{}{"id": id, "type": "slowWidget"}, nil
+}
+
+func TestServeTimeout(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, SlowWidget{}, func(schema *types.Schema) {
+		schema.Store = &slowWidgetStore{}
+		schema.Timeouts = map[string]time.Duration{http.MethodGet: 50 * time.Millisecond}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/slowwidgets/fast", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/slowwidgets/stream", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/slowwidgets/slow", nil))
+	require.Equal(t, http.StatusGatewayTimeout, resp.Code)
+	require.Contains(t, resp.Body.String(), `"code":"Timeout"`)
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/meta/slowwidgets/abort", nil))
+	})
+}
+
+type PreviewWidget struct {
+	types.Resource
+	Size int64 `json:"size"`
+}
+
+type previewWidgetStore struct {
+	empty.Store
+	updated bool
+}
+
+func (p *previewWidgetStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": id, "type": "previewWidget", "size": int64(1)}, nil
+}
+
+func (p *previewWidgetStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	if !apiContext.DryRun {
+		p.updated = true
+	}
+	return map[string]interface{}{"id": id, "type": "previewWidget", "size": data["size"]}, nil
+}
+
+func TestServeDryRun(t *testing.T) {
+	store := &previewWidgetStore{}
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, PreviewWidget{}, func(schema *types.Schema) {
+		schema.Store = store
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
+		schema.DryRun = true
+	})
+	schemas.MustImportAndCustomize(&builtin.Version, VersionedWidget{}, func(schema *types.Schema) {
+		schema.Store = &versionedWidgetStore{}
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	put := func(url, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, httptest.NewRequest(http.MethodPut, url, strings.NewReader(body)))
+		return resp
+	}
+
+	resp := put("http://localhost/meta/versionedwidgets/one?dryRun=true", `{"version":"2"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	resp = put("http://localhost/meta/previewwidgets/one?dryRun=true", `{"size":2}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.False(t, store.updated)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	require.Equal(t, "dryRun", result["type"])
+	require.Equal(t, float64(2), result["object"].(map[string]interface{})["size"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"field": "size", "op": "replace", "old": float64(1), "new": float64(2)},
+	}, result["changes"])
+}
+
+type PluginWidget struct {
+	types.Resource
+	Name string `json:"name"`
+}
+
+type pluginWidgetStore struct {
+	empty.Store
+	name    string
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *pluginWidgetStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	if p.release != nil {
+		close(p.entered)
+		<-p.release
+	}
+	return map[string]interface{}{"id": id, "type": "pluginWidget", "name": p.name}, nil
+}
+
+func TestServeRemoveSchema(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, PluginWidget{}, func(schema *types.Schema) {
+		schema.Store = &pluginWidgetStore{name: "first", entered: entered, release: release}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	get := func() *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/pluginwidgets/one", nil))
+		return resp
+	}
+
+	inflight := make(chan *httptest.ResponseRecorder)
+	go func() {
+		inflight <- get()
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	replacement := *srv.Schemas.Schema(&builtin.Version, "pluginWidget")
+	replacement.Store = &pluginWidgetStore{name: "second"}
+	require.Error(t, srv.ReplaceSchema(ctx, replacement), "replacing waits for the request in flight")
+
+	resp := get()
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Contains(t, resp.Body.String(), `"second"`)
+
+	close(release)
+	require.Contains(t, (<-inflight).Body.String(), `"first"`)
+
+	require.NoError(t, srv.RemoveSchema(context.Background(), &builtin.Version, "pluginWidget"))
+	require.Equal(t, http.StatusNotFound, get().Code)
+}
+
+func TestServeRemoveSchemaWaitsForProfiles(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, PluginWidget{}, func(schema *types.Schema) {
+		schema.Store = &pluginWidgetStore{name: "first", entered: entered, release: release}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+	public, err := srv.ForProfile(types.Profile{Name: "public"})
+	require.NoError(t, err)
+
+	inflight := make(chan *httptest.ResponseRecorder)
+	go func() {
+		resp := httptest.NewRecorder()
+		public.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/pluginwidgets/one", nil))
+		inflight <- resp
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Error(t, srv.RemoveSchema(ctx, &builtin.Version, "pluginWidget"), "removing waits for the request of the profile")
+
+	close(release)
+	require.Equal(t, http.StatusOK, (<-inflight).Code)
+}
+
+func TestServeReplaceSchemaOfProfile(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, PluginWidget{}, func(schema *types.Schema) {
+		schema.Store = &pluginWidgetStore{name: "first"}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	srv.ProfileResolver = func(apiContext *types.APIContext) *types.Profile {
+		return &types.Profile{Name: "tenant"}
+	}
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	get := func() string {
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/pluginwidgets/one", nil))
+		return resp.Body.String()
+	}
+	require.Contains(t, get(), `"first"`)
+
+	replacement := *srv.Schemas.Schema(&builtin.Version, "pluginWidget")
+	replacement.Store = &pluginWidgetStore{name: "second"}
+	require.NoError(t, srv.ReplaceSchema(context.Background(), replacement))
+	require.Contains(t, get(), `"second"`)
+}
+
+func (p *pluginWidgetStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{
+		{"id": "one", "type": "pluginWidget", "name": p.name},
+		{"id": "two", "type": "pluginWidget", "name": p.name},
+	}, nil
+}
+
+func TestServeStats(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, PluginWidget{}, func(schema *types.Schema) {
+		schema.Store = &pluginWidgetStore{name: "first"}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	for _, url := range []string{"/meta/pluginwidgets?name=first", "/meta/pluginwidgets?name_ne=second", "/meta/pluginwidgets"} {
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost"+url, nil))
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+	// lists of stores outside of collection requests aren't counted
+	schema := schemas.Schema(&builtin.Version, "pluginWidget")
+	_, err := schema.Store.List(&types.APIContext{}, schema, &types.QueryOptions{})
+	require.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	srv.StatsHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/stats", nil))
+	var stats map[string]api.SchemaStats
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+
+	require.Equal(t, api.SchemaStats{
+		Requests:              map[string]int64{http.MethodGet: 3},
+		Collections:           3,
+		AverageCollectionSize: 2,
+		TopFilters:            []api.FilterStats{{Field: "name", Count: 2}},
+	}, stats["pluginWidget"])
+	require.Contains(t, stats, "schema", "schemas never requested are reported")
+}
+
+func TestServeReadOnly(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, Widget{}, func(schema *types.Schema) {
+		schema.Store = &widgetStore{}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, httptest.NewRequest(method, "http://localhost/meta/widgets/one", nil))
+		return resp
+	}
+
+	srv.SetReadOnly(true, "upgrading")
+	resp := serve(http.MethodDelete)
+	require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	require.Contains(t, resp.Body.String(), "API is read only: upgrading")
+	require.Equal(t, http.StatusOK, serve(http.MethodGet).Code)
+
+	srv.SetReadOnly(false, "")
+	srv.SetSchemaReadOnly("widget", true, "")
+	require.Equal(t, http.StatusServiceUnavailable, serve(http.MethodDelete).Code)
+
+	resp = httptest.NewRecorder()
+	srv.ReadOnlyHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "http://localhost/readonly", strings.NewReader(`{"enabled":false}`)))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, api.ReadOnlyStatus{}, srv.ReadOnly())
+	require.NotEqual(t, http.StatusServiceUnavailable, serve(http.MethodDelete).Code)
+}
+
+func TestServeSummaryView(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, PluginWidget{}, func(schema *types.Schema) {
+		schema.Store = &pluginWidgetStore{name: "first"}
+		schema.SummaryFields = []string{"id"}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	get := func(url string) string {
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost"+url, nil))
+		require.Equal(t, http.StatusOK, resp.Code)
+		return resp.Body.String()
+	}
+
+	summary := get("/meta/pluginwidgets")
+	require.Contains(t, summary, `"id":"two"`)
+	require.NotContains(t, summary, `"first"`)
+	require.Contains(t, get("/meta/pluginwidgets?view=full"), `"first"`)
+	require.Contains(t, get("/meta/pluginwidgets/one"), `"first"`)
+	require.Contains(t, get("/meta/schemas/pluginWidget"), `"summaryFields":["id"]`)
+}
+
+type WidgetPart struct {
+	types.Resource
+	WidgetID string `json:"widgetId" norman:"type=reference[widget]"`
+}
+
+type widgetPartStore struct {
+	empty.Store
+}
+
+func (w *widgetPartStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "part", "type": "widgetPart", "widgetId": data["widgetId"]}, nil
+}
+
+func TestServeReferences(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, Widget{}, func(schema *types.Schema) {
+		schema.Store = &widgetStore{}
+	})
+	schemas.MustImportAndCustomize(&builtin.Version, WidgetPart{}, func(schema *types.Schema) {
+		schema.Store = &widgetPartStore{}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	srv.ReferenceValidator = api.NewReferenceValidator
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://localhost/meta/widgetparts", strings.NewReader(body)))
+		return resp
+	}
+
+	resp := post(`{"widgetId":"two"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	require.Contains(t, resp.Body.String(), "InvalidReference")
+
+	resp = post(`{"widgetId":"one"}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var part map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &part))
+	links := part["links"].(map[string]interface{})
+	require.Equal(t, "http://localhost/meta/widgets/one", links["widgetId"])
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Contains(t, resp.Body.String(), `"widgetParts":"http://localhost/meta/widgetParts?widgetId=one"`)
+}
+
+type TenantWidget struct {
+	types.Resource
+	Size   int64  `json:"size" norman:"default=1"`
+	Secret string `json:"secret"`
+}
+
+type tenantWidgetStore struct {
+	empty.Store
+}
+
+func (w *tenantWidgetStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	data["id"] = "one"
+	data["type"] = "tenantWidget"
+	return data, nil
+}
+
+func TestServeTenantProfile(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, TenantWidget{}, func(schema *types.Schema) {
+		schema.Store = &tenantWidgetStore{}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	srv.ProfileResolver = func(apiContext *types.APIContext) *types.Profile {
+		if apiContext.Request.Header.Get("X-Tenant") != "acme" {
+			return nil
+		}
+		return &types.Profile{
+			Name:          "acme",
+			Defaults:      map[string]map[string]interface{}{"tenantWidget": {"size": 5}},
+			ExcludeFields: map[string][]string{"tenantWidget": {"secret"}},
+		}
+	}
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	create := func(tenant string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost/meta/tenantwidgets", strings.NewReader(`{"secret":"s"}`))
+		req.Header.Set("X-Tenant", tenant)
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+		return result
+	}
+
+	widget := create("")
+	require.Equal(t, float64(1), widget["size"])
+	require.Equal(t, "s", widget["secret"])
+
+	widget = create("acme")
+	require.Equal(t, float64(5), widget["size"])
+	require.NotContains(t, widget, "secret")
+}
+
+func TestServeForProfile(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, TenantWidget{}, func(schema *types.Schema) {
+		schema.Store = &tenantWidgetStore{}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+	public, err := srv.ForProfile(types.Profile{Name: "public", ExcludeFields: map[string][]string{"tenantWidget": {"secret"}}})
+	require.NoError(t, err)
+
+	create := func() int {
+		resp := httptest.NewRecorder()
+		public.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://localhost/meta/tenantwidgets", strings.NewReader(`{}`)))
+		return resp.Code
+	}
+	require.Equal(t, http.StatusCreated, create())
+
+	srv.SetReadOnly(true, "maintenance")
+	require.Equal(t, http.StatusServiceUnavailable, create())
+	srv.SetReadOnly(false, "")
+
+	srv.Schemas.Use("deny", 0, func(apiContext *types.APIContext, next func() error) error {
+		return httperror.NewAPIError(httperror.PermissionDenied, "denied")
+	})
+	require.Equal(t, http.StatusForbidden, create())
+}
+
+type PanicWidget struct {
+	types.Resource
+}
+
+type panicWidgetStore struct {
+	empty.Store
+}
+
+func (p *panicWidgetStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	panic("broken store")
+}
+
+func TestServePanic(t *testing.T) {
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, PanicWidget{}, func(schema *types.Schema) {
+		schema.Store = &panicWidgetStore{}
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	var fingerprints []interface{}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/meta/panicwidgets/one", nil)
+		req.Header.Set(types.RequestIDHeader, "test-request-id")
+		resp := httptest.NewRecorder()
+		srv.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		require.Equal(t, "ServerError", body["code"])
+		require.Equal(t, "test-request-id", body["requestId"])
+		require.NotEmpty(t, body["fingerprint"])
+		fingerprints = append(fingerprints, body["fingerprint"])
+	}
+	require.Equal(t, fingerprints[0], fingerprints[1])
+
+	// the parser panics before the schema of the request is known
+	srv.Parser = func(rw http.ResponseWriter, req *http.Request) (*types.APIContext, error) {
+		panic("broken parser")
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/meta/panicwidgets/one", nil)
+	req.Header.Set(types.RequestIDHeader, "test-request-id")
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	require.Equal(t, "test-request-id", body["requestId"])
+	require.NotEmpty(t, body["fingerprint"])
+
+	handler := ehandler.Recovery(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		panic("broken handler")
+	}))
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/other", nil))
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.Contains(t, resp.Body.String(), `"fingerprint"`)
+}
+
+type denyGet struct {
+	authorization.AllAccess
+}
+
+func (d *denyGet) CanGet(apiContext *types.APIContext, schema *types.Schema) error {
+	return httperror.NewAPIError(httperror.PermissionDenied, "can not get "+schema.ID)
+}
+
+func TestServeScopes(t *testing.T) {
+	version := types.APIVersion{Group: "scope.cattle.io", Version: "v1", Path: "/v1", Scopes: []string{"widget"}}
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&version, Widget{}, func(schema *types.Schema) {
+		schema.Store = &widgetStore{}
+	})
+	schemas.MustImportAndCustomize(&version, WidgetPart{}, func(schema *types.Schema) {
+		schema.Store = &widgetPartStore{}
+	})
+	schemas.MustImportAndCustomize(&version, TenantWidget{}, func(schema *types.Schema) {
+		schema.Store = &tenantWidgetStore{}
+	})
+	require.NoError(t, schemas.Err())
+
+	tests := []struct {
+		name   string
+		url    string
+		access types.AccessControl
+		code   int
+	}{
+		{name: "scoped collection", url: "http://localhost/v1/widgets/one/widgetparts", code: http.StatusOK},
+		{name: "missing parent", url: "http://localhost/v1/widgets/two/widgetparts", code: http.StatusNotFound},
+		{name: "unreadable parent", url: "http://localhost/v1/widgets/one/widgetparts", access: &denyGet{}, code: http.StatusForbidden},
+		{name: "type without reference to the scope", url: "http://localhost/v1/widgets/one/tenantwidgets", code: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := api.NewAPIServer()
+			if test.access != nil {
+				srv.AccessControl = test.access
+			}
+			require.NoError(t, srv.AddSchemas(schemas))
+
+			resp := httptest.NewRecorder()
+			srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, test.url, nil))
+			require.Equal(t, test.code, resp.Code, resp.Body.String())
+		})
+	}
+}
+
+type fixedUser types.User
+
+func (f *fixedUser) Authenticate(req *http.Request) (*types.User, error) {
+	return (*types.User)(f), nil
+}
+
+type headerStore struct {
+	empty.Store
+	header http.Header
+}
+
+func (h *headerStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	h.header = apiContext.Request.Header.Clone()
+	return map[string]interface{}{"id": id, "type": "widget"}, nil
+}
+
+func TestServeImpersonation(t *testing.T) {
+	store := &headerStore{}
+	schemas := types.NewSchemas().AddSchemas(builtin.Schemas)
+	schemas.MustImportAndCustomize(&builtin.Version, Widget{}, func(schema *types.Schema) {
+		schema.Store = store
+	})
+	require.NoError(t, schemas.Err())
+
+	srv := api.NewAPIServer()
+	srv.Authenticator = &fixedUser{Name: "alice", Groups: []string{"devs", "ops"}}
+	require.NoError(t, srv.AddSchemas(schemas))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/meta/widgets/one", nil)
+	req.Header.Set("Impersonate-User", "system:admin")
+	req.Header.Set("Impersonate-Group", "system:masters")
+	req.Header.Set("Impersonate-Extra-Scopes", "all")
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	require.Equal(t, "alice", store.header.Get("Impersonate-User"))
+	require.Equal(t, []string{"devs", "ops"}, store.header.Values("Impersonate-Group"))
+	require.Empty(t, store.header.Values("Impersonate-Extra-Scopes"))
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/rancher/norman/types"
+)
+
+const topFilters = 10
+
+// SchemaStats is the usage of a schema since the server started.
+type SchemaStats struct {
+	Requests              map[string]int64 `json:"requests"`
+	Watchers              int64            `json:"watchers"`
+	Collections           int64            `json:"collections"`
+	AverageCollectionSize float64          `json:"averageCollectionSize"`
+	TopFilters            []FilterStats    `json:"topFilters,omitempty"`
+}
+
+// FilterStats is the number of collection requests filtering on Field.
+type FilterStats struct {
+	Field string `json:"field"`
+	Count int64  `json:"count"`
+}
+
+type schemaUsage struct {
+	requests       map[string]int64
+	watchers       int64
+	collections    int64
+	collectionSize int64
+	filters        map[string]int64
+}
+
+// usageStats counts the requests of each schema, see Server.Stats.
+type usageStats struct {
+	lock    sync.Mutex
+	schemas map[string]*schemaUsage
+}
+
+// usage returns the usage of schemaID, the lock must be held.
+func (u *usageStats) usage(schemaID string) *schemaUsage {
+	if u.schemas == nil {
+		u.schemas = map[string]*schemaUsage{}
+	}
+	usage, ok := u.schemas[schemaID]
+	if !ok {
+		usage = &schemaUsage{
+			requests: map[string]int64{},
+			filters:  map[string]int64{},
+		}
+		u.schemas[schemaID] = usage
+	}
+	return usage
+}
+
+func (u *usageStats) request(apiContext *types.APIContext) {
+	schema := apiContext.Schema
+	var filters []string
+	if apiContext.Method == http.MethodGet && apiContext.ID == "" {
+		for key := range apiContext.Query {
+			field, _, _ := strings.Cut(key, "_")
+			if _, ok := schema.CollectionFilters[field]; ok {
+				filters = append(filters, field)
+			}
+		}
+	}
+
+	u.lock.Lock()
+	usage := u.usage(schema.ID)
+	usage.requests[apiContext.Method]++
+	for _, field := range filters {
+		usage.filters[field]++
+	}
+	u.lock.Unlock()
+
+	metrics.IncAPIRequest(schema.ID, apiContext.Method)
+	for _, field := range filters {
+		metrics.IncAPIFilter(schema.ID, field)
+	}
+}
+
+func (u *usageStats) collection(schemaID string, size int) {
+	u.lock.Lock()
+	usage := u.usage(schemaID)
+	usage.collections++
+	usage.collectionSize += int64(size)
+	u.lock.Unlock()
+	metrics.ObserveAPICollectionSize(schemaID, size)
+}
+
+func (u *usageStats) watch(schemaID string, delta int) {
+	u.lock.Lock()
+	u.usage(schemaID).watchers += int64(delta)
+	u.lock.Unlock()
+	metrics.AddAPIWatchers(schemaID, delta)
+}
+
+// Stats returns the usage of every schema by ID, including the schemas that were never requested.
+func (s *Server) Stats() map[string]SchemaStats {
+	result := map[string]SchemaStats{}
+	for _, schema := range s.Schemas.Schemas() {
+		result[schema.ID] = SchemaStats{
+			Requests: map[string]int64{},
+		}
+	}
+
+	s.stats.lock.Lock()
+	defer s.stats.lock.Unlock()
+	for schemaID, usage := range s.stats.schemas {
+		stats := SchemaStats{
+			Requests:    map[string]int64{},
+			Watchers:    usage.watchers,
+			Collections: usage.collections,
+		}
+		for method, count := range usage.requests {
+			stats.Requests[method] = count
+		}
+		if usage.collections > 0 {
+			stats.AverageCollectionSize = float64(usage.collectionSize) / float64(usage.collections)
+		}
+		for field, count := range usage.filters {
+			stats.TopFilters = append(stats.TopFilters, FilterStats{Field: field, Count: count})
+		}
+		sort.Slice(stats.TopFilters, func(i, j int) bool {
+			if stats.TopFilters[i].Count != stats.TopFilters[j].Count {
+				return stats.TopFilters[i].Count > stats.TopFilters[j].Count
+			}
+			return stats.TopFilters[i].Field < stats.TopFilters[j].Field
+		})
+		if len(stats.TopFilters) > topFilters {
+			stats.TopFilters = stats.TopFilters[:topFilters]
+		}
+		result[schemaID] = stats
+	}
+	return result
+}
+
+// StatsHandler serves Stats as JSON, it is meant to be mounted on an internal endpoint.
+func (s *Server) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(s.Stats())
+	})
+}
+
+// statsResponseWriter counts the collections served in the responses of collection requests, rather than the
+// lists of stores which handlers and other stores make as well.
+type statsResponseWriter struct {
+	types.ResponseWriter
+	stats *usageStats
+}
+
+func (s *statsResponseWriter) Write(apiContext *types.APIContext, code int, obj interface{}) {
+	if data, ok := obj.([]map[string]interface{}); ok && code < http.StatusBadRequest && apiContext.Schema != nil &&
+		apiContext.Method == http.MethodGet && apiContext.ID == "" {
+		s.stats.collection(apiContext.Schema.ID, len(data))
+	}
+	s.ResponseWriter.Write(apiContext, code, obj)
+}
+
+// statsStore counts the watches of a store.
+type statsStore struct {
+	types.Store
+	stats *usageStats
+}
+
+func (s *statsStore) Watch(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) (chan map[string]interface{}, error) {
+	c, err := s.Store.Watch(apiContext, schema, opt)
+	if err != nil || c == nil {
+		return c, err
+	}
+
+	var done <-chan struct{}
+	if apiContext.Request != nil {
+		done = apiContext.Request.Context().Done()
+	}
+
+	s.stats.watch(schema.ID, 1)
+	result := make(chan map[string]interface{})
+	go func() {
+		defer s.stats.watch(schema.ID, -1)
+		defer close(result)
+		for item := range c {
+			select {
+			case result <- item:
+			case <-done:
+				for range c {
+				}
+				return
+			}
+		}
+	}()
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+)
+
+// handleWithTimeout runs handler with the remaining time in the context of the request, and responds with a
+// Timeout error if it didn't start responding in time. The context of the handler is canceled on timeout, so that it
+// stops instead of writing after the error was sent. Handlers that started responding are waited for. Upgraded
+// requests, such as websockets, are not timed out.
+func handleWithTimeout(apiRequest *types.APIContext, timeout time.Duration, handler func(apiRequest *types.APIContext) error) error {
+	if apiRequest.Request.Header.Get("Upgrade") != "" {
+		return handler(apiRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(apiRequest.Request.Context(), timeout)
+	defer cancel()
+
+	writer := &timeoutWriter{
+		rw:     apiRequest.Response,
+		header: apiRequest.Response.Header().Clone(),
+	}
+	handlerRequest := *apiRequest
+	handlerRequest.Request = apiRequest.Request.WithContext(ctx)
+	handlerRequest.Response = writer
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					done <- errAborted
+					return
+				}
+				done <- httperror.Recovered(err, apiRequest.RequestID)
+			}
+		}()
+		done <- handler(&handlerRequest)
+	}()
+
+	select {
+	case err := <-done:
+		return handlerResult(err)
+	case <-ctx.Done():
+	}
+
+	if !writer.timeout() {
+		return handlerResult(<-done)
+	}
+	return httperror.NewAPIError(httperror.Timeout, fmt.Sprintf("request did not complete within %v", timeout))
+}
+
+// errAborted passes the http.ErrAbortHandler panics of handlers to the goroutine serving the request.
+var errAborted = errors.New("request aborted")
+
+// handlerResult returns the error of the handler, it panics again with http.ErrAbortHandler if the handler did, so
+// that the server aborts the response.
+func handlerResult(err error) error {
+	if err == errAborted {
+		panic(http.ErrAbortHandler)
+	}
+	return err
+}
+
+// timeoutWriter drops what handlers write after they timed out. It flushes and hijacks the connection for streams,
+// which starts the response.
+type timeoutWriter struct {
+	lock        sync.Mutex
+	rw          http.ResponseWriter
+	header      http.Header
+	wroteHeader bool
+	timedOut    bool
+}
+
+// timeout marks the writer as timed out, unless the response has started.
+func (t *timeoutWriter) timeout() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.wroteHeader {
+		return false
+	}
+	t.timedOut = true
+	return true
+}
+
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+func (t *timeoutWriter) WriteHeader(code int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.writeHeader(code)
+}
+
+func (t *timeoutWriter) writeHeader(code int) {
+	if t.timedOut || t.wroteHeader {
+		return
+	}
+	t.wroteHeader = true
+	for k, v := range t.header {
+		t.rw.Header()[k] = v
+	}
+	t.rw.WriteHeader(code)
+}
+
+func (t *timeoutWriter) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	t.writeHeader(http.StatusOK)
+	return t.rw.Write(p)
+}
+
+func (t *timeoutWriter) Flush() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.timedOut {
+		return
+	}
+	t.writeHeader(http.StatusOK)
+	if flusher, ok := t.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (t *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	hijacker, ok := t.rw.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	t.wroteHeader = true
+	return hijacker.Hijack()
+}
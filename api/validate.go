@@ -72,7 +72,7 @@ func CheckCSRF(apiContext *types.APIContext) error {
 	}
 
 	// Not an else-if, because this should happen even if there was no cookie to begin with.
-	if apiContext.Method != http.MethodGet {
+	if apiContext.Method != http.MethodGet && apiContext.Method != http.MethodOptions {
 		/*
 		 * Very important to use apiContext.Method and not apiContext.Request.Method. The client can override the HTTP method with _method
 		 */
@@ -22,6 +22,7 @@ func addSchemasHeader(apiContext *types.APIContext) error {
 	}
 
 	apiContext.Response.Header().Set("X-Api-Schemas", apiContext.URLBuilder.Collection(schema, version))
+	apiContext.Response.Header().Set("X-Api-Schemas-Hash", apiContext.Schemas.Hash())
 	return nil
 }
 
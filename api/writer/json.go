@@ -9,6 +9,7 @@ import (
 	"github.com/rancher/norman/parse/builder"
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/types/definition"
+	"github.com/rancher/norman/types/slice"
 	"github.com/sirupsen/logrus"
 )
 
@@ -61,6 +62,7 @@ func (j *EncodingResponseWriter) writeMapSlice(builder *builder.Builder, apiCont
 	for _, value := range input {
 		converted := j.convert(builder, apiContext, value)
 		if converted != nil {
+			summarize(apiContext, converted)
 			collection.Data = append(collection.Data, converted)
 		}
 	}
@@ -79,6 +81,7 @@ func (j *EncodingResponseWriter) writeInterfaceSlice(builder *builder.Builder, a
 		case map[string]interface{}:
 			converted := j.convert(builder, apiContext, v)
 			if converted != nil {
+				summarize(apiContext, converted)
 				collection.Data = append(collection.Data, converted)
 			}
 		default:
@@ -131,6 +134,14 @@ func (j *EncodingResponseWriter) convert(b *builder.Builder, context *types.APIC
 		schema.Formatter(context, rawResource)
 	}
 
+	if actionAccess, ok := context.AccessControl.(types.ActionAccessControl); ok {
+		for action := range rawResource.Actions {
+			if actionAccess.CanAction(context, input, schema, action) != nil {
+				delete(rawResource.Actions, action)
+			}
+		}
+	}
+
 	return rawResource
 }
 
@@ -148,6 +159,18 @@ func (j *EncodingResponseWriter) addLinks(b *builder.Builder, schema *types.Sche
 		rawResource.Links["remove"] = self
 	}
 
+	for name, subResource := range schema.SubResources {
+		verb := http.MethodGet
+		if len(subResource.Methods) > 0 && !slice.ContainsString(subResource.Methods, http.MethodGet) {
+			verb = http.MethodPut
+		}
+		if context.AccessControl.CanDo(schema.Version.Group, schema.PluralName+"/"+name, verb, context, input, schema) == nil {
+			rawResource.Links[name] = context.URLBuilder.Link(name, rawResource)
+		}
+	}
+
+	j.addReferenceLinks(schema, context, rawResource)
+
 	subContextVersion := context.Schemas.SubContextVersionForSchema(schema)
 	for _, backRef := range context.Schemas.References(schema) {
 		if backRef.Schema.CanList(context) != nil {
@@ -170,6 +193,27 @@ func (j *EncodingResponseWriter) addLinks(b *builder.Builder, schema *types.Sche
 	}
 }
 
+// addReferenceLinks links the objects referenced by the reference fields that are set, by the name of the field.
+func (j *EncodingResponseWriter) addReferenceLinks(schema *types.Schema, context *types.APIContext, rawResource *types.RawResource) {
+	for name, field := range schema.ResourceFields {
+		if !definition.IsReferenceType(field.Type) {
+			continue
+		}
+		if _, ok := rawResource.Links[name]; ok {
+			continue
+		}
+		id := toString(rawResource.Values[name])
+		if id == "" {
+			continue
+		}
+		refSchema := context.Schemas.Schema(&schema.Version, definition.SubType(field.Type))
+		if refSchema == nil || refSchema.CanGet(context) != nil {
+			continue
+		}
+		rawResource.Links[name] = context.URLBuilder.ResourceLinkByID(refSchema, id)
+	}
+}
+
 func newCollection(apiContext *types.APIContext) *types.GenericCollection {
 	result := &types.GenericCollection{
 		Collection: types.Collection{
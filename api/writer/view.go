@@ -0,0 +1,30 @@
+package writer
+
+import (
+	"github.com/rancher/norman/types"
+)
+
+const (
+	// ViewQuery selects the view of the objects of collections, FullView returns them complete even if their
+	// schema has SummaryFields.
+	ViewQuery = "view"
+	FullView  = "full"
+)
+
+// summarize removes the fields of resource, an object of a collection, that aren't SummaryFields of its schema.
+func summarize(apiContext *types.APIContext, resource *types.RawResource) {
+	if resource.Schema == nil || len(resource.Schema.SummaryFields) == 0 || apiContext.Query.Get(ViewQuery) == FullView {
+		return
+	}
+
+	values := make(map[string]interface{}, len(resource.Schema.SummaryFields)+1)
+	if id, ok := resource.Values["id"]; ok {
+		values["id"] = id
+	}
+	for _, field := range resource.Schema.SummaryFields {
+		if value, ok := resource.Values[field]; ok {
+			values[field] = value
+		}
+	}
+	resource.Values = values
+}
@@ -0,0 +1,110 @@
+package authentication
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/rancher/norman/objectclient"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const tokenCacheSize = 4096
+
+// CRDTokenStore reads tokens from custom resources. Tokens take the form <name>:<secret>, where name is the name
+// of a resource whose spec has the fields
+//
+//	tokenHash: lower case hex SHA-256 of the secret
+//	user:      user name
+//	groups:    group names
+//	scopes:    list of {schema, verbs}
+//	expiresAt: RFC 3339 expiry, optional
+//
+// The resources, and the names that don't exist, are cached for ttl, so changes to tokens take up to ttl to apply.
+type CRDTokenStore struct {
+	client objectclient.GenericClient
+	cache  *cache.LRUExpireCache
+	ttl    time.Duration
+}
+
+func NewCRDTokenStore(client objectclient.GenericClient, ttl time.Duration) *CRDTokenStore {
+	return &CRDTokenStore{
+		client: client.UnstructuredClient(),
+		cache:  cache.NewLRUExpireCache(tokenCacheSize),
+		ttl:    ttl,
+	}
+}
+
+func (c *CRDTokenStore) Lookup(ctx context.Context, token string) (*Token, error) {
+	name, secret, ok := strings.Cut(token, ":")
+	if !ok || name == "" || secret == "" {
+		return nil, nil
+	}
+
+	spec, err := c.spec(ctx, name)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(hash[:])), []byte(convert.ToString(spec["tokenHash"]))) != 1 {
+		return nil, nil
+	}
+
+	result := &Token{
+		User:   convert.ToString(spec["user"]),
+		Groups: convert.ToStringSlice(spec["groups"]),
+	}
+
+	var scopes []types.TokenScope
+	if err := convert.ToObj(spec["scopes"], &scopes); err != nil {
+		return nil, err
+	}
+	result.Scopes = scopes
+
+	if expiresAt := convert.ToString(spec["expiresAt"]); expiresAt != "" {
+		result.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// spec returns the spec of the token resource name, or nil if it doesn't exist.
+func (c *CRDTokenStore) spec(ctx context.Context, name string) (map[string]interface{}, error) {
+	if cached, ok := c.cache.Get(name); ok {
+		return cached.(map[string]interface{}), nil
+	}
+
+	client := c.client
+	if withContext, ok := client.(interface {
+		WithContext(ctx context.Context) *objectclient.ObjectClient
+	}); ok {
+		client = withContext.WithContext(ctx)
+	}
+
+	var spec map[string]interface{}
+	obj, err := client.Get(name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok && err == nil {
+		spec = convert.ToMapInterface(u.Object["spec"])
+		if spec == nil {
+			spec = map[string]interface{}{}
+		}
+	}
+
+	c.cache.Add(name, spec, c.ttl)
+	return spec, nil
+}
@@ -0,0 +1,91 @@
+package authentication
+
+import (
+	"strings"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/slice"
+)
+
+// ScopedAccess limits the wrapped access control to the token scopes of the requesting user.
+type ScopedAccess struct {
+	types.AccessControl
+}
+
+func NewScopedAccess(delegate types.AccessControl) *ScopedAccess {
+	return &ScopedAccess{
+		AccessControl: delegate,
+	}
+}
+
+func (s *ScopedAccess) CanCreate(apiContext *types.APIContext, schema *types.Schema) error {
+	if err := checkScope(apiContext, schema, "create"); err != nil {
+		return err
+	}
+	return s.AccessControl.CanCreate(apiContext, schema)
+}
+
+func (s *ScopedAccess) CanList(apiContext *types.APIContext, schema *types.Schema) error {
+	if err := checkScope(apiContext, schema, "list"); err != nil {
+		return err
+	}
+	return s.AccessControl.CanList(apiContext, schema)
+}
+
+func (s *ScopedAccess) CanGet(apiContext *types.APIContext, schema *types.Schema) error {
+	if err := checkScope(apiContext, schema, "get"); err != nil {
+		return err
+	}
+	return s.AccessControl.CanGet(apiContext, schema)
+}
+
+func (s *ScopedAccess) CanUpdate(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema) error {
+	if err := checkScope(apiContext, schema, "update"); err != nil {
+		return err
+	}
+	return s.AccessControl.CanUpdate(apiContext, obj, schema)
+}
+
+func (s *ScopedAccess) CanDelete(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema) error {
+	if err := checkScope(apiContext, schema, "delete"); err != nil {
+		return err
+	}
+	return s.AccessControl.CanDelete(apiContext, obj, schema)
+}
+
+func (s *ScopedAccess) CanDo(apiGroup, resource, verb string, apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema) error {
+	if err := checkScope(apiContext, schema, strings.ToLower(verb)); err != nil {
+		return err
+	}
+	return s.AccessControl.CanDo(apiGroup, resource, verb, apiContext, obj, schema)
+}
+
+// CanAction requires the update verb, like SARAccess.
+func (s *ScopedAccess) CanAction(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema, action string) error {
+	if err := checkScope(apiContext, schema, "update"); err != nil {
+		return err
+	}
+	if actionAccess, ok := s.AccessControl.(types.ActionAccessControl); ok {
+		return actionAccess.CanAction(apiContext, obj, schema, action)
+	}
+	return nil
+}
+
+func checkScope(apiContext *types.APIContext, schema *types.Schema, verb string) error {
+	user := apiContext.User
+	if user == nil || len(user.Scopes) == 0 {
+		return nil
+	}
+
+	for _, scope := range user.Scopes {
+		if scope.Schema != "*" && scope.Schema != schema.ID {
+			continue
+		}
+		if len(scope.Verbs) == 0 || slice.ContainsString(scope.Verbs, verb) || slice.ContainsString(scope.Verbs, "*") {
+			return nil
+		}
+	}
+
+	return httperror.NewAPIError(httperror.PermissionDenied, "token scope does not allow "+verb+" "+schema.ID)
+}
@@ -0,0 +1,75 @@
+package authentication
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+)
+
+// APIKeyHeader is an alternative to a bearer token in the Authorization header.
+const APIKeyHeader = "X-Api-Key"
+
+// Token is an API token issued to a user.
+type Token struct {
+	User      string
+	Groups    []string
+	Scopes    []types.TokenScope
+	ExpiresAt time.Time
+}
+
+// TokenStore looks up API tokens. Lookup returns nil, without an error, for unknown tokens.
+type TokenStore interface {
+	Lookup(ctx context.Context, token string) (*Token, error)
+}
+
+// TokenAuthenticator authenticates requests by the bearer token, or API key, they carry.
+type TokenAuthenticator struct {
+	Store TokenStore
+	// AllowAnonymous lets requests without a token through as anonymous requests.
+	AllowAnonymous bool
+}
+
+func NewTokenAuthenticator(store TokenStore) *TokenAuthenticator {
+	return &TokenAuthenticator{
+		Store: store,
+	}
+}
+
+func (t *TokenAuthenticator) Authenticate(req *http.Request) (*types.User, error) {
+	tokenValue := requestToken(req)
+	if tokenValue == "" {
+		if t.AllowAnonymous {
+			return nil, nil
+		}
+		return nil, httperror.NewAPIError(httperror.Unauthorized, "must authenticate")
+	}
+
+	token, err := t.Store.Lookup(req.Context(), tokenValue)
+	if err != nil {
+		return nil, httperror.WrapAPIError(err, httperror.ServerError, "failed to look up token")
+	}
+	if token == nil || (!token.ExpiresAt.IsZero() && time.Now().After(token.ExpiresAt)) {
+		return nil, httperror.NewAPIError(httperror.Unauthorized, "invalid token")
+	}
+
+	return &types.User{
+		Name:   token.User,
+		Groups: token.Groups,
+		Scopes: token.Scopes,
+	}, nil
+}
+
+func requestToken(req *http.Request) string {
+	if key := req.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,137 @@
+package authentication
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/objectclient"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeStore map[string]*Token
+
+func (f fakeStore) Lookup(ctx context.Context, token string) (*Token, error) {
+	return f[token], nil
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	auth := NewTokenAuthenticator(fakeStore{
+		"valid":   {User: "alice", Groups: []string{"devs"}},
+		"expired": {User: "bob", ExpiresAt: time.Now().Add(-time.Minute)},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer valid")
+	user, err := auth.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, []string{"devs"}, user.Groups)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "valid")
+	user, err = auth.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer expired")
+	_, err = auth.Authenticate(req)
+	assert.Equal(t, httperror.Unauthorized, err.(*httperror.APIError).Code)
+
+	_, err = auth.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, httperror.Unauthorized, err.(*httperror.APIError).Code)
+
+	auth.AllowAnonymous = true
+	user, err = auth.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Nil(t, user)
+}
+
+func TestScopedAccess(t *testing.T) {
+	access := NewScopedAccess(&authorization.AllAccess{})
+	schema := &types.Schema{
+		ID:                "cluster",
+		CollectionMethods: []string{http.MethodGet, http.MethodPost},
+		ResourceMethods:   []string{http.MethodGet, http.MethodPut},
+	}
+	apiContext := &types.APIContext{
+		User: &types.User{
+			Name: "alice",
+			Scopes: []types.TokenScope{
+				{Schema: "cluster", Verbs: []string{"get", "list"}},
+				{Schema: "*", Verbs: []string{"create"}},
+			},
+		},
+	}
+
+	assert.NoError(t, access.CanList(apiContext, schema))
+	assert.NoError(t, access.CanGet(apiContext, schema))
+	assert.NoError(t, access.CanCreate(apiContext, schema))
+	assert.Error(t, access.CanUpdate(apiContext, nil, schema))
+
+	apiContext.User.Scopes = nil
+	assert.NoError(t, access.CanUpdate(apiContext, nil, schema))
+}
+
+type fakeTokenClient struct {
+	objectclient.GenericClient
+	objs map[string]*unstructured.Unstructured
+	gets int
+}
+
+func (f *fakeTokenClient) UnstructuredClient() objectclient.GenericClient {
+	return f
+}
+
+func (f *fakeTokenClient) Get(name string, opts metav1.GetOptions) (runtime.Object, error) {
+	f.gets++
+	if obj, ok := f.objs[name]; ok {
+		return obj, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "tokens"}, name)
+}
+
+func TestCRDTokenStoreCaches(t *testing.T) {
+	hash := sha256.Sum256([]byte("secret"))
+	client := &fakeTokenClient{
+		objs: map[string]*unstructured.Unstructured{
+			"alice": {Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"tokenHash": hex.EncodeToString(hash[:]),
+					"user":      "alice",
+				},
+			}},
+		},
+	}
+	store := NewCRDTokenStore(client, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		token, err := store.Lookup(context.Background(), "alice:secret")
+		require.NoError(t, err)
+		require.NotNil(t, token)
+		assert.Equal(t, "alice", token.User)
+
+		token, err = store.Lookup(context.Background(), "alice:wrong")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+
+		token, err = store.Lookup(context.Background(), "bob:secret")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+	}
+	assert.Equal(t, 2, client.gets)
+}
@@ -0,0 +1,169 @@
+package authorization
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const sarCacheSize = 4096
+
+// SARAccess only allows updates, deletes and actions when a SelfSubjectAccessReview, made while impersonating
+// the requesting user, succeeds. Requests without impersonation headers are left to the wrapped access control.
+// Results are cached for ttl so that formatting a collection does not issue a review per row.
+type SARAccess struct {
+	types.AccessControl
+
+	// ResourceFor returns the API group and resource checked for a schema. It defaults to the schema version
+	// group and lower cased plural name.
+	ResourceFor func(schema *types.Schema) (string, string)
+
+	client rest.Interface
+	cache  *cache.LRUExpireCache
+	ttl    time.Duration
+}
+
+func NewSARAccess(delegate types.AccessControl, client kubernetes.Interface, ttl time.Duration) *SARAccess {
+	return &SARAccess{
+		AccessControl: delegate,
+		ResourceFor: func(schema *types.Schema) (string, string) {
+			return schema.Version.Group, strings.ToLower(schema.PluralName)
+		},
+		client: client.AuthorizationV1().RESTClient(),
+		cache:  cache.NewLRUExpireCache(sarCacheSize),
+		ttl:    ttl,
+	}
+}
+
+func (s *SARAccess) CanUpdate(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema) error {
+	if err := s.AccessControl.CanUpdate(apiContext, obj, schema); err != nil {
+		return err
+	}
+	return s.review(apiContext, obj, schema, "update")
+}
+
+func (s *SARAccess) CanDelete(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema) error {
+	if err := s.AccessControl.CanDelete(apiContext, obj, schema); err != nil {
+		return err
+	}
+	return s.review(apiContext, obj, schema, "delete")
+}
+
+// CanAction requires the Permissions of the action, or the update verb on the resource the action is performed on
+// if it has none, once the wrapped access control allows the action.
+func (s *SARAccess) CanAction(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema, action string) error {
+	if actionAccess, ok := s.AccessControl.(types.ActionAccessControl); ok {
+		if err := actionAccess.CanAction(apiContext, obj, schema, action); err != nil {
+			return err
+		}
+	}
+	permissions := actionPermissions(schema, action)
+	if len(permissions) == 0 {
+		return s.review(apiContext, obj, schema, "update")
+	}
+	for _, permission := range permissions {
+		if err := s.reviewPermission(apiContext, obj, schema, permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func actionPermissions(schema *types.Schema, action string) []types.ActionPermission {
+	if resourceAction, ok := schema.ResourceActions[action]; ok {
+		return resourceAction.Permissions
+	}
+	return schema.CollectionActions[action].Permissions
+}
+
+func (s *SARAccess) review(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema, verb string) error {
+	return s.reviewPermission(apiContext, obj, schema, types.ActionPermission{Verb: verb})
+}
+
+func (s *SARAccess) reviewPermission(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema, permission types.ActionPermission) error {
+	header := apiContext.Request.Header
+	user := header.Get("Impersonate-User")
+	if user == "" {
+		return nil
+	}
+	groups := header.Values("Impersonate-Group")
+
+	var namespace, name string
+	if id := convert.ToString(obj["id"]); id != "" {
+		var err error
+		if namespace, name, err = schema.IDs().ParseID(id); err != nil {
+			return httperror.NewAPIError(httperror.NotFound, err.Error())
+		}
+	}
+	verb := permission.Verb
+	group, resource := permission.Group, permission.Resource
+	subject := resource
+	if permission.Subresource != "" {
+		subject += "/" + permission.Subresource
+	}
+	if resource == "" {
+		group, resource = s.ResourceFor(schema)
+		subject = schema.ID
+	} else {
+		name = ""
+	}
+	attrs := &authorizationv1.ResourceAttributes{
+		Namespace:   namespace,
+		Verb:        verb,
+		Group:       group,
+		Resource:    resource,
+		Subresource: permission.Subresource,
+		Name:        name,
+	}
+
+	key := cacheKey(user, groups, attrs)
+	if allowed, ok := s.cache.Get(key); ok {
+		return toError(allowed.(bool), verb, subject)
+	}
+
+	request := s.client.Post().Resource("selfsubjectaccessreviews")
+	request.SetHeader("Impersonate-User", user)
+	request.SetHeader("Impersonate-Group", groups...)
+	for k, v := range header {
+		if strings.HasPrefix(k, "Impersonate-Extra-") {
+			request.SetHeader(k, v...)
+		}
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{}
+	err := request.Body(&authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: attrs,
+		},
+	}).Do(apiContext.Request.Context()).Into(review)
+	if err != nil {
+		logrus.Errorf("failed to review access of %s to %s %s: %v", user, verb, resource, err)
+		return toError(false, verb, subject)
+	}
+
+	s.cache.Add(key, review.Status.Allowed, s.ttl)
+	return toError(review.Status.Allowed, verb, subject)
+}
+
+func toError(allowed bool, verb, subject string) error {
+	if allowed {
+		return nil
+	}
+	return httperror.NewAPIError(httperror.PermissionDenied, "can not "+verb+" "+subject)
+}
+
+func cacheKey(user string, groups []string, attrs *authorizationv1.ResourceAttributes) string {
+	groups = append([]string(nil), groups...)
+	sort.Strings(groups)
+	return strings.Join([]string{user, strings.Join(groups, ","), attrs.Verb, attrs.Group, attrs.Resource,
+		attrs.Subresource, attrs.Namespace, attrs.Name}, "/")
+}
@@ -0,0 +1,106 @@
+package authorization
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest/fake"
+)
+
+func TestSARActionPermissions(t *testing.T) {
+	var reviewed []authorizationv1.ResourceAttributes
+	client := &fake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			review := &authorizationv1.SelfSubjectAccessReview{}
+			if err := json.NewDecoder(req.Body).Decode(review); err != nil {
+				return nil, err
+			}
+			attrs := *review.Spec.ResourceAttributes
+			reviewed = append(reviewed, attrs)
+			review.Status.Allowed = attrs.Resource != "pods" || attrs.Verb != "create"
+			body, err := json.Marshal(review)
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+			}, nil
+		}),
+	}
+	access := &SARAccess{
+		AccessControl: &AllAccess{},
+		ResourceFor: func(schema *types.Schema) (string, string) {
+			return "", "nodes"
+		},
+		client: client,
+		cache:  cache.NewLRUExpireCache(sarCacheSize),
+		ttl:    time.Minute,
+	}
+
+	schema := &types.Schema{
+		ID: "node",
+		ResourceActions: map[string]types.Action{
+			"cordon": {},
+			"drain": {Permissions: []types.ActionPermission{
+				{Verb: "update"},
+				{Verb: "create", Resource: "pods", Subresource: "eviction"},
+			}},
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/v1/nodes/a?action=drain", nil)
+	req.Header.Set("Impersonate-User", "alice")
+	apiContext := &types.APIContext{Request: req}
+	obj := map[string]interface{}{"id": "a"}
+
+	require.NoError(t, access.CanAction(apiContext, obj, schema, "cordon"))
+	assert.Equal(t, []authorizationv1.ResourceAttributes{
+		{Verb: "update", Resource: "nodes", Name: "a"},
+	}, reviewed)
+
+	err := access.CanAction(apiContext, obj, schema, "drain")
+	assert.EqualError(t, err, "PermissionDenied 403: can not create pods/eviction")
+	assert.Equal(t, []authorizationv1.ResourceAttributes{
+		{Verb: "update", Resource: "nodes", Name: "a"},
+		{Verb: "create", Resource: "pods", Subresource: "eviction"},
+	}, reviewed, "the cached review of update on the node is reused")
+}
+
+type denyActions struct {
+	AllAccess
+}
+
+func (d *denyActions) CanAction(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema, action string) error {
+	return httperror.NewAPIError(httperror.PermissionDenied, "can not "+action)
+}
+
+func TestSARActionDelegate(t *testing.T) {
+	access := &SARAccess{
+		AccessControl: &denyActions{},
+		cache:         cache.NewLRUExpireCache(sarCacheSize),
+		ttl:           time.Minute,
+	}
+
+	schema := &types.Schema{
+		ID:              "node",
+		ResourceActions: map[string]types.Action{"cordon": {}},
+	}
+	apiContext := &types.APIContext{Request: httptest.NewRequest(http.MethodPost, "http://localhost/v1/nodes/a?action=cordon", nil)}
+
+	err := access.CanAction(apiContext, map[string]interface{}{"id": "a"}, schema, "cordon")
+	assert.EqualError(t, err, "PermissionDenied 403: can not cordon")
+}
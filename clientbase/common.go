@@ -2,8 +2,6 @@ package clientbase
 
 import (
 	"bytes"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -55,9 +53,24 @@ type ClientOpts struct {
 	Timeout    time.Duration
 	HTTPClient *http.Client
 	WSDialer   *websocket.Dialer
-	CACerts    string
-	Insecure   bool
-	ProxyURL   string
+	// CACerts replaces the system CA certificates, AdditionalCACerts is added to them, or to CACerts
+	CACerts           string
+	AdditionalCACerts string
+	Insecure          bool
+	// ProxyURL is used for HTTP and HTTPS requests to hosts not matched by NoProxy, the proxy environment
+	// variables are used when it is empty
+	ProxyURL string
+	NoProxy  string
+	// ClientCert and ClientKey are the PEM encoded certificate and key presented to the server
+	ClientCert string
+	ClientKey  string
+	// PinnedPublicKeys are the base64 encoded SHA-256 hashes of public keys, see PublicKeyPin. When set, a
+	// certificate of the verified chain of the server must have one of them, so they can't be used with Insecure.
+	PinnedPublicKeys []string
+	// DebugLogger logs the HTTP exchanges of the client, LogrusDebugLogger is used if it is not set and Debug is
+	// set. DebugBodyLimit is the number of bytes of bodies logged, DefaultDebugBodyLimit if not set.
+	DebugLogger    DebugLogger
+	DebugBodyLimit int
 }
 
 func (c *ClientOpts) getAuthHeader() string {
@@ -184,57 +197,21 @@ func NewAPIClient(opts *ClientOpts) (APIBaseClient, error) {
 
 	client.Timeout = opts.Timeout
 
-	var proxy func(*http.Request) (*url.URL, error)
-	if opts.ProxyURL != "" {
-		proxyURL, err := url.Parse(opts.ProxyURL)
-		if err != nil {
-			return result, fmt.Errorf("invalid proxy address %q: %w", opts.ProxyURL, err)
-		}
-		proxy = http.ProxyURL(proxyURL)
-	} else {
-		proxy = http.ProxyFromEnvironment
-	}
-
-	if opts.CACerts != "" {
-		if Debug {
-			fmt.Println("Some CAcerts are provided.")
-		}
-		roots := x509.NewCertPool()
-		ok := roots.AppendCertsFromPEM([]byte(opts.CACerts))
-		if !ok {
-			return result, err
-		}
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs: roots,
-			},
-			Proxy: proxy,
-		}
-		client.Transport = tr
+	proxy, err := opts.proxy()
+	if err != nil {
+		return result, err
 	}
 
-	if opts.Insecure {
-		if Debug {
-			fmt.Println("Insecure TLS set.")
-		}
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: opts.Insecure,
-			},
-			Proxy: proxy,
-		}
-		client.Transport = tr
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return result, err
 	}
 
-	if !(opts.Insecure) && (opts.CACerts == "") {
-		if Debug {
-			fmt.Println("Insecure TLS not set and no CAcerts is provided.")
-		}
-		tr := &http.Transport{
-			Proxy: proxy,
-		}
-		client.Transport = tr
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           proxy,
 	}
+	client.Transport = opts.debugTransport(transport)
 
 	req, err := http.NewRequest("GET", opts.URL, nil)
 	if err != nil {
@@ -266,10 +243,6 @@ func NewAPIClient(opts *ClientOpts) (APIBaseClient, error) {
 		}
 		req.Header.Add("Authorization", opts.getAuthHeader())
 
-		if Debug {
-			fmt.Println("GET " + req.URL.String())
-		}
-
 		resp, err = client.Do(req)
 		if err != nil {
 			return result, err
@@ -289,10 +262,6 @@ func NewAPIClient(opts *ClientOpts) (APIBaseClient, error) {
 		return result, err
 	}
 
-	if Debug {
-		fmt.Println("Response <= " + string(bytes))
-	}
-
 	err = json.Unmarshal(bytes, &schemas)
 	if err != nil {
 		return result, err
@@ -312,12 +281,11 @@ func NewAPIClient(opts *ClientOpts) (APIBaseClient, error) {
 
 	if result.Opts.WSDialer != nil {
 		result.Ops.Dialer = result.Opts.WSDialer
+	} else {
+		result.Ops.Dialer.Proxy = proxy
 	}
 
-	ht, ok := client.Transport.(*http.Transport)
-	if ok {
-		result.Ops.Dialer.TLSClientConfig = ht.TLSClientConfig
-	}
+	result.Ops.Dialer.TLSClientConfig = transport.TLSClientConfig
 
 	return result, nil
 }
@@ -338,11 +306,23 @@ func (a *APIBaseClient) Websocket(url string, headers map[string][]string) (*web
 		httpHeaders.Add("Authorization", a.Opts.getAuthHeader())
 	}
 
-	if Debug {
-		fmt.Println("WS " + url)
+	if a.Opts == nil || a.Opts.debugLogger() == nil {
+		return a.Ops.Dialer.Dial(url, http.Header(httpHeaders))
 	}
 
-	return a.Ops.Dialer.Dial(url, http.Header(httpHeaders))
+	start := time.Now()
+	conn, resp, err := a.Ops.Dialer.Dial(url, http.Header(httpHeaders))
+	exchange := Exchange{
+		Method:   "WS",
+		URL:      redactRawURL(url),
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if resp != nil {
+		exchange.Status = resp.StatusCode
+	}
+	a.Opts.debugLogger()(exchange)
+	return conn, resp, err
 }
 
 func (a *APIBaseClient) List(schemaType string, opts *types.ListOpts, respObject interface{}) error {
@@ -0,0 +1,254 @@
+package clientbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultDebugBodyLimit is the number of bytes of bodies logged when ClientOpts.DebugBodyLimit is not set
+	DefaultDebugBodyLimit = 4096
+	// maxDebugBody is the size of the largest response bodies read to be logged, larger bodies aren't logged as
+	// they can't be redacted without being read whole
+	maxDebugBody = 1 << 20
+
+	redacted = "[redacted]"
+)
+
+// Exchange summarizes an HTTP request of a client and its response. Bodies are truncated and secrets are
+// redacted from them and from the URL, headers are left out. The bodies of streamed responses, such as watches,
+// and of responses over 1MiB aren't logged.
+type Exchange struct {
+	Method       string
+	URL          string
+	Status       int
+	Duration     time.Duration
+	RequestBody  string
+	ResponseBody string
+	Err          error
+}
+
+// DebugLogger logs the exchanges of clients in debug mode.
+type DebugLogger func(exchange Exchange)
+
+// LogrusDebugLogger logs exchanges at info level with their fields, it is used when Debug is set and
+// ClientOpts.DebugLogger is not.
+func LogrusDebugLogger(exchange Exchange) {
+	entry := logrus.WithFields(logrus.Fields{
+		"method":   exchange.Method,
+		"url":      exchange.URL,
+		"status":   exchange.Status,
+		"duration": exchange.Duration,
+	})
+	if exchange.RequestBody != "" {
+		entry = entry.WithField("request", exchange.RequestBody)
+	}
+	if exchange.ResponseBody != "" {
+		entry = entry.WithField("response", exchange.ResponseBody)
+	}
+	if exchange.Err != nil {
+		entry = entry.WithError(exchange.Err)
+	}
+	entry.Info("rancher client request")
+}
+
+// secretKeys are the parts of the JSON keys and query parameters whose values are redacted, compared in lower case.
+var secretKeys = []string{"password", "secret", "token", "privatekey", "credential", "certificate"}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ClientOpts) debugLogger() DebugLogger {
+	if c.DebugLogger != nil {
+		return c.DebugLogger
+	}
+	if Debug {
+		return LogrusDebugLogger
+	}
+	return nil
+}
+
+// debugTransport wraps transport to log its exchanges when debugging is enabled.
+func (c *ClientOpts) debugTransport(transport http.RoundTripper) http.RoundTripper {
+	logger := c.debugLogger()
+	if logger == nil {
+		return transport
+	}
+	limit := c.DebugBodyLimit
+	if limit <= 0 {
+		limit = DefaultDebugBodyLimit
+	}
+	return &debugTransport{
+		transport: transport,
+		logger:    logger,
+		limit:     limit,
+	}
+}
+
+type debugTransport struct {
+	transport http.RoundTripper
+	logger    DebugLogger
+	limit     int
+}
+
+func (d *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := Exchange{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+	}
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			content, _ := io.ReadAll(body)
+			body.Close()
+			exchange.RequestBody = d.summarize(content)
+		}
+	}
+
+	start := time.Now()
+	resp, err := d.transport.RoundTrip(req)
+	exchange.Duration = time.Since(start)
+	exchange.Err = err
+	if resp != nil {
+		exchange.Status = resp.StatusCode
+		if !streaming(req, resp) {
+			exchange.ResponseBody = d.readBody(resp)
+		}
+	}
+	d.logger(exchange)
+	return resp, err
+}
+
+// streaming returns whether resp is streamed until the client closes it, its body is then left unread.
+func streaming(req *http.Request, resp *http.Response) bool {
+	return req.URL.Query().Get("watch") == "true" ||
+		resp.StatusCode == http.StatusSwitchingProtocols ||
+		strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// readBody returns the summary of the body of resp and replaces the body with a reader of the same content.
+// Bodies over maxDebugBody are only partially read and not logged.
+func (d *debugTransport) readBody(resp *http.Response) string {
+	if resp.Body == nil {
+		return ""
+	}
+	content, readErr := io.ReadAll(io.LimitReader(resp.Body, maxDebugBody+1))
+	if len(content) > maxDebugBody {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(content), resp.Body), resp.Body}
+		return fmt.Sprintf("(body over %d bytes not logged)", maxDebugBody)
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(content))
+	if readErr != nil {
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(content), errReader{readErr}))
+	}
+	return d.summarize(content)
+}
+
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+// summarize redacts the secrets of a JSON body and truncates it to the limit.
+func (d *debugTransport) summarize(content []byte) string {
+	if len(content) == 0 {
+		return ""
+	}
+	var data interface{}
+	if err := json.Unmarshal(content, &data); err == nil {
+		if redactedContent, err := json.Marshal(redactJSON(data)); err == nil {
+			content = redactedContent
+		}
+	}
+	if len(content) > d.limit {
+		return fmt.Sprintf("%s...(%d more bytes)", content[:d.limit], len(content)-d.limit)
+	}
+	return string(content)
+}
+
+// secretDataKeys are the keys of the maps of Secrets whose values are all redacted.
+var secretDataKeys = []string{"data", "stringData"}
+
+func redactJSON(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if isSecret(v) {
+			for _, key := range secretDataKeys {
+				if values, ok := v[key].(map[string]interface{}); ok {
+					for name := range values {
+						values[name] = redacted
+					}
+				}
+			}
+		}
+		for key, value := range v {
+			if _, isString := value.(string); isString && isSecretKey(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJSON(value)
+		}
+	}
+	return data
+}
+
+// isSecret returns whether data is a Kubernetes Secret or a Rancher API secret.
+func isSecret(data map[string]interface{}) bool {
+	if data["kind"] == "Secret" {
+		return true
+	}
+	kind, _ := data["type"].(string)
+	return strings.HasSuffix(strings.ToLower(kind), "secret")
+}
+
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redactedURL.User.Username())
+	}
+	query := redactedURL.Query()
+	changed := false
+	for key := range query {
+		if isSecretKey(key) {
+			query.Set(key, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		redactedURL.RawQuery = query.Encode()
+	}
+	return redactedURL.String()
+}
+
+func redactRawURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return redactURL(u)
+}
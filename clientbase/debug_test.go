@@ -0,0 +1,109 @@
+package clientbase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`{"name":"foo","token":"abc","data":[{"password":"hunter2"}]}`))
+	}))
+	defer server.Close()
+
+	var exchanges []Exchange
+	opts := &ClientOpts{
+		DebugLogger: func(exchange Exchange) {
+			exchanges = append(exchanges, exchange)
+		},
+		DebugBodyLimit: 80,
+	}
+	client := &http.Client{Transport: opts.debugTransport(http.DefaultTransport)}
+
+	resp, err := client.Post(server.URL+"/v3?token=xyz", "application/json",
+		strings.NewReader(`{"secretKey":"s3cr3t","value":"`+strings.Repeat("x", 100)+`"}`))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Contains(t, string(body), "hunter2", "the response body is left intact")
+
+	require.Len(t, exchanges, 1)
+	exchange := exchanges[0]
+	assert.Equal(t, http.MethodPost, exchange.Method)
+	assert.Equal(t, http.StatusOK, exchange.Status)
+	assert.Equal(t, server.URL+"/v3?token=%5Bredacted%5D", exchange.URL)
+	assert.NotContains(t, exchange.RequestBody, "s3cr3t")
+	assert.Contains(t, exchange.RequestBody, "more bytes)")
+	assert.Equal(t, `{"data":[{"password":"[redacted]"}],"name":"foo","token":"[redacted]"}`, exchange.ResponseBody)
+}
+
+func TestDebugTransportSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`{"kind":"Secret","data":{"tls.crt":"Y2VydA=="},"stringData":{"key":"value"}}`))
+	}))
+	defer server.Close()
+
+	var exchanges []Exchange
+	opts := &ClientOpts{
+		DebugLogger: func(exchange Exchange) {
+			exchanges = append(exchanges, exchange)
+		},
+	}
+	client := &http.Client{Transport: opts.debugTransport(http.DefaultTransport)}
+
+	resp, err := client.Get(server.URL + "/v1/secrets/s")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, exchanges, 1)
+	assert.Equal(t, `{"data":{"tls.crt":"[redacted]"},"kind":"Secret","stringData":{"key":"[redacted]"}}`, exchanges[0].ResponseBody)
+}
+
+func TestDebugTransportStreaming(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("watch") == "true" {
+			_, _ = rw.Write([]byte(`{"type":"ADDED"}`))
+			rw.(http.Flusher).Flush()
+			<-done
+			return
+		}
+		_, _ = rw.Write([]byte(strings.Repeat("x", maxDebugBody+10)))
+	}))
+	defer server.Close()
+	defer close(done)
+
+	var exchanges []Exchange
+	opts := &ClientOpts{
+		DebugLogger: func(exchange Exchange) {
+			exchanges = append(exchanges, exchange)
+		},
+	}
+	client := &http.Client{Transport: opts.debugTransport(http.DefaultTransport)}
+
+	resp, err := client.Get(server.URL + "/v1/pods?watch=true")
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+	assert.Empty(t, exchanges[0].ResponseBody)
+	event := make([]byte, len(`{"type":"ADDED"}`))
+	_, err = io.ReadFull(resp.Body, event)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"ADDED"}`, string(event))
+	resp.Body.Close()
+
+	resp, err = client.Get(server.URL + "/v1/pods")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Len(t, body, maxDebugBody+10)
+	require.Len(t, exchanges, 2)
+	assert.Equal(t, "(body over 1048576 bytes not logged)", exchanges[1].ResponseBody)
+}
@@ -58,10 +58,6 @@ func (a *APIOperations) DoGet(url string, opts *types.ListOpts, respObject inter
 		return err
 	}
 
-	if Debug {
-		fmt.Println("GET " + url)
-	}
-
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return err
@@ -85,10 +81,6 @@ func (a *APIOperations) DoGet(url string, opts *types.ListOpts, respObject inter
 		return err
 	}
 
-	if Debug {
-		fmt.Println("Response <= " + string(byteContent))
-	}
-
 	if err := json.Unmarshal(byteContent, respObject); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Failed to parse: %s", byteContent))
 	}
@@ -130,11 +122,6 @@ func (a *APIOperations) DoModify(method string, url string, createObj interface{
 		return err
 	}
 
-	if Debug {
-		fmt.Println(method + " " + url)
-		fmt.Println("Request => " + string(bodyContent))
-	}
-
 	req, err := http.NewRequest(method, url, bytes.NewBuffer(bodyContent))
 	if err != nil {
 		return err
@@ -160,9 +147,6 @@ func (a *APIOperations) DoModify(method string, url string, createObj interface{
 	}
 
 	if len(byteContent) > 0 {
-		if Debug {
-			fmt.Println("Response <= " + string(byteContent))
-		}
 		return json.Unmarshal(byteContent, respObject)
 	}
 
@@ -326,18 +310,11 @@ func (a *APIOperations) doAction(
 
 	var input io.Reader
 
-	if Debug {
-		fmt.Println("POST " + actionURL)
-	}
-
 	if inputObject != nil {
 		bodyContent, err := json.Marshal(inputObject)
 		if err != nil {
 			return err
 		}
-		if Debug {
-			fmt.Println("Request => " + string(bodyContent))
-		}
 		input = bytes.NewBuffer(bodyContent)
 	}
 
@@ -366,10 +343,6 @@ func (a *APIOperations) doAction(
 		return err
 	}
 
-	if Debug {
-		fmt.Println("Response <= " + string(byteContent))
-	}
-
 	if nil != respObject {
 		return json.Unmarshal(byteContent, respObject)
 	}
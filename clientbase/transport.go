@@ -0,0 +1,107 @@
+package clientbase
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+func (c *ClientOpts) proxy() (func(*http.Request) (*url.URL, error), error) {
+	if c.ProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	if _, err := url.Parse(c.ProxyURL); err != nil {
+		return nil, fmt.Errorf("invalid proxy address %q: %w", c.ProxyURL, err)
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  c.ProxyURL,
+		HTTPSProxy: c.ProxyURL,
+		NoProxy:    c.NoProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+func (c *ClientOpts) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if c.CACerts != "" {
+		if Debug {
+			fmt.Println("Some CAcerts are provided.")
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(c.CACerts)) {
+			return nil, errors.New("failed to parse CA certificates")
+		}
+		config.RootCAs = roots
+	}
+
+	if c.AdditionalCACerts != "" {
+		roots := config.RootCAs
+		if roots == nil {
+			systemRoots, err := x509.SystemCertPool()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to load the system CA certificates")
+			}
+			roots = systemRoots
+		}
+		if !roots.AppendCertsFromPEM([]byte(c.AdditionalCACerts)) {
+			return nil, errors.New("failed to parse additional CA certificates")
+		}
+		config.RootCAs = roots
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.Insecure {
+		if Debug {
+			fmt.Println("Insecure TLS set.")
+		}
+		config.InsecureSkipVerify = true
+	}
+
+	if len(c.PinnedPublicKeys) > 0 {
+		pins := map[string]bool{}
+		for _, pin := range c.PinnedPublicKeys {
+			pins[pin] = true
+		}
+		// only the verified chains are trusted, the peer certificates are whatever the server sent
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.VerifiedChains) == 0 {
+				return errors.New("pinned public keys require a verified certificate chain")
+			}
+			for _, chain := range state.VerifiedChains {
+				for _, cert := range chain {
+					if pins[PublicKeyPin(cert)] {
+						return nil
+					}
+				}
+			}
+			return errors.New("no certificate of the server matches a pinned public key")
+		}
+	}
+
+	return config, nil
+}
+
+// PublicKeyPin returns the base64 encoded SHA-256 hash of the public key of cert, the format of
+// ClientOpts.PinnedPublicKeys.
+func PublicKeyPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
@@ -0,0 +1,44 @@
+package clientbase
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnedPublicKeys(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	cert := server.Certificate()
+	opts := &ClientOpts{
+		CACerts: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+	}
+
+	get := func() error {
+		config, err := opts.tlsConfig()
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	opts.PinnedPublicKeys = []string{PublicKeyPin(cert)}
+	assert.NoError(t, get())
+
+	opts.PinnedPublicKeys = []string{"bm90IHRoZSByaWdodCBrZXk="}
+	assert.Error(t, get())
+
+	// without verification, the certificates sent by the server prove nothing
+	opts.CACerts = ""
+	opts.Insecure = true
+	opts.PinnedPublicKeys = []string{PublicKeyPin(cert)}
+	assert.Error(t, get())
+}
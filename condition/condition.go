@@ -155,6 +155,7 @@ func (c Cond) Track(obj runtime.Object, client ObjectClientGetter, f func() (run
 	c.LastUpdated(retObj, c.GetLastUpdated(obj))
 	c.Reason(retObj, c.GetReason(obj))
 	c.Message(retObj, c.GetMessage(obj))
+	c.setObservedGeneration(retObj, c.ObservedGeneration(obj))
 
 	if obj, ok := retObj.(metav1.Object); ok {
 		updated, uerr := client.ObjectClient().Update(obj.GetName(), retObj)
@@ -176,6 +177,7 @@ func (c Cond) do2(setReturned bool, obj runtime.Object, f func() (runtime.Object
 	ts := c.GetLastUpdated(obj)
 	reason := c.GetReason(obj)
 	message := c.GetMessage(obj)
+	generation := c.ObservedGeneration(obj)
 
 	checkObj := obj
 	retObj, err := c.doInternal(setReturned, obj, f)
@@ -198,7 +200,8 @@ func (c Cond) do2(setReturned bool, obj runtime.Object, f func() (runtime.Object
 	changed := status != c.GetStatus(checkObj) ||
 		ts != c.GetLastUpdated(checkObj) ||
 		reason != c.GetReason(checkObj) ||
-		message != c.GetMessage(checkObj)
+		message != c.GetMessage(checkObj) ||
+		generation != c.ObservedGeneration(checkObj)
 
 	return retObj, changed, err
 }
@@ -231,6 +234,7 @@ func (c Cond) doInternal(setReturned bool, obj runtime.Object, f func() (runtime
 	c.True(setObject)
 	c.Reason(setObject, "")
 	c.Message(setObject, "")
+	c.observeGeneration(setObject)
 	return obj, nil
 }
 
@@ -0,0 +1,104 @@
+package condition
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SetObservedGeneration sets status.observedGeneration of obj to its generation. It returns true if the status
+// changed, false too for objects without an int64 status.observedGeneration.
+func SetObservedGeneration(obj runtime.Object) bool {
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	return setGeneration(getValue(obj, "Status", "ObservedGeneration"), metadata.GetGeneration())
+}
+
+// IsCurrent returns whether the status of obj was computed from its current spec, that is whether
+// status.observedGeneration is the generation of obj. Objects without status.observedGeneration are always current.
+func IsCurrent(obj runtime.Object) bool {
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return true
+	}
+	observed := getValue(obj, "Status", "ObservedGeneration")
+	if !observed.IsValid() || observed.Kind() != reflect.Int64 {
+		return true
+	}
+	return observed.Int() >= metadata.GetGeneration()
+}
+
+// ObserveGeneration calls f and, once it succeeds, sets status.observedGeneration of the object it returned, or of
+// obj if it returned nil, to its generation.
+func ObserveGeneration(obj runtime.Object, f func() (runtime.Object, error)) (runtime.Object, error) {
+	newObj, err := f()
+	if err != nil {
+		return newObj, err
+	}
+	if newObj == nil || reflect.ValueOf(newObj).IsNil() {
+		newObj = obj
+	}
+	SetObservedGeneration(newObj)
+	return newObj, nil
+}
+
+// ObservedGeneration returns the observedGeneration of the condition, 0 if it isn't set or the conditions of obj
+// don't have one.
+func (c Cond) ObservedGeneration(obj runtime.Object) int64 {
+	cond := findOrNotCreateCond(obj, string(c))
+	if cond == nil {
+		return 0
+	}
+	observed := getFieldValue(*cond, "ObservedGeneration")
+	if !observed.IsValid() || observed.Kind() != reflect.Int64 {
+		return 0
+	}
+	return observed.Int()
+}
+
+// IsCurrent returns whether the condition was set for the current generation of obj, false if obj doesn't have the
+// condition and always true if the conditions of obj don't have an observedGeneration.
+func (c Cond) IsCurrent(obj runtime.Object) bool {
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return true
+	}
+	cond := findOrNotCreateCond(obj, string(c))
+	if cond == nil {
+		return false
+	}
+	observed := getFieldValue(*cond, "ObservedGeneration")
+	if !observed.IsValid() || observed.Kind() != reflect.Int64 {
+		return true
+	}
+	return observed.Int() >= metadata.GetGeneration()
+}
+
+// observeGeneration sets the observedGeneration of the condition to the generation of obj, when the conditions of
+// obj have one.
+func (c Cond) observeGeneration(obj runtime.Object) {
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	c.setObservedGeneration(obj, metadata.GetGeneration())
+}
+
+func (c Cond) setObservedGeneration(obj runtime.Object, generation int64) {
+	cond := findOrNotCreateCond(obj, string(c))
+	if cond == nil {
+		return
+	}
+	setGeneration(getFieldValue(*cond, "ObservedGeneration"), generation)
+}
+
+func setGeneration(observed reflect.Value, generation int64) bool {
+	if !observed.IsValid() || observed.Kind() != reflect.Int64 || !observed.CanSet() || observed.Int() == generation {
+		return false
+	}
+	observed.SetInt(generation)
+	return true
+}
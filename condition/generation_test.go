@@ -0,0 +1,68 @@
+package condition
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type generationCondition struct {
+	GenericCondition
+	ObservedGeneration int64
+}
+
+type generationObject struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+	Status struct {
+		ObservedGeneration int64
+		Conditions         []generationCondition
+	}
+}
+
+func (g *generationObject) DeepCopyObject() runtime.Object {
+	result := *g
+	result.Status.Conditions = append([]generationCondition(nil), g.Status.Conditions...)
+	return &result
+}
+
+func TestObservedGeneration(t *testing.T) {
+	object := &generationObject{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	assert.False(t, IsCurrent(object))
+
+	_, err := ObserveGeneration(object, func() (runtime.Object, error) {
+		return nil, errors.New("failed")
+	})
+	assert.Error(t, err)
+	assert.False(t, IsCurrent(object))
+
+	obj, err := ObserveGeneration(object, func() (runtime.Object, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, IsCurrent(obj))
+	assert.Equal(t, int64(2), object.Status.ObservedGeneration)
+	assert.False(t, SetObservedGeneration(object))
+
+	ready := Cond("Ready")
+	assert.False(t, ready.IsCurrent(object))
+	_, err = ready.Do(object, func() (runtime.Object, error) {
+		return object, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), ready.ObservedGeneration(object))
+	assert.True(t, ready.IsCurrent(object))
+
+	object.Generation = 3
+	assert.False(t, IsCurrent(object))
+	assert.False(t, ready.IsCurrent(object))
+
+	node := &v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: "Ready"}}}}
+	assert.True(t, IsCurrent(node))
+	assert.True(t, ready.IsCurrent(node))
+	assert.False(t, SetObservedGeneration(node))
+}
@@ -0,0 +1,36 @@
+package condition
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Prune removes the conditions of obj whose type is not in allowed, so that conditions set by earlier versions of
+// a controller do not pile up in the status across upgrades. Conditions owned by other controllers must be in
+// allowed too. It returns true if a condition was removed and the status has to be updated.
+func Prune(obj runtime.Object, allowed ...Cond) bool {
+	condSlice := getValue(obj, "Status", "Conditions")
+	if !condSlice.IsValid() || condSlice.Kind() != reflect.Slice {
+		return false
+	}
+
+	keep := map[string]bool{}
+	for _, c := range allowed {
+		keep[string(c)] = true
+	}
+
+	pruned := reflect.MakeSlice(condSlice.Type(), 0, condSlice.Len())
+	for i := 0; i < condSlice.Len(); i++ {
+		cond := condSlice.Index(i)
+		if keep[getFieldValue(cond, "Type").String()] {
+			pruned = reflect.Append(pruned, cond)
+		}
+	}
+
+	if pruned.Len() == condSlice.Len() {
+		return false
+	}
+	condSlice.Set(pruned)
+	return true
+}
@@ -0,0 +1,28 @@
+package condition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestPrune(t *testing.T) {
+	node := &v1.Node{
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{Type: "Ready", Status: "True"},
+				{Type: "Obsolete", Status: "False"},
+				{Type: "Provisioned", Status: "True"},
+			},
+		},
+	}
+
+	assert.True(t, Prune(node, "Ready", "Provisioned"))
+	assert.Equal(t, []v1.NodeCondition{
+		{Type: "Ready", Status: "True"},
+		{Type: "Provisioned", Status: "True"},
+	}, node.Status.Conditions)
+
+	assert.False(t, Prune(node, "Ready", "Provisioned"))
+}
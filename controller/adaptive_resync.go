@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+type AdaptiveResyncOptions struct {
+	// Min is the resync interval of keys whose last handling failed, defaults to 30 seconds
+	Min time.Duration
+	// Max is the longest resync interval of stable keys, defaults to 1 hour
+	Max time.Duration
+}
+
+// resyncState is the resync interval of a key and when its resync is due.
+type resyncState struct {
+	interval time.Duration
+	due      time.Time
+}
+
+type adaptiveResync struct {
+	sync.Mutex
+	controller GenericController
+	opts       AdaptiveResyncOptions
+	keys       map[string]resyncState
+	now        func() time.Time
+}
+
+// NewAdaptiveResyncHandler wraps a handler so that each key is requeued after an interval of its own instead of
+// the fixed resync period of the informer. The interval drops to Min when the handler fails and doubles, up to
+// Max, each time it succeeds on a resync, so failing objects stay fresh while stable ones are reconciled less and
+// less often. Calls for the events of the informer don't change the interval. The resyncs aren't events: unlike
+// Enqueue they don't make the handlers with predicates of the controller run, see AddHandlerWithPredicates.
+// It is meant for controllers whose informer resync is disabled.
+func NewAdaptiveResyncHandler(controller GenericController, opts AdaptiveResyncOptions, handler HandlerFunc) HandlerFunc {
+	if opts.Min <= 0 {
+		opts.Min = 30 * time.Second
+	}
+	if opts.Max < opts.Min {
+		opts.Max = time.Hour
+		if opts.Max < opts.Min {
+			opts.Max = opts.Min
+		}
+	}
+
+	ar := &adaptiveResync{
+		controller: controller,
+		opts:       opts,
+		keys:       map[string]resyncState{},
+		now:        time.Now,
+	}
+	return ar.handler(handler)
+}
+
+func (a *adaptiveResync) handler(handler HandlerFunc) HandlerFunc {
+	return func(key string, obj interface{}) (interface{}, error) {
+		result, err := handler(key, obj)
+		if obj == nil {
+			a.forget(key)
+			return result, err
+		}
+
+		namespace, name, splitErr := cache.SplitMetaNamespaceKey(key)
+		if splitErr != nil {
+			return result, err
+		}
+		if after, ok := a.next(key, err); ok {
+			if g, ok := a.controller.(*genericController); ok {
+				g.enqueueAfter(namespace, name, after)
+			} else {
+				a.controller.EnqueueAfter(namespace, name, after)
+			}
+		}
+		return result, err
+	}
+}
+
+// next returns the interval after which key must be resynced and whether to enqueue it, which it isn't if the
+// handler succeeded on an event before the resync already enqueued.
+func (a *adaptiveResync) next(key string, err error) (time.Duration, bool) {
+	a.Lock()
+	defer a.Unlock()
+
+	now := a.now()
+	state, ok := a.keys[key]
+	pending := ok && now.Before(state.due)
+	switch {
+	case err != nil || !ok:
+		state.interval = a.opts.Min
+	case pending:
+		return 0, false
+	case state.interval*2 > a.opts.Max:
+		state.interval = a.opts.Max
+	default:
+		state.interval *= 2
+	}
+
+	due := now.Add(state.interval)
+	// the workqueue keeps the earliest of the times a key is enqueued after
+	if !pending || due.Before(state.due) {
+		state.due = due
+	}
+	a.keys[key] = state
+	return state.interval, true
+}
+
+func (a *adaptiveResync) forget(key string) {
+	a.Lock()
+	defer a.Unlock()
+	delete(a.keys, key)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type enqueueRecorder struct {
+	GenericController
+	after []time.Duration
+}
+
+func (e *enqueueRecorder) EnqueueAfter(namespace, name string, after time.Duration) {
+	e.after = append(e.after, after)
+}
+
+func TestAdaptiveResync(t *testing.T) {
+	recorder := &enqueueRecorder{}
+	start := time.Now()
+	now := start
+	ar := &adaptiveResync{
+		controller: recorder,
+		opts:       AdaptiveResyncOptions{Min: time.Second, Max: 5 * time.Second},
+		keys:       map[string]resyncState{},
+		now:        func() time.Time { return now },
+	}
+	var err error
+	handler := ar.handler(func(key string, obj interface{}) (interface{}, error) {
+		return obj, err
+	})
+	at := func(offset time.Duration, obj interface{}) {
+		now = start.Add(offset)
+		_, _ = handler("default/a", obj)
+	}
+
+	at(0, "obj")
+	// events before the resync don't change the interval
+	at(500*time.Millisecond, "obj")
+	at(time.Second, "obj")
+	at(3*time.Second, "obj")
+	at(7*time.Second, "obj")
+	at(12*time.Second, "obj")
+	err = errors.New("failed")
+	at(12500*time.Millisecond, "obj")
+	err = nil
+	at(13*time.Second, "obj")
+	at(13500*time.Millisecond, "obj")
+	at(14*time.Second, nil)
+	at(15*time.Second, "obj")
+
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+		time.Second, 2 * time.Second,
+		time.Second,
+	}, recorder.after)
+}
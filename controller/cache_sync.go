@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rancher/lasso/pkg/cache"
+	"github.com/rancher/norman/metrics"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+type CacheSyncPolicy string
+
+const (
+	// CacheSyncFailFast exits the process, so that it is restarted, when a cache does not sync in time.
+	CacheSyncFailFast CacheSyncPolicy = "failFast"
+	// CacheSyncDegraded starts the controllers without waiting any longer for the caches that did not sync.
+	CacheSyncDegraded CacheSyncPolicy = "degraded"
+	// CacheSyncRetry starts the controllers like CacheSyncDegraded and keeps waiting for the caches that did not
+	// sync in the background, logging them every timeout until they do.
+	CacheSyncRetry CacheSyncPolicy = "retry"
+)
+
+type CacheSyncOptions struct {
+	// Timeout is how long to wait for the initial sync of the caches, defaults to 5 minutes
+	Timeout time.Duration
+	// Policy defaults to CacheSyncDegraded
+	Policy CacheSyncPolicy
+}
+
+type cacheSyncFactory struct {
+	cache.SharedCacheFactory
+	opts CacheSyncOptions
+	// degraded holds, by kind, whether the caches given up on are reported as synced
+	degraded sync.Map
+}
+
+// NewCacheSyncFactory wraps a cache factory so that waiting for the initial sync of its caches, when the
+// controller factory built on it starts, is bounded by a timeout instead of blocking forever on a cache whose watch
+// is broken, by RBAC or an unavailable aggregated API for instance. Caches that are not synced are reported by
+// the cache_unsynced metric. With the degraded and retry policies, the caches of the factory report that they
+// synced once the timeout expired, so that the controllers using them, which wait for their cache to sync before
+// starting their workers, start as well.
+func NewCacheSyncFactory(delegate cache.SharedCacheFactory, opts CacheSyncOptions) cache.SharedCacheFactory {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.Policy == "" {
+		opts.Policy = CacheSyncDegraded
+	}
+	return &cacheSyncFactory{
+		SharedCacheFactory: delegate,
+		opts:               opts,
+	}
+}
+
+func (c *cacheSyncFactory) WaitForCacheSync(ctx context.Context) map[schema.GroupVersionKind]bool {
+	timeoutCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	result := c.SharedCacheFactory.WaitForCacheSync(timeoutCtx)
+	if ctx.Err() != nil {
+		return result
+	}
+
+	var unsynced []schema.GroupVersionKind
+	for gvk, synced := range result {
+		metrics.SetCacheUnsynced(gvk.String(), !synced)
+		if !synced {
+			unsynced = append(unsynced, gvk)
+		}
+	}
+	if len(unsynced) == 0 {
+		return result
+	}
+
+	if c.opts.Policy != CacheSyncFailFast {
+		for _, gvk := range unsynced {
+			c.degradedFlag(gvk).Store(true)
+		}
+	}
+	switch c.opts.Policy {
+	case CacheSyncFailFast:
+		logrus.Fatalf("caches of %v did not sync within %v", unsynced, c.opts.Timeout)
+	case CacheSyncRetry:
+		logrus.Warnf("caches of %v did not sync within %v, starting degraded and waiting in the background", unsynced, c.opts.Timeout)
+		for _, gvk := range unsynced {
+			go c.waitInBackground(ctx, gvk)
+		}
+	default:
+		logrus.Warnf("caches of %v did not sync within %v, starting degraded", unsynced, c.opts.Timeout)
+	}
+	return result
+}
+
+func (c *cacheSyncFactory) ForKind(gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, error) {
+	informer, err := c.SharedCacheFactory.ForKind(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return &degradedInformer{SharedIndexInformer: informer, degraded: c.degradedFlag(gvk)}, nil
+}
+
+// ForResourceKind is used by the controllers of the controller factory to get their cache.
+func (c *cacheSyncFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) (toolscache.SharedIndexInformer, error) {
+	informer, err := c.SharedCacheFactory.ForResourceKind(gvr, kind, namespaced)
+	if err != nil {
+		return nil, err
+	}
+	gvk := gvr.GroupVersion().WithKind(kind)
+	if kind == "" {
+		if gvk, err = c.SharedClientFactory().GVKForResource(gvr); err != nil {
+			return informer, nil
+		}
+	}
+	return &degradedInformer{SharedIndexInformer: informer, degraded: c.degradedFlag(gvk)}, nil
+}
+
+func (c *cacheSyncFactory) degradedFlag(gvk schema.GroupVersionKind) *atomic.Bool {
+	flag, _ := c.degraded.LoadOrStore(gvk, &atomic.Bool{})
+	return flag.(*atomic.Bool)
+}
+
+// degradedInformer reports that it synced once its kind is degraded.
+type degradedInformer struct {
+	toolscache.SharedIndexInformer
+	degraded *atomic.Bool
+}
+
+func (d *degradedInformer) HasSynced() bool {
+	return d.degraded.Load() || d.SharedIndexInformer.HasSynced()
+}
+
+func (c *cacheSyncFactory) waitInBackground(ctx context.Context, gvk schema.GroupVersionKind) {
+	informer, err := c.SharedCacheFactory.ForKind(gvk)
+	if err != nil {
+		logrus.Errorf("failed to get the cache of %v: %v", gvk, err)
+		return
+	}
+
+	for {
+		timeoutCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+		synced := toolscache.WaitForCacheSync(timeoutCtx.Done(), informer.HasSynced)
+		cancel()
+
+		if synced {
+			logrus.Infof("cache of %v synced", gvk)
+			metrics.SetCacheUnsynced(gvk.String(), false)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.Warnf("cache of %v is still not synced", gvk)
+	}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+type stuckCacheFactory struct {
+	cache.SharedCacheFactory
+}
+
+// stuckInformer never syncs, like the cache of a watch denied by RBAC.
+type stuckInformer struct {
+	toolscache.SharedIndexInformer
+}
+
+func (s *stuckInformer) HasSynced() bool {
+	return false
+}
+
+func (s *stuckCacheFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) (toolscache.SharedIndexInformer, error) {
+	return &stuckInformer{}, nil
+}
+
+func (s *stuckCacheFactory) WaitForCacheSync(ctx context.Context) map[schema.GroupVersionKind]bool {
+	<-ctx.Done()
+	return map[schema.GroupVersionKind]bool{
+		{Version: "v1", Kind: "ConfigMap"}: false,
+	}
+}
+
+func TestCacheSyncTimeout(t *testing.T) {
+	factory := NewCacheSyncFactory(&stuckCacheFactory{}, CacheSyncOptions{
+		Timeout: 10 * time.Millisecond,
+	})
+
+	done := make(chan map[schema.GroupVersionKind]bool)
+	go func() {
+		done <- factory.WaitForCacheSync(context.Background())
+	}()
+
+	select {
+	case result := <-done:
+		assert.False(t, result[schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}])
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting for the caches did not time out")
+	}
+}
+
+func TestCacheSyncDegradedStartsControllers(t *testing.T) {
+	factory := NewCacheSyncFactory(&stuckCacheFactory{}, CacheSyncOptions{
+		Timeout: 10 * time.Millisecond,
+	})
+	informer, err := factory.ForResourceKind(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ConfigMap", true)
+	assert.NoError(t, err)
+	assert.False(t, informer.HasSynced())
+
+	factory.WaitForCacheSync(context.Background())
+
+	// controllers wait for their cache with the context they run with, before starting their workers
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.True(t, toolscache.WaitForCacheSync(ctx.Done(), informer.HasSynced))
+}
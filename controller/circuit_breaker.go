@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+)
+
+type CircuitBreakerOptions struct {
+	// Failures is the number of handler errors within Window that opens the breaker
+	Failures int
+	Window   time.Duration
+	// Delay is how long the breaker stays open; keys handled while open are requeued after the remaining time
+	Delay time.Duration
+}
+
+type circuitBreaker struct {
+	sync.Mutex
+	controller GenericController
+	name       string
+	opts       CircuitBreakerOptions
+	now        func() time.Time
+	failures   []time.Time
+	openUntil  time.Time
+	// trips is the number of times the breaker opened
+	trips int
+}
+
+// NewCircuitBreakerHandler wraps a handler that depends on an external system so that after repeated failures
+// keys are requeued with a long delay instead of being retried in a hot loop. Once the breaker closes again a
+// single failure reopens it until a call succeeds.
+func NewCircuitBreakerHandler(controller GenericController, name string, opts CircuitBreakerOptions, handler HandlerFunc) HandlerFunc {
+	if opts.Failures <= 0 {
+		opts.Failures = 5
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.Delay <= 0 {
+		opts.Delay = 5 * time.Minute
+	}
+
+	cb := &circuitBreaker{
+		controller: controller,
+		name:       name,
+		opts:       opts,
+		now:        time.Now,
+	}
+	return cb.handler(handler)
+}
+
+func (c *circuitBreaker) handler(handler HandlerFunc) HandlerFunc {
+	return func(key string, obj interface{}) (interface{}, error) {
+		if wait := c.remaining(); wait > 0 {
+			namespace, name, err := cache.SplitMetaNamespaceKey(key)
+			if err != nil {
+				return obj, err
+			}
+			c.controller.EnqueueAfter(namespace, name, wait)
+			return obj, nil
+		}
+
+		result, err := handler(key, obj)
+		c.record(err)
+		return result, err
+	}
+}
+
+func (c *circuitBreaker) remaining() time.Duration {
+	c.Lock()
+	defer c.Unlock()
+	return c.openUntil.Sub(c.now())
+}
+
+func (c *circuitBreaker) record(err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err == nil {
+		c.failures = nil
+		if !c.openUntil.IsZero() {
+			c.openUntil = time.Time{}
+			metrics.SetCircuitBreakerOpen(c.controllerName(), c.name, false)
+		}
+		return
+	}
+
+	now := c.now()
+	if now.Before(c.openUntil) {
+		// the call started before the breaker opened
+		return
+	}
+	halfOpen := !c.openUntil.IsZero()
+	failures := c.failures[:0]
+	for _, failure := range c.failures {
+		if now.Sub(failure) < c.opts.Window {
+			failures = append(failures, failure)
+		}
+	}
+	c.failures = append(failures, now)
+
+	if halfOpen || len(c.failures) >= c.opts.Failures {
+		logrus.Warnf("circuit breaker for %s handler %s opened for %v after error: %v", c.controllerName(), c.name,
+			c.opts.Delay, err)
+		c.failures = nil
+		c.openUntil = now.Add(c.opts.Delay)
+		c.trips++
+		metrics.SetCircuitBreakerOpen(c.controllerName(), c.name, true)
+		metrics.IncCircuitBreakerTrips(c.controllerName(), c.name)
+	}
+}
+
+func (c *circuitBreaker) controllerName() string {
+	if g, ok := c.controller.(*genericController); ok {
+		return g.name
+	}
+	return ""
+}
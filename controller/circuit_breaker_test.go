@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	recorder := &enqueueRecorder{}
+	now := time.Now()
+	cb := &circuitBreaker{
+		controller: recorder,
+		name:       "test",
+		opts:       CircuitBreakerOptions{Failures: 2, Window: time.Minute, Delay: 5 * time.Minute},
+		now:        func() time.Time { return now },
+	}
+	calls := 0
+	var handlerErr error
+	handler := cb.handler(func(key string, obj interface{}) (interface{}, error) {
+		calls++
+		return obj, handlerErr
+	})
+	call := func() error {
+		_, err := handler("ns/a", nil)
+		return err
+	}
+
+	handlerErr = errors.New("unavailable")
+	assert.Error(t, call())
+	assert.Equal(t, 0, cb.trips)
+	assert.Error(t, call())
+	assert.Equal(t, 1, cb.trips)
+
+	// keys are requeued after the remaining delay while the breaker is open
+	now = now.Add(time.Minute)
+	assert.NoError(t, call())
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []time.Duration{4 * time.Minute}, recorder.after)
+
+	// failures of calls started before the breaker opened are not trips
+	cb.record(handlerErr)
+	assert.Equal(t, 1, cb.trips)
+	assert.Equal(t, 4*time.Minute, cb.remaining())
+
+	// a single failure reopens the breaker once the delay passed
+	now = now.Add(5 * time.Minute)
+	assert.Error(t, call())
+	assert.Equal(t, 2, cb.trips)
+	assert.Equal(t, 5*time.Minute, cb.remaining())
+
+	// a success closes it
+	now = now.Add(5 * time.Minute)
+	handlerErr = nil
+	assert.NoError(t, call())
+	assert.True(t, cb.openUntil.IsZero())
+
+	// failures outside of the window do not open it
+	handlerErr = errors.New("unavailable")
+	assert.Error(t, call())
+	now = now.Add(2 * time.Minute)
+	assert.Error(t, call())
+	assert.Equal(t, 2, cb.trips)
+	assert.Equal(t, 6, calls)
+}
@@ -0,0 +1,357 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/rancher/norman/objectclient"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// ConfigKey is the key of the config in the ConfigMaps and Secrets watched by WatchConfig and WatchConfigSecret.
+const ConfigKey = "config"
+
+// Config is the tuning of controllers that can change while they run, in YAML or JSON.
+type Config struct {
+	// HandlerGates are the percentages of the keys handlers run for by handler name, see SetHandlerGate
+	HandlerGates map[string]int `json:"handlerGates,omitempty"`
+	// DisallowedNamespaces are the namespaces whose objects are restricted targets, see objectclient.RestrictTargets:
+	// clients with restricted targets don't write them and their lifecycles only finalize them. Configs setting
+	// them replace the restrictions registered by RestrictTargets.
+	DisallowedNamespaces []string `json:"disallowedNamespaces,omitempty"`
+	// Workers is a concurrency limit, not a number of workers: it bounds the handler calls running at once by
+	// controller name, across all workers of the controller, unbounded if 0. It can only lower the concurrency of
+	// the workers the controller was started with.
+	Workers map[string]int `json:"workers,omitempty"`
+	// Resync enqueues every object of controllers by controller name at their period, on top of the resync of
+	// their informer
+	Resync map[string]metav1.Duration `json:"resync,omitempty"`
+	// RateLimits bound the rate at which handlers are called by controller name
+	RateLimits map[string]RateLimit `json:"rateLimits,omitempty"`
+	// SerializeKeys, AnnotateErrors and LagOutlierThreshold set SerializeKeys, AnnotateErrors and
+	// LogReconcileLagOutliers if set. Once configs don't set them anymore they are back to the value set in code.
+	SerializeKeys       *bool            `json:"serializeKeys,omitempty"`
+	AnnotateErrors      *bool            `json:"annotateErrors,omitempty"`
+	LagOutlierThreshold *metav1.Duration `json:"lagOutlierThreshold,omitempty"`
+}
+
+// RateLimit is a token bucket rate limit of QPS calls per second with bursts of Burst calls.
+type RateLimit struct {
+	QPS   float32 `json:"qps"`
+	Burst int     `json:"burst"`
+}
+
+// Validate returns an error for invalid configs, which aren't applied.
+func (c *Config) Validate() error {
+	for name, percent := range c.HandlerGates {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("gate of handler %s must be between 0 and 100, not %d", name, percent)
+		}
+	}
+	for _, namespace := range c.DisallowedNamespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid disallowed namespace %s: %v", namespace, errs)
+		}
+	}
+	for name, workers := range c.Workers {
+		if workers < 0 {
+			return fmt.Errorf("workers of controller %s must not be negative", name)
+		}
+	}
+	for name, period := range c.Resync {
+		if period.Duration < 0 {
+			return fmt.Errorf("resync period of controller %s must not be negative", name)
+		}
+	}
+	for name, limit := range c.RateLimits {
+		if limit.QPS <= 0 || limit.Burst <= 0 {
+			return fmt.Errorf("rate limit of controller %s must have a positive qps and burst", name)
+		}
+	}
+	if c.LagOutlierThreshold != nil && c.LagOutlierThreshold.Duration < 0 {
+		return fmt.Errorf("lag outlier threshold must not be negative")
+	}
+	return nil
+}
+
+// ConfigStatus is the config applied by ApplyConfig, and the error of the last config that failed to apply.
+type ConfigStatus struct {
+	Applied   *Config   `json:"applied,omitempty"`
+	AppliedAt time.Time `json:"appliedAt,omitempty"`
+	// Source is the ConfigMap or Secret the config was read from, and ResourceVersion its version
+	Source          string `json:"source,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+var config = struct {
+	lock      sync.Mutex
+	status    ConfigStatus
+	throttles map[string]*throttle
+	// serializeKeys, annotateErrors and lagOutlierThreshold are the values set in code while configs override them
+	serializeKeys       *bool
+	annotateErrors      *bool
+	lagOutlierThreshold *time.Duration
+	// changed is closed and replaced when a config is applied
+	changed chan struct{}
+}{
+	changed: make(chan struct{}),
+}
+
+// throttle holds the worker slots and rate limiter of a controller.
+type throttle struct {
+	workers int
+	slots   chan struct{}
+	limit   RateLimit
+	limiter flowcontrol.RateLimiter
+}
+
+// ApplyConfig validates and applies c, replacing the previously applied config: the handler gates it set are
+// removed if c doesn't set them anymore.
+func ApplyConfig(c Config) error {
+	return applyConfig(c, "", "")
+}
+
+func applyConfig(c Config, source, resourceVersion string) error {
+	config.lock.Lock()
+	defer config.lock.Unlock()
+
+	if err := c.Validate(); err != nil {
+		config.status.Error = err.Error()
+		return err
+	}
+
+	if config.status.Applied != nil {
+		for name := range config.status.Applied.HandlerGates {
+			if _, ok := c.HandlerGates[name]; !ok {
+				RemoveHandlerGate(name)
+			}
+		}
+	}
+	for name, percent := range c.HandlerGates {
+		SetHandlerGate(name, percent)
+	}
+	if len(c.DisallowedNamespaces) > 0 {
+		disallowed := map[string]bool{}
+		for _, namespace := range c.DisallowedNamespaces {
+			disallowed[namespace] = true
+		}
+		objectclient.RestrictTargets(func(_ schema.GroupVersionKind, namespace string) bool {
+			return disallowed[namespace]
+		})
+	} else if config.status.Applied != nil && len(config.status.Applied.DisallowedNamespaces) > 0 {
+		objectclient.RestrictTargets(nil)
+	}
+	config.throttles = throttles(c, config.throttles)
+	override(c.SerializeKeys, &config.serializeKeys, serializeKeys.Load, SerializeKeys)
+	override(c.AnnotateErrors, &config.annotateErrors, annotateErrors.Load, AnnotateErrors)
+	var lagOutlierThreshold *time.Duration
+	if c.LagOutlierThreshold != nil {
+		lagOutlierThreshold = &c.LagOutlierThreshold.Duration
+	}
+	override(lagOutlierThreshold, &config.lagOutlierThreshold, func() time.Duration {
+		return time.Duration(lagOutlier.Load())
+	}, LogReconcileLagOutliers)
+
+	config.status = ConfigStatus{
+		Applied:         &c,
+		AppliedAt:       time.Now().UTC(),
+		Source:          source,
+		ResourceVersion: resourceVersion,
+	}
+	close(config.changed)
+	config.changed = make(chan struct{})
+	return nil
+}
+
+// override sets a setting to value if set. saved holds the value set in code while configs override it, which the
+// setting is set back to once value isn't set anymore.
+func override[T any](value *T, saved **T, get func() T, set func(T)) {
+	switch {
+	case value != nil:
+		if *saved == nil {
+			current := get()
+			*saved = &current
+		}
+		set(*value)
+	case *saved != nil:
+		set(**saved)
+		*saved = nil
+	}
+}
+
+// throttles returns the throttles of the controllers with workers or rate limits in c, keeping the previous
+// throttles whose settings didn't change.
+func throttles(c Config, previous map[string]*throttle) map[string]*throttle {
+	result := map[string]*throttle{}
+	for name, workers := range c.Workers {
+		if workers > 0 {
+			result[name] = &throttle{workers: workers}
+		}
+	}
+	for name, limit := range c.RateLimits {
+		if result[name] == nil {
+			result[name] = &throttle{}
+		}
+		result[name].limit = limit
+	}
+	for name, t := range result {
+		if old, ok := previous[name]; ok && old.workers == t.workers && old.limit == t.limit {
+			result[name] = old
+			continue
+		}
+		if t.workers > 0 {
+			t.slots = make(chan struct{}, t.workers)
+		}
+		if t.limit.QPS > 0 {
+			t.limiter = flowcontrol.NewTokenBucketRateLimiter(t.limit.QPS, t.limit.Burst)
+		}
+	}
+	return result
+}
+
+// throttleHandler waits for the rate limit and a worker slot of controller in the applied config, and returns the
+// func releasing the slot.
+func throttleHandler(ctx context.Context, controller string) (func(), error) {
+	config.lock.Lock()
+	t := config.throttles[controller]
+	config.lock.Unlock()
+	if t == nil {
+		return func() {}, nil
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if t.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-t.slots
+	}, nil
+}
+
+// resyncPeriod returns the resync period of controller in the applied config, and a channel closed when another
+// config is applied.
+func resyncPeriod(controller string) (time.Duration, <-chan struct{}) {
+	config.lock.Lock()
+	defer config.lock.Unlock()
+	var period time.Duration
+	if config.status.Applied != nil {
+		period = config.status.Applied.Resync[controller].Duration
+	}
+	return period, config.changed
+}
+
+// CurrentConfig returns the status of the config applied by ApplyConfig.
+func CurrentConfig() ConfigStatus {
+	config.lock.Lock()
+	defer config.lock.Unlock()
+	return config.status
+}
+
+// NewConfigHandler returns a debug handler reporting CurrentConfig.
+func NewConfigHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(CurrentConfig())
+	})
+}
+
+// WatchConfig applies the config of the ConfigMap name in namespace, and its changes, until ctx is done. Invalid
+// configs are logged and reported by CurrentConfig, the previous config stays applied. Deleting the ConfigMap
+// applies an empty config.
+func WatchConfig(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	factory := configInformerFactory(client, namespace, name)
+	return watchConfig(ctx, factory, factory.Core().V1().ConfigMaps().Informer(), func(obj interface{}) (string, string, bool) {
+		configMap, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return "", "", false
+		}
+		data, ok := configMap.Data[ConfigKey]
+		return data, configMap.ResourceVersion, ok
+	}, "configmap "+namespace+"/"+name)
+}
+
+// WatchConfigSecret is WatchConfig for a Secret.
+func WatchConfigSecret(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	factory := configInformerFactory(client, namespace, name)
+	return watchConfig(ctx, factory, factory.Core().V1().Secrets().Informer(), func(obj interface{}) (string, string, bool) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return "", "", false
+		}
+		data, ok := secret.Data[ConfigKey]
+		return string(data), secret.ResourceVersion, ok
+	}, "secret "+namespace+"/"+name)
+}
+
+func configInformerFactory(client kubernetes.Interface, namespace, name string) informers.SharedInformerFactory {
+	return informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = "metadata.name=" + name
+		}))
+}
+
+func watchConfig(ctx context.Context, factory informers.SharedInformerFactory, informer cache.SharedIndexInformer,
+	data func(obj interface{}) (string, string, bool), source string) error {
+	apply := func(obj interface{}) {
+		content, resourceVersion, ok := data(obj)
+		if !ok {
+			logrus.Warnf("controller config %s has no %s key", source, ConfigKey)
+			return
+		}
+		if err := applyConfigData(content, source, resourceVersion); err != nil {
+			logrus.Errorf("failed to apply controller config %s: %v", source, err)
+			return
+		}
+		logrus.Infof("applied controller config %s at version %s", source, resourceVersion)
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: apply,
+		UpdateFunc: func(_, newObj interface{}) {
+			apply(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if err := applyConfig(Config{}, source, ""); err != nil {
+				logrus.Errorf("failed to reset controller config %s: %v", source, err)
+				return
+			}
+			logrus.Infof("reset controller config, %s was deleted", source)
+		},
+	}); err != nil {
+		return err
+	}
+	factory.Start(ctx.Done())
+	return nil
+}
+
+func applyConfigData(content, source, resourceVersion string) error {
+	var c Config
+	if err := yaml.Unmarshal([]byte(content), &c); err != nil {
+		config.lock.Lock()
+		config.status.Error = err.Error()
+		config.lock.Unlock()
+		return err
+	}
+	return applyConfig(c, source, resourceVersion)
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/objectclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestApplyConfig(t *testing.T) {
+	defer ApplyConfig(Config{})
+
+	require.NoError(t, applyConfigData(`
+handlerGates:
+  config-test-a: 0
+  config-test-b: 0
+disallowedNamespaces: [kube-system]
+lagOutlierThreshold: 30s
+`, "configmap cattle-system/controllers", "5"))
+	status := CurrentConfig()
+	assert.Equal(t, "5", status.ResourceVersion)
+	assert.Equal(t, 30*time.Second, status.Applied.LagOutlierThreshold.Duration)
+	assert.False(t, gates.allowed("config-test-a", "ns/key"))
+	assert.True(t, objectclient.IsRestrictedTarget(corev1.SchemeGroupVersion.WithKind("Pod"), "kube-system"))
+	assert.False(t, objectclient.IsRestrictedTarget(corev1.SchemeGroupVersion.WithKind("Pod"), "default"))
+
+	err := applyConfigData("handlerGates: {config-test-a: 200}", "configmap cattle-system/controllers", "6")
+	assert.EqualError(t, err, "gate of handler config-test-a must be between 0 and 100, not 200")
+	status = CurrentConfig()
+	assert.Equal(t, "5", status.ResourceVersion)
+	assert.Equal(t, err.Error(), status.Error)
+
+	require.NoError(t, ApplyConfig(Config{HandlerGates: map[string]int{"config-test-b": 0}}))
+	assert.True(t, gates.allowed("config-test-a", "ns/key"))
+	assert.False(t, gates.allowed("config-test-b", "ns/key"))
+	assert.False(t, objectclient.IsRestrictedTarget(corev1.SchemeGroupVersion.WithKind("Pod"), "kube-system"))
+	assert.Empty(t, CurrentConfig().Error)
+}
+
+func TestApplyConfigKeepsCodeSettings(t *testing.T) {
+	defer ApplyConfig(Config{})
+	defer SerializeKeys(false)
+	defer LogReconcileLagOutliers(0)
+
+	SerializeKeys(true)
+	LogReconcileLagOutliers(time.Minute)
+	require.NoError(t, ApplyConfig(Config{HandlerGates: map[string]int{"config-test-a": 50}}))
+	assert.True(t, serializeKeys.Load())
+	assert.Equal(t, int64(time.Minute), lagOutlier.Load())
+
+	require.NoError(t, applyConfigData("{serializeKeys: false, lagOutlierThreshold: 5s}", "", ""))
+	assert.False(t, serializeKeys.Load())
+	assert.Equal(t, int64(5*time.Second), lagOutlier.Load())
+
+	require.NoError(t, ApplyConfig(Config{}))
+	assert.True(t, serializeKeys.Load())
+	assert.Equal(t, int64(time.Minute), lagOutlier.Load())
+}
+
+func TestThrottleHandler(t *testing.T) {
+	defer ApplyConfig(Config{})
+	require.NoError(t, ApplyConfig(Config{Workers: map[string]int{"ThrottledController": 1}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release, err := throttleHandler(ctx, "ThrottledController")
+	require.NoError(t, err)
+	_, err = throttleHandler(ctx, "ThrottledController")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = throttleHandler(ctx, "OtherController")
+	assert.NoError(t, err)
+
+	// unchanged settings keep the slots in use
+	require.NoError(t, ApplyConfig(Config{Workers: map[string]int{"ThrottledController": 1}}))
+	_, err = throttleHandler(ctx, "ThrottledController")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	release, err = throttleHandler(ctx, "ThrottledController")
+	require.NoError(t, err)
+	release()
+
+	assert.Error(t, ApplyConfig(Config{RateLimits: map[string]RateLimit{"ThrottledController": {QPS: 1}}}))
+}
+
+type enqueueingSharedController struct {
+	fakeSharedController
+	informer cache.SharedIndexInformer
+	enqueued atomic.Int32
+}
+
+func (e *enqueueingSharedController) Informer() cache.SharedIndexInformer {
+	return e.informer
+}
+
+func (e *enqueueingSharedController) Enqueue(namespace, name string) {
+	e.enqueued.Add(1)
+}
+
+func TestConfigResync(t *testing.T) {
+	defer ApplyConfig(Config{})
+
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &metav1.PartialObjectMetadata{}, 0, cache.Indexers{})
+	require.NoError(t, informer.GetStore().Add(&metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"},
+	}))
+	shared := &enqueueingSharedController{informer: informer}
+	g := NewGenericController("", "ResyncController", shared).(*genericController)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.AddHandler(ctx, "resync", func(key string, obj interface{}) (interface{}, error) {
+		return obj, nil
+	})
+
+	require.NoError(t, ApplyConfig(Config{Resync: map[string]metav1.Duration{"ResyncController": {Duration: 10 * time.Millisecond}}}))
+	assert.Eventually(t, func() bool {
+		return shared.enqueued.Load() >= 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestWatchConfigDelete(t *testing.T) {
+	defer ApplyConfig(Config{})
+
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-system", Name: "controllers", ResourceVersion: "3"},
+		Data:       map[string]string{ConfigKey: "serializeKeys: true"},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, WatchConfig(ctx, client, "cattle-system", "controllers"))
+	assert.Eventually(t, func() bool {
+		return CurrentConfig().ResourceVersion == "3"
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, serializeKeys.Load())
+
+	require.NoError(t, client.CoreV1().ConfigMaps("cattle-system").Delete(ctx, "controllers", metav1.DeleteOptions{}))
+	assert.Eventually(t, func() bool {
+		return !serializeKeys.Load()
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, CurrentConfig().ResourceVersion)
+}
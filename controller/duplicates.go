@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/rancher/norman/types/values"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DuplicateUpdateOptions selects the fields whose changes don't need an object to be handled again.
+// metadata.resourceVersion, metadata.managedFields and the annotations of AnnotateErrors are always ignored.
+type DuplicateUpdateOptions struct {
+	// IgnoreFields are the paths of other ignored fields, like {"status", "lastProbeTime"}
+	IgnoreFields [][]string
+	// IgnoreAnnotations are the ignored annotations, a key ending with * ignores the annotations with that prefix
+	IgnoreAnnotations []string
+}
+
+var duplicateUpdates atomic.Pointer[DuplicateUpdateOptions]
+
+// SuppressDuplicateUpdates skips handlers for the updates of an object that only change fields ignored by opts
+// since the handler last succeeded on it, nil handles every update. Resyncs, enqueues and retries of an object
+// whose resourceVersion didn't change are always handled.
+func SuppressDuplicateUpdates(opts *DuplicateUpdateOptions) {
+	duplicateUpdates.Store(opts)
+}
+
+type handledObject struct {
+	resourceVersion string
+	hash            [sha256.Size]byte
+}
+
+// seen returns the object handled by handler name for key, and whether it is the same as the object the handler
+// last succeeded on once ignored fields are left out.
+func (g *genericController) seen(name, key string, obj runtime.Object) (handledObject, bool) {
+	opts := duplicateUpdates.Load()
+	if opts == nil || obj == nil {
+		return handledObject{}, false
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return handledObject{}, false
+	}
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return handledObject{}, false
+	}
+	hash, err := semanticHash(opts, data)
+	if err != nil {
+		return handledObject{}, false
+	}
+
+	handled := handledObject{
+		resourceVersion: accessor.GetResourceVersion(),
+		hash:            hash,
+	}
+	last, ok := g.handled.Load(name + "/" + key)
+	if !ok {
+		return handled, false
+	}
+	lastHandled := last.(handledObject)
+	return handled, lastHandled.resourceVersion != handled.resourceVersion && lastHandled.hash == handled.hash
+}
+
+// recordHandled remembers the object a handler succeeded on, and forgets it once the handler fails or the object
+// is deleted.
+func (g *genericController) recordHandled(name, key string, handled handledObject, err error) {
+	if err != nil || handled.resourceVersion == "" {
+		g.handled.Delete(name + "/" + key)
+		return
+	}
+	g.handled.Store(name+"/"+key, handled)
+}
+
+func (g *genericController) skipDuplicate(name, key string, handled handledObject) {
+	logrus.Tracef("%s skipped key %s for handler %s, only ignored fields changed", g.name, key, name)
+	metrics.IncDuplicateUpdates(g.name, name)
+	g.recordHandled(name, key, handled, nil)
+}
+
+func semanticHash(opts *DuplicateUpdateOptions, data map[string]interface{}) ([sha256.Size]byte, error) {
+	values.RemoveValue(data, "metadata", "resourceVersion")
+	values.RemoveValue(data, "metadata", "managedFields")
+	for _, field := range opts.IgnoreFields {
+		values.RemoveValue(data, field...)
+	}
+	if annotations, ok := values.GetValueN(data, "metadata", "annotations").(map[string]interface{}); ok {
+		for key := range annotations {
+			if strings.HasPrefix(key, ErrorAnnotationPrefix) || ignoredAnnotation(opts.IgnoreAnnotations, key) {
+				delete(annotations, key)
+			}
+		}
+		if len(annotations) == 0 {
+			values.RemoveValue(data, "metadata", "annotations")
+		}
+	}
+
+	// maps are marshalled with sorted keys, so equal objects have equal hashes
+	content, err := json.Marshal(data)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(content), nil
+}
+
+func ignoredAnnotation(ignored []string, key string) bool {
+	for _, pattern := range ignored {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(key, prefix) || pattern == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSuppressDuplicateUpdates(t *testing.T) {
+	SuppressDuplicateUpdates(&DuplicateUpdateOptions{IgnoreAnnotations: []string{"example.com/*"}})
+	defer SuppressDuplicateUpdates(nil)
+
+	g := &genericController{name: "duplicates-test"}
+	configMap := func(resourceVersion, value string, annotations map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "foo",
+				ResourceVersion: resourceVersion,
+				Annotations:     annotations,
+			},
+			Data: map[string]string{"key": value},
+		}
+	}
+
+	handled, duplicate := g.seen("handler", "foo", configMap("1", "a", nil))
+	assert.False(t, duplicate, "never handled")
+	g.recordHandled("handler", "foo", handled, nil)
+
+	_, duplicate = g.seen("handler", "foo", configMap("1", "a", nil))
+	assert.False(t, duplicate, "resyncs are handled")
+
+	_, duplicate = g.seen("handler", "foo", configMap("2", "a", map[string]string{"example.com/seen": "now"}))
+	assert.True(t, duplicate, "only an ignored annotation changed")
+
+	handled, duplicate = g.seen("handler", "foo", configMap("3", "b", nil))
+	assert.False(t, duplicate, "data changed")
+	g.recordHandled("handler", "foo", handled, errors.New("failed"))
+
+	_, duplicate = g.seen("handler", "foo", configMap("4", "b", nil))
+	assert.False(t, duplicate, "the handler failed on the last change")
+}
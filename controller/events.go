@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// FailureEventReason is the reason of the events recorded by RecordFailureEvents.
+const FailureEventReason = "ReconcileFailed"
+
+type failureEventsConfig struct {
+	recorder  record.EventRecorder
+	threshold int
+	interval  time.Duration
+}
+
+var failureEvents atomic.Pointer[failureEventsConfig]
+
+// RecordFailureEvents makes handlers record a warning event with their last error on the object they failed to
+// process threshold times in a row, at most once per interval for each handler and object, so that users can see
+// why their objects aren't converging without the controller logs. A nil recorder disables the events.
+func RecordFailureEvents(recorder record.EventRecorder, threshold int, interval time.Duration) {
+	if recorder == nil {
+		failureEvents.Store(nil)
+		return
+	}
+	failureEvents.Store(&failureEventsConfig{
+		recorder:  recorder,
+		threshold: threshold,
+		interval:  interval,
+	})
+}
+
+func (g *genericController) recordFailureEvent(handler, key string, obj runtime.Object, failures int, err error) {
+	k := entryKey(handler, key)
+	if err == nil || obj == nil {
+		g.failureEvents.Delete(k)
+		return
+	}
+	config := failureEvents.Load()
+	if config == nil || failures < config.threshold {
+		return
+	}
+	now := time.Now()
+	if last, ok := g.failureEvents.Load(k); ok && now.Sub(last.(time.Time)) < config.interval {
+		return
+	}
+	// the events recorded more than interval ago don't hold back any event, dropping them bounds the entries of
+	// the objects and handlers that stopped failing without succeeding, such as dropped or forgotten keys
+	g.failureEvents.Range(func(k, last interface{}) bool {
+		if now.Sub(last.(time.Time)) >= config.interval {
+			g.failureEvents.Delete(k)
+		}
+		return true
+	})
+	g.failureEvents.Store(k, now)
+	config.recorder.Eventf(obj, corev1.EventTypeWarning, FailureEventReason, "%s failed %d times in a row: %v",
+		handler, failures, err)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordFailureEvent(t *testing.T) {
+	defer RecordFailureEvents(nil, 0, 0)
+	recorder := record.NewFakeRecorder(10)
+	RecordFailureEvents(recorder, 3, time.Hour)
+
+	g := &genericController{}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+	err := errors.New("image not found")
+
+	g.recordFailureEvent("pods", "ns/pod", pod, 2, err)
+	assert.Empty(t, recorder.Events)
+	g.recordFailureEvent("pods", "ns/pod", pod, 3, err)
+	assert.Equal(t, "Warning ReconcileFailed pods failed 3 times in a row: image not found", <-recorder.Events)
+	g.recordFailureEvent("pods", "ns/pod", pod, 4, err)
+	assert.Empty(t, recorder.Events)
+
+	g.recordFailureEvent("pods", "ns/pod", pod, 0, nil)
+	g.recordFailureEvent("pods", "ns/pod", pod, 3, err)
+	assert.Len(t, recorder.Events, 1)
+}
+
+func TestRecordFailureEventEviction(t *testing.T) {
+	defer RecordFailureEvents(nil, 0, 0)
+	recorder := record.NewFakeRecorder(10)
+	RecordFailureEvents(recorder, 1, 10*time.Millisecond)
+
+	g := &genericController{}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+	err := errors.New("image not found")
+	entries := func() (keys []string) {
+		g.failureEvents.Range(func(k, _ interface{}) bool {
+			keys = append(keys, k.(string))
+			return true
+		})
+		return keys
+	}
+
+	g.recordFailureEvent("pods", "ns/a", pod, 1, err)
+	g.recordFailureEvent("pods", "ns/b", pod, 1, err)
+	assert.ElementsMatch(t, []string{"pods/ns/a", "pods/ns/b"}, entries())
+
+	// the object was deleted
+	g.recordFailureEvent("pods", "ns/a", nil, 2, err)
+	assert.Equal(t, []string{"pods/ns/b"}, entries())
+
+	// the entries of the keys that stopped failing expire
+	time.Sleep(10 * time.Millisecond)
+	g.recordFailureEvent("pods", "ns/c", pod, 1, err)
+	assert.Equal(t, []string{"pods/ns/c"}, entries())
+	assert.Len(t, recorder.Events, 3)
+}
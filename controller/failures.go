@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	errorAnnotationDomain = "controller.cattle.io/"
+	// ErrorAnnotationPrefix is followed by the name of the handler in the annotations set by AnnotateErrors.
+	ErrorAnnotationPrefix = errorAnnotationDomain + "error."
+)
+
+var annotateErrors atomic.Bool
+
+func init() {
+	metrics.SetKeyFailures(func(limit int) []metrics.KeyFailure {
+		var result []metrics.KeyFailure
+		for _, failure := range WorstFailures(limit) {
+			result = append(result, metrics.KeyFailure{
+				Controller: failure.Controller,
+				Handler:    failure.Handler,
+				Key:        failure.Key,
+				Failures:   failure.Retries,
+				First:      failure.FirstFailure,
+			})
+		}
+		return result
+	})
+}
+
+// AnnotateErrors makes handlers record their last error on the object they failed to process, in a
+// controller.cattle.io/error.<handler> annotation holding the message and the time of the first consecutive
+// failure with it. The annotation is removed once the handler succeeds.
+func AnnotateErrors(enabled bool) {
+	annotateErrors.Store(enabled)
+}
+
+// KeyFailure is a key a handler of Controller is failing to process.
+type KeyFailure struct {
+	Controller string `json:"controller"`
+	QueueEntry
+}
+
+// WorstFailures returns the keys that handlers have been failing to process the longest, at most limit.
+func WorstFailures(limit int) []KeyFailure {
+	var result []KeyFailure
+	for controller, entries := range queues.list() {
+		for _, entry := range entries {
+			if entry.Handler == "" || entry.Retries == 0 {
+				continue
+			}
+			result = append(result, KeyFailure{
+				Controller: controller,
+				QueueEntry: entry,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].FirstFailure.Equal(result[j].FirstFailure) {
+			return result[i].FirstFailure.Before(result[j].FirstFailure)
+		}
+		return result[i].Retries > result[j].Retries
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// NewFailuresHandler returns a debug handler listing the keys that handlers have been failing to process the
+// longest, 20 unless the limit query parameter is set.
+func NewFailuresHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		limit := 20
+		if value := req.URL.Query().Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil {
+				http.Error(rw, "invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(WorstFailures(limit))
+	})
+}
+
+type errorAnnotation struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// annotateError sets or removes the error annotation of handler on obj, only when it changes so that the update
+// doesn't trigger the handler again for the same error.
+func (g *genericController) annotateError(ctx context.Context, handler string, obj runtime.Object, err error) {
+	if !annotateErrors.Load() || obj == nil {
+		return
+	}
+	client := g.controller.Client()
+	objMeta, metaErr := meta.Accessor(obj)
+	if client == nil || metaErr != nil {
+		return
+	}
+
+	key := errorAnnotationKey(handler)
+	var current errorAnnotation
+	value, annotated := objMeta.GetAnnotations()[key]
+	if annotated {
+		_ = json.Unmarshal([]byte(value), &current)
+	}
+
+	var annotation interface{}
+	if err == nil {
+		if !annotated {
+			return
+		}
+	} else {
+		if annotated && current.Message == err.Error() {
+			return
+		}
+		data, _ := json.Marshal(errorAnnotation{
+			Message: err.Error(),
+			Time:    time.Now().UTC(),
+		})
+		annotation = string(data)
+	}
+
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				key: annotation,
+			},
+		},
+	})
+	if patchErr := client.Patch(ctx, objMeta.GetNamespace(), objMeta.GetName(), types.MergePatchType, patch,
+		obj.DeepCopyObject(), metav1.PatchOptions{}); patchErr != nil {
+		logrus.Debugf("%s failed to annotate the error of handler %s on %s: %v", g.name, handler,
+			queueKey(objMeta.GetNamespace(), objMeta.GetName()), patchErr)
+	}
+}
+
+// errorAnnotationKey returns the annotation of handler, whose name is sanitized to be a valid annotation name.
+func errorAnnotationKey(handler string) string {
+	name := []byte("error." + handler)
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_') {
+			name[i] = '-'
+		}
+	}
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return errorAnnotationDomain + strings.TrimRight(string(name), "-_.")
+}
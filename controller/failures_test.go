@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorstFailures(t *testing.T) {
+	tracker := queues.tracker("failures-test", func(namespace, name string) {})
+	tracker.done("handler", "ns/old", errors.New("first"))
+	tracker.done("handler", "ns/new", errors.New("second"))
+	tracker.done("handler", "ns/old", errors.New("third"))
+	tracker.done("handler", "ns/fixed", errors.New("fourth"))
+	tracker.done("handler", "ns/fixed", nil)
+
+	var failures []KeyFailure
+	for _, failure := range WorstFailures(0) {
+		if failure.Controller == "failures-test" {
+			failures = append(failures, failure)
+		}
+	}
+	if assert.Len(t, failures, 2) {
+		assert.Equal(t, "ns/old", failures[0].Key)
+		assert.Equal(t, 2, failures[0].Retries)
+		assert.Equal(t, "third", failures[0].LastError)
+		assert.Equal(t, "ns/new", failures[1].Key)
+	}
+}
+
+func TestErrorAnnotationKey(t *testing.T) {
+	assert.Equal(t, "controller.cattle.io/error.cluster-agent", errorAnnotationKey("cluster agent"))
+	key := errorAnnotationKey(strings.Repeat("a", 100))
+	assert.Len(t, strings.TrimPrefix(key, "controller.cattle.io/"), 63)
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Feature gates handlers on conditions, such as feature flags or APIs served by the cluster. The conditions are
+// evaluated when the feature is created and on Refresh, not for every key. Generated controllers gate handlers and
+// lifecycles on Enabled with AddFeatureHandler and AddFeatureLifecycle, and Requeue calls them again for every
+// object of their controller when the feature gets enabled.
+type Feature struct {
+	name       string
+	conditions []func() bool
+	enabled    atomic.Bool
+
+	lock      sync.Mutex
+	listeners int
+	onEnabled map[int]func()
+}
+
+// NewFeature returns a feature enabled while all conditions are true.
+func NewFeature(name string, conditions ...func() bool) *Feature {
+	f := &Feature{
+		name:       name,
+		conditions: conditions,
+	}
+	f.enabled.Store(f.evaluate())
+	return f
+}
+
+func (f *Feature) Name() string {
+	return f.name
+}
+
+// Enabled returns whether the conditions were all true when last evaluated.
+func (f *Feature) Enabled() bool {
+	return f.enabled.Load()
+}
+
+// Refresh evaluates the conditions again.
+func (f *Feature) Refresh() {
+	enabled := f.evaluate()
+	if f.enabled.Swap(enabled) == enabled {
+		return
+	}
+	if !enabled {
+		logrus.Infof("feature %s disabled", f.name)
+		return
+	}
+
+	logrus.Infof("feature %s enabled", f.name)
+	f.lock.Lock()
+	listeners := make([]func(), 0, len(f.onEnabled))
+	for _, listener := range f.onEnabled {
+		listeners = append(listeners, listener)
+	}
+	f.lock.Unlock()
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// RefreshOn refreshes the feature each time source is invalidated, such as a discoverycache.Cache when CRDs or
+// APIServices change.
+func (f *Feature) RefreshOn(source interface{ OnInvalidate(func()) }) {
+	source.OnInvalidate(f.Refresh)
+}
+
+// OnEnabled calls listener each time the feature gets enabled by Refresh, until ctx is done.
+func (f *Feature) OnEnabled(ctx context.Context, listener func()) {
+	f.lock.Lock()
+	if f.onEnabled == nil {
+		f.onEnabled = map[int]func(){}
+	}
+	f.listeners++
+	id := f.listeners
+	f.onEnabled[id] = listener
+	f.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		delete(f.onEnabled, id)
+	}()
+}
+
+// Requeue enqueues every object in the cache of controller each time the feature gets enabled, until ctx is done,
+// so that its handlers gated on the feature handle them as if they were just added.
+func (f *Feature) Requeue(ctx context.Context, controller GenericController) {
+	f.OnEnabled(ctx, func() {
+		for _, key := range controller.Informer().GetStore().ListKeys() {
+			namespace, name, err := cache.SplitMetaNamespaceKey(key)
+			if err != nil {
+				continue
+			}
+			controller.Enqueue(namespace, name)
+		}
+	})
+}
+
+func (f *Feature) evaluate() bool {
+	for _, condition := range f.conditions {
+		if !condition() {
+			return false
+		}
+	}
+	return true
+}
+
+// APIServed is a condition true when the API server serves gvr. When discovery fails it keeps its last value, false
+// if discovery never succeeded. Clients should cache discovery, as a discoverycache.Cache does.
+func APIServed(client discovery.DiscoveryInterface, gvr schema.GroupVersionResource) func() bool {
+	var served atomic.Bool
+	return func() bool {
+		list, err := client.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+		if errors.IsNotFound(err) {
+			served.Store(false)
+			return false
+		}
+		if err != nil {
+			logrus.Debugf("failed to discover resources of %s: %v", gvr.GroupVersion(), err)
+			return served.Load()
+		}
+		result := false
+		for _, resource := range list.APIResources {
+			if resource.Name == gvr.Resource {
+				result = true
+				break
+			}
+		}
+		served.Store(result)
+		return result
+	}
+}
+
+// NewFeatureHandler wraps a handler of controller so that it is only called while feature is enabled, and requeues
+// the objects of controller when the feature gets enabled until ctx is done, see Requeue.
+func NewFeatureHandler(ctx context.Context, controller GenericController, feature *Feature, handler HandlerFunc) HandlerFunc {
+	feature.Requeue(ctx, controller)
+	return func(key string, obj interface{}) (interface{}, error) {
+		if !feature.Enabled() {
+			return nil, nil
+		}
+		return handler(key, obj)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+type featureController struct {
+	GenericController
+	informer cache.SharedIndexInformer
+	enqueued []string
+}
+
+func (f *featureController) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+func (f *featureController) Enqueue(namespace, name string) {
+	f.enqueued = append(f.enqueued, namespace+"/"+name)
+}
+
+func TestFeatureHandler(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &metav1.PartialObjectMetadata{}, 0, cache.Indexers{})
+	assert.NoError(t, informer.GetStore().Add(&metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"},
+	}))
+	controller := &featureController{informer: informer}
+
+	flag := false
+	feature := NewFeature("test", func() bool { return true }, func() bool { return flag })
+	assert.False(t, feature.Enabled())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var handled []string
+	handler := NewFeatureHandler(ctx, controller, feature, func(key string, obj interface{}) (interface{}, error) {
+		handled = append(handled, key)
+		return obj, nil
+	})
+
+	_, _ = handler("default/a", "obj")
+	assert.Empty(t, handled)
+
+	flag = true
+	feature.Refresh()
+	assert.True(t, feature.Enabled())
+	assert.Equal(t, []string{"default/a"}, controller.enqueued)
+	_, _ = handler("default/a", "obj")
+	assert.Equal(t, []string{"default/a"}, handled)
+
+	// refreshing an enabled feature doesn't enqueue again
+	feature.Refresh()
+	assert.Len(t, controller.enqueued, 1)
+
+	flag = false
+	feature.Refresh()
+	_, _ = handler("default/a", "obj")
+	assert.Len(t, handled, 1)
+
+	// listeners are removed with the handler context
+	cancel()
+	assert.Eventually(t, func() bool {
+		feature.lock.Lock()
+		defer feature.lock.Unlock()
+		return len(feature.onEnabled) == 0
+	}, time.Second, 10*time.Millisecond)
+	flag = true
+	feature.Refresh()
+	assert.Len(t, controller.enqueued, 1)
+}
+
+func TestAPIServed(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	client.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "policy/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "podsecuritypolicies", Kind: "PodSecurityPolicy"}},
+	}}
+
+	assert.True(t, APIServed(client, schema.GroupVersionResource{
+		Group: "policy", Version: "v1beta1", Resource: "podsecuritypolicies",
+	})())
+	assert.False(t, APIServed(client, schema.GroupVersionResource{
+		Group: "policy", Version: "v1", Resource: "podsecuritypolicies",
+	})())
+
+	// discovery errors keep the last result
+	served := APIServed(client, schema.GroupVersionResource{
+		Group: "policy", Version: "v1beta1", Resource: "podsecuritypolicies",
+	})
+	assert.True(t, served())
+	client.PrependReactor("get", "resource", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("connection refused")
+	})
+	assert.True(t, served())
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+var gates = handlerGates{
+	percent: map[string]uint32{},
+}
+
+// SetHandlerGate runs the handlers named name for percent of the keys, between 0 (disabled) and 100. Keys are
+// picked by hashing, so an object is consistently handled, or skipped, while the percentage is unchanged, and
+// raising the percentage only adds keys. Gates can be changed while controllers run.
+func SetHandlerGate(name string, percent int) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	gates.set(name, uint32(percent))
+}
+
+// RemoveHandlerGate runs the handlers named name for every key again.
+func RemoveHandlerGate(name string) {
+	gates.remove(name)
+}
+
+type handlerGates struct {
+	lock    sync.RWMutex
+	percent map[string]uint32
+}
+
+func (h *handlerGates) set(name string, percent uint32) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.percent[name] = percent
+}
+
+func (h *handlerGates) remove(name string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.percent, name)
+}
+
+func (h *handlerGates) allowed(name, key string) bool {
+	h.lock.RLock()
+	percent, ok := h.percent[name]
+	h.lock.RUnlock()
+	if !ok || percent >= 100 {
+		return true
+	}
+	if percent == 0 {
+		return false
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return hash.Sum32()%100 < percent
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerGate(t *testing.T) {
+	defer RemoveHandlerGate("test")
+
+	assert.True(t, gates.allowed("test", "default/a"))
+
+	SetHandlerGate("test", 0)
+	assert.False(t, gates.allowed("test", "default/a"))
+
+	SetHandlerGate("test", 20)
+	var allowed20 []string
+	for i := 0; i < 1000; i++ {
+		if key := "default/" + strconv.Itoa(i); gates.allowed("test", key) {
+			allowed20 = append(allowed20, key)
+		}
+	}
+	assert.InDelta(t, 200, len(allowed20), 50)
+
+	SetHandlerGate("test", 50)
+	for _, key := range allowed20 {
+		assert.True(t, gates.allowed("test", key))
+	}
+
+	SetHandlerGate("test", 100)
+	assert.True(t, gates.allowed("test", "default/a"))
+}
@@ -2,9 +2,13 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/norman/pkg/rbac"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -16,6 +20,8 @@ type HandlerFunc func(key string, obj interface{}) (interface{}, error)
 type GenericController interface {
 	Informer() cache.SharedIndexInformer
 	AddHandler(ctx context.Context, name string, handler HandlerFunc)
+	AddRemoveHandler(ctx context.Context, name string, handler RemoveHandlerFunc)
+	AddHandlerWithPredicates(ctx context.Context, name string, handler HandlerFunc, predicates ...Predicate)
 	Enqueue(namespace, name string)
 	EnqueueAfter(namespace, name string, after time.Duration)
 }
@@ -25,6 +31,28 @@ type genericController struct {
 	informer   cache.SharedIndexInformer
 	name       string
 	namespace  string
+	queue      *queueTracker
+	tombstones tombstones
+	// handled holds the objects handlers last succeeded on, by handler and key, see SuppressDuplicateUpdates
+	handled sync.Map
+	// predicates holds the keys that passed the predicates of handlers, see AddHandlerWithPredicates
+	predicates predicateKeys
+	// created and lagObserved are the creation time of the controller and the resourceVersions handlers last
+	// succeeded on by handler and key, see observeLag
+	created     time.Time
+	lagObserved sync.Map
+	// failureEvents holds the times of the last failure events by handler and key, see RecordFailureEvents
+	failureEvents sync.Map
+	// resyncOnce starts the resync of the controller at the period of the applied config, see Config
+	resyncOnce sync.Once
+	// handlers holds the handlers registered by AddHandler in order, see EnqueuePriority
+	handlersLock sync.Mutex
+	handlers     []namedHandler
+}
+
+type namedHandler struct {
+	name    string
+	handler controller.SharedControllerHandlerFunc
 }
 
 func NewGenericController(namespace, name string, controller controller.SharedController) GenericController {
@@ -33,6 +61,8 @@ func NewGenericController(namespace, name string, controller controller.SharedCo
 		informer:   controller.Informer(),
 		name:       name,
 		namespace:  namespace,
+		queue:      queues.tracker(name, controller.Enqueue),
+		created:    time.Now(),
 	}
 }
 
@@ -41,27 +71,202 @@ func (g *genericController) Informer() cache.SharedIndexInformer {
 }
 
 func (g *genericController) Enqueue(namespace, name string) {
+	g.predicates.pass("", queueKey(namespace, name))
+	g.enqueue(namespace, name)
+}
+
+// enqueue enqueues the key for the handlers whose predicates it passes.
+func (g *genericController) enqueue(namespace, name string) {
+	g.queue.queued(queueKey(namespace, name), 0)
 	g.controller.Enqueue(namespace, name)
 }
 
 func (g *genericController) EnqueueAfter(namespace, name string, after time.Duration) {
+	g.predicates.pass("", queueKey(namespace, name))
+	g.enqueueAfter(namespace, name, after)
+}
+
+// enqueueAfter enqueues the key after the delay for the handlers whose predicates it passes.
+func (g *genericController) enqueueAfter(namespace, name string, after time.Duration) {
+	g.queue.queued(queueKey(namespace, name), after)
 	g.controller.EnqueueAfter(namespace, name, after)
 }
 
 func (g *genericController) AddHandler(ctx context.Context, name string, handler HandlerFunc) {
-	g.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(func(key string, obj runtime.Object) (runtime.Object, error) {
+	if client := g.controller.Client(); client != nil {
+		rbac.Record(client.GVR, g.namespace, "get", "list", "watch")
+	}
+	g.resyncOnce.Do(func() {
+		go g.resync(ctx)
+	})
+	g.queue.addHandler(name)
+	go func() {
+		<-ctx.Done()
+		g.queue.removeHandler(name)
+	}()
+	sharedHandler := controller.SharedControllerHandlerFunc(func(key string, obj runtime.Object) (runtime.Object, error) {
+		if !inShard(key) {
+			return obj, nil
+		}
+		if !g.queue.start(name, key) {
+			logrus.Tracef("%s dropped key %s for handler %s", g.name, key, name)
+			g.predicates.take(name, key)
+			return obj, controller.ErrIgnore
+		}
+		if !g.predicates.take(name, key) {
+			return obj, nil
+		}
 		if !isNamespace(g.namespace, obj) {
 			return obj, nil
 		}
+		if !gates.allowed(name, key) {
+			logrus.Tracef("%s skipped key %s for gated handler %s", g.name, key, name)
+			g.queue.done(name, key, nil)
+			return obj, nil
+		}
+		handled, duplicate := g.seen(name, key, obj)
+		if duplicate {
+			g.skipDuplicate(name, key, handled)
+			g.queue.done(name, key, nil)
+			return obj, nil
+		}
+		if serializeKeys.Load() {
+			defer keys.acquire(g.lockKey(key))()
+		}
+		release, err := throttleHandler(ctx, g.name)
+		if err != nil {
+			return obj, err
+		}
+		defer release()
 		logrus.Tracef("%s calling handler %s %s", g.name, name, key)
 		result, err := handler(key, obj)
 		runtimeObject, _ := result.(runtime.Object)
 		if _, ok := err.(*ForgetError); ok {
+			g.queue.done(name, key, nil)
+			g.recordHandled(name, key, handled, err)
 			logrus.Tracef("%v %v completed with dropped err: %v", g.name, key, err)
 			return runtimeObject, controller.ErrIgnore
 		}
+		failures := g.queue.done(name, key, err)
+		g.recordHandled(name, key, handled, err)
+		if err != nil {
+			// retries always call the handler
+			g.predicates.pass(name, key)
+		} else {
+			g.observeLag(name, key, obj)
+		}
+		g.annotateError(ctx, name, obj, err)
+		g.recordFailureEvent(name, key, obj, failures, err)
 		return runtimeObject, err
-	}))
+	})
+	g.addSharedHandler(ctx, name, sharedHandler)
+	g.controller.RegisterHandler(ctx, name, sharedHandler)
+}
+
+func (g *genericController) addSharedHandler(ctx context.Context, name string, handler controller.SharedControllerHandlerFunc) {
+	g.handlersLock.Lock()
+	g.handlers = append(g.handlers, namedHandler{name: name, handler: handler})
+	g.handlersLock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		g.handlersLock.Lock()
+		defer g.handlersLock.Unlock()
+		for i, h := range g.handlers {
+			if h.name == name {
+				g.handlers = append(g.handlers[:i:i], g.handlers[i+1:]...)
+				break
+			}
+		}
+	}()
+}
+
+// EnqueuePriority runs the handlers of the object on the calling goroutine, ahead of the keys waiting in the queue,
+// for example to finalize the objects holding up the deletion of a namespace. The key is enqueued if a handler
+// fails, to be retried like any other key.
+func (g *genericController) EnqueuePriority(namespace, name string) {
+	key := queueKey(namespace, name)
+	if !inShard(key) {
+		return
+	}
+
+	var obj runtime.Object
+	if item, exists, err := g.informer.GetStore().GetByKey(key); err != nil {
+		g.Enqueue(namespace, name)
+		return
+	} else if exists {
+		obj = item.(runtime.Object)
+	}
+
+	g.handlersLock.Lock()
+	handlers := append([]namedHandler(nil), g.handlers...)
+	g.handlersLock.Unlock()
+
+	g.predicates.pass("", key)
+	g.queue.queued(key, 0)
+	failed := false
+	for _, h := range handlers {
+		newObj, err := h.handler(key, obj)
+		if err != nil && !errors.Is(err, controller.ErrIgnore) {
+			logrus.Debugf("%s handler %s failed on prioritized key %s: %v", g.name, h.name, key, err)
+			failed = true
+		}
+		if newObj != nil && !reflect.ValueOf(newObj).IsNil() {
+			obj = newObj
+		}
+	}
+	if failed {
+		g.Enqueue(namespace, name)
+	}
+}
+
+// resync enqueues every object of the controller at the resync period of the applied config until ctx is done.
+func (g *genericController) resync(ctx context.Context) {
+	var (
+		current time.Duration
+		timer   *time.Timer
+		tick    <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		period, changed := resyncPeriod(g.name)
+		if period != current {
+			if timer != nil {
+				timer.Stop()
+				timer, tick = nil, nil
+			}
+			if period > 0 {
+				timer = time.NewTimer(period)
+				tick = timer.C
+			}
+			current = period
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case <-tick:
+			for _, key := range g.informer.GetStore().ListKeys() {
+				namespace, name, err := cache.SplitMetaNamespaceKey(key)
+				if err != nil {
+					continue
+				}
+				g.controller.Enqueue(namespace, name)
+			}
+			timer.Reset(current)
+		}
+	}
+}
+
+func queueKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
 }
 
 func isNamespace(namespace string, obj runtime.Object) bool {
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestEnqueuePriority(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &corev1.ConfigMap{}, 0, cache.Indexers{})
+	require.NoError(t, informer.GetStore().Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      "a",
+		Namespace: "default",
+		UID:       "1",
+	}}))
+	shared := &fakeSharedController{informer: informer}
+	g := NewGenericController("", "priority-test", shared).(*genericController)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		seen    []string
+		failing error
+	)
+	g.AddHandler(ctx, "first", func(key string, obj interface{}) (interface{}, error) {
+		if obj == nil {
+			seen = append(seen, "first "+key+" deleted")
+			return nil, nil
+		}
+		cm := obj.(*corev1.ConfigMap).DeepCopy()
+		cm.Data = map[string]string{"first": "done"}
+		seen = append(seen, "first "+key)
+		return cm, nil
+	})
+	g.AddHandler(ctx, "second", func(key string, obj interface{}) (interface{}, error) {
+		if obj == nil {
+			seen = append(seen, "second "+key+" deleted")
+			return nil, failing
+		}
+		seen = append(seen, "second "+key+" "+obj.(*corev1.ConfigMap).Data["first"])
+		return obj, failing
+	})
+
+	g.EnqueuePriority("default", "a")
+	assert.Equal(t, []string{"first default/a", "second default/a done"}, seen)
+	assert.Empty(t, shared.enqueued, "handled without the queue")
+
+	seen = nil
+	failing = errors.New("failed")
+	g.EnqueuePriority("default", "b")
+	assert.Equal(t, []string{"first default/b deleted", "second default/b deleted"}, seen)
+	assert.Equal(t, []string{"default/b"}, shared.enqueued, "retried through the queue")
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	serializeKeys atomic.Bool
+	keys          = keyLocks{
+		locks: map[string]*keyLock{},
+	}
+)
+
+// SerializeKeys makes the handlers of every controller of a resource in the process handle a key one at a time.
+// Handlers of one controller already do, but handlers registered for the same resource through different
+// controllers, of different factories or names, run concurrently by default, which causes conflicts when they
+// update the same object.
+func SerializeKeys(enabled bool) {
+	serializeKeys.Store(enabled)
+}
+
+type keyLocks struct {
+	lock  sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// acquire locks key and returns the function that unlocks it. Locks are dropped once no handler holds or waits
+// for them.
+func (k *keyLocks) acquire(key string) func() {
+	k.lock.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.lock.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		k.lock.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.lock.Unlock()
+	}
+}
+
+// lockKey returns the key locked while handling key, the same for every controller of the resource.
+func (g *genericController) lockKey(key string) string {
+	if client := g.controller.Client(); client != nil {
+		return client.GVR.String() + "/" + key
+	}
+	return g.name + "/" + key
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type clientSharedController struct {
+	fakeSharedController
+	client *client.Client
+}
+
+func (c *clientSharedController) Client() *client.Client {
+	return c.client
+}
+
+func TestLockKeyPerResource(t *testing.T) {
+	pods := &client.Client{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}}
+	secrets := &client.Client{GVR: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}}
+
+	first := NewGenericController("", "first", &clientSharedController{client: pods}).(*genericController)
+	second := NewGenericController("", "second", &clientSharedController{client: pods}).(*genericController)
+	other := NewGenericController("", "first", &clientSharedController{client: secrets}).(*genericController)
+
+	assert.Equal(t, first.lockKey("default/a"), second.lockKey("default/a"))
+	assert.NotEqual(t, first.lockKey("default/a"), first.lockKey("default/b"))
+	assert.NotEqual(t, first.lockKey("default/a"), other.lockKey("default/a"))
+}
+
+func TestKeyLocks(t *testing.T) {
+	locks := keyLocks{locks: map[string]*keyLock{}}
+
+	release := locks.acquire("pods/default/a")
+	acquired := make(chan struct{})
+	go func() {
+		defer locks.acquire("pods/default/a")()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("the key was acquired twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	locks.acquire("pods/default/b")()
+
+	release()
+	<-acquired
+	assert.Eventually(t, func() bool {
+		locks.lock.Lock()
+		defer locks.lock.Unlock()
+		return len(locks.locks) == 0
+	}, time.Second, 10*time.Millisecond)
+}
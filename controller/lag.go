@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var lagOutlier atomic.Int64
+
+// LogReconcileLagOutliers logs the objects that took longer than threshold to be handled after their last
+// modification, 0 turns logging off.
+func LogReconcileLagOutliers(threshold time.Duration) {
+	lagOutlier.Store(int64(threshold))
+}
+
+// observeLag records the time since obj was last modified once handler name succeeds on it. Each resourceVersion
+// of an object is observed once per handler, and objects last modified before the controller was created are
+// left out, so that resyncs and the initial listing don't count as lag. Objects without managed fields times, such
+// as objects whose managed fields are stripped from the cache, are left out as well.
+func (g *genericController) observeLag(name, key string, obj runtime.Object) {
+	if obj == nil {
+		g.lagObserved.Delete(name + "/" + key)
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	if observed, ok := g.lagObserved.Load(name + "/" + key); ok && observed == accessor.GetResourceVersion() {
+		return
+	}
+	g.lagObserved.Store(name+"/"+key, accessor.GetResourceVersion())
+
+	modified, ok := lastModified(accessor)
+	if !ok || modified.Before(g.created) {
+		return
+	}
+	lag := time.Since(modified)
+	metrics.ObserveReconcileLag(g.name, name, lag)
+	if threshold := time.Duration(lagOutlier.Load()); threshold > 0 && lag > threshold {
+		logrus.Warnf("%s handler %s completed on %s %v after its last modification", g.name, name, key, lag.Round(time.Millisecond))
+	}
+}
+
+// lastModified returns the latest of the creation, deletion and managed fields times of an object, which have a
+// precision of a second. Deletion timestamps in the future, set for graceful deletions, are left out. It returns
+// false if the object has no managed fields time, as its updates can't be told apart from its creation then.
+func lastModified(accessor metav1.Object) (time.Time, bool) {
+	modified := accessor.GetCreationTimestamp().Time
+	if deleted := accessor.GetDeletionTimestamp(); deleted != nil && deleted.After(modified) && deleted.Time.Before(time.Now()) {
+		modified = deleted.Time
+	}
+	found := false
+	for _, entry := range accessor.GetManagedFields() {
+		if entry.Time == nil {
+			continue
+		}
+		found = true
+		if entry.Time.After(modified) {
+			modified = entry.Time.Time
+		}
+	}
+	return modified, found
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLastModified(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	updated := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	future := metav1.NewTime(time.Now().Add(time.Minute))
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}}
+	_, ok := lastModified(pod)
+	assert.False(t, ok)
+
+	pod.ManagedFields = []metav1.ManagedFieldsEntry{{Time: &updated}, {}}
+	modified, ok := lastModified(pod)
+	assert.True(t, ok)
+	assert.Equal(t, updated.Time, modified)
+
+	pod.DeletionTimestamp = &future
+	modified, _ = lastModified(pod)
+	assert.Equal(t, updated.Time, modified)
+}
+
+func TestObserveLag(t *testing.T) {
+	g := &genericController{name: "pods", created: time.Now().Add(-time.Hour)}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:         "default",
+		Name:              "a",
+		ResourceVersion:   "1",
+		CreationTimestamp: metav1.Now(),
+	}}
+
+	g.observeLag("sync", "default/a", pod)
+	observed, ok := g.lagObserved.Load("sync/default/a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", observed)
+
+	g.observeLag("sync", "default/a", nil)
+	_, ok = g.lagObserved.Load("sync/default/a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// metadataOnly is implemented by the informers caching only the metadata of objects, as
+// *metav1.PartialObjectMetadata, see the metadatacache package.
+type metadataOnly interface {
+	MetadataOnly() bool
+}
+
+// RequireFullObjects panics if the informer of c caches only the metadata of the objects of gvk. The generated
+// typed controllers call it when they are built, their listers and handlers need the full objects.
+func RequireFullObjects(c GenericController, gvk schema.GroupVersionKind) {
+	if informer, ok := c.Informer().(metadataOnly); ok && informer.MetadataOnly() {
+		panic(fmt.Sprintf("%s is cached as metadata only, it must be handled by a generic controller", gvk))
+	}
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type metadataOnlyInformer struct {
+	cache.SharedIndexInformer
+}
+
+func (metadataOnlyInformer) MetadataOnly() bool {
+	return true
+}
+
+func TestRequireFullObjects(t *testing.T) {
+	gvk := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &corev1.ConfigMap{}, 0, cache.Indexers{})
+
+	full := NewGenericController("", "full", &fakeSharedController{informer: informer})
+	assert.NotPanics(t, func() { RequireFullObjects(full, gvk) })
+
+	metadata := NewGenericController("", "metadata", &fakeSharedController{informer: metadataOnlyInformer{informer}})
+	assert.PanicsWithValue(t, "/v1, Kind=ConfigMap is cached as metadata only, it must be handled by a generic controller",
+		func() { RequireFullObjects(metadata, gvk) })
+}
@@ -0,0 +1,286 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+type orderedController struct {
+	gvr        schema.GroupVersionResource
+	kind       string
+	controller controller.SharedController
+	// done is closed once the controller started or failed to, ready only once it started
+	done  chan struct{}
+	ready chan struct{}
+}
+
+// OrderedFactory is a controller factory that starts each controller once the caches of the kinds it depends on
+// are synced and the controllers of those kinds are running, instead of starting all the controllers at once.
+type OrderedFactory struct {
+	controller.SharedControllerFactory
+
+	lock         sync.Mutex
+	controllers  map[schema.GroupVersionKind]*orderedController
+	resources    map[schema.GroupVersionResource]bool
+	pending      []*orderedController
+	dependencies map[schema.GroupVersionKind][]schema.GroupVersionKind
+}
+
+// NewOrderedFactory wraps a controller factory to start its controllers in the order of their dependencies, declared
+// with DependsOn. It is meant to be passed to the generated NewFromControllerFactory.
+func NewOrderedFactory(delegate controller.SharedControllerFactory) *OrderedFactory {
+	return &OrderedFactory{
+		SharedControllerFactory: delegate,
+		controllers:             map[schema.GroupVersionKind]*orderedController{},
+		resources:               map[schema.GroupVersionResource]bool{},
+		dependencies:            map[schema.GroupVersionKind][]schema.GroupVersionKind{},
+	}
+}
+
+// DependsOn delays the controller of kind until the caches of deps are synced and their controllers, if any, are
+// running. Kinds without a controller only need their cache to be synced.
+func (o *OrderedFactory) DependsOn(kind schema.GroupVersionKind, deps ...schema.GroupVersionKind) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.dependencies[kind] = append(o.dependencies[kind], deps...)
+}
+
+func (o *OrderedFactory) ForObject(obj runtime.Object) (controller.SharedController, error) {
+	gvk, err := o.SharedCacheFactory().SharedClientFactory().GVKForObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	return o.ForKind(gvk)
+}
+
+func (o *OrderedFactory) ForKind(gvk schema.GroupVersionKind) (controller.SharedController, error) {
+	gvr, namespaced, err := o.SharedCacheFactory().SharedClientFactory().ResourceForGVK(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return o.ForResourceKind(gvr, gvk.Kind, namespaced), nil
+}
+
+func (o *OrderedFactory) ForResource(gvr schema.GroupVersionResource, namespaced bool) controller.SharedController {
+	return o.ForResourceKind(gvr, "", namespaced)
+}
+
+func (o *OrderedFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) controller.SharedController {
+	result := o.SharedControllerFactory.ForResourceKind(gvr, kind, namespaced)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.resources[gvr] {
+		return result
+	}
+	o.resources[gvr] = true
+	o.pending = append(o.pending, &orderedController{
+		gvr:        gvr,
+		kind:       kind,
+		controller: result,
+		done:       make(chan struct{}),
+		ready:      make(chan struct{}),
+	})
+	return result
+}
+
+// Ready returns whether the controller of kind is running.
+func (o *OrderedFactory) Ready(kind schema.GroupVersionKind) bool {
+	o.lock.Lock()
+	c, ok := o.controllers[kind]
+	o.lock.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case <-c.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// Readiness returns whether each controller started by Start is running.
+func (o *OrderedFactory) Readiness() map[schema.GroupVersionKind]bool {
+	o.lock.Lock()
+	kinds := make([]schema.GroupVersionKind, 0, len(o.controllers))
+	for kind := range o.controllers {
+		kinds = append(kinds, kind)
+	}
+	o.lock.Unlock()
+
+	result := make(map[schema.GroupVersionKind]bool, len(kinds))
+	for _, kind := range kinds {
+		result[kind] = o.Ready(kind)
+	}
+	return result
+}
+
+// Start starts the caches and then every controller once its dependencies are ready, with workers workers each.
+// It returns once all the controllers are running, or with an error if the dependencies have a cycle.
+func (o *OrderedFactory) Start(ctx context.Context, workers int) error {
+	controllers, dependencies, err := o.resolve()
+	if err != nil {
+		return err
+	}
+	if err := checkCycles(dependencies); err != nil {
+		return err
+	}
+
+	cacheFactory := o.SharedCacheFactory()
+	for _, deps := range dependencies {
+		for _, dep := range deps {
+			if _, ok := controllers[dep]; !ok {
+				if _, err := cacheFactory.ForKind(dep); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := cacheFactory.Start(ctx); err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errLock  sync.Mutex
+		startErr error
+	)
+	for kind, c := range controllers {
+		select {
+		case <-c.done:
+			// started by a previous call
+			continue
+		default:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := o.start(ctx, kind, c, controllers, dependencies[kind], workers); err != nil {
+				errLock.Lock()
+				if startErr == nil {
+					startErr = err
+				}
+				errLock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return startErr
+}
+
+func (o *OrderedFactory) start(ctx context.Context, kind schema.GroupVersionKind, c *orderedController,
+	controllers map[schema.GroupVersionKind]*orderedController, deps []schema.GroupVersionKind, workers int) error {
+	defer close(c.done)
+
+	for _, dep := range deps {
+		if depController, ok := controllers[dep]; ok {
+			select {
+			case <-depController.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if !o.Ready(dep) {
+				return fmt.Errorf("controller of %v not started, controller of %v failed to start", kind, dep)
+			}
+			continue
+		}
+
+		informer, err := o.SharedCacheFactory().ForKind(dep)
+		if err != nil {
+			return err
+		}
+		logrus.Debugf("controller of %v waiting for the cache of %v", kind, dep)
+		if !toolscache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+			return ctx.Err()
+		}
+	}
+
+	if !toolscache.WaitForCacheSync(ctx.Done(), c.controller.Informer().HasSynced) {
+		return ctx.Err()
+	}
+	if err := c.controller.Start(ctx, workers); err != nil {
+		return err
+	}
+	close(c.ready)
+	return nil
+}
+
+// resolve maps the controllers created so far to their kind.
+func (o *OrderedFactory) resolve() (map[schema.GroupVersionKind]*orderedController, map[schema.GroupVersionKind][]schema.GroupVersionKind, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, c := range o.pending {
+		kind := c.gvr.GroupVersion().WithKind(c.kind)
+		if c.kind == "" {
+			var err error
+			kind, err = o.SharedCacheFactory().SharedClientFactory().GVKForResource(c.gvr)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		o.controllers[kind] = c
+	}
+	o.pending = nil
+
+	controllers := make(map[schema.GroupVersionKind]*orderedController, len(o.controllers))
+	for kind, c := range o.controllers {
+		controllers[kind] = c
+	}
+	dependencies := make(map[schema.GroupVersionKind][]schema.GroupVersionKind, len(o.dependencies))
+	for kind, deps := range o.dependencies {
+		dependencies[kind] = append([]schema.GroupVersionKind(nil), deps...)
+	}
+	return controllers, dependencies, nil
+}
+
+func checkCycles(dependencies map[schema.GroupVersionKind][]schema.GroupVersionKind) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[schema.GroupVersionKind]int{}
+
+	var visit func(kind schema.GroupVersionKind, path []string) error
+	visit = func(kind schema.GroupVersionKind, path []string) error {
+		path = append(path, kind.String())
+		switch state[kind] {
+		case visiting:
+			return fmt.Errorf("controller dependency cycle: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+		state[kind] = visiting
+		for _, dep := range dependencies[kind] {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[kind] = visited
+		return nil
+	}
+
+	kinds := make([]schema.GroupVersionKind, 0, len(dependencies))
+	for kind := range dependencies {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].String() < kinds[j].String()
+	})
+	for _, kind := range kinds {
+		if err := visit(kind, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCheckCycles(t *testing.T) {
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	secrets := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	deployments := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	assert.NoError(t, checkCycles(map[schema.GroupVersionKind][]schema.GroupVersionKind{
+		deployments: {pods, secrets},
+		pods:        {secrets},
+	}))
+
+	err := checkCycles(map[schema.GroupVersionKind][]schema.GroupVersionKind{
+		deployments: {pods},
+		pods:        {secrets},
+		secrets:     {deployments},
+	})
+	assert.EqualError(t, err, "controller dependency cycle: /v1, Kind=Pod -> /v1, Kind=Secret -> apps/v1, Kind=Deployment -> /v1, Kind=Pod")
+}
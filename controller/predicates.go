@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Predicate filters the events of an object before its handler runs, a nil func passes every event.
+type Predicate struct {
+	Create func(obj interface{}) bool
+	Update func(oldObj, newObj interface{}) bool
+	Delete func(obj interface{}) bool
+}
+
+// predicateFilter holds the predicates of a handler and the keys that passed them, or must be handled whatever the
+// predicates, and were not handled yet.
+type predicateFilter struct {
+	predicates []Predicate
+	pending    map[string]bool
+}
+
+// predicateKeys holds the predicate filters of handlers by name.
+type predicateKeys struct {
+	lock     sync.Mutex
+	handlers map[string]*predicateFilter
+}
+
+func (p *predicateKeys) register(handler string, predicates []Predicate) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.handlers == nil {
+		p.handlers = map[string]*predicateFilter{}
+	}
+	p.handlers[handler] = &predicateFilter{
+		predicates: predicates,
+		pending:    map[string]bool{},
+	}
+}
+
+func (p *predicateKeys) unregister(handler string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.handlers, handler)
+}
+
+// pass marks key to be handled by handler, or by every handler with predicates if handler is "", whatever the
+// predicates.
+func (p *predicateKeys) pass(handler, key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for name, filter := range p.handlers {
+		if handler == "" || handler == name {
+			filter.pending[key] = true
+		}
+	}
+}
+
+// event marks key to be handled by handler if the event passes all of its predicates, and returns whether it did.
+// Exactly one of created, updated and deleted is set.
+func (p *predicateKeys) event(handler, key string, created, deleted bool, oldObj, obj interface{}) bool {
+	p.lock.Lock()
+	filter, ok := p.handlers[handler]
+	var predicates []Predicate
+	if ok {
+		predicates = filter.predicates
+	}
+	p.lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	for _, predicate := range predicates {
+		switch {
+		case created:
+			if predicate.Create != nil && !predicate.Create(obj) {
+				return false
+			}
+		case deleted:
+			if predicate.Delete != nil && !predicate.Delete(obj) {
+				return false
+			}
+		default:
+			if predicate.Update != nil && !predicate.Update(oldObj, obj) {
+				return false
+			}
+		}
+	}
+
+	p.pass(handler, key)
+	return true
+}
+
+// take returns whether handler should handle key. Handlers without predicates handle every key.
+func (p *predicateKeys) take(handler, key string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	filter, ok := p.handlers[handler]
+	if !ok {
+		return true
+	}
+	if !filter.pending[key] {
+		return false
+	}
+	delete(filter.pending, key)
+	return true
+}
+
+// AddHandlerWithPredicates adds a handler only called for the keys of the events passing all predicates, like
+// controller-runtime predicates. Predicates are evaluated by an event handler of the informer, with the objects of
+// the informer events, and the keys passing them are enqueued. The shared workqueue holds the keys of all handlers
+// of the controller, so handler skips the keys dequeued for other handlers. Enqueue and retries of failed keys
+// always call handler.
+func (g *genericController) AddHandlerWithPredicates(ctx context.Context, name string, handler HandlerFunc, predicates ...Predicate) {
+	if len(predicates) == 0 {
+		g.AddHandler(ctx, name, handler)
+		return
+	}
+
+	g.predicates.register(name, predicates)
+	onEvent := func(created, deleted bool, oldObj, obj interface{}) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil || !inShard(key) {
+			return
+		}
+		if !g.predicates.event(name, key, created, deleted, finalState(oldObj), finalState(obj)) {
+			return
+		}
+		// the shared handler may have run before the key passed
+		if namespace, objName, err := cache.SplitMetaNamespaceKey(key); err == nil {
+			g.enqueue(namespace, objName)
+		}
+	}
+	registration, err := g.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			onEvent(true, false, nil, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			onEvent(false, false, oldObj, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			onEvent(false, true, nil, obj)
+		},
+	})
+	if err != nil {
+		g.predicates.unregister(name)
+		logrus.Errorf("failed to add predicates of handler %s to %s: %v", name, g.name, err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		_ = g.informer.RemoveEventHandler(registration)
+		g.predicates.unregister(name)
+	}()
+
+	g.AddHandler(ctx, name, handler)
+}
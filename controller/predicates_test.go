@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeSharedController struct {
+	controller.SharedController
+	informer cache.SharedIndexInformer
+	handler  controller.SharedControllerHandler
+	enqueued []string
+}
+
+func (f *fakeSharedController) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+func (f *fakeSharedController) Client() *client.Client {
+	return nil
+}
+
+func (f *fakeSharedController) Enqueue(namespace, name string) {
+	f.enqueued = append(f.enqueued, queueKey(namespace, name))
+}
+
+func (f *fakeSharedController) EnqueueAfter(namespace, name string, after time.Duration) {
+	f.enqueued = append(f.enqueued, queueKey(namespace, name))
+}
+
+func (f *fakeSharedController) RegisterHandler(ctx context.Context, name string, handler controller.SharedControllerHandler) {
+	f.handler = handler
+}
+
+type fakeInformer struct {
+	cache.SharedIndexInformer
+	handler cache.ResourceEventHandler
+}
+
+func (f *fakeInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	f.handler = handler
+	return nil, nil
+}
+
+func (f *fakeInformer) RemoveEventHandler(handle cache.ResourceEventHandlerRegistration) error {
+	f.handler = nil
+	return nil
+}
+
+func TestPredicateKeys(t *testing.T) {
+	var keys predicateKeys
+	assert.True(t, keys.take("plain", "default/a"))
+	assert.False(t, keys.event("plain", "default/a", true, false, nil, "create"))
+
+	keys.register("filtered", []Predicate{{
+		Create: func(obj interface{}) bool { return obj == "create" },
+		Update: func(oldObj, newObj interface{}) bool { return oldObj != newObj },
+		Delete: func(obj interface{}) bool { return obj == "delete" },
+	}})
+	assert.False(t, keys.take("filtered", "default/a"))
+	assert.False(t, keys.event("filtered", "default/a", true, false, nil, "v1"))
+	assert.False(t, keys.take("filtered", "default/a"))
+	assert.True(t, keys.event("filtered", "default/b", true, false, nil, "create"))
+	assert.True(t, keys.take("filtered", "default/b"))
+	assert.False(t, keys.take("filtered", "default/b"))
+	assert.False(t, keys.event("filtered", "default/a", false, false, "v1", "v1"))
+	assert.True(t, keys.event("filtered", "default/a", false, false, "v1", "v2"))
+	assert.True(t, keys.take("filtered", "default/a"))
+	assert.False(t, keys.event("filtered", "default/a", false, true, nil, "v2"))
+	assert.True(t, keys.event("filtered", "default/a", false, true, nil, "delete"))
+	assert.True(t, keys.take("filtered", "default/a"))
+
+	keys.pass("filtered", "default/b")
+	assert.True(t, keys.take("filtered", "default/b"))
+	assert.False(t, keys.take("filtered", "default/b"))
+
+	keys.register("other", nil)
+	keys.pass("", "default/b")
+	assert.True(t, keys.take("filtered", "default/b"))
+	assert.True(t, keys.take("other", "default/b"))
+
+	keys.unregister("filtered")
+	assert.True(t, keys.take("filtered", "default/b"))
+}
+
+func TestAddHandlerWithPredicates(t *testing.T) {
+	informer := &fakeInformer{}
+	shared := &fakeSharedController{informer: informer}
+	g := NewGenericController("", "predicates-test", shared).(*genericController)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var handled []string
+	g.AddHandlerWithPredicates(ctx, "labels", func(key string, obj interface{}) (interface{}, error) {
+		handled = append(handled, key)
+		return obj, nil
+	}, Predicate{
+		Update: func(oldObj, newObj interface{}) bool {
+			return oldObj.(*corev1.Pod).Labels["app"] != newObj.(*corev1.Pod).Labels["app"]
+		},
+	})
+	require.NotNil(t, shared.handler)
+	require.NotNil(t, informer.handler)
+
+	pod := func(resourceVersion, app string) runtime.Object {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            "p1",
+			Namespace:       "default",
+			ResourceVersion: resourceVersion,
+			Labels:          map[string]string{"app": app},
+		}}
+	}
+	// the informer delivers the events, the workqueue delivers the key of each of them to the shared handler
+	informer.handler.OnAdd(pod("1", "a"), false)
+	handle := func(obj runtime.Object) {
+		_, err := shared.handler.OnChange("default/p1", obj)
+		require.NoError(t, err)
+	}
+	handle(pod("1", "a"))
+	for _, update := range [][2]runtime.Object{
+		{pod("1", "a"), pod("2", "a")},
+		{pod("2", "a"), pod("3", "b")},
+		{pod("3", "b"), pod("4", "b")},
+	} {
+		informer.handler.OnUpdate(update[0], update[1])
+		handle(update[1])
+	}
+	assert.Equal(t, []string{"default/p1", "default/p1"}, handled)
+	assert.Equal(t, []string{"default/p1", "default/p1"}, shared.enqueued)
+
+	g.Enqueue("default", "p1")
+	handle(pod("4", "b"))
+	assert.Len(t, handled, 3)
+
+	// adaptive resyncs don't pass the predicates
+	g.enqueueAfter("default", "p1", time.Second)
+	handle(pod("4", "b"))
+	assert.Len(t, handled, 3)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		g.predicates.lock.Lock()
+		defer g.predicates.lock.Unlock()
+		return len(g.predicates.handlers) == 0
+	}, time.Second, 10*time.Millisecond)
+}
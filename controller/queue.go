@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+var queues = queueRegistry{
+	trackers: map[string]*queueTracker{},
+}
+
+// QueueEntry describes a key that was explicitly enqueued or is waiting to be retried after a handler error.
+type QueueEntry struct {
+	Key       string    `json:"key"`
+	Handler   string    `json:"handler,omitempty"`
+	Retries   int       `json:"retries"`
+	LastError string    `json:"lastError,omitempty"`
+	Queued    time.Time `json:"queued,omitempty"`
+	NextRetry time.Time `json:"nextRetry,omitempty"`
+	// FirstFailure is the time of the first of the consecutive errors of Handler
+	FirstFailure time.Time `json:"firstFailure,omitempty"`
+}
+
+type queueRegistry struct {
+	sync.Mutex
+	trackers map[string]*queueTracker
+}
+
+func (q *queueRegistry) tracker(name string, enqueue func(namespace, name string)) *queueTracker {
+	q.Lock()
+	defer q.Unlock()
+
+	if t, ok := q.trackers[name]; ok {
+		return t
+	}
+	t := &queueTracker{
+		enqueue:  enqueue,
+		handlers: map[string]bool{},
+		entries:  map[string]*QueueEntry{},
+		dropped:  map[string]map[string]bool{},
+		// mirrors the default lasso rate limiter so that retry times can be estimated
+		limiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemFastSlowRateLimiter(time.Millisecond, 2*time.Minute, 30),
+			workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 30*time.Second),
+		),
+	}
+	q.trackers[name] = t
+	return t
+}
+
+func (q *queueRegistry) get(name string) (*queueTracker, bool) {
+	q.Lock()
+	defer q.Unlock()
+	t, ok := q.trackers[name]
+	return t, ok
+}
+
+func (q *queueRegistry) list() map[string][]QueueEntry {
+	q.Lock()
+	defer q.Unlock()
+
+	result := map[string][]QueueEntry{}
+	for name, t := range q.trackers {
+		result[name] = t.list()
+	}
+	return result
+}
+
+type queueTracker struct {
+	sync.Mutex
+	enqueue  func(namespace, name string)
+	handlers map[string]bool
+	entries  map[string]*QueueEntry
+	dropped  map[string]map[string]bool
+	limiter  workqueue.RateLimiter
+}
+
+func (q *queueTracker) addHandler(handler string) {
+	q.Lock()
+	defer q.Unlock()
+	q.handlers[handler] = true
+}
+
+func (q *queueTracker) removeHandler(handler string) {
+	q.Lock()
+	defer q.Unlock()
+	delete(q.handlers, handler)
+}
+
+func entryKey(handler, key string) string {
+	return handler + "/" + key
+}
+
+func (q *queueTracker) queued(key string, after time.Duration) {
+	q.Lock()
+	defer q.Unlock()
+
+	delete(q.dropped, key)
+	entry, ok := q.entries[entryKey("", key)]
+	if !ok {
+		entry = &QueueEntry{
+			Key:    key,
+			Queued: time.Now(),
+		}
+		q.entries[entryKey("", key)] = entry
+	}
+	entry.NextRetry = time.Now().Add(after)
+}
+
+// start records that handler is processing key and returns false if the pending retry was dropped.
+func (q *queueTracker) start(handler, key string) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	delete(q.entries, entryKey("", key))
+	if q.dropped[key][handler] {
+		delete(q.dropped[key], handler)
+		if len(q.dropped[key]) == 0 {
+			delete(q.dropped, key)
+		}
+		return false
+	}
+	return true
+}
+
+// done records that handler finished processing key and returns its number of consecutive failures.
+func (q *queueTracker) done(handler, key string, err error) int {
+	q.Lock()
+	defer q.Unlock()
+
+	k := entryKey(handler, key)
+	if err == nil {
+		delete(q.entries, k)
+		q.limiter.Forget(k)
+		return 0
+	}
+
+	entry, ok := q.entries[k]
+	if !ok {
+		entry = &QueueEntry{
+			Key:     key,
+			Handler: handler,
+		}
+		q.entries[k] = entry
+	}
+	if entry.Retries == 0 {
+		entry.FirstFailure = time.Now()
+	}
+	entry.Retries++
+	entry.LastError = err.Error()
+	entry.NextRetry = time.Now().Add(q.limiter.When(k))
+	return entry.Retries
+}
+
+// drop makes the handlers skip the pending processing of key: every handler if key was enqueued, or the handlers
+// waiting to retry it.
+func (q *queueTracker) drop(key string) {
+	q.Lock()
+	defer q.Unlock()
+
+	for k, entry := range q.entries {
+		if entry.Key != key {
+			continue
+		}
+		if q.dropped[key] == nil {
+			q.dropped[key] = map[string]bool{}
+		}
+		if entry.Handler == "" {
+			for handler := range q.handlers {
+				q.dropped[key][handler] = true
+			}
+		} else {
+			q.dropped[key][entry.Handler] = true
+		}
+		delete(q.entries, k)
+		q.limiter.Forget(k)
+	}
+}
+
+func (q *queueTracker) list() []QueueEntry {
+	q.Lock()
+	defer q.Unlock()
+
+	result := make([]QueueEntry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Key == result[j].Key {
+			return result[i].Handler < result[j].Handler
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// NewQueueHandler returns a debug handler listing the keys queued or pending retry for each controller. A POST
+// with the controller and key query parameters force-enqueues the key and a DELETE drops it. If token is set
+// every request must carry it as a bearer token; without a token only listing is allowed.
+func NewQueueHandler(token string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if token != "" {
+			auth := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+				http.Error(rw, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		} else if req.Method != http.MethodGet {
+			http.Error(rw, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		if req.Method == http.MethodGet {
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(queues.list())
+			return
+		}
+
+		tracker, ok := queues.get(req.URL.Query().Get("controller"))
+		key := req.URL.Query().Get("key")
+		if !ok || key == "" {
+			http.Error(rw, "controller and key are required", http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case http.MethodPost:
+			namespace, name := "", key
+			if i := strings.Index(key, "/"); i >= 0 {
+				namespace, name = key[:i], key[i+1:]
+			}
+			tracker.queued(key, 0)
+			tracker.enqueue(namespace, name)
+		case http.MethodDelete:
+			tracker.drop(key)
+		default:
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	})
+}
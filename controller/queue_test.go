@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueTrackerDrop(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending func(q *queueTracker)
+		// started is whether each handler processes the key after the drop
+		started map[string]bool
+	}{
+		{
+			name: "enqueued key",
+			pending: func(q *queueTracker) {
+				q.queued("ns/a", 0)
+			},
+			started: map[string]bool{"first": false, "second": false},
+		},
+		{
+			name: "retry of a handler",
+			pending: func(q *queueTracker) {
+				q.done("first", "ns/a", errors.New("failed"))
+			},
+			started: map[string]bool{"first": false, "second": true},
+		},
+		{
+			name:    "key not pending",
+			pending: func(q *queueTracker) {},
+			started: map[string]bool{"first": true, "second": true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := (&queueRegistry{trackers: map[string]*queueTracker{}}).tracker("test", func(string, string) {})
+			q.addHandler("first")
+			q.addHandler("second")
+			test.pending(q)
+
+			q.drop("ns/a")
+			assert.Empty(t, q.list())
+			for handler, started := range test.started {
+				assert.Equal(t, started, q.start(handler, "ns/a"), handler)
+			}
+			// the drop only applies to the pending processing
+			assert.True(t, q.start("first", "ns/a"))
+			assert.True(t, q.start("second", "ns/a"))
+		})
+	}
+}
+
+func TestQueueHandler(t *testing.T) {
+	var enqueued []string
+	q := queues.tracker("queue-handler-test", func(namespace, name string) {
+		enqueued = append(enqueued, namespace+"/"+name)
+	})
+	q.addHandler("handler")
+	q.done("handler", "ns/failing", errors.New("failed"))
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		token  string
+		code   int
+	}{
+		{name: "list without token", method: http.MethodGet, url: "/", code: http.StatusOK},
+		{name: "enqueue without token", method: http.MethodPost, url: "/?controller=queue-handler-test&key=ns/a", code: http.StatusForbidden},
+		{name: "wrong token", method: http.MethodGet, url: "/", token: "wrong", code: http.StatusUnauthorized},
+		{name: "unknown controller", method: http.MethodPost, url: "/?controller=missing&key=ns/a", token: "secret", code: http.StatusBadRequest},
+		{name: "enqueue", method: http.MethodPost, url: "/?controller=queue-handler-test&key=ns/a", token: "secret", code: http.StatusNoContent},
+		{name: "drop", method: http.MethodDelete, url: "/?controller=queue-handler-test&key=ns/failing", token: "secret", code: http.StatusNoContent},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := ""
+			if test.token != "" {
+				token = "secret"
+			}
+			req := httptest.NewRequest(test.method, test.url, nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			resp := httptest.NewRecorder()
+			NewQueueHandler(token).ServeHTTP(resp, req)
+			assert.Equal(t, test.code, resp.Code, resp.Body.String())
+		})
+	}
+
+	assert.Equal(t, []string{"ns/a"}, enqueued)
+	assert.False(t, q.start("handler", "ns/failing"))
+
+	resp := httptest.NewRecorder()
+	NewQueueHandler("").ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+	var listed map[string][]QueueEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	require.Len(t, listed["queue-handler-test"], 1)
+	assert.Equal(t, "ns/a", listed["queue-handler-test"][0].Key)
+}
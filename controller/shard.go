@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"hash/fnv"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	shardCountEnv = "CATTLE_CONTROLLER_SHARD_COUNT"
+	shardIndexEnv = "CATTLE_CONTROLLER_SHARD_INDEX"
+)
+
+type shardConfig struct {
+	index int32
+	count int32
+}
+
+var shard atomic.Pointer[shardConfig]
+
+func init() {
+	count, index := os.Getenv(shardCountEnv), os.Getenv(shardIndexEnv)
+	if count == "" {
+		return
+	}
+
+	c, err := strconv.Atoi(count)
+	if err != nil {
+		logrus.Errorf("invalid %s %q, sharding disabled: %v", shardCountEnv, count, err)
+		return
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		logrus.Errorf("invalid %s %q, sharding disabled: %v", shardIndexEnv, index, err)
+		return
+	}
+	SetShard(i, c)
+}
+
+// SetShard makes this replica handle only the keys that hash to shard index of count, so that replicas started
+// with the same count and different indexes process disjoint sets of objects. A count below 2 handles every key.
+// The shard is read from CATTLE_CONTROLLER_SHARD_COUNT and CATTLE_CONTROLLER_SHARD_INDEX at startup.
+func SetShard(index, count int) {
+	if count < 2 {
+		shard.Store(nil)
+		return
+	}
+	if index < 0 || index >= count {
+		logrus.Errorf("shard index %d is not below shard count %d, sharding disabled", index, count)
+		shard.Store(nil)
+		return
+	}
+	shard.Store(&shardConfig{
+		index: int32(index),
+		count: int32(count),
+	})
+}
+
+func inShard(key string) bool {
+	config := shard.Load()
+	if config == nil {
+		return true
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	return jumpHash(hash.Sum64(), config.count) == config.index
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach, which moves as few keys as possible between shards
+// when the shard count changes.
+func jumpHash(key uint64, buckets int32) int32 {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int32(b)
+}
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShard(t *testing.T) {
+	defer SetShard(0, 0)
+
+	counts := map[int]int{}
+	for i := 0; i < 3; i++ {
+		SetShard(i, 3)
+		for k := 0; k < 300; k++ {
+			if inShard("default/" + strconv.Itoa(k)) {
+				counts[k]++
+			}
+		}
+	}
+	for k := 0; k < 300; k++ {
+		assert.Equal(t, 1, counts[k])
+	}
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// ContextHandlerFunc is a HandlerFunc that stops when ctx is cancelled.
+type ContextHandlerFunc func(ctx context.Context, key string, obj interface{}) (interface{}, error)
+
+// NewTimeoutHandler wraps a handler so that a call running longer than timeout has its context cancelled and
+// its key requeued with an error, instead of holding a worker while stuck on an external call. The result of a
+// call that returns after its timeout is dropped, and the key is requeued with an error until that call returned,
+// so that a key is never handled by two calls at once.
+func NewTimeoutHandler(controller GenericController, name string, timeout time.Duration, handler ContextHandlerFunc) HandlerFunc {
+	controllerName := ""
+	if g, ok := controller.(*genericController); ok {
+		controllerName = g.name
+	}
+
+	var running sync.Map
+	return func(key string, obj interface{}) (interface{}, error) {
+		if _, busy := running.LoadOrStore(key, struct{}{}); busy {
+			return obj, fmt.Errorf("handler %s is still running on key %s", name, key)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		type result struct {
+			obj interface{}
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			defer running.Delete(key)
+			obj, err := handler(ctx, key, obj)
+			done <- result{obj: obj, err: err}
+		}()
+
+		select {
+		case r := <-done:
+			return r.obj, r.err
+		case <-ctx.Done():
+			logrus.Warnf("%s handler %s timed out after %v on key %s", controllerName, name, timeout, key)
+			metrics.IncHandlerTimeouts(controllerName, name)
+			return obj, fmt.Errorf("handler %s timed out after %v", name, timeout)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutHandler(t *testing.T) {
+	cancelled := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	handler := NewTimeoutHandler(nil, "slow", 10*time.Millisecond, func(ctx context.Context, key string, obj interface{}) (interface{}, error) {
+		if key == "fast" {
+			return "done", nil
+		}
+		<-ctx.Done()
+		once.Do(func() { close(cancelled) })
+		<-release
+		return nil, ctx.Err()
+	})
+
+	result, err := handler("fast", "obj")
+	assert.NoError(t, err)
+	assert.Equal(t, "done", result)
+
+	result, err = handler("stuck", "obj")
+	assert.EqualError(t, err, "handler slow timed out after 10ms")
+	assert.Equal(t, "obj", result)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("context of the handler was not cancelled")
+	}
+
+	_, err = handler("stuck", "obj")
+	assert.EqualError(t, err, "handler slow is still running on key stuck")
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, err := handler("stuck", "obj")
+		return err.Error() != "handler slow is still running on key stuck"
+	}, time.Second, 10*time.Millisecond)
+}
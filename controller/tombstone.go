@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RemoveHandlerFunc is called with the last known state of a deleted object.
+type RemoveHandlerFunc func(key string, obj interface{}) error
+
+// tombstones keeps the final state of deleted objects until each remove handler has seen it.
+type tombstones struct {
+	sync.Mutex
+	objects map[string]map[string]interface{}
+}
+
+func (t *tombstones) put(handler, key string, obj interface{}) {
+	t.Lock()
+	defer t.Unlock()
+	if t.objects == nil {
+		t.objects = map[string]map[string]interface{}{}
+	}
+	if t.objects[handler] == nil {
+		t.objects[handler] = map[string]interface{}{}
+	}
+	t.objects[handler][key] = obj
+}
+
+func (t *tombstones) get(handler, key string) (interface{}, bool) {
+	t.Lock()
+	defer t.Unlock()
+	obj, ok := t.objects[handler][key]
+	return obj, ok
+}
+
+func (t *tombstones) remove(handler, key string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.objects[handler], key)
+}
+
+// AddRemoveHandler calls handler with the final state of deleted objects, as last seen by the informer, so that
+// cleanup doesn't need a finalizer just to read the object. Deletes missed while the watch was down are handled
+// with the state of the object in the cache. Errors are retried like those of other handlers; the final state
+// is dropped once handler succeeds or an object with the same key is created again.
+func (g *genericController) AddRemoveHandler(ctx context.Context, name string, handler RemoveHandlerFunc) {
+	registration, err := g.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil || !inShard(key) {
+				return
+			}
+			g.tombstones.put(name, key, finalState(obj))
+			// the shared handler may have run before the tombstone was recorded
+			namespace, objName, err := cache.SplitMetaNamespaceKey(key)
+			if err == nil {
+				g.enqueue(namespace, objName)
+			}
+		},
+	})
+	if err != nil {
+		logrus.Errorf("failed to add remove handler %s to %s: %v", name, g.name, err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		_ = g.informer.RemoveEventHandler(registration)
+	}()
+
+	g.AddHandler(ctx, name, func(key string, obj interface{}) (interface{}, error) {
+		if obj != nil {
+			g.tombstones.remove(name, key)
+			return obj, nil
+		}
+
+		final, ok := g.tombstones.get(name, key)
+		if !ok {
+			return nil, nil
+		}
+		if runtimeObject, ok := final.(runtime.Object); ok && !isNamespace(g.namespace, runtimeObject) {
+			g.tombstones.remove(name, key)
+			return nil, nil
+		}
+		if err := handler(key, final); err != nil {
+			return nil, err
+		}
+		g.tombstones.remove(name, key)
+		return nil, nil
+	})
+}
+
+func finalState(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestTombstones(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}}
+
+	assert.Equal(t, pod, finalState(pod))
+	assert.Equal(t, pod, finalState(cache.DeletedFinalStateUnknown{Key: "default/a", Obj: pod}))
+
+	var ts tombstones
+	_, ok := ts.get("cleanup", "default/a")
+	assert.False(t, ok)
+
+	ts.put("cleanup", "default/a", pod)
+	obj, ok := ts.get("cleanup", "default/a")
+	assert.True(t, ok)
+	assert.Equal(t, pod, obj)
+	_, ok = ts.get("other", "default/a")
+	assert.False(t, ok)
+
+	ts.remove("cleanup", "default/a")
+	_, ok = ts.get("cleanup", "default/a")
+	assert.False(t, ok)
+}
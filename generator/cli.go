@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/rancher/norman/types"
+)
+
+type cliFlag struct {
+	Flag   string
+	Field  string
+	Getter string
+	Setter string
+	Zero   string
+	Usage  string
+}
+
+// cobraFlags are the flags cobra adds to the commands, the flags of fields with the same names are prefixed with
+// field- to not shadow them.
+var cobraFlags = map[string]bool{
+	"help":    true,
+	"version": true,
+}
+
+// GenerateCLI writes a cobra command per schema, with list, get, create, update, delete and action sub
+// commands. Create and update flags are generated from the fields of the schema that have a simple type. The
+// commands are added to a root command with AddCommands in the generated package. The CLI is only generated by this
+// function, the generated package imports github.com/spf13/cobra.
+func GenerateCLI(schemas *types.Schemas, privateTypes map[string]bool, outputDir, cliOutputPackage string) error {
+	baseDir := defaultSourceTree()
+
+	if err := generateCLI(path.Join(outputDir, cliOutputPackage), schemas, privateTypes); err != nil {
+		return err
+	}
+
+	return Gofmt(baseDir, filepath.Join(outputDir, cliOutputPackage))
+}
+
+func generateCLI(cliDir string, schemas *types.Schemas, privateTypes map[string]bool) error {
+	pkg := path.Base(cliDir)
+
+	if err := prepareDirs(cliDir); err != nil {
+		return err
+	}
+
+	var cliTypes []*types.Schema
+	for _, schema := range schemas.Schemas() {
+		if _, privateType := privateTypes[schema.ID]; privateType || blackListTypes[schema.ID] || !hasGet(schema) {
+			continue
+		}
+
+		if err := generateCLIType(cliDir, pkg, schema, schemas); err != nil {
+			return err
+		}
+		cliTypes = append(cliTypes, schema)
+	}
+
+	return generateCLIRoot(cliDir, pkg, cliTypes)
+}
+
+func generateCLIRoot(outputDir, pkg string, schemas []*types.Schema) error {
+	template, err := template.New("cli.template").
+		Funcs(funcs()).
+		Parse(cliTemplate)
+	if err != nil {
+		return err
+	}
+
+	output, err := os.Create(path.Join(outputDir, "zz_generated_cli.go"))
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	return template.Execute(output, map[string]interface{}{
+		"package": pkg,
+		"schemas": schemas,
+	})
+}
+
+func generateCLIType(outputDir, pkg string, schema *types.Schema, schemas *types.Schemas) error {
+	filePath := strings.ToLower("zz_generated_" + addUnderscore(schema.ID) + "_command.go")
+	output, err := os.Create(path.Join(outputDir, filePath))
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	typeTemplate, err := template.New("cli_type.template").
+		Funcs(funcs()).
+		Parse(cliTypeTemplate)
+	if err != nil {
+		return err
+	}
+
+	return typeTemplate.Execute(output, map[string]interface{}{
+		"package":         pkg,
+		"schema":          schema,
+		"createFlags":     getCLIFlags(schema, true),
+		"updateFlags":     getCLIFlags(schema, false),
+		"resourceActions": getResourceActions(schema, schemas),
+	})
+}
+
+func getCLIFlags(schema *types.Schema, create bool) []cliFlag {
+	var result []cliFlag
+	for name, field := range schema.ResourceFields {
+		if (create && !field.Create) || (!create && !field.Update) || name == "id" || name == "type" {
+			continue
+		}
+
+		flag := cliFlag{
+			Flag:  strings.ReplaceAll(addUnderscore(name), "_", "-"),
+			Field: name,
+			Usage: field.Description,
+		}
+		switch field.Type {
+		case "string", "password", "dnsLabel", "dnsLabelRestricted", "hostname", "enum", "date", "duration",
+			"quantity", "base64":
+			flag.Getter, flag.Setter, flag.Zero = "GetString", "String", `""`
+		case "int":
+			flag.Getter, flag.Setter, flag.Zero = "GetInt64", "Int64", "0"
+		case "float":
+			flag.Getter, flag.Setter, flag.Zero = "GetFloat64", "Float64", "0"
+		case "boolean":
+			flag.Getter, flag.Setter, flag.Zero = "GetBool", "Bool", "false"
+		case "array[string]":
+			flag.Getter, flag.Setter, flag.Zero = "GetStringSlice", "StringSlice", "nil"
+		case "map[string]":
+			flag.Getter, flag.Setter, flag.Zero = "GetStringToString", "StringToString", "nil"
+		default:
+			continue
+		}
+		if cobraFlags[flag.Flag] {
+			flag.Flag = "field-" + flag.Flag
+		}
+		if len(field.Options) > 0 {
+			flag.Usage = strings.TrimSpace(flag.Usage + " (" + strings.Join(field.Options, ", ") + ")")
+		}
+		result = append(result, flag)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Flag < result[j].Flag
+	})
+	return result
+}
+
+func hasPut(schema *types.Schema) bool {
+	return contains(schema.ResourceMethods, http.MethodPut)
+}
+
+func hasDelete(schema *types.Schema) bool {
+	return contains(schema.ResourceMethods, http.MethodDelete)
+}
@@ -0,0 +1,214 @@
+package generator
+
+var cliTemplate = `package {{.package}}
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rancher/norman/clientbase"
+	"github.com/spf13/cobra"
+)
+
+type ClientGetter func() (clientbase.APIBaseClientInterface, error)
+
+func AddCommands(root *cobra.Command, getClient ClientGetter) {
+	{{- range .schemas}}
+	root.AddCommand(new{{.CodeName}}Command(getClient))
+	{{- end}}
+}
+
+func printJSON(cmd *cobra.Command, obj interface{}) error {
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(obj)
+}
+
+func parseFilters(filters []string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid filter %q, expected key=value", filter)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+func parseInput(input string) (interface{}, error) {
+	if input == "" {
+		return nil, nil
+	}
+	var result map[string]interface{}
+	return result, json.Unmarshal([]byte(input), &result)
+}
+`
+
+var cliTypeTemplate = `package {{.package}}
+
+import (
+	"github.com/rancher/norman/types"
+	"github.com/spf13/cobra"
+)
+
+func new{{.schema.CodeName}}Command(getClient ClientGetter) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "{{.schema.ID}}",
+		Short: "Manage {{.schema.PluralName}}",
+	}
+
+	var filters []string
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List {{.schema.PluralName}}",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient()
+			if err != nil {
+				return err
+			}
+			opts, err := parseFilters(filters)
+			if err != nil {
+				return err
+			}
+			resp := map[string]interface{}{}
+			if err := client.List("{{.schema.ID}}", &types.ListOpts{Filters: opts}, &resp); err != nil {
+				return err
+			}
+			return printJSON(cmd, resp["data"])
+		},
+	}
+	list.Flags().StringArrayVar(&filters, "filter", nil, "filter by key=value")
+	cmd.AddCommand(list)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get ID",
+		Short: "Show a {{.schema.ID}}",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient()
+			if err != nil {
+				return err
+			}
+			resp := map[string]interface{}{}
+			if err := client.ByID("{{.schema.ID}}", args[0], &resp); err != nil {
+				return err
+			}
+			return printJSON(cmd, resp)
+		},
+	})
+{{if .schema | hasPost}}
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a {{.schema.ID}}",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient()
+			if err != nil {
+				return err
+			}
+			body := map[string]interface{}{}
+			{{- range .createFlags}}
+			if cmd.Flags().Changed("{{.Flag}}") {
+				body["{{.Field}}"], _ = cmd.Flags().{{.Getter}}("{{.Flag}}")
+			}
+			{{- end}}
+			resp := map[string]interface{}{}
+			if err := client.Create("{{.schema.ID}}", body, &resp); err != nil {
+				return err
+			}
+			return printJSON(cmd, resp)
+		},
+	}
+	{{- range .createFlags}}
+	create.Flags().{{.Setter}}("{{.Flag}}", {{.Zero}}, {{printf "%q" .Usage}})
+	{{- end}}
+	cmd.AddCommand(create)
+{{end}}
+{{- if .schema | hasPut}}
+	update := &cobra.Command{
+		Use:   "update ID",
+		Short: "Update a {{.schema.ID}}",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient()
+			if err != nil {
+				return err
+			}
+			existing := &types.Resource{}
+			if err := client.ByID("{{.schema.ID}}", args[0], existing); err != nil {
+				return err
+			}
+			body := map[string]interface{}{}
+			{{- range .updateFlags}}
+			if cmd.Flags().Changed("{{.Flag}}") {
+				body["{{.Field}}"], _ = cmd.Flags().{{.Getter}}("{{.Flag}}")
+			}
+			{{- end}}
+			resp := map[string]interface{}{}
+			if err := client.Update("{{.schema.ID}}", existing, body, &resp); err != nil {
+				return err
+			}
+			return printJSON(cmd, resp)
+		},
+	}
+	{{- range .updateFlags}}
+	update.Flags().{{.Setter}}("{{.Flag}}", {{.Zero}}, {{printf "%q" .Usage}})
+	{{- end}}
+	cmd.AddCommand(update)
+{{end}}
+{{- if .schema | hasDelete}}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete a {{.schema.ID}}",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient()
+			if err != nil {
+				return err
+			}
+			existing := &types.Resource{}
+			if err := client.ByID("{{.schema.ID}}", args[0], existing); err != nil {
+				return err
+			}
+			return client.Delete(existing)
+		},
+	})
+{{end}}
+{{- range $name, $action := .resourceActions}}
+	{
+		var input string
+		action := &cobra.Command{
+			Use:   "{{$name}} ID",
+			Short: "Run the {{$name}} action on a {{$.schema.ID}}",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				client, err := getClient()
+				if err != nil {
+					return err
+				}
+				existing := &types.Resource{}
+				if err := client.ByID("{{$.schema.ID}}", args[0], existing); err != nil {
+					return err
+				}
+				body, err := parseInput(input)
+				if err != nil {
+					return err
+				}
+				resp := map[string]interface{}{}
+				if err := client.Action("{{$.schema.ID}}", "{{$name}}", existing, body, &resp); err != nil {
+					return err
+				}
+				return printJSON(cmd, resp)
+			},
+		}
+		action.Flags().StringVar(&input, "input", "", "action input{{if $action.Input}} ({{$action.Input}}){{end}} as JSON")
+		cmd.AddCommand(action)
+	}
+{{- end}}
+
+	return cmd
+}
+`
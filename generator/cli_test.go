@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Manual struct {
+	types.Resource
+	Help    string   `json:"help" norman:"required"`
+	Pages   int64    `json:"pages"`
+	Authors []string `json:"authors"`
+}
+
+func cliSchemas() *types.Schemas {
+	schemas := types.NewSchemas()
+	schemas.MustImportAndCustomize(&version, Manual{}, func(schema *types.Schema) {
+		schema.CollectionMethods = []string{http.MethodGet, http.MethodPost}
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut, http.MethodDelete}
+		schema.ResourceActions = map[string]types.Action{"publish": {}}
+	})
+	return schemas
+}
+
+func TestGetCLIFlagsCobraFlags(t *testing.T) {
+	schema := cliSchemas().Schema(&version, "manual")
+	require.NotNil(t, schema)
+
+	var flags []string
+	for _, flag := range getCLIFlags(schema, true) {
+		flags = append(flags, flag.Flag)
+	}
+	assert.Equal(t, []string{"authors", "field-help", "pages"}, flags)
+}
+
+func TestGenerateCLICompiles(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	norman, err := filepath.Abs("..")
+	require.NoError(t, err)
+
+	// norman doesn't depend on cobra, the generated commands are built in a module of their own that does
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(fmt.Sprintf(`module example.com/cli
+
+go 1.24.0
+
+require (
+	github.com/rancher/norman v0.0.0
+	github.com/spf13/cobra v1.9.1
+)
+
+replace github.com/rancher/norman => %s
+`, norman)), 0644))
+	require.NoError(t, generateCLI(filepath.Join(dir, "cli"), cliSchemas(), nil))
+
+	// the dependencies are only read from the module cache, the test is skipped if cobra isn't in it
+	env := append(os.Environ(), "GOPROXY=off", "GOSUMDB=off", "GOFLAGS=-mod=mod")
+	download := exec.Command(goBin, "mod", "download", "github.com/spf13/cobra")
+	download.Dir = dir
+	download.Env = env
+	if output, err := download.CombinedOutput(); err != nil {
+		t.Skipf("cobra is not in the module cache: %v\n%s", err, output)
+	}
+
+	vet := exec.Command(goBin, "vet", "./cli")
+	vet.Dir = dir
+	vet.Env = env
+	output, err := vet.CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
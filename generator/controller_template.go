@@ -70,16 +70,24 @@ type {{.schema.CodeName}}HandlerFunc func(key string, obj *{{.prefix}}{{.schema.
 
 type {{.schema.CodeName}}ChangeHandlerFunc func(obj *{{.prefix}}{{.schema.CodeName}}) (runtime.Object, error)
 
+type {{.schema.CodeName}}RemoveHandlerFunc func(key string, obj *{{.prefix}}{{.schema.CodeName}}) error
+
+type {{.schema.CodeName}}IndexFunc func(obj *{{.prefix}}{{.schema.CodeName}}) ([]string, error)
+
 type {{.schema.CodeName}}Lister interface {
 	List(namespace string, selector labels.Selector) (ret []*{{.prefix}}{{.schema.CodeName}}, err error)
 	Get(namespace, name string) (*{{.prefix}}{{.schema.CodeName}}, error)
+	GetByIndex(indexName, key string) ([]*{{.prefix}}{{.schema.CodeName}}, error)
 }
 
 type {{.schema.CodeName}}Controller interface {
 	Generic() controller.GenericController
 	Informer() cache.SharedIndexInformer
 	Lister() {{.schema.CodeName}}Lister
+	AddIndexer(indexName string, indexer {{.schema.CodeName}}IndexFunc) error
 	AddHandler(ctx context.Context, name string, handler {{.schema.CodeName}}HandlerFunc)
+	AddHandlerWithPredicates(ctx context.Context, name string, handler {{.schema.CodeName}}HandlerFunc, predicates ...controller.Predicate)
+	AddRemoveHandler(ctx context.Context, name string, handler {{.schema.CodeName}}RemoveHandlerFunc)
 	AddFeatureHandler(ctx context.Context, enabled func() bool, name string, sync {{.schema.CodeName}}HandlerFunc)
 	AddClusterScopedHandler(ctx context.Context, name, clusterName string, handler {{.schema.CodeName}}HandlerFunc)
 	AddClusterScopedFeatureHandler(ctx context.Context, enabled func() bool, name, clusterName string, handler {{.schema.CodeName}}HandlerFunc)
@@ -92,7 +100,14 @@ type {{.schema.CodeName}}Interface interface {
 	Create(*{{.prefix}}{{.schema.CodeName}}) (*{{.prefix}}{{.schema.CodeName}}, error)
 	GetNamespaced(namespace, name string, opts metav1.GetOptions) (*{{.prefix}}{{.schema.CodeName}}, error)
 	Get(name string, opts metav1.GetOptions) (*{{.prefix}}{{.schema.CodeName}}, error)
+{{- if .specType }}
+	UpdateSpec(meta metav1.ObjectMeta, spec {{.specType}}) (*{{.prefix}}{{.schema.CodeName}}, error)
+	UpdateStatus(*{{.prefix}}{{.schema.CodeName}}) (*{{.prefix}}{{.schema.CodeName}}, error)
+{{- else }}
 	Update(*{{.prefix}}{{.schema.CodeName}}) (*{{.prefix}}{{.schema.CodeName}}, error)
+{{- end }}
+	Exists(namespace, name string) (bool, error)
+	Count(selector labels.Selector) (int, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteNamespaced(namespace, name string, options *metav1.DeleteOptions) error
 	List(opts metav1.ListOptions) (*{{.prefix}}{{.schema.CodeName}}List, error)
@@ -145,6 +160,20 @@ func (l *{{.schema.ID}}Lister) Get(namespace, name string) (*{{.prefix}}{{.schem
 	return obj.(*{{.prefix}}{{.schema.CodeName}}), nil
 }
 
+func (l *{{.schema.ID}}Lister) GetByIndex(indexName, key string) ([]*{{.prefix}}{{.schema.CodeName}}, error) {
+	objs, err := l.controller.Informer().GetIndexer().ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*{{.prefix}}{{.schema.CodeName}}, 0, len(objs))
+	for _, obj := range objs {
+		if v, ok := obj.(*{{.prefix}}{{.schema.CodeName}}); ok {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
 type {{.schema.ID}}Controller struct {
 	ns string
 	controller.GenericController
@@ -161,6 +190,18 @@ func (c *{{.schema.ID}}Controller) Lister() {{.schema.CodeName}}Lister {
 	}
 }
 
+// AddIndexer registers an index of the cache, to be queried with Lister().GetByIndex. Indexers should be added
+// when the controller is set up, before the cache starts.
+func (c *{{.schema.ID}}Controller) AddIndexer(indexName string, indexer {{.schema.CodeName}}IndexFunc) error {
+	return c.Informer().AddIndexers(cache.Indexers{
+		indexName: func(obj interface{}) ([]string, error) {
+			if v, ok := obj.(*{{.prefix}}{{.schema.CodeName}}); ok {
+				return indexer(v)
+			}
+			return nil, nil
+		},
+	})
+}
 
 func (c *{{.schema.ID}}Controller) AddHandler(ctx context.Context, name string, handler {{.schema.CodeName}}HandlerFunc) {
 	c.GenericController.AddHandler(ctx, name, func(key string, obj interface{}) (interface{}, error) {
@@ -174,6 +215,29 @@ func (c *{{.schema.ID}}Controller) AddHandler(ctx context.Context, name string,
 	})
 }
 
+// AddHandlerWithPredicates adds a handler only called for the objects of the informer events passing all predicates.
+func (c *{{.schema.ID}}Controller) AddHandlerWithPredicates(ctx context.Context, name string, handler {{.schema.CodeName}}HandlerFunc, predicates ...controller.Predicate) {
+	c.GenericController.AddHandlerWithPredicates(ctx, name, func(key string, obj interface{}) (interface{}, error) {
+		if obj == nil {
+			return handler(key, nil)
+		} else if v, ok := obj.(*{{.prefix}}{{.schema.CodeName}}); ok {
+			return handler(key, v)
+		} else {
+			return nil, nil
+		}
+	}, predicates...)
+}
+
+// AddRemoveHandler calls handler with the final state of deleted objects.
+func (c *{{.schema.ID}}Controller) AddRemoveHandler(ctx context.Context, name string, handler {{.schema.CodeName}}RemoveHandlerFunc) {
+	c.GenericController.AddRemoveHandler(ctx, name, func(key string, obj interface{}) error {
+		if v, ok := obj.(*{{.prefix}}{{.schema.CodeName}}); ok {
+			return handler(key, v)
+		}
+		return nil
+	})
+}
+
 func (c *{{.schema.ID}}Controller) AddFeatureHandler(ctx context.Context, enabled func() bool, name string, handler {{.schema.CodeName}}HandlerFunc) {
 	c.GenericController.AddHandler(ctx, name, func(key string, obj interface{}) (interface{}, error) {
 		if !enabled() {
@@ -228,6 +292,7 @@ func (c {{.schema.ID}}Factory) List() runtime.Object {
 func (s *{{.schema.ID}}Client) Controller() {{.schema.CodeName}}Controller {
 	genericController := controller.NewGenericController(s.ns, {{.schema.CodeName}}GroupVersionKind.Kind+"Controller",
 		s.client.controllerFactory.ForResourceKind({{.schema.CodeName}}GroupVersionResource, {{.schema.CodeName}}GroupVersionKind.Kind, {{.schema | namespaced}}))
+	controller.RequireFullObjects(genericController, {{.schema.CodeName}}GroupVersionKind)
 
 	return &{{.schema.ID}}Controller{
 		ns: s.ns,
@@ -261,16 +326,43 @@ func (s *{{.schema.ID}}Client) GetNamespaced(namespace, name string, opts metav1
 	return obj.(*{{.prefix}}{{.schema.CodeName}}), err
 }
 
+{{- if .specType }}
+
+// UpdateSpec updates the metadata and spec of a {{.schema.CodeName}}, its status is written by UpdateStatus. There is
+// no Update taking a whole {{.schema.CodeName}}, as the status subresource drops the status it would write.
+func (s *{{.schema.ID}}Client) UpdateSpec(meta metav1.ObjectMeta, spec {{.specType}}) (*{{.prefix}}{{.schema.CodeName}}, error) {
+	o := New{{.schema.CodeName}}(meta.Namespace, meta.Name, {{.prefix}}{{.schema.CodeName}}{ObjectMeta: meta, Spec: spec})
+	obj, err := s.objectClient.Update(o.Name, o)
+	return obj.(*{{.prefix}}{{.schema.CodeName}}), err
+}
+{{- else }}
+
 func (s *{{.schema.ID}}Client) Update(o *{{.prefix}}{{.schema.CodeName}}) (*{{.prefix}}{{.schema.CodeName}}, error) {
 	obj, err := s.objectClient.Update(o.Name, o)
 	return obj.(*{{.prefix}}{{.schema.CodeName}}), err
 }
+{{- end }}
 
 func (s *{{.schema.ID}}Client) UpdateStatus(o *{{.prefix}}{{.schema.CodeName}}) (*{{.prefix}}{{.schema.CodeName}}, error) {
 	obj, err := s.objectClient.UpdateStatus(o.Name, o)
 	return obj.(*{{.prefix}}{{.schema.CodeName}}), err
 }
 
+// cachedObjectClient returns the object client answering Exists and Count from the informer of the controller of
+// {{.schema.CodeName}} once it has synced. The informer is created if needed, to be started with the controllers.
+func (s *{{.schema.ID}}Client) cachedObjectClient() *objectclient.ObjectClient {
+	informer := s.client.controllerFactory.ForResourceKind({{.schema.CodeName}}GroupVersionResource, {{.schema.CodeName}}GroupVersionKind.Kind, {{.schema | namespaced}}).Informer()
+	return s.objectClient.WithCache(informer)
+}
+
+func (s *{{.schema.ID}}Client) Exists(namespace, name string) (bool, error) {
+	return s.cachedObjectClient().Exists(namespace, name)
+}
+
+func (s *{{.schema.ID}}Client) Count(selector labels.Selector) (int, error) {
+	return s.cachedObjectClient().Count(selector)
+}
+
 func (s *{{.schema.ID}}Client) Delete(name string, options *metav1.DeleteOptions) error {
 	return s.objectClient.Delete(name, options)
 }
@@ -316,9 +408,17 @@ func (s *{{.schema.ID}}Client) AddLifecycle(ctx context.Context, name string, li
 	s.Controller().AddHandler(ctx, name, sync)
 }
 
+// AddFeatureLifecycle adds a lifecycle only called while enabled, except to finalize objects being deleted so that
+// they don't wait on the finalizer of a disabled feature. With a controller.Feature, pass feature.Enabled and call
+// feature.Requeue with the generic controller to handle every object again when the feature gets enabled.
 func (s *{{.schema.ID}}Client) AddFeatureLifecycle(ctx context.Context, enabled func() bool, name string, lifecycle {{.schema.CodeName}}Lifecycle) {
 	sync := New{{.schema.CodeName}}LifecycleAdapter(name, false, s, lifecycle)
-	s.Controller().AddFeatureHandler(ctx, enabled, name, sync)
+	s.Controller().AddHandler(ctx, name, func(key string, obj *{{.prefix}}{{.schema.CodeName}}) (runtime.Object, error) {
+		if !enabled() && (obj == nil || obj.DeletionTimestamp == nil) {
+			return nil, nil
+		}
+		return sync(key, obj)
+	})
 }
 
 func (s *{{.schema.ID}}Client) AddClusterScopedHandler(ctx context.Context, name, clusterName string, sync {{.schema.CodeName}}HandlerFunc) {
@@ -336,6 +436,11 @@ func (s *{{.schema.ID}}Client) AddClusterScopedLifecycle(ctx context.Context, na
 
 func (s *{{.schema.ID}}Client) AddClusterScopedFeatureLifecycle(ctx context.Context, enabled func() bool, name, clusterName string, lifecycle {{.schema.CodeName}}Lifecycle) {
 	sync := New{{.schema.CodeName}}LifecycleAdapter(name+"_"+clusterName, true, s, lifecycle)
-	s.Controller().AddClusterScopedFeatureHandler(ctx, enabled, name, clusterName, sync)
+	s.Controller().AddClusterScopedHandler(ctx, name, clusterName, func(key string, obj *{{.prefix}}{{.schema.CodeName}}) (runtime.Object, error) {
+		if !enabled() && (obj == nil || obj.DeletionTimestamp == nil) {
+			return nil, nil
+		}
+		return sync(key, obj)
+	})
 }
 `
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// indexTest runs in the package of the generated controller of config maps
+const indexTest = `package v1
+
+import (
+	"testing"
+
+	"github.com/rancher/norman/controller"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type informerController struct {
+	controller.GenericController
+	informer cache.SharedIndexInformer
+}
+
+func (i *informerController) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+func TestGetByIndex(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &v1.ConfigMap{}, 0, cache.Indexers{})
+	c := &configMapController{GenericController: &informerController{informer: informer}}
+	err := c.AddIndexer("owner", func(obj *v1.ConfigMap) ([]string, error) {
+		return []string{obj.Data["owner"]}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, owner := range map[string]string{"a": "alice", "b": "bob", "c": "alice"} {
+		cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Data: map[string]string{"owner": owner}}
+		if err := informer.GetIndexer().Add(cm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := c.Lister().GetByIndex("owner", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[0].Data["owner"] != "alice" || result[1].Data["owner"] != "alice" {
+		t.Fatalf("unexpected config maps %v", result)
+	}
+	if _, err := c.Lister().GetByIndex("missing", "alice"); err == nil {
+		t.Fatal("expected an error for an index that does not exist")
+	}
+}
+`
+
+func TestGenerateControllerIndexes(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	coreVersion := types.APIVersion{Version: "v1", Path: "/api/v1"}
+	schemas := types.NewSchemas()
+	schema, err := schemas.Import(&coreVersion, corev1.ConfigMap{})
+	require.NoError(t, err)
+	schema.Scope = types.NamespaceScope
+
+	// the directory is in the module for the generated code to import its dependencies, its name starts with an
+	// underscore for ./... to skip it
+	dir, err := os.MkdirTemp(".", "_indexes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, generateController(true, dir, schema, schemas))
+	require.NoError(t, generateLifecycle(true, dir, schema, schemas))
+	require.NoError(t, generateK8sClient(true, dir, &coreVersion, []*types.Schema{schema}))
+	require.NoError(t, generateScheme(true, dir, &coreVersion, []*types.Schema{schema}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index_test.go"), []byte(indexTest), 0644))
+
+	output, err := exec.Command(goBin, "test", "./"+dir).CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
@@ -0,0 +1,240 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/types"
+)
+
+// conversionScopes are the structs of backing types whose fields are matched to the fields of client types, by
+// JSON name, after the top level fields.
+var conversionScopes = []string{"metadata", "spec", "status"}
+
+type conversionField struct {
+	// From and To are the statements copying the field from the backing type to the client type, and back
+	From string
+	To   string
+}
+
+// GenerateConversions writes, next to the client types generated by GenerateClient, functions converting the
+// client types of schemas from and to the backing Kubernetes types of objs, by schema ID, with round-trip tests
+// filling the backing types with randfill. Fields are copied with compiled assignments when the client and backing
+// fields have the same JSON name and compatible types; the other fields, like those renamed by mappers, are listed
+// in the generated code and left to the runtime conversion. The conversions covering every field of types without
+// mappers are registered with schemaconvert.RegisterConversion, in place of the runtime conversion. Generate writes the conversions of the types it generates
+// controllers for from the types their schemas were imported from.
+func GenerateConversions(schemas *types.Schemas, objs map[string]interface{}, outputDir, cattleOutputPackage string) error {
+	baseDir := defaultSourceTree()
+	cattleDir := path.Join(outputDir, cattleOutputPackage)
+
+	ids := make([]string, 0, len(objs))
+	for id := range objs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		schema := findSchema(schemas, id)
+		if schema == nil {
+			return fmt.Errorf("no schema %s to generate conversions for", id)
+		}
+		if err := generateConversion(cattleDir, schema, schemas, reflect.TypeOf(objs[id])); err != nil {
+			return errors.Wrapf(err, "failed to generate conversions of %s", id)
+		}
+	}
+
+	return Gofmt(baseDir, filepath.Join(outputDir, cattleOutputPackage))
+}
+
+func findSchema(schemas *types.Schemas, id string) *types.Schema {
+	for _, schema := range schemas.Schemas() {
+		if schema.ID == id {
+			return schema
+		}
+	}
+	return nil
+}
+
+func generateConversion(outputDir string, schema *types.Schema, schemas *types.Schemas, backing reflect.Type) error {
+	if backing.Kind() == reflect.Ptr {
+		backing = backing.Elem()
+	}
+	if backing.Kind() != reflect.Struct || backing.PkgPath() == "" {
+		return fmt.Errorf("backing type %v is not a named struct", backing)
+	}
+
+	alias := packageAlias(backing.PkgPath())
+	fields, skipped := getConversionFields(schema, schemas, backing, alias)
+	data := map[string]interface{}{
+		"schema":      schema,
+		"importPath":  backing.PkgPath(),
+		"alias":       alias,
+		"backingType": alias + "." + backing.Name(),
+		"fields":      fields,
+		"skipped":     skipped,
+		// the conversions of types without mappers and skipped fields are complete, and replace the runtime ones
+		"register": len(skipped) == 0 && schema.InternalSchema == nil,
+	}
+
+	for fileName, text := range map[string]string{
+		"zz_generated_" + addUnderscore(schema.ID) + "_conversion.go":      conversionTemplate,
+		"zz_generated_" + addUnderscore(schema.ID) + "_conversion_test.go": conversionTestTemplate,
+	} {
+		tmpl, err := template.New(fileName).Funcs(funcs()).Parse(text)
+		if err != nil {
+			return err
+		}
+		output, err := os.Create(path.Join(outputDir, strings.ToLower(fileName)))
+		if err != nil {
+			return err
+		}
+		err = tmpl.Execute(output, data)
+		output.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func packageAlias(importPath string) string {
+	alias := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(path.Base(importPath)))
+	return "backing" + alias
+}
+
+// getConversionFields returns the conversions of the fields of the client type of schema, and the JSON names of the
+// fields that can't be converted with an assignment.
+func getConversionFields(schema *types.Schema, schemas *types.Schemas, backing reflect.Type, alias string) ([]conversionField, []string) {
+	names := make([]string, 0, len(schema.ResourceFields))
+	for name := range schema.ResourceFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		fields  []conversionField
+		skipped []string
+	)
+	for _, name := range names {
+		if strings.EqualFold(name, "id") && hasGet(schema) {
+			continue
+		}
+		field := schema.ResourceFields[name]
+		selector, backingField, ok := findBackingField(backing, name)
+		if !ok {
+			skipped = append(skipped, name)
+			continue
+		}
+		from, to, ok := assignments(getGoType(field, schema, schemas), backingField, backing.PkgPath(), alias)
+		if !ok {
+			skipped = append(skipped, name)
+			continue
+		}
+		fields = append(fields, conversionField{
+			From: fmt.Sprintf("out.%s = %s", field.CodeName, fmt.Sprintf(from, "in."+selector)),
+			To:   fmt.Sprintf("out.%s = %s", selector, fmt.Sprintf(to, "in."+field.CodeName)),
+		})
+	}
+	return fields, skipped
+}
+
+// findBackingField returns the selector of the field of t with the JSON name name, at the top level or in one of
+// the conversionScopes.
+func findBackingField(t reflect.Type, name string) (string, reflect.Type, bool) {
+	if selector, fieldType, ok := fieldByJSONName(t, name); ok {
+		return selector, fieldType, true
+	}
+	for _, scope := range conversionScopes {
+		selector, scopeType, ok := fieldByJSONName(t, scope)
+		if !ok || scopeType.Kind() != reflect.Struct {
+			continue
+		}
+		if fieldSelector, fieldType, ok := fieldByJSONName(scopeType, name); ok {
+			return selector + "." + fieldSelector, fieldType, true
+		}
+	}
+	return "", nil, false
+}
+
+func fieldByJSONName(t reflect.Type, name string) (string, reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && (jsonName == "" || strings.Contains(f.Tag.Get("json"), ",inline")) && f.Type.Kind() == reflect.Struct {
+			if selector, fieldType, ok := fieldByJSONName(f.Type, name); ok {
+				return f.Name + "." + selector, fieldType, true
+			}
+			continue
+		}
+		if jsonName == "" {
+			jsonName = f.Name
+		}
+		if jsonName == name {
+			return f.Name, f.Type, true
+		}
+	}
+	return "", nil, false
+}
+
+// assignments returns the format of the expressions converting a backing field of type t to the client type
+// goType, and back, whether the types are compatible.
+func assignments(goType string, t reflect.Type, pkgPath, alias string) (string, string, bool) {
+	if t.String() == goType && t.PkgPath() == "" && isPlain(t) {
+		return "%s", "%s", true
+	}
+
+	cast := t.Name()
+	if t.PkgPath() != "" {
+		if t.PkgPath() != pkgPath {
+			return "", "", false
+		}
+		cast = alias + "." + t.Name()
+	}
+	switch {
+	case goType == "string" && t.Kind() == reflect.String,
+		goType == "bool" && t.Kind() == reflect.Bool,
+		goType == "float64" && (t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64),
+		goType == "int64" && isInt(t.Kind()):
+		return goType + "(%s)", cast + "(%s)", true
+	}
+	return "", "", false
+}
+
+// isPlain returns whether values of t can be assigned to the client type without a deep conversion.
+func isPlain(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return t.Elem().PkgPath() == "" && isPlain(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().PkgPath() == "" && isPlain(t.Elem())
+	case reflect.Struct, reflect.Interface, reflect.Array, reflect.Chan, reflect.Func:
+		return false
+	}
+	return true
+}
+
+// isInt returns whether every value of kind fits in the int64 of client types, uint and uint64 don't.
+func isInt(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package generator
+
+var conversionTemplate = `package client
+
+import (
+{{- if .register}}
+	"github.com/rancher/norman/types/convert/schemaconvert"
+{{- end}}
+	{{.alias}} "{{.importPath}}"
+)
+{{- if .register}}
+
+func init() {
+	schemaconvert.RegisterConversion(&{{.schema.CodeName}}{}, &{{.backingType}}{}, func(from, target interface{}) {
+		{{.schema.CodeName}}ToObject(from.(*{{.schema.CodeName}}), target.(*{{.backingType}}))
+	})
+}
+{{- end}}
+
+{{- if .skipped}}
+
+// Fields of {{.schema.CodeName}} not converted by {{.schema.CodeName}}FromObject and {{.schema.CodeName}}ToObject:
+{{- range .skipped}}
+//   - {{.}}
+{{- end}}
+{{- end}}
+
+// {{.schema.CodeName}}FromObject returns the {{.schema.CodeName}} of in, with the fields that map one to one.
+func {{.schema.CodeName}}FromObject(in *{{.backingType}}) *{{.schema.CodeName}} {
+	out := &{{.schema.CodeName}}{}
+{{- range .fields}}
+	{{.From}}
+{{- end}}
+	return out
+}
+
+// {{.schema.CodeName}}ToObject sets the fields of out that map one to one to the fields of in.
+func {{.schema.CodeName}}ToObject(in *{{.schema.CodeName}}, out *{{.backingType}}) {
+{{- range .fields}}
+	{{.To}}
+{{- end}}
+}
+`
+
+var conversionTestTemplate = `package client
+
+import (
+	"reflect"
+	"testing"
+
+	{{.alias}} "{{.importPath}}"
+	"sigs.k8s.io/randfill"
+)
+
+func Test{{.schema.CodeName}}ConversionRoundTrip(t *testing.T) {
+	filler := randfill.New().NilChance(0.2).NumElements(0, 3)
+	for i := 0; i < 100; i++ {
+		in := &{{.backingType}}{}
+		filler.Fill(in)
+
+		converted := {{.schema.CodeName}}FromObject(in)
+		out := &{{.backingType}}{}
+		{{.schema.CodeName}}ToObject(converted, out)
+		if roundTrip := {{.schema.CodeName}}FromObject(out); !reflect.DeepEqual(converted, roundTrip) {
+			t.Fatalf("round trip of %#v changed it to %#v", converted, roundTrip)
+		}
+	}
+}
+`
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	// randfill is imported by the generated conversion tests built by TestGenerateConversionCompiles
+	_ "sigs.k8s.io/randfill"
+)
+
+func TestIsInt(t *testing.T) {
+	for kind, expected := range map[reflect.Kind]bool{
+		reflect.Int:    true,
+		reflect.Int64:  true,
+		reflect.Uint8:  true,
+		reflect.Uint32: true,
+		reflect.Uint:   false,
+		reflect.Uint64: false,
+		reflect.String: false,
+	} {
+		assert.Equal(t, expected, isInt(kind), kind.String())
+	}
+}
+
+func TestGenerateConversionCompiles(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	schemas := types.NewSchemas().
+		MustImport(&version, corev1.ConfigMap{}).
+		MustImport(&version, corev1.ConfigMapKeySelector{})
+
+	// the directory is in the module for the generated code to import its dependencies, its name starts with an
+	// underscore for ./... to skip it
+	dir, err := os.MkdirTemp(".", "_conversion")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, schema := range schemas.Schemas() {
+		require.NoError(t, generateType(dir, schema, schemas))
+	}
+	configMap := schemas.Schema(&version, "configMap")
+	require.NotNil(t, configMap)
+	require.NoError(t, generateClient(dir, []*types.Schema{configMap}))
+	for _, id := range []string{"configMap", "configMapKeySelector"} {
+		schema := schemas.Schema(&version, id)
+		require.NoError(t, generateConversion(dir, schema, schemas, schema.GoType))
+	}
+
+	output, err := exec.Command(goBin, "test", "./"+dir).CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
@@ -1,10 +1,12 @@
 package generator
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/types/convert"
@@ -22,8 +24,11 @@ func funcs() template.FuncMap {
 		"toLower":             strings.ToLower,
 		"hasGet":              hasGet,
 		"hasPost":             hasPost,
+		"hasPut":              hasPut,
+		"hasDelete":           hasDelete,
 		"getCollectionOutput": getCollectionOutput,
 		"namespaced":          namespaced,
+		"enumConstName":       enumConstName,
 	}
 }
 
@@ -31,6 +36,27 @@ func addUnderscore(input string) string {
 	return strings.ToLower(underscoreRegexp.ReplaceAllString(input, `${1}_${2}`))
 }
 
+// statusSubresourceSpec returns the Go type of the spec of schema when it declares the status subresource, "" if
+// it doesn't. The generated clients of these schemas update the spec and status apart.
+func statusSubresourceSpec(schema *types.Schema, schemas *types.Schemas, prefix string) (string, error) {
+	if !schema.StatusSubresource {
+		return "", nil
+	}
+	fields := schema.ResourceFields
+	if schema.InternalSchema != nil {
+		fields = schema.InternalSchema.ResourceFields
+	}
+	spec, ok := fields["spec"]
+	if _, status := fields["status"]; !ok || !status {
+		return "", fmt.Errorf("schema %s declares the status subresource without spec and status fields", schema.ID)
+	}
+	specSchema := schemas.Schema(&schema.Version, spec.Type)
+	if specSchema == nil || specSchema.PkgName != schema.PkgName {
+		return "", fmt.Errorf("spec %s of schema %s must be a type of package %s", spec.Type, schema.ID, schema.PkgName)
+	}
+	return prefix + specSchema.CodeName, nil
+}
+
 func hasGet(schema *types.Schema) bool {
 	return contains(schema.CollectionMethods, http.MethodGet)
 }
@@ -52,6 +78,17 @@ func contains(list []string, needle string) bool {
 	return false
 }
 
+// enumConstName converts an enum option such as "read-write" or "ReadWriteOnce" into an identifier suffix.
+func enumConstName(option string) string {
+	parts := strings.FieldsFunc(option, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, part := range parts {
+		parts[i] = convert.Capitalize(part)
+	}
+	return strings.Join(parts, "")
+}
+
 func getCollectionOutput(output, codeName string) string {
 	if output == "collection" {
 		return codeName + "Collection"
@@ -0,0 +1,162 @@
+package generator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Widget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WidgetSpec   `json:"spec"`
+	Status WidgetStatus `json:"status"`
+}
+
+type WidgetSpec struct {
+	Size int64 `json:"size"`
+}
+
+type WidgetStatus struct {
+	Ready bool `json:"ready"`
+}
+
+type Gadget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Size int64 `json:"size"`
+}
+
+var version = types.APIVersion{Group: "test.cattle.io", Version: "v1", Path: "/v1"}
+
+func statusSchemas(statusSubresource bool) *types.Schemas {
+	schemas := types.NewSchemas()
+	for _, obj := range []interface{}{Widget{}, Gadget{}} {
+		schemas.MustImportAndCustomize(&version, obj, func(schema *types.Schema) {
+			schema.StatusSubresource = statusSubresource
+		})
+	}
+	return schemas
+}
+
+func TestStatusSubresourceSpec(t *testing.T) {
+	schemas := statusSchemas(true)
+
+	specType, err := statusSubresourceSpec(schemas.Schema(&version, "widget"), schemas, "v1.")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.WidgetSpec", specType)
+
+	_, err = statusSubresourceSpec(schemas.Schema(&version, "gadget"), schemas, "")
+	assert.Error(t, err)
+
+	schemas = statusSchemas(false)
+	specType, err = statusSubresourceSpec(schemas.Schema(&version, "widget"), schemas, "")
+	require.NoError(t, err)
+	assert.Empty(t, specType)
+}
+
+func TestGenerateControllerStatusSubresource(t *testing.T) {
+	tests := []struct {
+		name              string
+		statusSubresource bool
+		contains          []string
+		excludes          []string
+	}{
+		{
+			name:              "status subresource",
+			statusSubresource: true,
+			contains: []string{
+				"UpdateSpec(meta metav1.ObjectMeta, spec WidgetSpec) (*Widget, error)",
+				"UpdateStatus(*Widget) (*Widget, error)",
+			},
+			excludes: []string{"\tUpdate(*Widget) (*Widget, error)"},
+		},
+		{
+			name:     "no status subresource",
+			contains: []string{"\tUpdate(*Widget) (*Widget, error)"},
+			excludes: []string{"UpdateSpec", "UpdateStatus(*Widget) (*Widget, error)"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schemas := statusSchemas(test.statusSubresource)
+			dir := t.TempDir()
+			require.NoError(t, generateController(false, dir, schemas.Schema(&version, "widget"), schemas))
+
+			output, err := os.ReadFile(filepath.Join(dir, "zz_generated_widget_controller.go"))
+			require.NoError(t, err)
+			for _, s := range test.contains {
+				assert.Contains(t, string(output), s)
+			}
+			for _, s := range test.excludes {
+				assert.NotContains(t, string(output), s)
+			}
+		})
+	}
+
+	schemas := statusSchemas(true)
+	assert.Error(t, generateController(false, t.TempDir(), schemas.Schema(&version, "gadget"), schemas))
+}
+
+func TestGenerateControllerCachedExists(t *testing.T) {
+	schemas := statusSchemas(false)
+	dir := t.TempDir()
+	require.NoError(t, generateController(false, dir, schemas.Schema(&version, "widget"), schemas))
+
+	output, err := os.ReadFile(filepath.Join(dir, "zz_generated_widget_controller.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "s.client.controllerFactory.ForResourceKind(WidgetGroupVersionResource, WidgetGroupVersionKind.Kind, false).Informer()")
+	assert.Contains(t, string(output), "return s.objectClient.WithCache(informer)")
+	assert.Contains(t, string(output), "return s.cachedObjectClient().Exists(namespace, name)")
+	assert.Contains(t, string(output), "return s.cachedObjectClient().Count(selector)")
+}
+
+type Volume struct {
+	types.Resource
+	Mode   string `json:"mode" norman:"type=enum,options=read-write|ReadOnly|2x"`
+	Access string `json:"access" norman:"type=enum,options=a-b|a_b"`
+	Sync   string `json:"sync" norman:"type=enum,options=on|--"`
+}
+
+func TestGenerateTypeEnums(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	schemas := types.NewSchemas().MustImport(&version, Volume{})
+	schema := schemas.Schema(&version, "volume")
+	require.NotNil(t, schema)
+
+	// the directory is in the module for the generated code to import its dependencies, its name starts with an
+	// underscore for ./... to skip it
+	dir, err := os.MkdirTemp(".", "_enums")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = generateType(dir, schema, schemas)
+	assert.EqualError(t, err, `constant VolumeAccessAB of option "a_b" of field access of volume has the same name as the one of option "a-b" of field access`)
+
+	delete(schema.ResourceFields, "access")
+	err = generateType(dir, schema, schemas)
+	assert.EqualError(t, err, `option "--" of field sync of volume has no letters or digits to name its constant`)
+
+	delete(schema.ResourceFields, "sync")
+	require.NoError(t, generateType(dir, schema, schemas))
+	require.NoError(t, generateClient(dir, []*types.Schema{schema}))
+	content, err := os.ReadFile(filepath.Join(dir, "zz_generated_volume.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `VolumeModeReadWrite = "read-write"`)
+	assert.Contains(t, string(content), `VolumeMode2x = "2x"`)
+
+	output, err := exec.Command(goBin, "vet", "./"+dir).CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
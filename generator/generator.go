@@ -12,6 +12,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -32,8 +33,9 @@ var (
 )
 
 type fieldInfo struct {
-	Name string
-	Type string
+	Name    string
+	Type    string
+	Options []string
 }
 
 func getGoType(field types.Field, schema *types.Schema, schemas *types.Schemas) string {
@@ -73,6 +75,10 @@ func getTypeString(nullable bool, typeName string, pointer bool, schema *types.S
 		return "string"
 	case "date":
 		return "string"
+	case "duration":
+		return "string"
+	case "quantity":
+		return "string"
 	case "string":
 		return "string"
 	case "enum":
@@ -112,13 +118,47 @@ func getTypeMap(schema *types.Schema, schemas *types.Schemas) map[string]fieldIn
 			continue
 		}
 		result[field.CodeName] = fieldInfo{
-			Name: name,
-			Type: getGoType(field, schema, schemas),
+			Name:    name,
+			Type:    getGoType(field, schema, schemas),
+			Options: field.Options,
 		}
 	}
 	return result
 }
 
+// checkConstNames returns an error if the constants of the type and its enum options, as generated by the type
+// template, don't have distinct names, like the ones of the options "a-b" and "a_b".
+func checkConstNames(schema *types.Schema, structFields map[string]fieldInfo) error {
+	names := map[string]string{
+		schema.CodeName + "Type": "the type",
+	}
+	for key := range structFields {
+		names[schema.CodeName+"Field"+key] = "field " + key
+	}
+
+	keys := make([]string, 0, len(structFields))
+	for key := range structFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, option := range structFields[key].Options {
+			suffix := enumConstName(option)
+			if suffix == "" {
+				return fmt.Errorf("option %q of field %s of %s has no letters or digits to name its constant",
+					option, structFields[key].Name, schema.ID)
+			}
+			name := schema.CodeName + key + suffix
+			if other, ok := names[name]; ok {
+				return fmt.Errorf("constant %s of option %q of field %s of %s has the same name as the one of %s",
+					name, option, structFields[key].Name, schema.ID, other)
+			}
+			names[name] = fmt.Sprintf("option %q of field %s", option, structFields[key].Name)
+		}
+	}
+	return nil
+}
+
 func getResourceActions(schema *types.Schema, schemas *types.Schemas) map[string]types.Action {
 	result := map[string]types.Action{}
 	for name, action := range schema.ResourceActions {
@@ -152,6 +192,11 @@ func getCollectionActions(schema *types.Schema, schemas *types.Schemas) map[stri
 }
 
 func generateType(outputDir string, schema *types.Schema, schemas *types.Schemas) error {
+	structFields := getTypeMap(schema, schemas)
+	if err := checkConstNames(schema, structFields); err != nil {
+		return err
+	}
+
 	filePath := strings.ToLower("zz_generated_" + addUnderscore(schema.ID) + ".go")
 	output, err := os.Create(path.Join(outputDir, filePath))
 	if err != nil {
@@ -168,7 +213,7 @@ func generateType(outputDir string, schema *types.Schema, schemas *types.Schemas
 
 	return typeTemplate.Execute(output, map[string]interface{}{
 		"schema":            schema,
-		"structFields":      getTypeMap(schema, schemas),
+		"structFields":      structFields,
 		"resourceActions":   getResourceActions(schema, schemas),
 		"collectionActions": getCollectionActions(schema, schemas),
 	})
@@ -227,10 +272,16 @@ func generateController(external bool, outputDir string, schema *types.Schema, s
 		prefix = schema.Version.Version + "."
 	}
 
+	specType, err := statusSubresourceSpec(schema, schemas, prefix)
+	if err != nil {
+		return err
+	}
+
 	return typeTemplate.Execute(output, map[string]interface{}{
 		"schema":        schema,
 		"importPackage": importPackage,
 		"prefix":        prefix,
+		"specType":      specType,
 	})
 }
 
@@ -461,6 +512,14 @@ func Generate(schemas *types.Schemas, privateTypes map[string]bool, basePackage,
 		if err := generateClient(cattleDir, cattleClientTypes); err != nil {
 			return err
 		}
+		for _, schema := range controllers {
+			if privateTypes[schema.ID] || schema.GoType == nil {
+				continue
+			}
+			if err := generateConversion(cattleDir, schema, schemas, schema.GoType); err != nil {
+				return errors.Wrapf(err, "failed to generate conversions of %s", schema.ID)
+			}
+		}
 	}
 
 	if len(controllers) > 0 {
@@ -474,6 +533,9 @@ func Generate(schemas *types.Schemas, privateTypes map[string]bool, basePackage,
 		if err := generateFakes(k8sDir, controllers); err != nil {
 			return err
 		}
+		if err := generateRBAC(k8sDir, controllers); err != nil {
+			return err
+		}
 	}
 
 	if err := Gofmt(baseDir, filepath.Join(outputDir, k8sOutputPackage)); err != nil {
@@ -516,7 +578,7 @@ func prepareDirs(dirs ...string) error {
 
 func Gofmt(workDir, pkg string) error {
 	return filepath.Walk(filepath.Join(workDir, pkg), func(path string, info os.FileInfo, _ error) error {
-		if info.IsDir() {
+		if info.IsDir() || filepath.Ext(path) != ".go" {
 			return nil
 		}
 
@@ -23,6 +23,9 @@ type Client struct {
 }
 
 func NewForConfig(cfg rest.Config) (Interface, error) {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = objectclient.DefaultUserAgent()
+	}
 	scheme := runtime.NewScheme()
 	if err := {{.prefix}}AddToScheme(scheme); err != nil {
 		return nil, err
@@ -58,7 +61,7 @@ type {{.CodeNamePlural}}Getter interface {
 
 func (c *Client) {{.CodeNamePlural}}(namespace string) {{.CodeName}}Interface {
 	sharedClient := c.clientFactory.ForResourceKind({{.CodeName}}GroupVersionResource, {{.CodeName}}GroupVersionKind.Kind, {{ . | namespaced }})
-	objectClient := objectclient.NewObjectClient(namespace, sharedClient, &{{.CodeName}}Resource, {{.CodeName}}GroupVersionKind, {{.ID}}Factory{})
+	objectClient := objectclient.NewProtobufObjectClient(namespace, sharedClient, &{{.CodeName}}Resource, {{.CodeName}}GroupVersionKind, {{.ID}}Factory{})
 	return &{{.ID}}Client{
 		ns:           namespace,
 		client:       c,
@@ -15,6 +15,13 @@ type {{.schema.CodeName}}Lifecycle interface {
 	Updated(obj *{{.prefix}}{{.schema.CodeName}}) (runtime.Object, error)
 }
 
+// {{.schema.CodeName}}LifecycleVersion is implemented by {{.schema.CodeName}}Lifecycles whose semantics change between
+// releases, see lifecycle.ObjectLifecycleVersion.
+type {{.schema.CodeName}}LifecycleVersion interface {
+	Version() string
+	Migrate(oldVersion string, obj *{{.prefix}}{{.schema.CodeName}}) (runtime.Object, error)
+}
+
 type {{.schema.ID}}LifecycleAdapter struct {
 	lifecycle {{.schema.CodeName}}Lifecycle
 }
@@ -53,11 +60,34 @@ func (w *{{.schema.ID}}LifecycleAdapter) Updated(obj runtime.Object) (runtime.Ob
 	return o, err
 }
 
+type {{.schema.ID}}VersionedLifecycleAdapter struct {
+	*{{.schema.ID}}LifecycleAdapter
+	versioned {{.schema.CodeName}}LifecycleVersion
+}
+
+func (w *{{.schema.ID}}VersionedLifecycleAdapter) Version() string {
+	return w.versioned.Version()
+}
+
+func (w *{{.schema.ID}}VersionedLifecycleAdapter) Migrate(oldVersion string, obj runtime.Object) (runtime.Object, error) {
+	o, err := w.versioned.Migrate(oldVersion, obj.(*{{.prefix}}{{.schema.CodeName}}))
+	if o == nil {
+		return nil, err
+	}
+	return o, err
+}
+
 func New{{.schema.CodeName}}LifecycleAdapter(name string, clusterScoped bool, client {{.schema.CodeName}}Interface, l {{.schema.CodeName}}Lifecycle) {{.schema.CodeName}}HandlerFunc {
 	if clusterScoped {
 		resource.PutClusterScoped({{.schema.CodeName}}GroupVersionResource)
 	}
-	adapter := &{{.schema.ID}}LifecycleAdapter{lifecycle: l}
+	var adapter lifecycle.ObjectLifecycle = &{{.schema.ID}}LifecycleAdapter{lifecycle: l}
+	if versioned, ok := l.({{.schema.CodeName}}LifecycleVersion); ok {
+		adapter = &{{.schema.ID}}VersionedLifecycleAdapter{
+			{{.schema.ID}}LifecycleAdapter: adapter.(*{{.schema.ID}}LifecycleAdapter),
+			versioned: versioned,
+		}
+	}
 	syncFn := lifecycle.NewObjectLifecycleAdapter(name, clusterScoped, adapter, client.ObjectClient())
 	return func(key string, obj *{{.prefix}}{{.schema.CodeName}}) (runtime.Object, error) {
 		newObj, err := syncFn(key, obj)
@@ -0,0 +1,38 @@
+package generator
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/rancher/norman/pkg/rbac"
+	"github.com/rancher/norman/types"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// controllerVerbs are the verbs the generated controllers and clients use on their resources.
+var controllerVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}
+
+// generateRBAC writes the ClusterRole granting the generated controllers and clients of schemas their verbs, named
+// after the group, the rules recorded at runtime by the rbac package cover the other resources they touch.
+func generateRBAC(outputDir string, schemas []*types.Schema) error {
+	recorder := rbac.NewRecorder()
+	for _, s := range schemas {
+		gvr := schema.GroupVersionResource{
+			Group:    s.Version.Group,
+			Version:  s.Version.Version,
+			Resource: strings.ToLower(s.PluralName),
+		}
+		recorder.Record(gvr, "", controllerVerbs...)
+		if s.StatusSubresource {
+			gvr.Resource += "/status"
+			recorder.Record(gvr, "", "update")
+		}
+	}
+
+	data, err := recorder.YAML(schemas[0].Version.Group)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(outputDir, "zz_generated_rbac.yaml"), data, 0644)
+}
@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestGenerateRBAC(t *testing.T) {
+	schemas := statusSchemas(true)
+	dir := t.TempDir()
+	require.NoError(t, generateRBAC(dir, []*types.Schema{schemas.Schema(&version, "widget")}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "zz_generated_rbac.yaml"))
+	require.NoError(t, err)
+	role := &rbacv1.ClusterRole{}
+	require.NoError(t, yaml.Unmarshal(data, role))
+
+	assert.Equal(t, "test.cattle.io", role.Name)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{"test.cattle.io"}, Resources: []string{"widgets"}, Verbs: []string{"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch"}},
+		{APIGroups: []string{"test.cattle.io"}, Resources: []string{"widgets/status"}, Verbs: []string{"update"}},
+	}, role.Rules)
+}
@@ -24,6 +24,15 @@ const (
 	{{$.schema.CodeName}}Field{{$key}} = "{{$value.Name}}"
 {{- end}}
 )
+{{range $key, $value := .structFields}}
+{{- if $value.Options}}
+const (
+{{- range $option := $value.Options}}
+	{{$.schema.CodeName}}{{$key}}{{enumConstName $option}} = "{{$option}}"
+{{- end}}
+)
+{{end}}
+{{- end}}
 
 type {{.schema.CodeName}} struct {
 {{- if .schema | hasGet }}
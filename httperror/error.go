@@ -2,6 +2,7 @@ package httperror
 
 import (
 	"fmt"
+	"time"
 )
 
 var (
@@ -10,6 +11,7 @@ var (
 	NotFound         = ErrorCode{"NotFound", 404}
 	MethodNotAllowed = ErrorCode{"MethodNotAllow", 405}
 	Conflict         = ErrorCode{"Conflict", 409}
+	TooManyRequests  = ErrorCode{"TooManyRequests", 429}
 
 	InvalidDateFormat  = ErrorCode{"InvalidDateFormat", 422}
 	InvalidFormat      = ErrorCode{"InvalidFormat", 422}
@@ -29,9 +31,14 @@ var (
 	InvalidType        = ErrorCode{"InvalidType", 422}
 	ActionNotAvailable = ErrorCode{"ActionNotAvailable", 404}
 	InvalidState       = ErrorCode{"InvalidState", 422}
+	QuotaExceeded      = ErrorCode{"QuotaExceeded", 422}
+
+	InvalidFieldCombination = ErrorCode{"InvalidFieldCombination", 422}
 
 	ServerError        = ErrorCode{"ServerError", 500}
 	ClusterUnavailable = ErrorCode{"ClusterUnavailable", 503}
+	ReadOnly           = ErrorCode{"ReadOnly", 503}
+	Timeout            = ErrorCode{"Timeout", 504}
 )
 
 type ErrorCode struct {
@@ -44,10 +51,18 @@ func (e ErrorCode) String() string {
 }
 
 type APIError struct {
-	Code      ErrorCode
-	Message   string
-	Cause     error
-	FieldName string
+	Code       ErrorCode
+	Message    string
+	Cause      error
+	FieldName  string
+	FieldNames []string
+	// Current is the current state of the resource of a Conflict, for clients to merge their changes and retry
+	Current map[string]interface{}
+	// Retryable is true when the request can be sent again unchanged, after RetryAfter if it is set
+	Retryable  bool
+	RetryAfter time.Duration
+	// Fingerprint identifies the code path of a recovered panic, see Recovered
+	Fingerprint string
 }
 
 func NewAPIErrorLong(status int, code, message string) error {
@@ -72,6 +87,39 @@ func NewFieldAPIError(code ErrorCode, fieldName, message string) error {
 	}
 }
 
+// NewFieldsAPIError returns an error about the combination of values of several fields.
+func NewFieldsAPIError(code ErrorCode, fieldNames []string, message string) error {
+	err := &APIError{
+		Code:       code,
+		Message:    message,
+		FieldNames: fieldNames,
+	}
+	if len(fieldNames) > 0 {
+		err.FieldName = fieldNames[0]
+	}
+	return err
+}
+
+// NewConflictAPIError returns a Conflict error with the current state of the resource the client is out of date on.
+func NewConflictAPIError(message string, current map[string]interface{}) error {
+	return &APIError{
+		Code:    Conflict,
+		Message: message,
+		Current: current,
+	}
+}
+
+// NewRetryableAPIError returns an error for a request that can be sent again unchanged after retryAfter, 0 if
+// there is no advised delay.
+func NewRetryableAPIError(code ErrorCode, message string, retryAfter time.Duration) error {
+	return &APIError{
+		Code:       code,
+		Message:    message,
+		Retryable:  true,
+		RetryAfter: retryAfter,
+	}
+}
+
 // WrapFieldAPIError will cause the API framework to log the underlying err before returning the APIError as a response.
 // err WILL NOT be in the API response
 func WrapFieldAPIError(err error, code ErrorCode, fieldName, message string) error {
@@ -1,15 +1,64 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
 	"net/url"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/httperror/i18n"
 	"github.com/rancher/norman/types"
 	"github.com/sirupsen/logrus"
 )
 
+const ProblemContentType = "application/problem+json"
+
+var translator atomic.Pointer[i18n.Translator]
+
+// SetTranslator makes ErrorHandler and ProblemErrorHandler return the messages of errors in the language the
+// client asks for in the Accept-Language header, when t has a translation. nil turns translation off.
+func SetTranslator(t i18n.Translator) {
+	if t == nil {
+		translator.Store(nil)
+		return
+	}
+	translator.Store(&t)
+}
+
 func ErrorHandler(request *types.APIContext, err error) {
+	error := localize(request, toAPIError(request, err))
+	setRetryAfter(request, error)
+
+	data := toError(error)
+	if request.RequestID != "" {
+		data["requestId"] = request.RequestID
+	}
+	request.WriteResponse(error.Code.Status, data)
+}
+
+// ProblemErrorHandler writes errors as RFC 7807 problem details instead of norman error resources. Set it as
+// Server.Defaults.ErrorHandler before adding schemas to use it for every schema.
+func ProblemErrorHandler(request *types.APIContext, err error) {
+	error := localize(request, toAPIError(request, err))
+	setRetryAfter(request, error)
+
+	data := toProblem(error)
+	data["instance"] = request.Request.URL.Path
+	if request.RequestID != "" {
+		data["requestId"] = request.RequestID
+	}
+
+	request.Response.Header().Set("Content-Type", ProblemContentType)
+	request.Response.WriteHeader(error.Code.Status)
+	if err := json.NewEncoder(request.Response).Encode(data); err != nil {
+		logrus.WithField("requestId", request.RequestID).Errorf("Failed to write problem response: %v", err)
+	}
+}
+
+func toAPIError(request *types.APIContext, err error) *httperror.APIError {
 	error := &httperror.APIError{}
 	if errors.As(err, &error) {
 		if error.Cause != nil {
@@ -17,19 +66,56 @@ func ErrorHandler(request *types.APIContext, err error) {
 			if url == "" {
 				url = request.Request.URL.String()
 			}
-			logrus.Errorf("API error response %v for %v %v. Cause: %v", error.Code.Status, request.Request.Method,
-				url, error.Cause)
-		}
-	} else {
-		logrus.Errorf("Unknown error: %v", err)
-		error = &httperror.APIError{
-			Code:    httperror.ServerError,
-			Message: err.Error(),
+			logrus.WithField("requestId", request.RequestID).Errorf("API error response %v for %v %v. Cause: %v",
+				error.Code.Status, request.Request.Method, url, error.Cause)
 		}
+		return withRetry(request, error, err)
 	}
 
-	data := toError(error)
-	request.WriteResponse(error.Code.Status, data)
+	logrus.WithField("requestId", request.RequestID).Errorf("Unknown error: %v", err)
+	return withRetry(request, &httperror.APIError{
+		Code:    httperror.ServerError,
+		Message: err.Error(),
+	}, err)
+}
+
+// withRetry fills in the retryability of apiError from err, the error it was made from, and the request.
+func withRetry(request *types.APIContext, apiError *httperror.APIError, err error) *httperror.APIError {
+	retryable, retryAfter := httperror.RequestRetryInfo(request.Request, err)
+	if retryable == apiError.Retryable && retryAfter == apiError.RetryAfter {
+		return apiError
+	}
+	result := *apiError
+	result.Retryable = retryable
+	result.RetryAfter = retryAfter
+	return &result
+}
+
+func setRetryAfter(request *types.APIContext, apiError *httperror.APIError) {
+	if apiError.Retryable && apiError.RetryAfter > 0 {
+		request.Response.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(apiError)))
+	}
+}
+
+func retryAfterSeconds(apiError *httperror.APIError) int {
+	return int(math.Ceil(apiError.RetryAfter.Seconds()))
+}
+
+func localize(request *types.APIContext, apiError *httperror.APIError) *httperror.APIError {
+	t := translator.Load()
+	if t == nil {
+		return apiError
+	}
+
+	message, lang, ok := i18n.Localize(*t, request.Request.Header.Get("Accept-Language"), apiError)
+	if !ok {
+		return apiError
+	}
+
+	request.Response.Header().Set("Content-Language", lang.String())
+	localized := *apiError
+	localized.Message = message
+	return &localized
 }
 
 func toError(apiError *httperror.APIError) map[string]interface{} {
@@ -42,6 +128,53 @@ func toError(apiError *httperror.APIError) map[string]interface{} {
 	if apiError.FieldName != "" {
 		e["fieldName"] = apiError.FieldName
 	}
+	if len(apiError.FieldNames) > 0 {
+		e["fieldNames"] = apiError.FieldNames
+	}
+	if apiError.Current != nil {
+		e["current"] = apiError.Current
+	}
+	if apiError.Fingerprint != "" {
+		e["fingerprint"] = apiError.Fingerprint
+	}
+	if apiError.Retryable {
+		e["retryable"] = true
+		if apiError.RetryAfter > 0 {
+			e["retryAfter"] = retryAfterSeconds(apiError)
+		}
+	}
 
 	return e
 }
+
+func toProblem(apiError *httperror.APIError) map[string]interface{} {
+	p := map[string]interface{}{
+		"type":   "urn:norman:error:" + apiError.Code.Code,
+		"title":  apiError.Code.Code,
+		"status": apiError.Code.Status,
+		"code":   apiError.Code.Code,
+	}
+	if apiError.Message != "" {
+		p["detail"] = apiError.Message
+	}
+	if apiError.FieldName != "" {
+		p["fieldName"] = apiError.FieldName
+	}
+	if len(apiError.FieldNames) > 0 {
+		p["fieldNames"] = apiError.FieldNames
+	}
+	if apiError.Current != nil {
+		p["current"] = apiError.Current
+	}
+	if apiError.Fingerprint != "" {
+		p["fingerprint"] = apiError.Fingerprint
+	}
+	if apiError.Retryable {
+		p["retryable"] = true
+		if apiError.RetryAfter > 0 {
+			p["retryAfter"] = retryAfterSeconds(apiError)
+		}
+	}
+
+	return p
+}
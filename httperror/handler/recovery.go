@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+)
+
+// Recovery turns the panics of next into ServerError responses with the fingerprint of the panic and the request
+// ID, see httperror.Recovered. It is meant for handlers served next to the API server, which recovers its own
+// panics. http.ErrAbortHandler panics are passed on so they still abort the response.
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			requestID := req.Header.Get(types.RequestIDHeader)
+			data := toError(httperror.Recovered(recovered, requestID))
+			if requestID != "" {
+				data["requestId"] = requestID
+			}
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(rw).Encode(data)
+		}()
+		next.ServeHTTP(rw, req)
+	})
+}
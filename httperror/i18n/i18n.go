@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/rancher/norman/httperror"
+	"golang.org/x/text/language"
+)
+
+// Translator provides the messages of API errors in other languages.
+type Translator interface {
+	// Languages returns the supported languages, the first one is used when none of them is accepted.
+	Languages() []language.Tag
+	// Translate returns the message of apiError in lang, false if there is no translation.
+	Translate(lang language.Tag, apiError *httperror.APIError) (string, bool)
+}
+
+// Localize picks the language of the Accept-Language header acceptLanguage supported by translator, and returns
+// the message of apiError in it.
+func Localize(translator Translator, acceptLanguage string, apiError *httperror.APIError) (string, language.Tag, bool) {
+	languages := translator.Languages()
+	if len(languages) == 0 {
+		return "", language.Und, false
+	}
+
+	accepted, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		accepted = nil
+	}
+	_, index, _ := language.NewMatcher(languages).Match(accepted...)
+	lang := languages[index]
+
+	message, ok := translator.Translate(lang, apiError)
+	return message, lang, ok
+}
+
+// Catalog is a Translator with one message per language and error code. Messages may refer to the field of the
+// error with {fieldName} and to the original message with {message}.
+type Catalog struct {
+	lock      sync.RWMutex
+	languages []language.Tag
+	messages  map[language.Tag]map[string]string
+}
+
+// NewCatalog returns an empty catalog, errors are not translated when the client accepts none of its languages
+// and defaultLanguage is not in it.
+func NewCatalog(defaultLanguage language.Tag) *Catalog {
+	return &Catalog{
+		languages: []language.Tag{defaultLanguage},
+		messages:  map[language.Tag]map[string]string{},
+	}
+}
+
+// Add sets the message of the errors with code in lang.
+func (c *Catalog) Add(lang language.Tag, code, message string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	messages, ok := c.messages[lang]
+	if !ok {
+		messages = map[string]string{}
+		c.messages[lang] = messages
+		if lang != c.languages[0] {
+			c.languages = append(c.languages, lang)
+		}
+	}
+	messages[code] = message
+}
+
+func (c *Catalog) Languages() []language.Tag {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]language.Tag(nil), c.languages...)
+}
+
+func (c *Catalog) Translate(lang language.Tag, apiError *httperror.APIError) (string, bool) {
+	c.lock.RLock()
+	message, ok := c.messages[lang][apiError.Code.Code]
+	c.lock.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	return strings.NewReplacer(
+		"{fieldName}", apiError.FieldName,
+		"{message}", apiError.Message,
+	).Replace(message), true
+}
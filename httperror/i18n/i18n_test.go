@@ -0,0 +1,36 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestLocalize(t *testing.T) {
+	catalog := NewCatalog(language.English)
+	catalog.Add(language.German, httperror.MissingRequired.Code, "{fieldName} ist erforderlich")
+	catalog.Add(language.French, httperror.MissingRequired.Code, "{fieldName} est obligatoire")
+
+	apiError := &httperror.APIError{
+		Code:      httperror.MissingRequired,
+		Message:   "name is required",
+		FieldName: "name",
+	}
+
+	message, lang, ok := Localize(catalog, "fr-CH, fr;q=0.9, en;q=0.8", apiError)
+	assert.True(t, ok)
+	assert.Equal(t, "name est obligatoire", message)
+	assert.Equal(t, language.French, lang)
+
+	message, _, ok = Localize(catalog, "de", apiError)
+	assert.True(t, ok)
+	assert.Equal(t, "name ist erforderlich", message)
+
+	_, _, ok = Localize(catalog, "ja", apiError)
+	assert.False(t, ok)
+
+	_, _, ok = Localize(catalog, "de", &httperror.APIError{Code: httperror.NotFound})
+	assert.False(t, ok)
+}
@@ -0,0 +1,92 @@
+package httperror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultPanicLogInterval = 10 * time.Minute
+
+var (
+	panicLogInterval atomic.Int64
+	panicLogged      sync.Map
+)
+
+func init() {
+	panicLogInterval.Store(int64(DefaultPanicLogInterval))
+}
+
+// SetPanicLogInterval sets how often the stack of the panics with the same fingerprint is logged, the other
+// occurrences only log their fingerprint and request ID.
+func SetPanicLogInterval(interval time.Duration) {
+	panicLogInterval.Store(int64(interval))
+}
+
+// PanicError is the cause of the ServerError returned for a recovered panic.
+type PanicError struct {
+	Value       interface{}
+	Fingerprint string
+	Stack       []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic %s: %v", p.Fingerprint, p.Value)
+}
+
+// Recovered returns the ServerError of a panic recovered while serving the request requestID, it must be called
+// by the deferred function calling recover. The fingerprint of the error identifies where the panic happened, so
+// that the responses of the panics of a bug can be matched with its logs and metric.
+func Recovered(value interface{}, requestID string) *APIError {
+	fingerprint := panicFingerprint(value)
+	stack := debug.Stack()
+	metrics.IncAPIPanic(fingerprint)
+
+	log := logrus.WithFields(logrus.Fields{
+		"requestId":   requestID,
+		"fingerprint": fingerprint,
+	})
+	now := time.Now()
+	last, loaded := panicLogged.LoadOrStore(fingerprint, now)
+	if !loaded || now.Sub(last.(time.Time)) >= time.Duration(panicLogInterval.Load()) {
+		panicLogged.Store(fingerprint, now)
+		log.Errorf("Panic serving api request: %v\n%s", value, stack)
+	} else {
+		log.Errorf("Panic serving api request: %v", value)
+	}
+
+	return &APIError{
+		Code:        ServerError,
+		Message:     "internal error " + fingerprint,
+		Cause:       &PanicError{Value: value, Fingerprint: fingerprint, Stack: stack},
+		Fingerprint: fingerprint,
+	}
+}
+
+// panicFingerprint hashes the type of the panic value and the functions and lines of the stack of the panic,
+// which are the same for every panic of a bug unlike the goroutine IDs and arguments in the stack trace.
+func panicFingerprint(value interface{}) string {
+	pcs := make([]uintptr, 32)
+	// skip runtime.Callers, panicFingerprint and Recovered
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	digest := sha256.New()
+	fmt.Fprintf(digest, "%T\n", value)
+	for {
+		frame, more := frames.Next()
+		digest.Write([]byte(frame.Function + ":" + strconv.Itoa(frame.Line) + "\n"))
+		if !more {
+			break
+		}
+	}
+	return hex.EncodeToString(digest.Sum(nil))[:12]
+}
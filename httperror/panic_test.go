@@ -0,0 +1,38 @@
+package httperror
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recoverFrom(f func()) (err *APIError) {
+	defer func() {
+		err = Recovered(recover(), "request")
+	}()
+	f()
+	return nil
+}
+
+func TestRecovered(t *testing.T) {
+	var errs []*APIError
+	for i := 0; i < 2; i++ {
+		errs = append(errs, recoverFrom(func() {
+			panic("first")
+		}))
+	}
+	other := recoverFrom(func() {
+		var m map[string]int
+		m["x"] = 1
+	})
+
+	assert.Equal(t, ServerError, errs[0].Code)
+	assert.Len(t, errs[0].Fingerprint, 12)
+	assert.Equal(t, errs[0].Fingerprint, errs[1].Fingerprint)
+	assert.NotEqual(t, errs[0].Fingerprint, other.Fingerprint)
+	assert.Contains(t, errs[0].Message, errs[0].Fingerprint)
+
+	cause := errs[0].Cause.(*PanicError)
+	assert.Equal(t, "first", cause.Value)
+	assert.NotEmpty(t, cause.Stack)
+}
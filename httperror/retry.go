@@ -0,0 +1,98 @@
+package httperror
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IdempotencyKeyHeader is the header of requests that servers apply once however many times they are sent.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RequestRetryInfo is RetryInfo for req, which is not retryable after a timeout, as it may have been applied, unless
+// it is idempotent: a GET, HEAD, OPTIONS, PUT or DELETE, or any request with an idempotency key.
+func RequestRetryInfo(req *http.Request, err error) (bool, time.Duration) {
+	retryable, retryAfter := RetryInfo(err)
+	if retryable && timedOut(err) && !idempotent(req) {
+		return false, 0
+	}
+	return retryable, retryAfter
+}
+
+// RetryInfo returns whether the request that failed with err can be sent again unchanged, and the delay advised
+// before doing so, see RequestRetryInfo for requests that may not be idempotent. APIErrors that are not marked
+// Retryable are retryable if their cause is, or if their status is 429, 503 or 504, except in read-only mode.
+func RetryInfo(err error) (bool, time.Duration) {
+	if err == nil {
+		return false, 0
+	}
+
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		if apiError.Retryable {
+			return true, apiError.RetryAfter
+		}
+		if retryable, retryAfter := RetryInfo(apiError.Cause); retryable {
+			return true, retryAfter
+		}
+		return apiError.Code != ReadOnly && retryableStatus(apiError.Code.Status), 0
+	}
+
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+		return true, time.Duration(seconds) * time.Second
+	}
+	if apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) {
+		return true, 0
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true, 0
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true, 0
+	}
+	return false, 0
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case 429, 503, 504:
+		return true
+	}
+	return false
+}
+
+// timedOut returns whether err is a timeout, after which the request may have been applied all the same.
+func timedOut(err error) bool {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		if apiError.Code.Status == http.StatusGatewayTimeout || apiError.Code.Code == string(metav1.StatusReasonTimeout) ||
+			apiError.Code.Code == string(metav1.StatusReasonServerTimeout) {
+			return true
+		}
+		return apiError.Cause != nil && timedOut(apiError.Cause)
+	}
+
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netError net.Error
+	return errors.As(err, &netError) && netError.Timeout()
+}
+
+func idempotent(req *http.Request) bool {
+	if req == nil || req.Header.Get(IdempotencyKeyHeader) != "" {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
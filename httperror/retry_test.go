@@ -0,0 +1,69 @@
+package httperror
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRetryInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		retryable  bool
+		retryAfter time.Duration
+	}{
+		{"marked", NewRetryableAPIError(Conflict, "busy", 3*time.Second), true, 3 * time.Second},
+		{"status", NewAPIError(ClusterUnavailable, "down"), true, 0},
+		{"read only", NewAPIError(ReadOnly, "maintenance"), false, 0},
+		{"not retryable", NewAPIError(InvalidFormat, "bad"), false, 0},
+		{"cause", WrapAPIError(apierrors.NewTooManyRequests("slow down", 5), ServerError, "failed"), true, 5 * time.Second},
+		{"cause not retryable", WrapAPIError(fmt.Errorf("failed"), ServerError, "failed"), false, 0},
+		{"kubernetes", apierrors.NewServerTimeout(schema.GroupResource{Resource: "pods"}, "list", 2), true, 2 * time.Second},
+		{"kubernetes not retryable", apierrors.NewBadRequest("bad"), false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			retryable, retryAfter := RetryInfo(test.err)
+			assert.Equal(t, test.retryable, retryable)
+			assert.Equal(t, test.retryAfter, retryAfter)
+		})
+	}
+}
+
+func TestRequestRetryInfo(t *testing.T) {
+	post := httptest.NewRequest(http.MethodPost, "/v3/pods", nil)
+	keyed := httptest.NewRequest(http.MethodPost, "/v3/pods", nil)
+	keyed.Header.Set(IdempotencyKeyHeader, "k1")
+	get := httptest.NewRequest(http.MethodGet, "/v3/pods", nil)
+
+	tests := []struct {
+		name      string
+		req       *http.Request
+		err       error
+		retryable bool
+	}{
+		{"post timeout", post, NewAPIError(Timeout, "slow"), false},
+		{"post deadline", post, WrapAPIError(context.DeadlineExceeded, ServerError, "slow"), false},
+		{"post unavailable", post, NewAPIError(ClusterUnavailable, "down"), true},
+		{"post too many requests", post, apierrors.NewTooManyRequests("slow down", 1), true},
+		{"keyed post timeout", keyed, NewAPIError(Timeout, "slow"), true},
+		{"get timeout", get, NewAPIError(Timeout, "slow"), true},
+		{"get server timeout", get, apierrors.NewServerTimeout(schema.GroupResource{Resource: "pods"}, "list", 0), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			retryable, _ := RequestRetryInfo(test.req, test.err)
+			assert.Equal(t, test.retryable, retryable)
+		})
+	}
+}
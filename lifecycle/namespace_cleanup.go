@@ -0,0 +1,153 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rancher/norman/controller"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultCleanupWait is how long NamespaceCleanup waits for the objects of a kind to be gone before it moves on to
+// the next kind.
+const DefaultCleanupWait = 30 * time.Second
+
+type cleanupKind struct {
+	name       string
+	controller controller.GenericController
+}
+
+// NamespaceCleanup enqueues the objects of a namespace being deleted to their controllers, one kind at a time in
+// the order the kinds were added, so that their Finalize runs without waiting for the other keys of the
+// controllers and namespace deletion is not held up by their finalizers. The objects are handled ahead of the
+// queued keys by the controllers supporting it, like the ones of NewGenericController. The cleanup of a namespace
+// is retried while objects of some kind were not finalized in time.
+type NamespaceCleanup struct {
+	wait  time.Duration
+	poll  time.Duration
+	lock  sync.Mutex
+	kinds []cleanupKind
+	// running holds the namespaces being cleaned up
+	running map[string]bool
+}
+
+// NewNamespaceCleanup returns a NamespaceCleanup waiting up to wait for the objects of each kind to be deleted,
+// DefaultCleanupWait if wait is not positive.
+func NewNamespaceCleanup(wait time.Duration) *NamespaceCleanup {
+	if wait <= 0 {
+		wait = DefaultCleanupWait
+	}
+	return &NamespaceCleanup{
+		wait:    wait,
+		poll:    time.Second,
+		running: map[string]bool{},
+	}
+}
+
+// Add cleans up the objects of controller, named name in logs, after the kinds added before.
+func (n *NamespaceCleanup) Add(name string, controller controller.GenericController) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.kinds = append(n.kinds, cleanupKind{
+		name:       name,
+		controller: controller,
+	})
+}
+
+// Register starts cleaning up namespaces once namespaces, the controller of namespaces, sees their deletion.
+func (n *NamespaceCleanup) Register(ctx context.Context, namespaces controller.GenericController) {
+	namespaces.AddHandler(ctx, "namespace-cleanup", func(key string, obj interface{}) (interface{}, error) {
+		if obj == nil {
+			return nil, nil
+		}
+		metadata, err := meta.Accessor(obj)
+		if err != nil || metadata.GetDeletionTimestamp() == nil {
+			return obj, nil
+		}
+
+		name := metadata.GetName()
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		if !n.running[name] {
+			n.running[name] = true
+			kinds := append([]cleanupKind(nil), n.kinds...)
+			go func() {
+				finalized := n.cleanup(ctx, name, kinds)
+				n.lock.Lock()
+				delete(n.running, name)
+				n.lock.Unlock()
+				if !finalized && ctx.Err() == nil {
+					namespaces.EnqueueAfter("", name, n.wait)
+				}
+			}()
+		}
+		return obj, nil
+	})
+}
+
+// cleanup returns whether the objects of all kinds were finalized.
+func (n *NamespaceCleanup) cleanup(ctx context.Context, namespace string, kinds []cleanupKind) bool {
+	finalized := true
+	for _, kind := range kinds {
+		indexer := kind.controller.Informer().GetIndexer()
+		remaining := objectsIn(indexer, namespace)
+		if len(remaining) == 0 {
+			continue
+		}
+
+		logrus.Debugf("cleaning up %d %s in deleted namespace %s", len(remaining), kind.name, namespace)
+		prioritized, ok := kind.controller.(interface{ EnqueuePriority(namespace, name string) })
+		for _, name := range remaining {
+			if ok {
+				prioritized.EnqueuePriority(namespace, name)
+			} else {
+				kind.controller.Enqueue(namespace, name)
+			}
+		}
+
+		deadline := time.NewTimer(n.wait)
+		ticker := time.NewTicker(n.poll)
+		for len(remaining) > 0 {
+			select {
+			case <-ctx.Done():
+				deadline.Stop()
+				ticker.Stop()
+				return false
+			case <-deadline.C:
+				logrus.Infof("%d %s in deleted namespace %s were not finalized after %v, moving on", len(remaining),
+					kind.name, namespace, n.wait)
+				remaining = nil
+				finalized = false
+			case <-ticker.C:
+				remaining = objectsIn(indexer, namespace)
+			}
+		}
+		deadline.Stop()
+		ticker.Stop()
+	}
+	return finalized
+}
+
+// objectsIn returns the names of the objects of indexer in namespace.
+func objectsIn(indexer cache.Indexer, namespace string) []string {
+	objs, err := indexer.ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		objs = nil
+		for _, obj := range indexer.List() {
+			if metadata, err := meta.Accessor(obj); err == nil && metadata.GetNamespace() == namespace {
+				objs = append(objs, obj)
+			}
+		}
+	}
+
+	var names []string
+	for _, obj := range objs {
+		if metadata, err := meta.Accessor(obj); err == nil {
+			names = append(names, metadata.GetName())
+		}
+	}
+	return names
+}
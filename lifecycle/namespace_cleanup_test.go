@@ -0,0 +1,138 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/controller"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeCleanupController struct {
+	controller.GenericController
+	informer cache.SharedIndexInformer
+	lock     *sync.Mutex
+	enqueued *[]string
+	finalize bool
+}
+
+func (f *fakeCleanupController) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+func (f *fakeCleanupController) Enqueue(namespace, name string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	*f.enqueued = append(*f.enqueued, namespace+"/"+name)
+	if f.finalize {
+		_ = f.informer.GetIndexer().Delete(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}})
+	}
+}
+
+func newFakeCleanupController(lock *sync.Mutex, enqueued *[]string, finalize bool, names ...string) *fakeCleanupController {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &corev1.ConfigMap{}, 0,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, name := range names {
+		_ = informer.GetIndexer().Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}})
+	}
+	_ = informer.GetIndexer().Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "x"}})
+	return &fakeCleanupController{
+		informer: informer,
+		lock:     lock,
+		enqueued: enqueued,
+		finalize: finalize,
+	}
+}
+
+func TestNamespaceCleanup(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		enqueued []string
+	)
+	cleanup := NewNamespaceCleanup(50 * time.Millisecond)
+	cleanup.poll = time.Millisecond
+	cleanup.Add("stuck", newFakeCleanupController(&lock, &enqueued, false, "a"))
+	cleanup.Add("first", newFakeCleanupController(&lock, &enqueued, true, "b", "c"))
+	cleanup.Add("second", newFakeCleanupController(&lock, &enqueued, true, "d"))
+
+	start := time.Now()
+	cleanup.cleanup(context.Background(), "ns", cleanup.kinds)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "waits for the stuck kind")
+	assert.ElementsMatch(t, []string{"ns/a", "ns/b", "ns/c", "ns/d"}, enqueued)
+	assert.Equal(t, "ns/a", enqueued[0])
+	assert.Equal(t, "ns/d", enqueued[3])
+}
+
+type priorityCleanupController struct {
+	*fakeCleanupController
+}
+
+func (p *priorityCleanupController) EnqueuePriority(namespace, name string) {
+	p.fakeCleanupController.Enqueue(namespace, "priority-"+name)
+}
+
+type fakeNamespaceController struct {
+	controller.GenericController
+	handler  controller.HandlerFunc
+	requeued chan string
+}
+
+func (f *fakeNamespaceController) AddHandler(ctx context.Context, name string, handler controller.HandlerFunc) {
+	f.handler = handler
+}
+
+func (f *fakeNamespaceController) EnqueueAfter(namespace, name string, after time.Duration) {
+	f.requeued <- name
+}
+
+func TestNamespaceCleanupRegister(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		enqueued []string
+	)
+	cleanup := NewNamespaceCleanup(20 * time.Millisecond)
+	cleanup.poll = time.Millisecond
+	cleanup.Add("stuck", &priorityCleanupController{newFakeCleanupController(&lock, &enqueued, false, "a")})
+
+	namespaces := &fakeNamespaceController{requeued: make(chan string, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cleanup.Register(ctx, namespaces)
+
+	now := metav1.Now()
+	terminating := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", DeletionTimestamp: &now}}
+	_, err := namespaces.handler("ns", &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}})
+	assert.NoError(t, err)
+	_, err = namespaces.handler("ns", terminating)
+	assert.NoError(t, err)
+	_, err = namespaces.handler("ns", terminating)
+	assert.NoError(t, err)
+
+	select {
+	case name := <-namespaces.requeued:
+		assert.Equal(t, "ns", name, "retried while objects are not finalized")
+	case <-time.After(5 * time.Second):
+		t.Fatal("namespace was not requeued")
+	}
+	lock.Lock()
+	assert.Equal(t, []string{"ns/priority-a"}, enqueued, "cleaned up once while running")
+	lock.Unlock()
+
+	// the retry cleans up again
+	assert.Eventually(t, func() bool {
+		cleanup.lock.Lock()
+		defer cleanup.lock.Unlock()
+		return !cleanup.running["ns"]
+	}, 5*time.Second, time.Millisecond)
+	_, err = namespaces.handler("ns", terminating)
+	assert.NoError(t, err)
+	<-namespaces.requeued
+	lock.Lock()
+	assert.Equal(t, []string{"ns/priority-a", "ns/priority-a"}, enqueued)
+	lock.Unlock()
+}
@@ -3,16 +3,22 @@ package lifecycle
 import (
 	"fmt"
 	"reflect"
+	"strings"
+	"sync/atomic"
 
 	"github.com/rancher/norman/objectclient"
 	"github.com/rancher/norman/types/slice"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 var (
 	created            = "lifecycle.cattle.io/create"
+	versioned          = "lifecycle.cattle.io/version"
 	finalizerKey       = "controller.cattle.io/"
 	ScopedFinalizerKey = "clusterscoped.controller.cattle.io/"
 )
@@ -28,19 +34,54 @@ type ObjectLifecycleCondition interface {
 	HasFinalize() bool
 }
 
+// ObjectLifecycleVersion is implemented by lifecycles whose semantics change between releases. Version is recorded
+// in a version annotation of the objects next to the create annotation, and Migrate is called once on the objects
+// recorded with another version, "" for the objects created before the lifecycle was versioned.
+type ObjectLifecycleVersion interface {
+	Version() string
+	Migrate(oldVersion string, obj runtime.Object) (runtime.Object, error)
+}
+
+// ObjectUpdater persists the changes a lifecycle makes to objects. It is implemented by *objectclient.ObjectClient.
+type ObjectUpdater interface {
+	GetNamespaced(namespace, name string, opts metav1.GetOptions) (runtime.Object, error)
+	Update(name string, o runtime.Object) (runtime.Object, error)
+}
+
+var _ ObjectUpdater = (*objectclient.ObjectClient)(nil)
+
+var namespaceTerminating atomic.Pointer[func(namespace string) bool]
+
+// SkipTerminatingNamespaces makes lifecycles of objects in namespaces for which terminating returns true only run
+// Finalize, as updates made by Create and Updated would be rejected anyway. terminating is normally backed by a
+// namespace cache, nil turns the check off.
+func SkipTerminatingNamespaces(terminating func(namespace string) bool) {
+	if terminating == nil {
+		namespaceTerminating.Store(nil)
+		return
+	}
+	namespaceTerminating.Store(&terminating)
+}
+
 type objectLifecycleAdapter struct {
 	name          string
 	clusterScoped bool
 	lifecycle     ObjectLifecycle
-	objectClient  *objectclient.ObjectClient
+	objectClient  ObjectUpdater
 }
 
 func NewObjectLifecycleAdapter(name string, clusterScoped bool, lifecycle ObjectLifecycle, objectClient *objectclient.ObjectClient) func(key string, obj interface{}) (interface{}, error) {
+	return NewObjectLifecycleAdapterForUpdater(name, clusterScoped, lifecycle, objectClient)
+}
+
+// NewObjectLifecycleAdapterForUpdater is NewObjectLifecycleAdapter for callers that persist objects with
+// something other than an ObjectClient.
+func NewObjectLifecycleAdapterForUpdater(name string, clusterScoped bool, lifecycle ObjectLifecycle, updater ObjectUpdater) func(key string, obj interface{}) (interface{}, error) {
 	o := objectLifecycleAdapter{
 		name:          name,
 		clusterScoped: clusterScoped,
 		lifecycle:     lifecycle,
-		objectClient:  objectClient,
+		objectClient:  updater,
 	}
 	return o.sync
 }
@@ -61,18 +102,32 @@ func (o *objectLifecycleAdapter) sync(key string, in interface{}) (interface{},
 		obj = newObj
 	}
 
+	if inTerminatingNamespace(obj) || o.restricted(obj) {
+		return nil, nil
+	}
+
 	if newObj, cont, err := o.create(obj); err != nil || !cont {
 		return nil, err
 	} else if newObj != nil {
 		obj = newObj
 	}
 
+	if newObj, err := o.migrate(obj); err != nil {
+		return nil, err
+	} else if newObj != nil {
+		obj = newObj
+	}
+
 	return o.record(obj, o.lifecycle.Updated)
 }
 
 func (o *objectLifecycleAdapter) update(name string, orig, obj runtime.Object) (runtime.Object, error) {
 	if obj != nil && orig != nil && !reflect.DeepEqual(orig, obj) {
 		newObj, err := o.objectClient.Update(name, obj)
+		if apierrors.IsConflict(err) && logrus.IsLevelEnabled(logrus.DebugLevel) {
+			fields, _ := objectclient.ConflictFields(obj, orig)
+			logrus.Debugf("lifecycle %s changed %s of stale %s: %v", o.name, strings.Join(fields, ", "), name, err)
+		}
 		if newObj != nil {
 			return newObj, err
 		}
@@ -112,6 +167,35 @@ func (o *objectLifecycleAdapter) finalize(obj runtime.Object) (runtime.Object, b
 	return obj, false, err
 }
 
+func inTerminatingNamespace(obj runtime.Object) bool {
+	terminating := namespaceTerminating.Load()
+	if terminating == nil {
+		return false
+	}
+	metadata, err := meta.Accessor(obj)
+	if err != nil || metadata.GetNamespace() == "" {
+		return false
+	}
+	return (*terminating)(metadata.GetNamespace())
+}
+
+// restricted returns whether obj is restricted by objectclient.RestrictTargets, for updaters restricting targets
+// like the ObjectClients returned by WithRestrictedTargets.
+func (o *objectLifecycleAdapter) restricted(obj runtime.Object) bool {
+	kinded, ok := o.objectClient.(interface {
+		GroupVersionKind() schema.GroupVersionKind
+		RestrictsTargets() bool
+	})
+	if !ok || !kinded.RestrictsTargets() {
+		return false
+	}
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	return objectclient.IsRestrictedTarget(kinded.GroupVersionKind(), metadata.GetNamespace())
+}
+
 func maybeDeepCopy(old, newObj runtime.Object) runtime.Object {
 	if old == newObj {
 		return old.DeepCopyObject()
@@ -153,6 +237,10 @@ func (o *objectLifecycleAdapter) createKey() string {
 	return created + "." + o.name
 }
 
+func (o *objectLifecycleAdapter) versionKey() string {
+	return versioned + "." + o.name
+}
+
 func (o *objectLifecycleAdapter) constructFinalizerKey() string {
 	if o.clusterScoped {
 		return ScopedFinalizerKey + o.name
@@ -205,36 +293,77 @@ func (o *objectLifecycleAdapter) create(obj runtime.Object) (runtime.Object, boo
 		return nil, true, nil
 	}
 
+	// The finalizer, the changes of the handler and the initialized annotation are written in a single update.
+	origObj := obj
+	obj = obj.DeepCopyObject()
 	if o.hasFinalize() {
-		obj, err = o.addFinalizer(obj)
-		if err != nil {
-			return obj, false, err
+		if err := o.addFinalizer(obj); err != nil {
+			return origObj, false, err
 		}
 	}
 
 	if !o.hasCreate() {
+		obj, err = o.update(metadata.GetName(), origObj, obj)
 		return obj, true, err
 	}
 
-	obj, err = o.record(obj, o.lifecycle.Create)
+	newObj, err := checkNil(obj, o.lifecycle.Create)
+	if newObj == nil {
+		newObj = obj
+	}
 	if err != nil {
-		return obj, false, err
+		newObj, _ = o.update(metadata.GetName(), origObj, newObj)
+		return newObj, false, err
 	}
 
-	obj, err = o.setInitialized(obj)
-	return obj, false, err
+	if err := o.setInitialized(newObj); err != nil {
+		return newObj, false, err
+	}
+	newObj, err = o.update(metadata.GetName(), origObj, newObj)
+	return newObj, false, err
 }
 
 func (o *objectLifecycleAdapter) isInitialized(metadata metav1.Object) bool {
-	initialized := o.createKey()
-	return metadata.GetAnnotations()[initialized] == "true"
+	return metadata.GetAnnotations()[o.createKey()] == "true"
 }
 
-func (o *objectLifecycleAdapter) setInitialized(obj runtime.Object) (runtime.Object, error) {
+// migrate calls Migrate of versioned lifecycles on the objects recorded with another version, and records the
+// current version. The object isn't updated if Migrate fails, so that it is retried.
+func (o *objectLifecycleAdapter) migrate(obj runtime.Object) (runtime.Object, error) {
+	lifecycle, ok := o.lifecycle.(ObjectLifecycleVersion)
+	if !ok {
+		return nil, nil
+	}
 	metadata, err := meta.Accessor(obj)
 	if err != nil {
 		return nil, err
 	}
+	oldVersion := metadata.GetAnnotations()[o.versionKey()]
+	if oldVersion == lifecycle.Version() {
+		return nil, nil
+	}
+
+	origObj := obj
+	newObj, err := checkNil(obj.DeepCopyObject(), func(obj runtime.Object) (runtime.Object, error) {
+		return lifecycle.Migrate(oldVersion, obj)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if newObj == nil {
+		newObj = origObj.DeepCopyObject()
+	}
+	if err := o.setInitialized(newObj); err != nil {
+		return nil, err
+	}
+	return o.update(metadata.GetName(), origObj, newObj)
+}
+
+func (o *objectLifecycleAdapter) setInitialized(obj runtime.Object) error {
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
 
 	initialized := o.createKey()
 
@@ -242,26 +371,22 @@ func (o *objectLifecycleAdapter) setInitialized(obj runtime.Object) (runtime.Obj
 		metadata.SetAnnotations(map[string]string{})
 	}
 	metadata.GetAnnotations()[initialized] = "true"
-
-	return o.objectClient.Update(metadata.GetName(), obj)
+	if lifecycle, ok := o.lifecycle.(ObjectLifecycleVersion); ok {
+		metadata.GetAnnotations()[o.versionKey()] = lifecycle.Version()
+	}
+	return nil
 }
 
-func (o *objectLifecycleAdapter) addFinalizer(obj runtime.Object) (runtime.Object, error) {
+func (o *objectLifecycleAdapter) addFinalizer(obj runtime.Object) error {
 	metadata, err := meta.Accessor(obj)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if slice.ContainsString(metadata.GetFinalizers(), o.constructFinalizerKey()) {
-		return obj, nil
-	}
-
-	obj = obj.DeepCopyObject()
-	metadata, err = meta.Accessor(obj)
-	if err != nil {
-		return nil, err
+		return nil
 	}
 
 	metadata.SetFinalizers(append(metadata.GetFinalizers(), o.constructFinalizerKey()))
-	return o.objectClient.Update(metadata.GetName(), obj)
+	return nil
 }
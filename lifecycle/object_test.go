@@ -0,0 +1,186 @@
+package lifecycle
+
+import (
+	"testing"
+
+	"github.com/rancher/norman/objectclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeUpdater struct {
+	updates []runtime.Object
+}
+
+func (f *fakeUpdater) GetNamespaced(namespace, name string, opts metav1.GetOptions) (runtime.Object, error) {
+	return f.updates[len(f.updates)-1], nil
+}
+
+func (f *fakeUpdater) Update(name string, o runtime.Object) (runtime.Object, error) {
+	f.updates = append(f.updates, o.DeepCopyObject())
+	return o, nil
+}
+
+type testLifecycle struct{}
+
+func (testLifecycle) Create(obj runtime.Object) (runtime.Object, error) {
+	obj.(*corev1.ConfigMap).Data = map[string]string{"created": "true"}
+	return obj, nil
+}
+
+func (testLifecycle) Finalize(obj runtime.Object) (runtime.Object, error) {
+	return obj, nil
+}
+
+func (testLifecycle) Updated(obj runtime.Object) (runtime.Object, error) {
+	return obj, nil
+}
+
+func TestCreateUpdatesOnce(t *testing.T) {
+	updater := &fakeUpdater{}
+	sync := NewObjectLifecycleAdapterForUpdater("test", false, testLifecycle{}, updater)
+
+	orig := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"}}
+	_, err := sync("default/cm", orig)
+	require.NoError(t, err)
+
+	require.Len(t, updater.updates, 1)
+	updated := updater.updates[0].(*corev1.ConfigMap)
+	assert.Equal(t, []string{"controller.cattle.io/test"}, updated.Finalizers)
+	assert.Equal(t, "true", updated.Annotations["lifecycle.cattle.io/create.test"])
+	assert.Equal(t, "true", updated.Data["created"])
+	assert.Empty(t, orig.Finalizers)
+
+	// objects that already have the finalizer are initialized with a single update as well
+	updater.updates = nil
+	_, err = sync("default/cm", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:       "cm",
+		Namespace:  "default",
+		Finalizers: []string{"controller.cattle.io/test"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, updater.updates, 1)
+	assert.Equal(t, "true", updater.updates[0].(*corev1.ConfigMap).Annotations["lifecycle.cattle.io/create.test"])
+}
+
+func TestSkipTerminatingNamespaces(t *testing.T) {
+	SkipTerminatingNamespaces(func(namespace string) bool {
+		return namespace == "terminating"
+	})
+	defer SkipTerminatingNamespaces(nil)
+
+	updater := &fakeUpdater{}
+	sync := NewObjectLifecycleAdapterForUpdater("test", false, testLifecycle{}, updater)
+
+	_, err := sync("terminating/cm", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "terminating"}})
+	require.NoError(t, err)
+	assert.Empty(t, updater.updates)
+
+	now := metav1.Now()
+	_, err = sync("terminating/cm", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:              "cm",
+		Namespace:         "terminating",
+		DeletionTimestamp: &now,
+		Finalizers:        []string{"controller.cattle.io/test"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, updater.updates, 1)
+	assert.Empty(t, updater.updates[0].(*corev1.ConfigMap).Finalizers)
+}
+
+type versionedLifecycle struct {
+	testLifecycle
+	migrated []string
+}
+
+func (v *versionedLifecycle) Version() string {
+	return "v2"
+}
+
+func (v *versionedLifecycle) Migrate(oldVersion string, obj runtime.Object) (runtime.Object, error) {
+	v.migrated = append(v.migrated, oldVersion)
+	obj.(*corev1.ConfigMap).Data = map[string]string{"migrated": "true"}
+	return obj, nil
+}
+
+func TestMigrate(t *testing.T) {
+	updater := &fakeUpdater{}
+	lifecycle := &versionedLifecycle{}
+	sync := NewObjectLifecycleAdapterForUpdater("test", false, lifecycle, updater)
+
+	_, err := sync("default/new", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default"}})
+	require.NoError(t, err)
+	require.Len(t, updater.updates, 1)
+	assert.Equal(t, "true", updater.updates[0].(*corev1.ConfigMap).Annotations["lifecycle.cattle.io/create.test"])
+	assert.Equal(t, "v2", updater.updates[0].(*corev1.ConfigMap).Annotations["lifecycle.cattle.io/version.test"])
+	assert.Empty(t, lifecycle.migrated)
+
+	_, err = sync("default/new", updater.updates[0])
+	require.NoError(t, err)
+	assert.Len(t, updater.updates, 1)
+	assert.Empty(t, lifecycle.migrated)
+
+	old := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:        "old",
+		Namespace:   "default",
+		Finalizers:  []string{"controller.cattle.io/test"},
+		Annotations: map[string]string{"lifecycle.cattle.io/create.test": "true"},
+	}}
+	_, err = sync("default/old", old)
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, lifecycle.migrated)
+	require.Len(t, updater.updates, 2)
+	migrated := updater.updates[1].(*corev1.ConfigMap)
+	assert.Equal(t, "true", migrated.Annotations["lifecycle.cattle.io/create.test"])
+	assert.Equal(t, "v2", migrated.Annotations["lifecycle.cattle.io/version.test"])
+	assert.Equal(t, "true", migrated.Data["migrated"])
+}
+
+type restrictingUpdater struct {
+	fakeUpdater
+	restricts bool
+}
+
+func (r *restrictingUpdater) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+}
+
+func (r *restrictingUpdater) RestrictsTargets() bool {
+	return r.restricts
+}
+
+func TestRestrictedTargets(t *testing.T) {
+	objectclient.RestrictTargets(func(gvk schema.GroupVersionKind, namespace string) bool {
+		return namespace == "kube-system"
+	})
+	defer objectclient.RestrictTargets(nil)
+
+	// lifecycles of clients that don't restrict targets still run
+	updater := &restrictingUpdater{}
+	sync := NewObjectLifecycleAdapterForUpdater("test", false, testLifecycle{}, updater)
+	_, err := sync("kube-system/cm", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "kube-system"}})
+	require.NoError(t, err)
+	assert.Len(t, updater.updates, 1)
+
+	updater = &restrictingUpdater{restricts: true}
+	sync = NewObjectLifecycleAdapterForUpdater("test", false, testLifecycle{}, updater)
+	_, err = sync("kube-system/cm", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "kube-system"}})
+	require.NoError(t, err)
+	assert.Empty(t, updater.updates)
+
+	// restricted objects are still finalized
+	now := metav1.Now()
+	_, err = sync("kube-system/cm", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:              "cm",
+		Namespace:         "kube-system",
+		DeletionTimestamp: &now,
+		Finalizers:        []string{"controller.cattle.io/test"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, updater.updates, 1)
+	assert.Empty(t, updater.updates[0].(*corev1.ConfigMap).Finalizers)
+}
@@ -0,0 +1,104 @@
+package lifecycle
+
+import (
+	"strings"
+
+	"github.com/rancher/norman/objectclient"
+	"github.com/rancher/norman/types/slice"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// ReprocessClient lists and updates the objects to reprocess. It is implemented by *objectclient.ObjectClient.
+type ReprocessClient interface {
+	List(opts metav1.ListOptions) (runtime.Object, error)
+	Update(name string, o runtime.Object) (runtime.Object, error)
+}
+
+var _ ReprocessClient = (*objectclient.ObjectClient)(nil)
+
+type ReprocessOptions struct {
+	// Names are the lifecycles to run Create of again, all of them if empty
+	Names []string
+	// QPS limits the updates per second, defaults to 5
+	QPS float32
+	// DryRun only returns the objects that would be updated
+	DryRun bool
+}
+
+// Reprocess clears the create annotations of lifecycles on the objects matching selector, so that their Create
+// runs again, after a fix to a controller for instance. It returns the namespace/name of the updated objects, and
+// carries on past objects that fail to update.
+func Reprocess(client ReprocessClient, selector labels.Selector, opts ReprocessOptions) ([]string, error) {
+	if opts.QPS <= 0 {
+		opts.QPS = 5
+	}
+
+	list, err := client.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := flowcontrol.NewTokenBucketRateLimiter(opts.QPS, 1)
+	defer limiter.Stop()
+
+	var (
+		keys []string
+		errs []error
+	)
+	for _, obj := range objs {
+		obj = obj.DeepCopyObject()
+		metadata, err := meta.Accessor(obj)
+		if err != nil {
+			return keys, err
+		}
+		if !clearCreated(metadata, opts.Names) {
+			continue
+		}
+
+		if !opts.DryRun {
+			limiter.Accept()
+			if _, err := client.Update(metadata.GetName(), obj); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		keys = append(keys, key(metadata))
+	}
+
+	return keys, utilerrors.NewAggregate(errs)
+}
+
+func clearCreated(metadata metav1.Object, names []string) bool {
+	annotations := metadata.GetAnnotations()
+	cleared := false
+	for annotation := range annotations {
+		if !strings.HasPrefix(annotation, created+".") {
+			continue
+		}
+		if len(names) > 0 && !slice.ContainsString(names, strings.TrimPrefix(annotation, created+".")) {
+			continue
+		}
+		delete(annotations, annotation)
+		cleared = true
+	}
+	if cleared {
+		metadata.SetAnnotations(annotations)
+	}
+	return cleared
+}
+
+func key(metadata metav1.Object) string {
+	if metadata.GetNamespace() == "" {
+		return metadata.GetName()
+	}
+	return metadata.GetNamespace() + "/" + metadata.GetName()
+}
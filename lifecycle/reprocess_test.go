@@ -0,0 +1,47 @@
+package lifecycle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type fakeReprocessClient struct {
+	fakeUpdater
+	list *corev1.ConfigMapList
+}
+
+func (f *fakeReprocessClient) List(opts metav1.ListOptions) (runtime.Object, error) {
+	return f.list, nil
+}
+
+func configMap(name string, annotations map[string]string) corev1.ConfigMap {
+	return corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Annotations: annotations}}
+}
+
+func TestReprocess(t *testing.T) {
+	client := &fakeReprocessClient{
+		list: &corev1.ConfigMapList{Items: []corev1.ConfigMap{
+			configMap("a", map[string]string{created + ".one": "true", created + ".two": "true"}),
+			configMap("b", map[string]string{created + ".two": "true"}),
+			configMap("c", nil),
+		}},
+	}
+
+	keys, err := Reprocess(client, labels.Everything(), ReprocessOptions{Names: []string{"one"}, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default/a"}, keys)
+	assert.Empty(t, client.updates)
+
+	keys, err = Reprocess(client, labels.Everything(), ReprocessOptions{QPS: 100})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default/a", "default/b"}, keys)
+	require.Len(t, client.updates, 2)
+	assert.Empty(t, client.updates[0].(*corev1.ConfigMap).Annotations)
+	assert.Equal(t, "true", client.list.Items[0].Annotations[created+".one"])
+}
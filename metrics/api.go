@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	apiMetricsEnv = "NORMAN_API_METRICS"
+
+	apiSubsystem = "norman_api"
+)
+
+var (
+	apiMetrics = false
+
+	apiRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: apiSubsystem,
+			Name:      "requests_total",
+			Help:      "Total count of API requests by schema and method",
+		},
+		[]string{"schema", "method"},
+	)
+
+	apiWatchers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: apiSubsystem,
+			Name:      "watchers",
+			Help:      "Number of active watches by schema",
+		},
+		[]string{"schema"},
+	)
+
+	apiCollectionSize = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Subsystem: apiSubsystem,
+			Name:      "collection_size",
+			Help:      "Number of objects listed by collection requests by schema",
+		},
+		[]string{"schema"},
+	)
+
+	apiFilters = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: apiSubsystem,
+			Name:      "filters_total",
+			Help:      "Total count of collection requests filtering on a field by schema",
+		},
+		[]string{"schema", "field"},
+	)
+
+	apiPanics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: apiSubsystem,
+			Name:      "panics_total",
+			Help:      "Total count of panics recovered while serving requests by fingerprint",
+		},
+		[]string{"fingerprint"},
+	)
+
+	apiWatchLimited = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: apiSubsystem,
+			Name:      "watch_limited_total",
+			Help:      "Total count of subscriptions rejected because their user had too many active subscriptions",
+		},
+	)
+)
+
+func init() {
+	if os.Getenv(apiMetricsEnv) == "true" {
+		apiMetrics = true
+		prometheus.MustRegister(apiRequests, apiWatchers, apiCollectionSize, apiFilters, apiWatchLimited, apiPanics)
+	}
+}
+
+func IncAPIRequest(schema, method string) {
+	if !apiMetrics {
+		return
+	}
+	apiRequests.WithLabelValues(LabelValue(apiSubsystem, "schema", schema), method).Inc()
+}
+
+func AddAPIWatchers(schema string, delta int) {
+	if !apiMetrics {
+		return
+	}
+	apiWatchers.WithLabelValues(LabelValue(apiSubsystem, "schema", schema)).Add(float64(delta))
+}
+
+func ObserveAPICollectionSize(schema string, size int) {
+	if !apiMetrics {
+		return
+	}
+	apiCollectionSize.WithLabelValues(LabelValue(apiSubsystem, "schema", schema)).Observe(float64(size))
+}
+
+func IncAPIFilter(schema, field string) {
+	if !apiMetrics {
+		return
+	}
+	apiFilters.WithLabelValues(LabelValue(apiSubsystem, "schema", schema), LabelValue(apiSubsystem, "field", field)).Inc()
+}
+
+func IncAPIWatchLimited() {
+	if !apiMetrics {
+		return
+	}
+	apiWatchLimited.Inc()
+}
+
+func IncAPIPanic(fingerprint string) {
+	if !apiMetrics {
+		return
+	}
+	apiPanics.WithLabelValues(LabelValue(apiSubsystem, "fingerprint", fingerprint)).Inc()
+}
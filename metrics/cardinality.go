@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	labelLimitsEnv = "NORMAN_METRICS_LABEL_LIMITS"
+
+	// Overflow replaces the values of a label once its limit of distinct values is reached.
+	Overflow = "other"
+)
+
+var labels = labelLimits{
+	limits: map[string]*labelLimit{},
+}
+
+func init() {
+	for _, part := range strings.Split(os.Getenv(labelLimitsEnv), ",") {
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		limit, err := strconv.Atoi(value)
+		if !ok || err != nil {
+			logrus.Errorf("invalid %s entry %q, expected [subsystem/]label=limit", labelLimitsEnv, part)
+			continue
+		}
+		subsystem, label, ok := strings.Cut(name, "/")
+		if !ok {
+			subsystem, label = "", name
+		}
+		SetSubsystemLabelLimit(subsystem, label, limit)
+	}
+}
+
+// SetLabelLimit caps the distinct values of label, in every subsystem, to limit. Values past the limit are
+// aggregated as Overflow, a limit of 0 aggregates all values as "", which disables the label. Limits are read
+// from NORMAN_METRICS_LABEL_LIMITS as well, as comma separated [subsystem/]label=limit.
+func SetLabelLimit(label string, limit int) {
+	SetSubsystemLabelLimit("", label, limit)
+}
+
+// SetSubsystemLabelLimit caps the distinct values of label in the metrics of subsystem, it overrides the limit
+// of the label for every subsystem.
+func SetSubsystemLabelLimit(subsystem, label string, limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	labels.set(subsystem+"/"+label, limit)
+}
+
+// RemoveLabelLimit lifts the limit of label in subsystem, or in every subsystem if subsystem is "".
+func RemoveLabelLimit(subsystem, label string) {
+	labels.remove(subsystem + "/" + label)
+}
+
+// LabelValue returns the value to report for label of a metric of subsystem, value unless the label is limited.
+func LabelValue(subsystem, label, value string) string {
+	return labels.value(subsystem, label, value)
+}
+
+type labelLimit struct {
+	limit int
+	seen  map[string]bool
+}
+
+type labelLimits struct {
+	lock   sync.Mutex
+	limits map[string]*labelLimit
+}
+
+func (l *labelLimits) set(key string, limit int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.limits[key] = &labelLimit{
+		limit: limit,
+		seen:  map[string]bool{},
+	}
+}
+
+func (l *labelLimits) remove(key string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.limits, key)
+}
+
+func (l *labelLimits) value(subsystem, label, value string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	limit, ok := l.limits[subsystem+"/"+label]
+	if !ok {
+		limit, ok = l.limits["/"+label]
+	}
+	if !ok {
+		return value
+	}
+	if limit.limit == 0 {
+		return ""
+	}
+	if limit.seen[value] {
+		return value
+	}
+	if len(limit.seen) >= limit.limit {
+		return Overflow
+	}
+	limit.seen[value] = true
+	return value
+}
+
+// flagGauge tracks the boolean values of a gauge by their labels before limiting, so that the gauge counts the true
+// values of the labels aggregated as Overflow instead of them overwriting one another.
+type flagGauge struct {
+	lock   sync.Mutex
+	values map[string]bool
+}
+
+// update sets the flag of key to value, and returns the change to add to the gauge.
+func (f *flagGauge) update(key string, value bool) float64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.values == nil {
+		f.values = map[string]bool{}
+	}
+	if f.values[key] == value {
+		return 0
+	}
+	if value {
+		f.values[key] = true
+		return 1
+	}
+	delete(f.values, key)
+	return -1
+}
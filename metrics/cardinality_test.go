@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelValue(t *testing.T) {
+	defer RemoveLabelLimit("", "name")
+	defer RemoveLabelLimit("test", "name")
+
+	assert.Equal(t, "a", LabelValue("test", "name", "a"))
+
+	SetLabelLimit("name", 2)
+	assert.Equal(t, "a", LabelValue("test", "name", "a"))
+	assert.Equal(t, "b", LabelValue("test", "name", "b"))
+	assert.Equal(t, Overflow, LabelValue("test", "name", "c"))
+	assert.Equal(t, "a", LabelValue("test", "name", "a"))
+	assert.Equal(t, "a", LabelValue("test", "namespace", "a"))
+
+	SetSubsystemLabelLimit("test", "name", 0)
+	assert.Equal(t, "", LabelValue("test", "name", "a"))
+	assert.Equal(t, "a", LabelValue("other", "name", "a"))
+}
+
+func TestFlagGauge(t *testing.T) {
+	var flags flagGauge
+	assert.Equal(t, 1.0, flags.update("a", true))
+	assert.Equal(t, 0.0, flags.update("a", true))
+	assert.Equal(t, 1.0, flags.update("b", true))
+	assert.Equal(t, -1.0, flags.update("a", false))
+	assert.Equal(t, 0.0, flags.update("a", false))
+	assert.Equal(t, 0.0, flags.update("c", false))
+}
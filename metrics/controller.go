@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsEnv = "NORMAN_CONTROLLER_METRICS"
+
+	controllerSubsystem = "norman_controller"
+)
+
+var (
+	prometheusMetrics = false
+
+	circuitBreakerOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: controllerSubsystem,
+			Name:      "circuit_breaker_open",
+			Help:      "Whether the circuit breaker of a controller handler is open, or how many are for aggregated labels",
+		},
+		[]string{"controller", "handler"},
+	)
+
+	circuitBreakerTrips = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: controllerSubsystem,
+			Name:      "circuit_breaker_trips_total",
+			Help:      "Total count of times the circuit breaker of a controller handler opened",
+		},
+		[]string{"controller", "handler"},
+	)
+
+	cacheUnsynced = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: controllerSubsystem,
+			Name:      "cache_unsynced",
+			Help:      "Whether a cache did not sync before the controllers were started, or how many for aggregated labels",
+		},
+		[]string{"kind"},
+	)
+
+	duplicateUpdates = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: controllerSubsystem,
+			Name:      "duplicate_updates_total",
+			Help:      "Total count of updates a controller handler skipped because only ignored fields changed",
+		},
+		[]string{"controller", "handler"},
+	)
+
+	handlerTimeouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: controllerSubsystem,
+			Name:      "handler_timeouts_total",
+			Help:      "Total count of controller handler calls cancelled because they exceeded their timeout",
+		},
+		[]string{"controller", "handler"},
+	)
+
+	reconcileLag = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: controllerSubsystem,
+			Name:      "reconcile_lag_seconds",
+			Help:      "Time between the last modification of an object and a controller handler completing on it",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 16),
+		},
+		[]string{"controller", "handler"},
+	)
+
+	circuitBreakerOpenFlags flagGauge
+	cacheUnsyncedFlags      flagGauge
+)
+
+func init() {
+	if os.Getenv(metricsEnv) == "true" {
+		prometheusMetrics = true
+		prometheus.MustRegister(circuitBreakerOpen, circuitBreakerTrips, cacheUnsynced, objectClientThrottled,
+			duplicateUpdates, handlerTimeouts, reconcileLag, keyFailuresCollector{})
+	}
+}
+
+func SetCircuitBreakerOpen(controllerName, handlerName string, open bool) {
+	if !prometheusMetrics {
+		return
+	}
+	delta := circuitBreakerOpenFlags.update(controllerName+"/"+handlerName, open)
+	controllerName = LabelValue(controllerSubsystem, "controller", controllerName)
+	handlerName = LabelValue(controllerSubsystem, "handler", handlerName)
+	circuitBreakerOpen.WithLabelValues(controllerName, handlerName).Add(delta)
+}
+
+// IncCircuitBreakerTrips counts a circuit breaker opening, including reopening after a failure once its delay passed.
+func IncCircuitBreakerTrips(controllerName, handlerName string) {
+	if !prometheusMetrics {
+		return
+	}
+	controllerName = LabelValue(controllerSubsystem, "controller", controllerName)
+	handlerName = LabelValue(controllerSubsystem, "handler", handlerName)
+	circuitBreakerTrips.WithLabelValues(controllerName, handlerName).Inc()
+}
+
+func SetCacheUnsynced(kind string, unsynced bool) {
+	if !prometheusMetrics {
+		return
+	}
+	delta := cacheUnsyncedFlags.update(kind, unsynced)
+	cacheUnsynced.WithLabelValues(LabelValue(controllerSubsystem, "kind", kind)).Add(delta)
+}
+
+func IncDuplicateUpdates(controllerName, handlerName string) {
+	if !prometheusMetrics {
+		return
+	}
+	controllerName = LabelValue(controllerSubsystem, "controller", controllerName)
+	handlerName = LabelValue(controllerSubsystem, "handler", handlerName)
+	duplicateUpdates.WithLabelValues(controllerName, handlerName).Inc()
+}
+
+func IncHandlerTimeouts(controllerName, handlerName string) {
+	if !prometheusMetrics {
+		return
+	}
+	controllerName = LabelValue(controllerSubsystem, "controller", controllerName)
+	handlerName = LabelValue(controllerSubsystem, "handler", handlerName)
+	handlerTimeouts.WithLabelValues(controllerName, handlerName).Inc()
+}
+
+func ObserveReconcileLag(controllerName, handlerName string, lag time.Duration) {
+	if !prometheusMetrics {
+		return
+	}
+	controllerName = LabelValue(controllerSubsystem, "controller", controllerName)
+	handlerName = LabelValue(controllerSubsystem, "handler", handlerName)
+	reconcileLag.WithLabelValues(controllerName, handlerName).Observe(lag.Seconds())
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// keyFailuresLimit is the number of keys reported by the key failure metrics, the ones failing the longest.
+const keyFailuresLimit = 10
+
+// KeyFailure is a key that a handler of a controller consecutively failed to process since First.
+type KeyFailure struct {
+	Controller string
+	Handler    string
+	Key        string
+	Failures   int
+	First      time.Time
+}
+
+var (
+	keyFailures atomic.Pointer[func(limit int) []KeyFailure]
+
+	keyFailuresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("", controllerSubsystem, "key_failures"),
+		"Consecutive failures of the keys that controller handlers failed to process the longest",
+		[]string{"controller", "handler", "key"}, nil,
+	)
+	keyFailureAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("", controllerSubsystem, "key_failure_age_seconds"),
+		"Time since the first consecutive failure of the keys that controller handlers failed to process the longest",
+		[]string{"controller", "handler", "key"}, nil,
+	)
+)
+
+// SetKeyFailures sets the function returning the keys that handlers failed to process the longest, at most limit.
+func SetKeyFailures(f func(limit int) []KeyFailure) {
+	keyFailures.Store(&f)
+}
+
+// keyFailuresCollector reports the worst failing keys when scraped, so that the keys reported are bounded.
+type keyFailuresCollector struct{}
+
+func (keyFailuresCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- keyFailuresDesc
+	ch <- keyFailureAgeDesc
+}
+
+func (keyFailuresCollector) Collect(ch chan<- prometheus.Metric) {
+	f := keyFailures.Load()
+	if f == nil {
+		return
+	}
+	now := time.Now()
+	for _, failure := range (*f)(keyFailuresLimit) {
+		ch <- prometheus.MustNewConstMetric(keyFailuresDesc, prometheus.GaugeValue, float64(failure.Failures),
+			failure.Controller, failure.Handler, failure.Key)
+		ch <- prometheus.MustNewConstMetric(keyFailureAgeDesc, prometheus.GaugeValue, now.Sub(failure.First).Seconds(),
+			failure.Controller, failure.Handler, failure.Key)
+	}
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const objectClientSubsystem = "objectclient"
+
+var objectClientThrottled = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Subsystem: objectClientSubsystem,
+		Name:      "throttled_total",
+		Help:      "Total count of object client requests throttled by the apiserver or the client rate limiter",
+	},
+	[]string{"resource"},
+)
+
+func IncObjectClientThrottled(resource string) {
+	if !prometheusMetrics {
+		return
+	}
+	objectClientThrottled.WithLabelValues(LabelValue(objectClientSubsystem, "resource", resource)).Inc()
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	tunnelMetricsEnv = "NORMAN_TUNNEL_METRICS"
+
+	tunnelSubsystem = "norman_tunnel"
+)
+
+var (
+	tunnelMetrics = false
+
+	tunnelConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: tunnelSubsystem,
+			Name:      "connections",
+			Help:      "Number of open connections through the tunnel of a downstream cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: tunnelSubsystem,
+			Name:      "bytes_total",
+			Help:      "Total count of bytes sent and received through the tunnel of a downstream cluster",
+		},
+		[]string{"cluster", "direction"},
+	)
+
+	tunnelDialErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: tunnelSubsystem,
+			Name:      "dial_errors_total",
+			Help:      "Total count of failed dials through the tunnel of a downstream cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelDialLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: tunnelSubsystem,
+			Name:      "dial_seconds",
+			Help:      "Time to dial a connection through the tunnel of a downstream cluster",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: tunnelSubsystem,
+			Name:      "requests_in_flight",
+			Help:      "Number of requests to a downstream cluster in flight",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelRequestsDelayed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: tunnelSubsystem,
+			Name:      "requests_delayed_total",
+			Help:      "Total count of requests to a downstream cluster delayed by its concurrency or rate limit",
+		},
+		[]string{"cluster", "limit"},
+	)
+)
+
+func init() {
+	if os.Getenv(tunnelMetricsEnv) == "true" {
+		tunnelMetrics = true
+		prometheus.MustRegister(tunnelConnections, tunnelBytes, tunnelDialErrors, tunnelDialLatency, tunnelRequests,
+			tunnelRequestsDelayed)
+	}
+}
+
+func AddTunnelConnections(cluster string, delta int) {
+	if !tunnelMetrics {
+		return
+	}
+	tunnelConnections.WithLabelValues(LabelValue(tunnelSubsystem, "cluster", cluster)).Add(float64(delta))
+}
+
+func AddTunnelBytes(cluster, direction string, n int) {
+	if !tunnelMetrics || n <= 0 {
+		return
+	}
+	tunnelBytes.WithLabelValues(LabelValue(tunnelSubsystem, "cluster", cluster), direction).Add(float64(n))
+}
+
+func ObserveTunnelDial(cluster string, latency time.Duration, err error) {
+	if !tunnelMetrics {
+		return
+	}
+	cluster = LabelValue(tunnelSubsystem, "cluster", cluster)
+	if err != nil {
+		tunnelDialErrors.WithLabelValues(cluster).Inc()
+		return
+	}
+	tunnelDialLatency.WithLabelValues(cluster).Observe(latency.Seconds())
+}
+
+func AddTunnelRequests(cluster string, delta int) {
+	if !tunnelMetrics {
+		return
+	}
+	tunnelRequests.WithLabelValues(LabelValue(tunnelSubsystem, "cluster", cluster)).Add(float64(delta))
+}
+
+func IncTunnelRequestsDelayed(cluster, limit string) {
+	if !tunnelMetrics {
+		return
+	}
+	tunnelRequestsDelayed.WithLabelValues(LabelValue(tunnelSubsystem, "cluster", cluster), limit).Inc()
+}
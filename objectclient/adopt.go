@@ -0,0 +1,128 @@
+package objectclient
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AdoptPolicy is what CreateOrAdopt does with an existing object not owned by the owner.
+type AdoptPolicy int
+
+const (
+	// AdoptPolicyAdopt adds the owner reference to the existing object, unless it is controlled by another owner.
+	AdoptPolicyAdopt AdoptPolicy = iota
+	// AdoptPolicyFail returns an ErrNotOwned.
+	AdoptPolicyFail
+	// AdoptPolicySkip leaves the existing object untouched.
+	AdoptPolicySkip
+)
+
+// AdoptResult is what CreateOrAdopt did.
+type AdoptResult string
+
+const (
+	AdoptResultCreated      AdoptResult = "Created"
+	AdoptResultAdopted      AdoptResult = "Adopted"
+	AdoptResultAlreadyOwned AdoptResult = "AlreadyOwned"
+	AdoptResultSkipped      AdoptResult = "Skipped"
+)
+
+// ErrNotOwned is returned by CreateOrAdopt when the existing object isn't owned by the owner and can't be adopted.
+type ErrNotOwned struct {
+	Namespace  string
+	Name       string
+	Owner      metav1.OwnerReference
+	Controller *metav1.OwnerReference
+}
+
+func (e *ErrNotOwned) Error() string {
+	target := e.Name
+	if e.Namespace != "" {
+		target = e.Namespace + "/" + e.Name
+	}
+	if e.Controller != nil {
+		return fmt.Sprintf("%s is controlled by %s %s, not %s %s", target, e.Controller.Kind, e.Controller.Name,
+			e.Owner.Kind, e.Owner.Name)
+	}
+	return fmt.Sprintf("%s is not owned by %s %s", target, e.Owner.Kind, e.Owner.Name)
+}
+
+// CreateOrAdopt creates o owned by owner or, if it already exists, makes sure the existing object is owned by
+// owner according to policy. It returns the created or existing object and what was done. o is not modified.
+func (p *ObjectClient) CreateOrAdopt(o runtime.Object, owner metav1.OwnerReference, policy AdoptPolicy) (runtime.Object, AdoptResult, error) {
+	// the owner reference is added to a copy, o is left untouched
+	o = o.DeepCopyObject()
+	obj, err := meta.Accessor(o)
+	if err != nil {
+		return nil, "", err
+	}
+	if !hasOwner(obj, owner) {
+		obj.SetOwnerReferences(append(obj.GetOwnerReferences(), owner))
+	}
+
+	result, err := p.Create(o)
+	if err == nil {
+		return result, AdoptResultCreated, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return result, "", err
+	}
+
+	ns := p.ns
+	if obj.GetNamespace() != "" {
+		ns = obj.GetNamespace()
+	}
+	existing, err := p.GetNamespaced(ns, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return existing, "", err
+	}
+	existingObj, err := meta.Accessor(existing)
+	if err != nil {
+		return existing, "", err
+	}
+
+	adoptResult, err := adopt(existingObj, owner, policy)
+	if err != nil || adoptResult != AdoptResultAdopted {
+		return existing, adoptResult, err
+	}
+	result, err = p.Update(existingObj.GetName(), existing)
+	if err != nil {
+		return result, "", err
+	}
+	return result, AdoptResultAdopted, nil
+}
+
+// adopt adds owner to the owner references of the existing obj if policy allows it.
+func adopt(obj metav1.Object, owner metav1.OwnerReference, policy AdoptPolicy) (AdoptResult, error) {
+	if hasOwner(obj, owner) {
+		return AdoptResultAlreadyOwned, nil
+	}
+
+	switch policy {
+	case AdoptPolicySkip:
+		return AdoptResultSkipped, nil
+	case AdoptPolicyAdopt:
+		controller := metav1.GetControllerOfNoCopy(obj)
+		if controller == nil || owner.Controller == nil || !*owner.Controller {
+			obj.SetOwnerReferences(append(obj.GetOwnerReferences(), owner))
+			return AdoptResultAdopted, nil
+		}
+		return "", &ErrNotOwned{Namespace: obj.GetNamespace(), Name: obj.GetName(), Owner: owner, Controller: controller}
+	default:
+		return "", &ErrNotOwned{Namespace: obj.GetNamespace(), Name: obj.GetName(), Owner: owner,
+			Controller: metav1.GetControllerOfNoCopy(obj)}
+	}
+}
+
+func hasOwner(obj metav1.Object, owner metav1.OwnerReference) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package objectclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+func TestAdopt(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "management.cattle.io", Version: "v3", Kind: "Cluster"}
+	owner := *metav1.NewControllerRef(&metav1.ObjectMeta{Name: "c-1", UID: "1"}, gvk)
+	other := *metav1.NewControllerRef(&metav1.ObjectMeta{Name: "c-2", UID: "2"}, gvk)
+	newSecret := func(refs ...metav1.OwnerReference) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "token", OwnerReferences: refs}}
+	}
+
+	result, err := adopt(newSecret(owner), owner, AdoptPolicyFail)
+	assert.NoError(t, err)
+	assert.Equal(t, AdoptResultAlreadyOwned, result)
+
+	secret := newSecret()
+	result, err = adopt(secret, owner, AdoptPolicySkip)
+	assert.NoError(t, err)
+	assert.Equal(t, AdoptResultSkipped, result)
+	assert.Empty(t, secret.OwnerReferences)
+
+	_, err = adopt(secret, owner, AdoptPolicyFail)
+	assert.EqualError(t, err, "ns/token is not owned by Cluster c-1")
+
+	result, err = adopt(secret, owner, AdoptPolicyAdopt)
+	assert.NoError(t, err)
+	assert.Equal(t, AdoptResultAdopted, result)
+	assert.Equal(t, []metav1.OwnerReference{owner}, secret.OwnerReferences)
+
+	_, err = adopt(newSecret(other), owner, AdoptPolicyAdopt)
+	assert.EqualError(t, err, "ns/token is controlled by Cluster c-2, not Cluster c-1")
+}
+
+func TestCreateOrAdopt(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "management.cattle.io", Version: "v3", Kind: "Cluster"}
+	owner := *metav1.NewControllerRef(&metav1.ObjectMeta{Name: "c-1", UID: "1"}, gvk)
+
+	var updated corev1.ConfigMap
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case http.MethodPost:
+			rw.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(rw).Encode(apierrors.NewAlreadyExists(corev1.Resource("configmaps"), "a").ErrStatus)
+		case http.MethodGet:
+			_ = json.NewEncoder(rw).Encode(corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", ResourceVersion: "1"},
+			})
+		case http.MethodPut:
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&updated))
+			_ = json.NewEncoder(rw).Encode(updated)
+		}
+	}))
+	defer server.Close()
+
+	restClient, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		APIPath: "/api",
+	})
+	require.NoError(t, err)
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	objectClient := NewObjectClient("default", client.NewClient(gvr, "ConfigMap", true, restClient, time.Minute),
+		&metav1.APIResource{Name: "configmaps", Namespaced: true}, corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		configMapFactory{})
+
+	input := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a"}}
+	result, adoptResult, err := objectClient.CreateOrAdopt(input, owner, AdoptPolicyAdopt)
+	require.NoError(t, err)
+	assert.Equal(t, AdoptResultAdopted, adoptResult)
+	assert.Equal(t, []metav1.OwnerReference{owner}, result.(*corev1.ConfigMap).OwnerReferences)
+	assert.Equal(t, []metav1.OwnerReference{owner}, updated.OwnerReferences)
+	assert.Empty(t, input.OwnerReferences, "the input is not modified")
+}
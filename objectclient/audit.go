@@ -0,0 +1,134 @@
+package objectclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+const ChangeCauseAnnotation = "cattle.io/change-cause"
+
+// defaultControllerName and defaultVersion describe the running binary, they are used when the client is built
+// without WithUserAgent and WithChangeCause.
+var defaultControllerName, defaultVersion = binaryInfo()
+
+func binaryInfo() (string, string) {
+	name := filepath.Base(os.Args[0])
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return name, info.Main.Version
+	}
+	return name, "unknown"
+}
+
+// UserAgent describes a norman controller, for example "cluster-controller/v2.8.0 norman
+// kube-apiserver-client/v1.34.1 (linux/amd64)", so that audit logs attribute writes to it.
+func UserAgent(controllerName, version string) string {
+	return fmt.Sprintf("%s/%s norman %s", controllerName, version, rest.DefaultKubernetesUserAgent())
+}
+
+// DefaultUserAgent is the UserAgent of the running binary, sent by the clients whose config has no user agent.
+func DefaultUserAgent() string {
+	return UserAgent(defaultControllerName, defaultVersion)
+}
+
+// withDefaultUserAgent returns c sending DefaultUserAgent if its config has no user agent.
+func withDefaultUserAgent(c *client.Client) *client.Client {
+	if c == nil || c.Config.Host == "" || c.Config.UserAgent != "" {
+		return c
+	}
+	result, err := derived(c, "agent", func(c *client.Client) (*client.Client, error) {
+		return c.WithAgent(DefaultUserAgent())
+	})
+	if err != nil {
+		logrus.Debugf("using the client-go user agent for %v: %v", c.GVR, err)
+		return c
+	}
+	return result
+}
+
+// WithUserAgent returns a copy of the client that sends userAgent on every request.
+func (p *ObjectClient) WithUserAgent(userAgent string) (*ObjectClient, error) {
+	c, err := p.client.WithAgent(userAgent)
+	if err != nil {
+		return nil, err
+	}
+	jsonClient := c
+	if p.jsonClient != p.client {
+		jsonClient, err = p.jsonClient.WithAgent(userAgent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := *p
+	result.client = c
+	result.jsonClient = jsonClient
+	return &result, nil
+}
+
+// WithChangeCause returns a copy of the client that sets the cattle.io/change-cause annotation to cause on the
+// objects it creates, updates and patches, instead of the name of the running binary. An empty cause leaves the
+// annotation untouched.
+func (p *ObjectClient) WithChangeCause(cause string) *ObjectClient {
+	result := *p
+	result.changeCause = cause
+	return &result
+}
+
+func (p *ObjectClient) stampChangeCause(o runtime.Object) {
+	obj, ok := o.(metav1.Object)
+	if p.changeCause == "" || !ok {
+		return
+	}
+
+	annotations := make(map[string]string, len(obj.GetAnnotations())+1)
+	for k, v := range obj.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[ChangeCauseAnnotation] = p.changeCause
+	obj.SetAnnotations(annotations)
+}
+
+// stampChangeCausePatch adds the change cause annotation to merge patches of the object, other patches are sent as
+// they are.
+func (p *ObjectClient) stampChangeCausePatch(patchType types.PatchType, data []byte, subresources []string) []byte {
+	if p.changeCause == "" || len(subresources) > 0 ||
+		(patchType != types.MergePatchType && patchType != types.StrategicMergePatchType) {
+		return data
+	}
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal(data, &patch); err != nil || patch == nil {
+		return data
+	}
+	if patch["metadata"] == nil {
+		patch["metadata"] = map[string]interface{}{}
+	}
+	metadata, ok := patch["metadata"].(map[string]interface{})
+	if !ok {
+		return data
+	}
+	if metadata["annotations"] == nil {
+		metadata["annotations"] = map[string]interface{}{}
+	}
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		return data
+	}
+	annotations[ChangeCauseAnnotation] = p.changeCause
+
+	result, err := json.Marshal(patch)
+	if err != nil {
+		return data
+	}
+	return result
+}
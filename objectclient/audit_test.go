@@ -0,0 +1,105 @@
+package objectclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type auditRequest struct {
+	userAgent string
+	body      string
+}
+
+func newAuditClient(t *testing.T, userAgent string) (*ObjectClient, *[]auditRequest) {
+	var requests []auditRequest
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		requests = append(requests, auditRequest{userAgent: req.UserAgent(), body: string(body)})
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	config := rest.Config{
+		Host:      server.URL,
+		UserAgent: userAgent,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		APIPath: "/api",
+	}
+	restClient, err := rest.RESTClientFor(&config)
+	require.NoError(t, err)
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	c := client.NewClient(gvr, "ConfigMap", true, restClient, time.Minute)
+	c.Config = config
+
+	return NewObjectClient("default", c, &metav1.APIResource{Name: "configmaps", Namespaced: true},
+		corev1.SchemeGroupVersion.WithKind("ConfigMap"), configMapFactory{}), &requests
+}
+
+func TestAuditDefaults(t *testing.T) {
+	objectClient, requests := newAuditClient(t, "")
+
+	_, err := objectClient.Create(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+	require.NoError(t, err)
+	_, err = objectClient.Patch("a", &corev1.ConfigMap{}, types.MergePatchType, []byte(`{"data":{"a":"b"}}`))
+	require.NoError(t, err)
+	_, err = objectClient.Patch("a", &corev1.ConfigMap{}, types.MergePatchType, []byte(`{"status":{}}`), "status")
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 3)
+	for _, request := range *requests {
+		assert.Equal(t, DefaultUserAgent(), request.userAgent)
+	}
+	stamped := `"` + ChangeCauseAnnotation + `":"` + defaultControllerName + `"`
+	assert.Contains(t, (*requests)[0].body, stamped)
+	assert.JSONEq(t, `{"data":{"a":"b"},"metadata":{"annotations":{"`+ChangeCauseAnnotation+`":"`+defaultControllerName+`"}}}`,
+		(*requests)[1].body)
+	assert.Equal(t, `{"status":{}}`, (*requests)[2].body)
+}
+
+func TestAuditOverrides(t *testing.T) {
+	objectClient, requests := newAuditClient(t, "")
+	objectClient, err := objectClient.WithUserAgent(UserAgent("test-controller", "v1.0.0"))
+	require.NoError(t, err)
+	objectClient = objectClient.WithChangeCause("rotate certificates")
+
+	_, err = objectClient.Update("a", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+	require.NoError(t, err)
+	_, err = objectClient.Patch("a", &corev1.ConfigMap{}, types.JSONPatchType, []byte(`[]`))
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 2)
+	assert.True(t, strings.HasPrefix((*requests)[0].userAgent, "test-controller/v1.0.0 norman "), (*requests)[0].userAgent)
+	assert.Contains(t, (*requests)[0].body, `"`+ChangeCauseAnnotation+`":"rotate certificates"`)
+	assert.Equal(t, `[]`, (*requests)[1].body)
+}
+
+func TestAuditConfigUserAgent(t *testing.T) {
+	objectClient, requests := newAuditClient(t, "configured/v2")
+
+	_, err := objectClient.Create(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 1)
+	assert.Equal(t, "configured/v2", (*requests)[0].userAgent)
+}
@@ -0,0 +1,40 @@
+package objectclient
+
+import (
+	"github.com/rancher/norman/pkg/bus"
+	"github.com/rancher/norman/types/convert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// WithBus returns a copy of the client that publishes the objects it creates, updates and deletes on b, with the
+// schema ID of the kind as type and namespace/name as key.
+func (p *ObjectClient) WithBus(b *bus.Bus) *ObjectClient {
+	result := *p
+	result.bus = b
+	return &result
+}
+
+func (p *ObjectClient) publish(verb bus.Verb, namespace, name string) {
+	if p.bus == nil {
+		return
+	}
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+	p.bus.Publish(bus.Event{
+		Type: convert.LowerTitle(p.gvk.Kind),
+		Verb: verb,
+		Key:  key,
+	})
+}
+
+func (p *ObjectClient) publishObject(verb bus.Verb, o runtime.Object) {
+	if p.bus == nil {
+		return
+	}
+	if obj, err := meta.Accessor(o); err == nil {
+		p.publish(verb, obj.GetNamespace(), obj.GetName())
+	}
+}
@@ -0,0 +1,27 @@
+package objectclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/norman/pkg/bus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPublishSchemaID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := bus.New()
+	events := b.Subscribe(ctx, "configMap")
+
+	client := &ObjectClient{
+		resource: &metav1.APIResource{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+		gvk:      schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+	}
+	client.WithBus(b).publish(bus.Update, "default", "a")
+
+	assert.Equal(t, bus.Event{Type: "configMap", Verb: bus.Update, Key: "default/a"}, <-events)
+}
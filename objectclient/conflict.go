@@ -0,0 +1,134 @@
+package objectclient
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var diagnoseConflicts atomic.Bool
+
+// ignoredConflictFields are the fields set by the API server, that always differ on conflicts.
+var ignoredConflictFields = map[string]bool{
+	"metadata.resourceVersion": true,
+	"metadata.managedFields":   true,
+}
+
+// DiagnoseConflicts makes Update and UpdateStatus fetch the current object when they fail with a conflict and return
+// an ErrConflict listing the fields the attempted write differs on, also logged at debug level.
+func DiagnoseConflicts(enabled bool) {
+	diagnoseConflicts.Store(enabled)
+}
+
+// ErrConflict is a conflict error of an update, with the differences between the attempted write and the current
+// object. apierrors.IsConflict is true for it.
+type ErrConflict struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+	// ResourceVersion is the version of the attempted write and CurrentResourceVersion the version of the current
+	// object
+	ResourceVersion        string
+	CurrentResourceVersion string
+	// Fields are the paths of the fields that differ, like spec.replicas
+	Fields []string
+	Err    error
+}
+
+func (e *ErrConflict) Error() string {
+	return fmt.Sprintf("%v (resourceVersion %s, current %s, fields differing: %s)", e.Err, e.ResourceVersion,
+		e.CurrentResourceVersion, strings.Join(e.Fields, ", "))
+}
+
+func (e *ErrConflict) Unwrap() error {
+	return e.Err
+}
+
+// ConflictFields returns the paths of the fields of attempted that differ from current, ignoring the fields
+// always set by the API server.
+func ConflictFields(attempted, current runtime.Object) ([]string, error) {
+	attemptedData, err := runtime.DefaultUnstructuredConverter.ToUnstructured(attempted)
+	if err != nil {
+		return nil, err
+	}
+	currentData, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	diffFields("", attemptedData, currentData, &fields)
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func diffFields(path string, attempted, current interface{}, fields *[]string) {
+	if ignoredConflictFields[path] {
+		return
+	}
+	attemptedMap, ok := attempted.(map[string]interface{})
+	currentMap, currentOk := current.(map[string]interface{})
+	if !ok || !currentOk {
+		if !reflect.DeepEqual(attempted, current) {
+			*fields = append(*fields, path)
+		}
+		return
+	}
+	for key, value := range attemptedMap {
+		diffFields(joinPath(path, key), value, currentMap[key], fields)
+	}
+	for key, value := range currentMap {
+		if _, ok := attemptedMap[key]; !ok {
+			diffFields(joinPath(path, key), nil, value, fields)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// diagnoseConflict returns an ErrConflict for err if it is the conflict of the update of o and conflicts are
+// diagnosed, err otherwise.
+func (p *ObjectClient) diagnoseConflict(namespace, name string, o runtime.Object, err error) error {
+	if !diagnoseConflicts.Load() || !apierrors.IsConflict(err) {
+		return err
+	}
+	current := p.Factory.Object()
+	if getErr := p.client.Get(p.ctx, namespace, name, current, metav1.GetOptions{}); getErr != nil {
+		logrus.Debugf("failed to get %s %s/%s to diagnose conflict: %v", p.gvk.Kind, namespace, name, getErr)
+		return err
+	}
+	fields, diffErr := ConflictFields(o, current)
+	if diffErr != nil {
+		logrus.Debugf("failed to diagnose conflict of %s %s/%s: %v", p.gvk.Kind, namespace, name, diffErr)
+		return err
+	}
+
+	conflict := &ErrConflict{
+		GVK:       p.gvk,
+		Namespace: namespace,
+		Name:      name,
+		Fields:    fields,
+		Err:       err,
+	}
+	if obj, metaErr := meta.Accessor(o); metaErr == nil {
+		conflict.ResourceVersion = obj.GetResourceVersion()
+	}
+	if obj, metaErr := meta.Accessor(current); metaErr == nil {
+		conflict.CurrentResourceVersion = obj.GetResourceVersion()
+	}
+	logrus.Debugf("conflict updating %s %s/%s: %v", p.gvk.Kind, namespace, name, conflict)
+	return conflict
+}
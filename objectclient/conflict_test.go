@@ -0,0 +1,39 @@
+package objectclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestConflictFields(t *testing.T) {
+	replicas, currentReplicas := int32(2), int32(3)
+	attempted := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", ResourceVersion: "1", Labels: map[string]string{"app": "web"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	current := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", ResourceVersion: "2", Labels: map[string]string{"app": "web", "tier": "front"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &currentReplicas},
+	}
+
+	fields, err := ConflictFields(attempted, current)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metadata.labels.tier", "spec.replicas"}, fields)
+
+	conflict := &ErrConflict{
+		GVK:                    schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Name:                   "web",
+		ResourceVersion:        "1",
+		CurrentResourceVersion: "2",
+		Fields:                 fields,
+		Err:                    apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web", nil),
+	}
+	assert.True(t, apierrors.IsConflict(conflict))
+	assert.Contains(t, conflict.Error(), "(resourceVersion 1, current 2, fields differing: metadata.labels.tier, spec.replicas)")
+}
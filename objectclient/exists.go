@@ -0,0 +1,115 @@
+package objectclient
+
+import (
+	"encoding/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// the metadata of objects is requested, but servers that can't convert to it answer with the full objects
+	acceptMetadata     = "application/json;as=PartialObjectMetadata;g=meta.k8s.io;v=v1,application/json"
+	acceptMetadataList = "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1,application/json"
+	countChunkSize     = 500
+)
+
+// Cache is the cache of the objects of a client, like the SharedIndexInformer of their controller.
+type Cache interface {
+	HasSynced() bool
+	GetIndexer() cache.Indexer
+}
+
+// WithCache returns a copy of the client answering Exists and Count from c once it has synced.
+func (p *ObjectClient) WithCache(c Cache) *ObjectClient {
+	result := *p
+	result.cache = c
+	return &result
+}
+
+// Exists returns whether the object namespace/name exists, reading only its metadata from the apiserver if the
+// cache of the client hasn't synced.
+func (p *ObjectClient) Exists(namespace, name string) (bool, error) {
+	if p.cache != nil && p.cache.HasSynced() {
+		key := name
+		if namespace != "" {
+			key = namespace + "/" + name
+		}
+		_, exists, err := p.cache.GetIndexer().GetByKey(key)
+		return exists, err
+	}
+
+	p.record(namespace, "get")
+	err := p.backoff(func() error {
+		return p.client.RESTClient.Get().
+			Prefix(p.prefix()...).
+			NamespaceIfScoped(namespace, p.client.Namespaced).
+			Resource(p.resource.Name).
+			Name(name).
+			SetHeader("Accept", acceptMetadata).
+			Do(p.ctx).
+			Error()
+	})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Count returns the number of objects in the namespace of the client matching selector, listing only their
+// metadata from the apiserver if the cache of the client hasn't synced.
+func (p *ObjectClient) Count(selector labels.Selector) (int, error) {
+	if selector == nil {
+		selector = labels.Everything()
+	}
+	if p.cache != nil && p.cache.HasSynced() {
+		count := 0
+		err := cache.ListAllByNamespace(p.cache.GetIndexer(), p.ns, selector, func(interface{}) {
+			count++
+		})
+		return count, err
+	}
+
+	p.record(p.ns, "list")
+	opts := metav1.ListOptions{
+		LabelSelector: selector.String(),
+		Limit:         countChunkSize,
+	}
+	count := 0
+	for {
+		var body []byte
+		err := p.backoff(func() (err error) {
+			body, err = p.client.RESTClient.Get().
+				Prefix(p.prefix()...).
+				NamespaceIfScoped(p.ns, p.client.Namespaced).
+				Resource(p.resource.Name).
+				VersionedParams(&opts, metav1.ParameterCodec).
+				SetHeader("Accept", acceptMetadataList).
+				Do(p.ctx).
+				Raw()
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		list := metav1.PartialObjectMetadataList{}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return 0, err
+		}
+		count += len(list.Items)
+		if list.Continue == "" {
+			return count, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+func (p *ObjectClient) prefix() []string {
+	if p.gvk.Group == "" {
+		return []string{p.getAPIPrefix(), p.gvk.Version}
+	}
+	return []string{p.getAPIPrefix(), p.gvk.Group, p.gvk.Version}
+}
@@ -0,0 +1,97 @@
+package objectclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+type testCache struct {
+	synced  bool
+	indexer cache.Indexer
+}
+
+func (t *testCache) HasSynced() bool {
+	return t.synced
+}
+
+func (t *testCache) GetIndexer() cache.Indexer {
+	return t.indexer
+}
+
+func TestExistsAndCount(t *testing.T) {
+	var accepts []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		accepts = append(accepts, req.Header.Get("Accept"))
+		rw.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/api/v1/namespaces/default/configmaps/a":
+			_ = json.NewEncoder(rw).Encode(metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+		case "/api/v1/namespaces/default/configmaps":
+			list := metav1.PartialObjectMetadataList{}
+			if req.URL.Query().Get("continue") == "" {
+				list.Items = []metav1.PartialObjectMetadata{{}, {}}
+				list.Continue = "next"
+			} else {
+				list.Items = []metav1.PartialObjectMetadata{{}}
+			}
+			_ = json.NewEncoder(rw).Encode(list)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(rw).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+		}
+	}))
+	defer server.Close()
+
+	restClient, err := rest.UnversionedRESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+	})
+	require.NoError(t, err)
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	objectClient := NewObjectClient("default", client.NewClient(gvr, "ConfigMap", true, restClient, time.Minute),
+		&metav1.APIResource{Name: "configmaps", Namespaced: true}, corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		configMapFactory{})
+
+	exists, err := objectClient.Exists("default", "a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = objectClient.Exists("default", "b")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	count, err := objectClient.Count(labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []string{acceptMetadata, acceptMetadata, acceptMetadataList, acceptMetadataList}, accepts)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b", Labels: map[string]string{"app": "x"}}}))
+	require.NoError(t, indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "c", Labels: map[string]string{"app": "x"}}}))
+	cached := objectClient.WithCache(&testCache{synced: true, indexer: indexer})
+	accepts = nil
+
+	exists, err = cached.Exists("default", "b")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = cached.Exists("default", "a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	count, err = cached.Count(labels.SelectorFromSet(labels.Set{"app": "x"}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Empty(t, accepts)
+}
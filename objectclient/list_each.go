@@ -0,0 +1,54 @@
+package objectclient
+
+import (
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DefaultListChunkSize is the size of the pages of ListEach and ListChunks when opts.Limit is not set.
+const DefaultListChunkSize = 500
+
+// ListEach lists the objects matching opts in pages of opts.Limit objects, DefaultListChunkSize if not set, and
+// calls fn with each of them, so that only one page is held in memory. It stops at the first error of fn, and
+// fails if the list changed too much for the server to continue it.
+func (p *ObjectClient) ListEach(opts metav1.ListOptions, fn func(obj runtime.Object) error) error {
+	return p.ListChunks(opts, func(list runtime.Object) error {
+		return meta.EachListItem(list, fn)
+	})
+}
+
+// ListChunks is ListEach calling fn with each page, a list of the ObjectFactory.
+func (p *ObjectClient) ListChunks(opts metav1.ListOptions, fn func(list runtime.Object) error) error {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultListChunkSize
+	}
+	p.record(p.ns, "list")
+
+	for {
+		logrus.Tracef("REST LIST %s/%s/%s/%s/%s continue %q", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, p.ns,
+			p.resource.Name, opts.Continue)
+		list := p.Factory.List()
+		if err := p.backoff(func() error {
+			return p.client.List(p.ctx, p.ns, list, opts)
+		}); err != nil {
+			return err
+		}
+		if err := fn(list); err != nil {
+			return err
+		}
+
+		listMeta, err := meta.ListAccessor(list)
+		if err != nil {
+			return err
+		}
+		if listMeta.GetContinue() == "" {
+			return nil
+		}
+		opts.Continue = listMeta.GetContinue()
+		// the continue token carries the resource version of the first page
+		opts.ResourceVersion = ""
+		opts.ResourceVersionMatch = ""
+	}
+}
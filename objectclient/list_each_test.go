@@ -0,0 +1,69 @@
+package objectclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+func TestListEach(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.RawQuery)
+		list := corev1.ConfigMapList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMapList"}}
+		switch req.URL.Query().Get("continue") {
+		case "":
+			list.Items = []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "b"}}}
+			list.Continue = "next"
+		case "next":
+			list.Items = []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "c"}}}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(list)
+	}))
+	defer server.Close()
+
+	restClient, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		APIPath: "/api",
+	})
+	require.NoError(t, err)
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	objectClient := NewObjectClient("default", client.NewClient(gvr, "ConfigMap", true, restClient, time.Minute),
+		&metav1.APIResource{Name: "configmaps", Namespaced: true}, corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		configMapFactory{})
+
+	var names []string
+	err = objectClient.ListEach(metav1.ListOptions{Limit: 2}, func(obj runtime.Object) error {
+		names = append(names, obj.(*corev1.ConfigMap).Name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+	assert.Equal(t, []string{"limit=2", "continue=next&limit=2"}, requests)
+}
+
+type configMapFactory struct{}
+
+func (configMapFactory) Object() runtime.Object {
+	return &corev1.ConfigMap{}
+}
+
+func (configMapFactory) List() runtime.Object {
+	return &corev1.ConfigMapList{}
+}
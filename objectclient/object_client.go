@@ -5,6 +5,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/norman/pkg/bus"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -42,6 +43,7 @@ type GenericClient interface {
 	Delete(name string, opts *metav1.DeleteOptions) error
 	List(opts metav1.ListOptions) (runtime.Object, error)
 	ListNamespaced(namespace string, opts metav1.ListOptions) (runtime.Object, error)
+	ListEach(opts metav1.ListOptions, fn func(obj runtime.Object) error) error
 	Watch(opts metav1.ListOptions) (watch.Interface, error)
 	DeleteCollection(deleteOptions *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Patch(name string, o runtime.Object, patchType types.PatchType, data []byte, subresources ...string) (runtime.Object, error)
@@ -49,36 +51,66 @@ type GenericClient interface {
 }
 
 type ObjectClient struct {
-	ctx      context.Context
-	client   *client.Client
-	resource *metav1.APIResource
-	gvk      schema.GroupVersionKind
-	ns       string
-	Factory  ObjectFactory
+	ctx        context.Context
+	client     *client.Client
+	jsonClient *client.Client
+	resource   *metav1.APIResource
+	gvk        schema.GroupVersionKind
+	ns         string
+	Factory    ObjectFactory
+
+	changeCause string
+	watchList   bool
+	bus         *bus.Bus
+	restricted  bool
+	cache       Cache
+	throttle    *throttleState
 }
 
+// NewObjectClient returns a client sending DefaultUserAgent, unless the config of client sets a user agent, and
+// setting the change cause annotation to the name of the running binary. WithUserAgent and WithChangeCause override
+// them.
 func NewObjectClient(namespace string, client *client.Client, apiResource *metav1.APIResource, gvk schema.GroupVersionKind, factory ObjectFactory) *ObjectClient {
+	client = withDefaultUserAgent(client)
 	return &ObjectClient{
-		ctx:      context.TODO(),
-		client:   client,
-		resource: apiResource,
-		gvk:      gvk,
-		ns:       namespace,
-		Factory:  factory,
+		ctx:         context.TODO(),
+		client:      client,
+		jsonClient:  client,
+		resource:    apiResource,
+		gvk:         gvk,
+		ns:          namespace,
+		Factory:     factory,
+		changeCause: defaultControllerName,
+		throttle:    &throttleState{},
 	}
 }
 
 func (p *ObjectClient) UnstructuredClient() GenericClient {
 	return &ObjectClient{
-		ctx:      p.ctx,
-		client:   p.client,
-		resource: p.resource,
-		gvk:      p.gvk,
-		ns:       p.ns,
-		Factory:  &UnstructuredObjectFactory{},
+		ctx:        p.ctx,
+		client:     p.jsonClient,
+		jsonClient: p.jsonClient,
+		resource:   p.resource,
+		gvk:        p.gvk,
+		ns:         p.ns,
+		Factory:    &UnstructuredObjectFactory{},
+
+		changeCause: p.changeCause,
+		watchList:   p.watchList,
+		bus:         p.bus,
+		restricted:  p.restricted,
+		cache:       p.cache,
+		throttle:    p.throttle,
 	}
 }
 
+// WithContext returns a copy of the client that makes its requests with ctx.
+func (p *ObjectClient) WithContext(ctx context.Context) *ObjectClient {
+	result := *p
+	result.ctx = ctx
+	return &result
+}
+
 func (p *ObjectClient) GroupVersionKind() schema.GroupVersionKind {
 	return p.gvk
 }
@@ -96,6 +128,9 @@ func (p *ObjectClient) Create(o runtime.Object) (runtime.Object, error) {
 	if ok && obj.GetNamespace() != "" {
 		ns = obj.GetNamespace()
 	}
+	if err := p.checkTarget("create", ns, objectName(o)); err != nil {
+		return p.ObjectFactory().Object(), err
+	}
 
 	if ok {
 		labels := obj.GetLabels()
@@ -112,22 +147,37 @@ func (p *ObjectClient) Create(o runtime.Object) (runtime.Object, error) {
 		labels["cattle.io/creator"] = "norman"
 		obj.SetLabels(labels)
 	}
+	p.stampChangeCause(o)
 
 	logrus.Tracef("REST CREATE %s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, ns, p.resource.Name)
+	p.record(ns, "create")
 	result := p.ObjectFactory().Object()
-	return result, p.client.Create(p.ctx, ns, o, result, metav1.CreateOptions{})
+	err := p.backoff(func() error {
+		return p.client.Create(p.ctx, ns, o, result, metav1.CreateOptions{})
+	})
+	if err != nil {
+		return result, err
+	}
+	p.publishObject(bus.Create, result)
+	return result, nil
 }
 
 func (p *ObjectClient) GetNamespaced(namespace, name string, opts metav1.GetOptions) (runtime.Object, error) {
 	logrus.Tracef("REST GET %s/%s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, namespace, p.resource.Name, name)
+	p.record(namespace, "get")
 	result := p.Factory.Object()
-	return result, p.client.Get(p.ctx, namespace, name, result, opts)
+	return result, p.backoff(func() error {
+		return p.client.Get(p.ctx, namespace, name, result, opts)
+	})
 }
 
 func (p *ObjectClient) Get(name string, opts metav1.GetOptions) (runtime.Object, error) {
 	logrus.Tracef("REST GET %s/%s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, p.ns, p.resource.Name, name)
+	p.record(p.ns, "get")
 	result := p.Factory.Object()
-	return result, p.client.Get(p.ctx, p.ns, name, result, opts)
+	return result, p.backoff(func() error {
+		return p.client.Get(p.ctx, p.ns, name, result, opts)
+	})
 }
 
 func (p *ObjectClient) Update(name string, o runtime.Object) (runtime.Object, error) {
@@ -139,8 +189,20 @@ func (p *ObjectClient) Update(name string, o runtime.Object) (runtime.Object, er
 	if len(name) == 0 {
 		return result, errors.New("object missing name")
 	}
+	if err := p.checkTarget("update", ns, name); err != nil && !deleting(o) {
+		return result, err
+	}
+	p.stampChangeCause(o)
 	logrus.Tracef("REST UPDATE %s/%s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, ns, p.resource.Name, name)
-	return result, p.client.Update(p.ctx, ns, o, result, metav1.UpdateOptions{})
+	p.record(ns, "update")
+	err := p.backoff(func() error {
+		return p.client.Update(p.ctx, ns, o, result, metav1.UpdateOptions{})
+	})
+	if err != nil {
+		return result, p.diagnoseConflict(ns, name, o, err)
+	}
+	p.publishObject(bus.Update, result)
+	return result, nil
 }
 
 func (p *ObjectClient) UpdateStatus(name string, o runtime.Object) (runtime.Object, error) {
@@ -152,47 +214,84 @@ func (p *ObjectClient) UpdateStatus(name string, o runtime.Object) (runtime.Obje
 	if len(name) == 0 {
 		return result, errors.New("object missing name")
 	}
+	if err := p.checkTarget("update", ns, name); err != nil {
+		return result, err
+	}
 	logrus.Tracef("REST UPDATE %s/%s/%s/%s/status/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, ns, p.resource.Name, name)
-	return result, p.client.UpdateStatus(p.ctx, ns, o, result, metav1.UpdateOptions{})
+	p.record(ns, "update", "status")
+	err := p.backoff(func() error {
+		return p.client.UpdateStatus(p.ctx, ns, o, result, metav1.UpdateOptions{})
+	})
+	if err != nil {
+		return result, p.diagnoseConflict(ns, name, o, err)
+	}
+	return result, nil
 }
 
 func (p *ObjectClient) DeleteNamespaced(namespace, name string, opts *metav1.DeleteOptions) error {
+	if err := p.checkTarget("delete", namespace, name); err != nil {
+		return err
+	}
 	logrus.Tracef("REST DELETE %s/%s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, namespace, p.resource.Name, name)
 	if opts == nil {
 		opts = &metav1.DeleteOptions{}
 	}
-	return p.client.Delete(p.ctx, namespace, name, *opts)
+	p.record(namespace, "delete")
+	err := p.backoff(func() error {
+		return p.client.Delete(p.ctx, namespace, name, *opts)
+	})
+	if err != nil {
+		return err
+	}
+	p.publish(bus.Delete, namespace, name)
+	return nil
 }
 
 func (p *ObjectClient) Delete(name string, opts *metav1.DeleteOptions) error {
+	if err := p.checkTarget("delete", p.ns, name); err != nil {
+		return err
+	}
 	logrus.Tracef("REST DELETE %s/%s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, p.ns, p.resource.Name, name)
 	if opts == nil {
 		opts = &metav1.DeleteOptions{}
 	}
-	return p.client.Delete(p.ctx, p.ns, name, *opts)
+	p.record(p.ns, "delete")
+	err := p.backoff(func() error {
+		return p.client.Delete(p.ctx, p.ns, name, *opts)
+	})
+	if err != nil {
+		return err
+	}
+	p.publish(bus.Delete, p.ns, name)
+	return nil
 }
 
 func (p *ObjectClient) List(opts metav1.ListOptions) (runtime.Object, error) {
-	result := p.Factory.List()
 	logrus.Tracef("REST LIST %s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, p.ns, p.resource.Name)
-	return result, p.client.List(p.ctx, p.ns, result, opts)
+	return p.list(p.ns, opts)
 }
 
 func (p *ObjectClient) ListNamespaced(namespace string, opts metav1.ListOptions) (runtime.Object, error) {
-	result := p.Factory.List()
 	logrus.Tracef("REST LIST %s/%s/%s/%s/%s", p.getAPIPrefix(), p.gvk.Group, p.gvk.Version, namespace, p.resource.Name)
-	return result, p.client.List(p.ctx, namespace, result, opts)
+	return p.list(namespace, opts)
 }
 
 func (p *ObjectClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	p.record(p.ns, "watch")
 	return p.client.Watch(p.ctx, p.ns, opts)
 }
 
 func (p *ObjectClient) DeleteCollection(deleteOptions *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	if err := p.checkTarget("deletecollection", p.ns, ""); err != nil {
+		return err
+	}
 	if deleteOptions == nil {
 		deleteOptions = &metav1.DeleteOptions{}
 	}
-	return p.client.DeleteCollection(p.ctx, p.ns, *deleteOptions, listOptions)
+	p.record(p.ns, "deletecollection")
+	return p.backoff(func() error {
+		return p.client.DeleteCollection(p.ctx, p.ns, *deleteOptions, listOptions)
+	})
 }
 
 func (p *ObjectClient) Patch(name string, o runtime.Object, patchType types.PatchType, data []byte, subresources ...string) (runtime.Object, error) {
@@ -204,7 +303,14 @@ func (p *ObjectClient) Patch(name string, o runtime.Object, patchType types.Patc
 	if len(name) == 0 {
 		return result, errors.New("object missing name")
 	}
-	return result, p.client.Patch(p.ctx, ns, name, patchType, data, result, metav1.PatchOptions{}, subresources...)
+	if err := p.checkTarget("patch", ns, name); err != nil {
+		return result, err
+	}
+	data = p.stampChangeCausePatch(patchType, data, subresources)
+	p.record(ns, "patch", subresources...)
+	return result, p.backoff(func() error {
+		return p.client.Patch(p.ctx, ns, name, patchType, data, result, metav1.PatchOptions{}, subresources...)
+	})
 }
 
 func (p *ObjectClient) ObjectFactory() ObjectFactory {
@@ -0,0 +1,73 @@
+package objectclient
+
+import (
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// NewProtobufObjectClient is NewObjectClient for built-in Kubernetes kinds, which are sent and received as
+// protobuf. Kinds that are not in the client-go scheme, such as CRDs, and UnstructuredClient keep using JSON.
+func NewProtobufObjectClient(namespace string, c *client.Client, apiResource *metav1.APIResource, gvk schema.GroupVersionKind, factory ObjectFactory) *ObjectClient {
+	objectClient := NewObjectClient(namespace, c, apiResource, gvk, factory)
+	if !scheme.Scheme.Recognizes(gvk) || c.Config.Host == "" {
+		return objectClient
+	}
+
+	protobufClient, err := derived(objectClient.client, "protobuf", withProtobuf)
+	if err != nil {
+		logrus.Debugf("using JSON for %v: %v", gvk, err)
+		return objectClient
+	}
+	objectClient.client = protobufClient
+	return objectClient
+}
+
+const (
+	derivedClientCacheSize = 1024
+	derivedClientTTL       = time.Hour
+)
+
+// derivedClients holds the clients built from the shared clients of lasso, which are built once per resource, so
+// that building an object client does not build a REST client each time.
+var derivedClients = cache.NewLRUExpireCache(derivedClientCacheSize)
+
+type derivedClientKey struct {
+	client  *client.Client
+	variant string
+}
+
+// derived returns the client built from c by build, named variant, building it only if it is not cached.
+func derived(c *client.Client, variant string, build func(*client.Client) (*client.Client, error)) (*client.Client, error) {
+	key := derivedClientKey{client: c, variant: variant}
+	if result, ok := derivedClients.Get(key); ok {
+		return result.(*client.Client), nil
+	}
+	result, err := build(c)
+	if err != nil {
+		return nil, err
+	}
+	derivedClients.Add(key, result, derivedClientTTL)
+	return result, nil
+}
+
+func withProtobuf(c *client.Client) (*client.Client, error) {
+	protobufClient := *c
+	config := c.Config
+	config.ContentType = runtime.ContentTypeProtobuf
+	config.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	restClient, err := rest.UnversionedRESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	protobufClient.RESTClient = restClient
+	protobufClient.Config = config
+	return &protobufClient, nil
+}
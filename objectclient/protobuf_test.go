@@ -0,0 +1,43 @@
+package objectclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+func TestProtobufObjectClientReusesRESTClients(t *testing.T) {
+	config := rest.Config{
+		Host: "https://localhost:6443",
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		APIPath: "/api",
+	}
+	restClient, err := rest.RESTClientFor(&config)
+	require.NoError(t, err)
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	shared := client.NewClient(gvr, "ConfigMap", true, restClient, time.Minute)
+	shared.Config = config
+
+	newClient := func() *ObjectClient {
+		return NewProtobufObjectClient("default", shared, &metav1.APIResource{Name: "configmaps", Namespaced: true},
+			corev1.SchemeGroupVersion.WithKind("ConfigMap"), configMapFactory{})
+	}
+	first, second := newClient(), newClient()
+
+	assert.Equal(t, runtime.ContentTypeProtobuf, first.client.Config.ContentType)
+	assert.Equal(t, DefaultUserAgent(), first.client.Config.UserAgent)
+	assert.Same(t, first.client, second.client)
+	assert.Same(t, first.jsonClient, second.jsonClient)
+	assert.NotSame(t, shared, first.jsonClient)
+}
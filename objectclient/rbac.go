@@ -0,0 +1,24 @@
+package objectclient
+
+import (
+	"strings"
+
+	"github.com/rancher/norman/pkg/rbac"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// record records verb on the resource, or its subresources, in namespace for the generated RBAC rules.
+func (p *ObjectClient) record(namespace, verb string, subresources ...string) {
+	if p.resource == nil {
+		return
+	}
+	resource := p.resource.Name
+	if len(subresources) > 0 {
+		resource += "/" + strings.Join(subresources, "/")
+	}
+	rbac.Record(schema.GroupVersionResource{
+		Group:    p.gvk.Group,
+		Version:  p.gvk.Version,
+		Resource: resource,
+	}, namespace, verb)
+}
@@ -0,0 +1,93 @@
+package objectclient
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var restrictedTargets atomic.Pointer[func(gvk schema.GroupVersionKind, namespace string) bool]
+
+// RestrictTargets registers the objects that must not be written to, by kind and namespace, "" for cluster scoped
+// objects. The clients returned by WithRestrictedTargets refuse to write them, and lifecycles using these clients
+// only finalize them. nil removes the restrictions.
+func RestrictTargets(restricted func(gvk schema.GroupVersionKind, namespace string) bool) {
+	if restricted == nil {
+		restrictedTargets.Store(nil)
+		return
+	}
+	restrictedTargets.Store(&restricted)
+}
+
+// IsRestrictedTarget returns whether objects of gvk in namespace are restricted by RestrictTargets.
+func IsRestrictedTarget(gvk schema.GroupVersionKind, namespace string) bool {
+	restricted := restrictedTargets.Load()
+	return restricted != nil && (*restricted)(gvk, namespace)
+}
+
+// ErrRestrictedTarget is returned by the writes of clients returned by WithRestrictedTargets to restricted objects.
+type ErrRestrictedTarget struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+	Verb      string
+}
+
+func (e *ErrRestrictedTarget) Error() string {
+	target := e.Name
+	if e.Namespace != "" {
+		target = e.Namespace + "/" + e.Name
+	}
+	return fmt.Sprintf("%s of %s %s is restricted", e.Verb, e.GVK.Kind, target)
+}
+
+// IsRestrictedTargetError returns whether err is, or wraps, an ErrRestrictedTarget.
+func IsRestrictedTargetError(err error) bool {
+	var restricted *ErrRestrictedTarget
+	return errors.As(err, &restricted)
+}
+
+// WithRestrictedTargets returns a copy of the client that refuses to create, update, patch and delete the objects
+// restricted by RestrictTargets, with an ErrRestrictedTarget. Objects being deleted can still be updated, for
+// their finalizers to be removed.
+func (p *ObjectClient) WithRestrictedTargets() *ObjectClient {
+	result := *p
+	result.restricted = true
+	return &result
+}
+
+// RestrictsTargets returns whether the client refuses writes to the objects restricted by RestrictTargets.
+func (p *ObjectClient) RestrictsTargets() bool {
+	return p.restricted
+}
+
+func (p *ObjectClient) checkTarget(verb, namespace, name string) error {
+	if !p.restricted || !IsRestrictedTarget(p.gvk, namespace) {
+		return nil
+	}
+	return &ErrRestrictedTarget{
+		GVK:       p.gvk,
+		Namespace: namespace,
+		Name:      name,
+		Verb:      verb,
+	}
+}
+
+func deleting(o runtime.Object) bool {
+	obj, ok := o.(metav1.Object)
+	return ok && obj.GetDeletionTimestamp() != nil
+}
+
+func objectName(o runtime.Object) string {
+	if obj, ok := o.(metav1.Object); ok {
+		if obj.GetName() != "" {
+			return obj.GetName()
+		}
+		return obj.GetGenerateName()
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package objectclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRestrictedTargets(t *testing.T) {
+	defer RestrictTargets(nil)
+	secrets := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	RestrictTargets(func(gvk schema.GroupVersionKind, namespace string) bool {
+		return gvk == secrets && namespace == "kube-system"
+	})
+	assert.True(t, IsRestrictedTarget(secrets, "kube-system"))
+	assert.False(t, IsRestrictedTarget(secrets, "default"))
+
+	client := (&ObjectClient{
+		gvk:      secrets,
+		resource: &metav1.APIResource{Name: "secrets"},
+		Factory:  &UnstructuredObjectFactory{},
+	}).WithRestrictedTargets()
+
+	_, err := client.Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "token"}})
+	assert.EqualError(t, err, "create of Secret kube-system/token is restricted")
+	assert.True(t, IsRestrictedTargetError(err))
+
+	// objects being deleted can be updated to remove their finalizers
+	_, err = client.Update("token", &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "token"}})
+	assert.True(t, IsRestrictedTargetError(err))
+	assert.True(t, client.RestrictsTargets())
+
+	err = client.DeleteNamespaced("kube-system", "token", nil)
+	assert.True(t, IsRestrictedTargetError(err))
+	assert.True(t, client.UnstructuredClient().(*ObjectClient).restricted)
+}
@@ -0,0 +1,100 @@
+package objectclient
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rancher/norman/metrics"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	minThrottleDelay   = 250 * time.Millisecond
+	maxThrottleDelay   = 30 * time.Second
+	maxThrottleRetries = 5
+)
+
+var throttleHandler atomic.Pointer[func(gvk schema.GroupVersionKind, delay time.Duration)]
+
+// OnThrottle calls handler each time a request of an object client is throttled, with the delay before it is
+// retried, so that controllers can pause their queue for a while. nil removes the handler.
+func OnThrottle(handler func(gvk schema.GroupVersionKind, delay time.Duration)) {
+	if handler == nil {
+		throttleHandler.Store(nil)
+		return
+	}
+	throttleHandler.Store(&handler)
+}
+
+// throttleState is the delay of the next retry of the requests of a client, it doubles while they are throttled and
+// is reset by the first request that isn't.
+type throttleState struct {
+	sync.Mutex
+	delay time.Duration
+}
+
+func (t *throttleState) next(suggested time.Duration) time.Duration {
+	t.Lock()
+	defer t.Unlock()
+
+	delay := t.delay * 2
+	if delay < minThrottleDelay {
+		delay = minThrottleDelay
+	}
+	if delay < suggested {
+		delay = suggested
+	}
+	if delay > maxThrottleDelay {
+		delay = maxThrottleDelay
+	}
+	t.delay = delay
+	return delay
+}
+
+func (t *throttleState) reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.delay = 0
+}
+
+// backoff retries f while the apiserver answers 429 without a Retry-After or the client rate limiter gives up. The
+// rest client already retries the 429 answers with a Retry-After, those are only reported once it gives up.
+func (p *ObjectClient) backoff(f func() error) error {
+	for i := 0; ; i++ {
+		err := f()
+		if !isThrottled(err) {
+			p.throttle.reset()
+			return err
+		}
+
+		metrics.IncObjectClientThrottled(p.resource.Name)
+		suggested, retriedByClient := apierrors.SuggestsClientDelay(err)
+		delay := p.throttle.next(time.Duration(suggested) * time.Second)
+		if handler := throttleHandler.Load(); handler != nil {
+			(*handler)(p.gvk, delay)
+		}
+		if retriedByClient || i >= maxThrottleRetries {
+			return err
+		}
+
+		logrus.Debugf("requests of %s are throttled, retrying in %v: %v", p.resource.Name, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-p.ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+func isThrottled(err error) bool {
+	if err == nil {
+		return false
+	}
+	return apierrors.IsTooManyRequests(err) || strings.Contains(err.Error(), "client rate limiter")
+}
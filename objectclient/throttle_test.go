@@ -0,0 +1,80 @@
+package objectclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestThrottleDelay(t *testing.T) {
+	state := throttleState{}
+
+	assert.Equal(t, minThrottleDelay, state.next(0))
+	assert.Equal(t, 2*minThrottleDelay, state.next(0))
+	assert.Equal(t, 5*time.Second, state.next(5*time.Second))
+	assert.Equal(t, maxThrottleDelay, state.next(time.Minute))
+
+	state.reset()
+	assert.Equal(t, minThrottleDelay, state.next(0))
+}
+
+func TestBackoff(t *testing.T) {
+	defer OnThrottle(nil)
+	var delays []time.Duration
+	OnThrottle(func(gvk schema.GroupVersionKind, delay time.Duration) {
+		delays = append(delays, delay)
+	})
+
+	client := &ObjectClient{
+		ctx:      context.Background(),
+		resource: &metav1.APIResource{Name: "backofftest"},
+		throttle: &throttleState{},
+	}
+
+	calls := 0
+	err := client.backoff(func() error {
+		calls++
+		if calls < 3 {
+			return apierrors.NewTooManyRequests("slow down", 0)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{minThrottleDelay, 2 * minThrottleDelay}, delays)
+
+	calls = 0
+	err = client.backoff(func() error {
+		calls++
+		return errors.New("other")
+	})
+	assert.EqualError(t, err, "other")
+	assert.Equal(t, 1, calls)
+
+	// the rest client already retried the answers with a Retry-After
+	delays = nil
+	calls = 0
+	err = client.backoff(func() error {
+		calls++
+		return apierrors.NewTooManyRequests("slow down", 1)
+	})
+	assert.True(t, apierrors.IsTooManyRequests(err))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []time.Duration{time.Second}, delays)
+}
+
+func TestBackoffStatePerClient(t *testing.T) {
+	throttled := &ObjectClient{ctx: context.Background(), resource: &metav1.APIResource{Name: "pods"}, throttle: &throttleState{}}
+	other := &ObjectClient{ctx: context.Background(), resource: &metav1.APIResource{Name: "pods"}, throttle: &throttleState{}}
+
+	throttled.throttle.next(0)
+	throttled.throttle.next(0)
+	assert.Equal(t, minThrottleDelay, other.throttle.next(0))
+	assert.Equal(t, 4*minThrottleDelay, throttled.throttle.next(0))
+}
@@ -0,0 +1,135 @@
+package objectclient
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metainternalversionvalidation "k8s.io/apimachinery/pkg/apis/meta/internalversion/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/utils/ptr"
+)
+
+var (
+	errWatchListClosed = errors.New("watch closed before the initial events were received")
+	watchListScheme    = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(metainternalversion.AddToScheme(watchListScheme))
+}
+
+// WithWatchList returns a copy of the client whose lists receive their initial state as a stream of watch events
+// (sendInitialEvents) instead of a single large LIST response. Informers are not affected, they use watch-list when
+// the client-go WatchListClient feature gate is enabled, for example with KUBE_FEATURE_WatchListClient=true.
+func (p *ObjectClient) WithWatchList() *ObjectClient {
+	result := *p
+	result.watchList = true
+	return &result
+}
+
+// watchListOptions returns the options of a watch-list request equivalent to opts, like
+// watchlist.PrepareWatchListOptionsFromListOptions, and false if there is none.
+func watchListOptions(opts metav1.ListOptions) (metav1.ListOptions, bool, error) {
+	internalOpts := &metainternalversion.ListOptions{}
+	if err := watchListScheme.Convert(&opts, internalOpts, nil); err != nil {
+		return metav1.ListOptions{}, false, err
+	}
+	if errs := metainternalversionvalidation.ValidateListOptions(internalOpts, true); len(errs) > 0 {
+		return metav1.ListOptions{}, false, nil
+	}
+	// the watch cache ignores the limit of lists at resource version 0
+	if opts.Limit > 0 && opts.ResourceVersion != "0" {
+		return metav1.ListOptions{}, false, nil
+	}
+	if opts.ResourceVersionMatch == metav1.ResourceVersionMatchExact {
+		return metav1.ListOptions{}, false, nil
+	}
+
+	result := opts
+	result.Limit = 0
+	result.ResourceVersionMatch = metav1.ResourceVersionMatchNotOlderThan
+	result.Watch = true
+	result.AllowWatchBookmarks = true
+	result.SendInitialEvents = ptr.To(true)
+
+	internalOpts = &metainternalversion.ListOptions{}
+	if err := watchListScheme.Convert(&result, internalOpts, nil); err != nil {
+		return metav1.ListOptions{}, false, err
+	}
+	if errs := metainternalversionvalidation.ValidateListOptions(internalOpts, true); len(errs) > 0 {
+		return metav1.ListOptions{}, false, nil
+	}
+	return result, true, nil
+}
+
+// list falls back to a regular LIST when watch-list is not enabled on the client, does not apply to opts, or is rejected by the
+// server.
+func (p *ObjectClient) list(namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+	p.record(namespace, "list")
+	if p.watchList {
+		watchListOpts, ok, err := watchListOptions(opts)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result, err := p.streamList(namespace, watchListOpts)
+			if err == nil {
+				return result, nil
+			}
+			if !apierrors.IsInvalid(err) && !apierrors.IsBadRequest(err) {
+				return nil, err
+			}
+			logrus.Debugf("watch-list of %s rejected, falling back to list: %v", p.resource.Name, err)
+		}
+	}
+
+	result := p.Factory.List()
+	return result, p.backoff(func() error {
+		return p.client.List(p.ctx, namespace, result, opts)
+	})
+}
+
+func (p *ObjectClient) streamList(namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+	p.record(namespace, "watch")
+	w, err := p.client.Watch(p.ctx, namespace, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+
+	var items []runtime.Object
+	for event := range w.ResultChan() {
+		switch event.Type {
+		case watch.Error:
+			return nil, apierrors.FromObject(event.Object)
+		case watch.Added:
+			items = append(items, event.Object)
+		case watch.Bookmark:
+			metadata, err := meta.Accessor(event.Object)
+			if err != nil {
+				return nil, err
+			}
+			if metadata.GetAnnotations()[metav1.InitialEventsAnnotationKey] != "true" {
+				continue
+			}
+
+			result := p.Factory.List()
+			if err := meta.SetList(result, items); err != nil {
+				return nil, err
+			}
+			listMeta, err := meta.ListAccessor(result)
+			if err != nil {
+				return nil, err
+			}
+			listMeta.SetResourceVersion(metadata.GetResourceVersion())
+			return result, nil
+		}
+	}
+
+	return nil, apierrors.NewInternalError(errWatchListClosed)
+}
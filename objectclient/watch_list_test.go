@@ -0,0 +1,79 @@
+package objectclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+func TestWatchList(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.RawQuery)
+		rw.Header().Set("Content-Type", "application/json")
+		if req.URL.Query().Get("watch") != "true" {
+			_ = json.NewEncoder(rw).Encode(corev1.ConfigMapList{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMapList"},
+				ListMeta: metav1.ListMeta{ResourceVersion: "5"},
+				Items:    []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}},
+			})
+			return
+		}
+		encoder := json.NewEncoder(rw)
+		_ = encoder.Encode(metav1.WatchEvent{Type: "ADDED", Object: jsonObject(t, &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "4"},
+		})})
+		_ = encoder.Encode(metav1.WatchEvent{Type: "BOOKMARK", Object: jsonObject(t, &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{ResourceVersion: "5", Annotations: map[string]string{
+				metav1.InitialEventsAnnotationKey: "true",
+			}},
+		})})
+	}))
+	defer server.Close()
+
+	restClient, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &corev1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		APIPath: "/api",
+	})
+	require.NoError(t, err)
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	objectClient := NewObjectClient("default", client.NewClient(gvr, "ConfigMap", true, restClient, time.Minute),
+		&metav1.APIResource{Name: "configmaps", Namespaced: true}, corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		configMapFactory{})
+
+	for _, c := range []*ObjectClient{objectClient, objectClient.WithWatchList()} {
+		result, err := c.List(metav1.ListOptions{})
+		require.NoError(t, err)
+		list := result.(*corev1.ConfigMapList)
+		assert.Equal(t, "5", list.ResourceVersion)
+		require.Len(t, list.Items, 1)
+		assert.Equal(t, "a", list.Items[0].Name)
+	}
+	require.Len(t, requests, 2)
+	assert.Equal(t, "", requests[0])
+	assert.Contains(t, requests[1], "sendInitialEvents=true")
+	assert.Contains(t, requests[1], "watch=true")
+}
+
+func jsonObject(t *testing.T, obj interface{}) runtime.RawExtension {
+	data, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return runtime.RawExtension{Raw: data}
+}
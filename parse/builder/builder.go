@@ -4,11 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/types/convert"
 	"github.com/rancher/norman/types/definition"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
@@ -32,9 +34,12 @@ type Builder struct {
 	Version      *types.APIVersion
 	Schemas      *types.Schemas
 	RefValidator types.ReferenceValidator
-	edit         bool
-	export       bool
-	yaml         bool
+	// Existing is the object an update applies to, the rules of its schema are checked on the update merged into
+	// it. Without it, updates are only checked on the rules of the fields they set.
+	Existing map[string]interface{}
+	edit     bool
+	export   bool
+	yaml     bool
 }
 
 func NewBuilder(apiRequest *types.APIContext) *Builder {
@@ -50,10 +55,30 @@ func NewBuilder(apiRequest *types.APIContext) *Builder {
 }
 
 func (b *Builder) Construct(schema *types.Schema, input map[string]interface{}, op Operation) (map[string]interface{}, error) {
+	// Existing is the object of schema, not of the nested types constructed with it
+	existing := b.Existing
+	b.Existing = nil
 	result, err := b.copyFields(schema, input, op)
+	b.Existing = existing
 	if err != nil {
 		return nil, err
 	}
+	if op == Update && existing != nil {
+		merged := map[string]interface{}{}
+		for k, v := range existing {
+			merged[k] = v
+		}
+		for k, v := range result {
+			merged[k] = v
+		}
+		if err := checkRules(schema, nil, merged, Create); err != nil {
+			return nil, err
+		}
+	} else if op == Create || op == Update {
+		if err := checkRules(schema, input, result, op); err != nil {
+			return nil, err
+		}
+	}
 	if (op == Create || op == Update) && schema.Validator != nil {
 		if err := schema.Validator(b.apiContext, schema, result); err != nil {
 			return nil, err
@@ -62,6 +87,44 @@ func (b *Builder) Construct(schema *types.Schema, input map[string]interface{},
 	return result, nil
 }
 
+func checkRules(schema *types.Schema, input, data map[string]interface{}, op Operation) error {
+	var (
+		fieldNames []string
+		messages   []string
+		seen       = map[string]bool{}
+	)
+
+	for _, rule := range schema.Rules {
+		if op == Update && !hasAnyField(input, rule.Fields) {
+			continue
+		}
+		if rule.Check(data) {
+			continue
+		}
+		messages = append(messages, rule.Message)
+		for _, field := range rule.Fields {
+			if !seen[field] {
+				seen[field] = true
+				fieldNames = append(fieldNames, field)
+			}
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	return httperror.NewFieldsAPIError(httperror.InvalidFieldCombination, fieldNames, strings.Join(messages, "; "))
+}
+
+func hasAnyField(input map[string]interface{}, fields []string) bool {
+	for _, field := range fields {
+		if _, ok := input[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *Builder) copyInputs(schema *types.Schema, input map[string]interface{}, op Operation, result map[string]interface{}) error {
 	for fieldName, value := range input {
 		field, ok := schema.ResourceFields[fieldName]
@@ -73,6 +136,19 @@ func (b *Builder) copyInputs(schema *types.Schema, input map[string]interface{},
 			continue
 		}
 
+		if op.IsList() && !b.apiContext.HasAnyRole(field.ReadRoles) {
+			continue
+		}
+		if (op == Create || op == Update) && !b.apiContext.HasAnyRole(field.WriteRoles) {
+			return httperror.NewFieldAPIError(httperror.PermissionDenied, fieldName, "not allowed to set the field")
+		}
+
+		var deletes []interface{}
+		if field.MergeStrategy == types.MergeStrategyMerge {
+			value, deletes = splitDeletes(field, value, op)
+		}
+
+		// null clears a nullable field, which is different from leaving it out of an update, and is ignored for others
 		wasNull := value == nil && (field.Nullable || field.Default == nil)
 		value, err := b.convert(field.Type, value, op)
 		if err != nil {
@@ -90,6 +166,12 @@ func (b *Builder) copyInputs(schema *types.Schema, input map[string]interface{},
 							return err
 						}
 					}
+					if err := checkMergeKeys(fieldName, field, append(slice, deletes...)); err != nil {
+						return err
+					}
+					if len(deletes) > 0 {
+						value = append(slice, deletes...)
+					}
 				} else {
 					if err := CheckFieldCriteria(fieldName, field, value); err != nil {
 						return err
@@ -119,6 +201,56 @@ func (b *Builder) copyInputs(schema *types.Schema, input map[string]interface{},
 	return nil
 }
 
+// splitDeletes removes the elements with a delete PatchDirective from value, an array merged by key, and returns
+// them reduced to their key and directive for updates, as they are not elements of the array type.
+func splitDeletes(field types.Field, value interface{}, op Operation) (interface{}, []interface{}) {
+	slice, ok := value.([]interface{})
+	if !ok {
+		return value, nil
+	}
+
+	var (
+		values  []interface{}
+		deletes []interface{}
+	)
+	for _, element := range slice {
+		m, ok := element.(map[string]interface{})
+		if !ok || m[types.PatchDirective] != types.PatchDelete {
+			values = append(values, element)
+			continue
+		}
+		if op == Update {
+			deletes = append(deletes, map[string]interface{}{
+				field.MergeKey:       m[field.MergeKey],
+				types.PatchDirective: types.PatchDelete,
+			})
+		}
+	}
+	if values == nil {
+		values = []interface{}{}
+	}
+	return values, deletes
+}
+
+// checkMergeKeys checks that the elements of an array merged by key all have a key, and that keys are unique.
+func checkMergeKeys(fieldName string, field types.Field, slice []interface{}) error {
+	if field.MergeStrategy != types.MergeStrategyMerge {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, value := range slice {
+		key := convert.ToString(convert.ToMapInterface(value)[field.MergeKey])
+		if key == "" {
+			return httperror.NewFieldAPIError(httperror.MissingRequired, fieldName, fmt.Sprintf("%s is required on each element", field.MergeKey))
+		}
+		if seen[key] {
+			return httperror.NewFieldAPIError(httperror.NotUnique, fieldName, fmt.Sprintf("%s %s is repeated", field.MergeKey, key))
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
 func (b *Builder) checkDefaultAndRequired(schema *types.Schema, input map[string]interface{}, op Operation, result map[string]interface{}) error {
 	for fieldName, field := range schema.ResourceFields {
 		val, hasKey := result[fieldName]
@@ -320,6 +452,39 @@ func ConvertSimple(fieldType string, value interface{}, op Operation) (interface
 		if v == "" {
 			return nil, nil
 		}
+		if op == Create || op == Update {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return value, httperror.NewAPIError(httperror.InvalidDateFormat, fmt.Sprintf("invalid RFC3339 timestamp %s", v))
+			}
+			return t.UTC().Format(time.RFC3339), nil
+		}
+		return v, nil
+	case "duration":
+		v := convert.ToString(value)
+		if v == "" {
+			return nil, nil
+		}
+		if op == Create || op == Update {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return value, httperror.NewAPIError(httperror.InvalidFormat, fmt.Sprintf("invalid duration %s", v))
+			}
+			return d.String(), nil
+		}
+		return v, nil
+	case "quantity":
+		v := convert.ToString(value)
+		if v == "" {
+			return nil, nil
+		}
+		if op == Create || op == Update {
+			q, err := resource.ParseQuantity(v)
+			if err != nil {
+				return value, httperror.NewAPIError(httperror.InvalidFormat, fmt.Sprintf("invalid quantity %s", v))
+			}
+			return q.String(), nil
+		}
 		return v, nil
 	case "boolean":
 		return convert.ToBool(value), nil
@@ -398,7 +563,7 @@ func (b *Builder) convert(fieldType string, value interface{}, op Operation) (in
 	case definition.IsArrayType(fieldType):
 		return b.convertArray(fieldType, value, op)
 	case definition.IsReferenceType(fieldType):
-		return b.convertReferenceType(fieldType, value)
+		return b.convertReferenceType(fieldType, value, op)
 	}
 
 	newValue, err := ConvertSimple(fieldType, value, op)
@@ -422,9 +587,14 @@ func (b *Builder) convertType(fieldType string, value interface{}, op Operation)
 	return b.Construct(schema, mapValue, op)
 }
 
-func (b *Builder) convertReferenceType(fieldType string, value interface{}) (string, error) {
+// convertReferenceType checks that the referenced object exists when it is set by a create, update or action
+// input.
+func (b *Builder) convertReferenceType(fieldType string, value interface{}, op Operation) (string, error) {
 	subType := definition.SubType(fieldType)
 	strVal := convert.ToString(value)
+	if strVal == "" || op.IsList() {
+		return strVal, nil
+	}
 	if b.RefValidator != nil && !b.RefValidator.Validate(subType, strVal) {
 		return "", httperror.NewAPIError(httperror.InvalidReference, fmt.Sprintf("Not found type: %s id: %s", subType, strVal))
 	}
@@ -3,8 +3,10 @@ package builder
 import (
 	"testing"
 
+	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEmptyStringWithDefault(t *testing.T) {
@@ -42,3 +44,230 @@ func TestEmptyStringWithDefault(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, "foo", value)
 }
+
+func TestRules(t *testing.T) {
+	schema := &types.Schema{
+		ResourceFields: map[string]types.Field{
+			"minReplicas": {Type: "int", Create: true, Update: true, Nullable: true},
+			"maxReplicas": {Type: "int", Create: true, Update: true, Nullable: true},
+			"image":       {Type: "string", Create: true, Update: true, Nullable: true},
+			"imageRef":    {Type: "string", Create: true, Update: true, Nullable: true},
+		},
+		Rules: []types.Rule{
+			types.LessOrEqual("minReplicas", "maxReplicas"),
+			types.ExactlyOneOf("image", "imageRef"),
+		},
+	}
+
+	builder := NewBuilder(&types.APIContext{})
+
+	_, err := builder.Construct(schema, map[string]interface{}{
+		"minReplicas": 1,
+		"maxReplicas": 2,
+		"image":       "nginx",
+	}, Create)
+	assert.NoError(t, err)
+
+	_, err = builder.Construct(schema, map[string]interface{}{
+		"minReplicas": 3,
+		"maxReplicas": 2,
+	}, Create)
+	apiError, ok := err.(*httperror.APIError)
+	if assert.True(t, ok) {
+		assert.Equal(t, httperror.InvalidFieldCombination, apiError.Code)
+		assert.Equal(t, []string{"minReplicas", "maxReplicas", "image", "imageRef"}, apiError.FieldNames)
+	}
+
+	// updates are checked merged into the existing object
+	builder.Existing = map[string]interface{}{
+		"minReplicas": int64(1),
+		"maxReplicas": int64(2),
+		"image":       "nginx",
+	}
+	_, err = builder.Construct(schema, map[string]interface{}{
+		"minReplicas": 3,
+	}, Update)
+	apiError, ok = err.(*httperror.APIError)
+	if assert.True(t, ok) {
+		assert.Equal(t, httperror.InvalidFieldCombination, apiError.Code)
+		assert.Equal(t, []string{"minReplicas", "maxReplicas"}, apiError.FieldNames)
+	}
+
+	_, err = builder.Construct(schema, map[string]interface{}{
+		"minReplicas": 2,
+		"imageRef":    nil,
+	}, Update)
+	assert.NoError(t, err)
+
+	_, err = builder.Construct(schema, map[string]interface{}{
+		"imageRef": "registry/nginx",
+	}, Update)
+	assert.Error(t, err)
+}
+
+func TestConvertTimeDurationQuantity(t *testing.T) {
+	tests := []struct {
+		fieldType string
+		value     interface{}
+		expected  interface{}
+		invalid   bool
+	}{
+		{fieldType: "date", value: "2024-03-01T10:00:00+02:00", expected: "2024-03-01T08:00:00Z"},
+		{fieldType: "date", value: "yesterday", invalid: true},
+		{fieldType: "duration", value: "90s", expected: "1m30s"},
+		{fieldType: "duration", value: "5", invalid: true},
+		{fieldType: "quantity", value: "1024Mi", expected: "1Gi"},
+		{fieldType: "quantity", value: 2, expected: "2"},
+		{fieldType: "quantity", value: "lots", invalid: true},
+	}
+
+	for _, test := range tests {
+		result, err := ConvertSimple(test.fieldType, test.value, Create)
+		if test.invalid {
+			assert.Error(t, err, "%s %v", test.fieldType, test.value)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, result)
+	}
+}
+
+func TestFieldRoles(t *testing.T) {
+	schema := &types.Schema{
+		ResourceFields: map[string]types.Field{
+			"name":           {Type: "string", Create: true, Update: true},
+			"internalConfig": {Type: "string", Create: true, Update: true, ReadRoles: []string{"admin"}, WriteRoles: []string{"admin"}},
+		},
+	}
+	data := map[string]interface{}{
+		"name":           "foo",
+		"internalConfig": "secret",
+	}
+
+	user := NewBuilder(&types.APIContext{User: &types.User{Name: "user"}})
+	result, err := user.Construct(schema, data, List)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "foo"}, result)
+
+	_, err = user.Construct(schema, data, Update)
+	assert.Equal(t, httperror.PermissionDenied, err.(*httperror.APIError).Code)
+
+	admin := NewBuilder(&types.APIContext{
+		User: &types.User{Name: "admin"},
+		RoleResolver: func(apiContext *types.APIContext) []string {
+			return []string{"admin"}
+		},
+	})
+	result, err = admin.Construct(schema, data, List)
+	require.NoError(t, err)
+	assert.Equal(t, data, result)
+
+	_, err = admin.Construct(schema, data, Update)
+	assert.NoError(t, err)
+}
+
+func TestUpdateNull(t *testing.T) {
+	schema := &types.Schema{
+		ResourceFields: map[string]types.Field{
+			"enabled": {Type: "boolean", Create: true, Update: true, Nullable: true},
+			"flag":    {Type: "boolean", Create: true, Update: true, Default: false},
+		},
+	}
+	builder := NewBuilder(&types.APIContext{})
+
+	result, err := builder.Construct(schema, map[string]interface{}{"enabled": nil}, Update)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"enabled": nil}, result)
+
+	result, err = builder.Construct(schema, map[string]interface{}{"enabled": false}, Update)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"enabled": false}, result)
+
+	result, err = builder.Construct(schema, map[string]interface{}{}, Update)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	result, err = builder.Construct(schema, map[string]interface{}{"flag": nil}, Update)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestMergeKeys(t *testing.T) {
+	version := types.APIVersion{Version: "v1", Group: "example.cattle.io", Path: "/v1"}
+	schemas := types.NewSchemas()
+	schemas.AddSchema(types.Schema{
+		ID:      "envVar",
+		Version: version,
+		ResourceFields: map[string]types.Field{
+			"name":  {Type: "string", Create: true, Update: true},
+			"value": {Type: "string", Create: true, Update: true},
+		},
+	})
+	schema := &types.Schema{
+		ResourceFields: map[string]types.Field{
+			"env": {Type: "array[envVar]", Create: true, Update: true, MergeStrategy: types.MergeStrategyMerge, MergeKey: "name"},
+		},
+	}
+	builder := NewBuilder(&types.APIContext{Schemas: schemas, Version: &version})
+
+	_, err := builder.Construct(schema, map[string]interface{}{"env": []interface{}{
+		map[string]interface{}{"name": "A", "value": "1"},
+	}}, Update)
+	require.NoError(t, err)
+
+	_, err = builder.Construct(schema, map[string]interface{}{"env": []interface{}{
+		map[string]interface{}{"value": "1"},
+	}}, Update)
+	assert.Equal(t, httperror.MissingRequired, err.(*httperror.APIError).Code)
+
+	_, err = builder.Construct(schema, map[string]interface{}{"env": []interface{}{
+		map[string]interface{}{"name": "A", "value": "1"},
+		map[string]interface{}{"name": "A", "value": "2"},
+	}}, Create)
+	assert.Equal(t, httperror.NotUnique, err.(*httperror.APIError).Code)
+
+	result, err := builder.Construct(schema, map[string]interface{}{"env": []interface{}{
+		map[string]interface{}{"name": "A", "value": "1"},
+		map[string]interface{}{"name": "B", "value": "ignored", types.PatchDirective: types.PatchDelete},
+	}}, Update)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "A", "value": "1"},
+		map[string]interface{}{"name": "B", types.PatchDirective: types.PatchDelete},
+	}, result["env"])
+
+	result, err = builder.Construct(schema, map[string]interface{}{"env": []interface{}{
+		map[string]interface{}{"name": "B", types.PatchDirective: types.PatchDelete},
+	}}, Create)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, result["env"])
+}
+
+type notFoundValidator struct {
+	types.ReferenceValidator
+}
+
+func (notFoundValidator) Validate(resourceType, resourceID string) bool {
+	return false
+}
+
+func TestReferenceValidation(t *testing.T) {
+	schema := &types.Schema{
+		ResourceFields: map[string]types.Field{
+			"clusterId": {Type: "reference[cluster]", Create: true, Update: true},
+		},
+	}
+	builder := NewBuilder(&types.APIContext{ReferenceValidator: notFoundValidator{}})
+	input := map[string]interface{}{"clusterId": "c1"}
+
+	for _, op := range []Operation{Create, Update} {
+		_, err := builder.Construct(schema, input, op)
+		assert.Error(t, err, op)
+	}
+	_, err := builder.convertReferenceType("reference[cluster]", "c1", Action)
+	assert.Equal(t, httperror.InvalidReference, err.(*httperror.APIError).Code)
+
+	result, err := builder.Construct(schema, input, List)
+	require.NoError(t, err)
+	assert.Equal(t, "c1", result["clusterId"])
+}
@@ -38,7 +38,17 @@ func parseOrder(apiContext *types.APIContext) types.SortOrder {
 
 func parseSort(schema *types.Schema, apiContext *types.APIContext) types.Sort {
 	sortField := apiContext.Query.Get("sort")
-	if _, ok := schema.CollectionFilters[sortField]; !ok {
+	if sortField == "" && schema.DefaultSort != nil {
+		sort := types.Sort{
+			Name:  schema.DefaultSort.Name,
+			Order: schema.DefaultSort.Order,
+		}
+		if sort.Order == "" || apiContext.Query.Get("order") != "" {
+			sort.Order = parseOrder(apiContext)
+		}
+		return sort
+	}
+	if _, ok := schema.CollectionFilters[sortField]; !ok || !canRead(schema, apiContext, sortField) {
 		sortField = ""
 	}
 	return types.Sort{
@@ -95,7 +105,7 @@ func parseFilters(schema *types.Schema, apiContext *types.APIContext) []*types.Q
 	for key, values := range apiContext.Query {
 		name, op := parseNameAndOp(key)
 		filter, ok := schema.CollectionFilters[name]
-		if !ok {
+		if !ok || !canRead(schema, apiContext, name) {
 			continue
 		}
 
@@ -108,5 +118,29 @@ func parseFilters(schema *types.Schema, apiContext *types.APIContext) []*types.Q
 		}
 	}
 
+	if apiContext.Query.Get("all") == "true" {
+		return conditions
+	}
+	for _, filter := range schema.DefaultFilters {
+		if !hasField(conditions, filter.Field) {
+			conditions = append(conditions, filter)
+		}
+	}
+
 	return conditions
 }
+
+// canRead returns whether the user can read the field name, which it can not filter or sort by otherwise.
+func canRead(schema *types.Schema, apiContext *types.APIContext, name string) bool {
+	field, ok := schema.ResourceFields[name]
+	return !ok || apiContext.HasAnyRole(field.ReadRoles)
+}
+
+func hasField(conditions []*types.QueryCondition, field string) bool {
+	for _, condition := range conditions {
+		if condition.Field == field {
+			return true
+		}
+	}
+	return false
+}
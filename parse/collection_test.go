@@ -0,0 +1,74 @@
+package parse
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryOptionsDefaults(t *testing.T) {
+	schema := &types.Schema{
+		CollectionFilters: map[string]types.Filter{
+			"name":   {Modifiers: []types.ModifierType{types.ModifierEQ}},
+			"system": {Modifiers: []types.ModifierType{types.ModifierEQ}},
+		},
+		DefaultSort: &types.Sort{Name: "name"},
+		DefaultFilters: []*types.QueryCondition{
+			types.NewConditionFromString("system", types.ModifierNE, "true"),
+		},
+	}
+	options := func(query string) types.QueryOptions {
+		q, _ := url.ParseQuery(query)
+		return QueryOptions(&types.APIContext{
+			Request: httptest.NewRequest("GET", "/?"+query, nil),
+			Query:   q,
+		}, schema)
+	}
+
+	opts := options("")
+	assert.Equal(t, types.Sort{Name: "name", Order: types.ASC}, opts.Sort)
+	assert.Equal(t, schema.DefaultFilters, opts.Conditions)
+
+	opts = options("order=desc&system=true")
+	assert.Equal(t, types.Sort{Name: "name", Order: types.DESC}, opts.Sort)
+	assert.Len(t, opts.Conditions, 1)
+	assert.Equal(t, "true", opts.Conditions[0].Value)
+
+	opts = options("all=true&sort=system")
+	assert.Equal(t, "system", opts.Sort.Name)
+	assert.Empty(t, opts.Conditions)
+}
+
+func TestQueryOptionsReadRoles(t *testing.T) {
+	schema := &types.Schema{
+		ResourceFields: map[string]types.Field{
+			"name":   {Type: "string"},
+			"secret": {Type: "string", ReadRoles: []string{"admin"}},
+		},
+		CollectionFilters: map[string]types.Filter{
+			"name":   {Modifiers: []types.ModifierType{types.ModifierEQ}},
+			"secret": {Modifiers: []types.ModifierType{types.ModifierEQ}},
+		},
+	}
+	options := func(user *types.User) types.QueryOptions {
+		query := "secret=x&name=y&sort=secret"
+		q, _ := url.ParseQuery(query)
+		return QueryOptions(&types.APIContext{
+			Request: httptest.NewRequest("GET", "/?"+query, nil),
+			Query:   q,
+			User:    user,
+		}, schema)
+	}
+
+	opts := options(&types.User{Name: "alice"})
+	assert.Empty(t, opts.Sort.Name)
+	assert.Len(t, opts.Conditions, 1)
+	assert.Equal(t, "name", opts.Conditions[0].Field)
+
+	opts = options(&types.User{Name: "admin", Groups: []string{"admin"}})
+	assert.Equal(t, "secret", opts.Sort.Name)
+	assert.Len(t, opts.Conditions, 2)
+}
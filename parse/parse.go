@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"sort"
@@ -11,6 +12,8 @@ import (
 	"github.com/rancher/norman/api/builtin"
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/slice"
 	"github.com/rancher/norman/urlbuilder"
 )
 
@@ -55,6 +58,16 @@ func DefaultURLParser(schemas *types.Schemas, url *url.URL) (ParsedURL, error) {
 		return result, nil
 	}
 
+	if subContext == nil {
+		var err error
+		subContext, prefix, parts, err = parseScope(schemas, version, parts)
+		if err != nil {
+			result.Version = version
+			result.SchemasVersion = schemaVersion
+			return result, err
+		}
+	}
+
 	result.Version = version
 	result.SchemasVersion = schemaVersion
 	result.SubContext = subContext
@@ -74,7 +87,12 @@ func Parse(rw http.ResponseWriter, req *http.Request, schemas *types.Schemas, ur
 
 	result := types.NewAPIContext(req, rw, schemas)
 	result.Method = parseMethod(req)
+	result.RequestID = RequestID(req)
 	result.ResponseFormat = parseResponseFormat(req)
+	if rw != nil && req.URL.Query().Get("_format") == "" {
+		// the response format is negotiated from the Accept header
+		rw.Header().Add("Vary", "Accept")
+	}
 	result.URLBuilder, _ = urlbuilder.New(req, types.APIVersion{}, schemas)
 
 	// The response format is guarenteed to be set even in the event of an error
@@ -142,6 +160,10 @@ func Parse(rw http.ResponseWriter, req *http.Request, schemas *types.Schemas, ur
 		return result, err
 	}
 
+	if result.Method == http.MethodPost || result.Method == http.MethodPut || result.Method == http.MethodDelete {
+		result.DryRun = convert.ToBool(req.URL.Query().Get("dryRun"))
+	}
+
 	return result, nil
 }
 
@@ -223,6 +245,40 @@ func parseVersionAndSubContext(schemas *types.Schemas, escapedPath string) (*typ
 	return nil, version, "/" + paths[0], paths[1:], attrs
 }
 
+// parseScope handles nested collections such as /v3/namespaces/foo/pods, where namespace is one of the version
+// scopes, by returning a sub context that restricts the nested type to the parent resource. Nested types without a
+// reference to the scope are rejected, they couldn't be filtered by the parent.
+func parseScope(schemas *types.Schemas, version *types.APIVersion, paths []string) (map[string]string, string, []string, error) {
+	if len(version.Scopes) == 0 || len(paths) < 3 {
+		return nil, "", paths, nil
+	}
+
+	scope := schemas.Schema(version, paths[0])
+	nested := schemas.Schema(version, paths[2])
+	if scope == nil || !slice.ContainsString(version.Scopes, scope.ID) || nested == nil {
+		return nil, "", paths, nil
+	}
+	if !referencesScope(nested, scope) {
+		return nil, "", paths, httperror.NewAPIError(httperror.NotFound, nested.ID+" can not be scoped by "+scope.ID)
+	}
+
+	subContext := map[string]string{
+		scope.Version.Path + "/schemas/" + scope.ID: paths[1],
+	}
+	return subContext, "/" + paths[0] + "/" + paths[1], paths[2:], nil
+}
+
+func referencesScope(schema, scope *types.Schema) bool {
+	ref := convert.ToReference(scope.ID)
+	fullRef := convert.ToFullReference(scope.Version.Path, scope.ID)
+	for _, field := range schema.ResourceFields {
+		if field.Type == ref || field.Type == fullRef {
+			return true
+		}
+	}
+	return false
+}
+
 func DefaultResolver(typeName string, apiContext *types.APIContext) error {
 	if typeName == "" {
 		return nil
@@ -265,14 +321,42 @@ func parseResponseFormat(req *http.Request) string {
 		return "html"
 	}
 
-	if isYaml(req) {
-		return "yaml"
-	}
-	return "json"
+	return acceptedFormat(req.Header.Get("Accept"))
 }
 
-func isYaml(req *http.Request) bool {
-	return strings.Contains(req.Header.Get("Accept"), "application/yaml")
+// acceptFormats are the media types of Accept headers negotiated to a response format.
+var acceptFormats = map[string]string{
+	"application/json":   "json",
+	"application/yaml":   "yaml",
+	"application/x-yaml": "yaml",
+	"text/yaml":          "yaml",
+	"text/x-yaml":        "yaml",
+}
+
+// acceptedFormat returns the format of the media type with the highest quality in accept, the first one of equal
+// qualities, json if none is known.
+func acceptedFormat(accept string) string {
+	format := "json"
+	best := 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		candidate, ok := acceptFormats[strings.ToLower(strings.TrimSpace(mediaType))]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(key) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > best {
+			format, best = candidate, quality
+		}
+	}
+	return format
 }
 
 func parseMethod(req *http.Request) string {
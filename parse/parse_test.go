@@ -0,0 +1,52 @@
+package parse
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResponseFormat(t *testing.T) {
+	for accept, format := range map[string]string{
+		"":                                   "json",
+		"application/yaml":                   "yaml",
+		"text/yaml; charset=utf-8":           "yaml",
+		"application/x-yaml":                 "yaml",
+		"application/json, application/yaml": "json",
+		"application/json;q=0.5, application/yaml": "yaml",
+		"application/yaml;q=0, application/json":   "json",
+		"image/png":                                "json",
+	} {
+		req := httptest.NewRequest("GET", "http://localhost/v3/clusters", nil)
+		req.Header.Set("Accept", accept)
+		assert.Equal(t, format, parseResponseFormat(req), accept)
+	}
+
+	req := httptest.NewRequest("GET", "http://localhost/v3/clusters?_format=yaml", nil)
+	assert.Equal(t, "yaml", parseResponseFormat(req))
+}
+
+func TestRequestID(t *testing.T) {
+	for id, accepted := range map[string]bool{
+		"test-request-id":        true,
+		"a1.B2_c3":               true,
+		strings.Repeat("a", 128): true,
+		strings.Repeat("a", 129): false,
+		"with space":             false,
+		"line\nbreak":            false,
+		"<script>":               false,
+		"\u00e9t\u00e9":          false,
+	} {
+		req := httptest.NewRequest("GET", "http://localhost/v3/clusters", nil)
+		req.Header.Set("X-Request-Id", id)
+		generated := RequestID(req)
+		if accepted {
+			assert.Equal(t, id, generated)
+		} else {
+			assert.NotEqual(t, id, generated)
+			assert.Len(t, generated, 32)
+		}
+	}
+}
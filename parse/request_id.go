@@ -0,0 +1,41 @@
+package parse
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/rancher/norman/types"
+)
+
+// maxRequestIDLength is the length of the longest request ID accepted from clients.
+const maxRequestIDLength = 128
+
+// RequestID returns the request ID supplied by the client in the X-Request-Id header, generating a new one if
+// the header is missing or invalid. The IDs of clients must be at most 128 letters, digits, '.', '_' or '-', as
+// they are copied to logs, responses and the requests to the apiserver.
+func RequestID(req *http.Request) string {
+	if id := req.Header.Get(types.RequestIDHeader); validRequestID(id) {
+		return id
+	}
+
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(bytes)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
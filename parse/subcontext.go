@@ -1,8 +1,10 @@
 package parse
 
 import (
+	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/slice"
 )
 
 type DefaultSubContextAttributeProvider struct {
@@ -48,3 +50,33 @@ func (d *DefaultSubContextAttributeProvider) create(apiContext *types.APIContext
 
 	return result
 }
+
+// ValidateScope checks that the parent of a nested collection, as in /v3/namespaces/foo/pods, exists and is readable
+// by the user, instead of answering an empty collection.
+func ValidateScope(apiContext *types.APIContext) error {
+	if apiContext.Version == nil {
+		return nil
+	}
+	for subContextSchemaID, id := range apiContext.SubContext {
+		scope := apiContext.Schemas.Schema(nil, subContextSchemaID)
+		if scope == nil || !slice.ContainsString(apiContext.Version.Scopes, scope.ID) {
+			continue
+		}
+		if err := apiContext.AccessControl.CanGet(apiContext, scope); err != nil {
+			return err
+		}
+
+		notFound := httperror.NewAPIError(httperror.NotFound, scope.ID+" "+id+" not found")
+		if scope.Store == nil {
+			return notFound
+		}
+		parent, err := scope.Store.ByID(apiContext, scope, id)
+		if err != nil {
+			return err
+		}
+		if parent == nil || apiContext.AccessControl.Filter(apiContext, scope, parent, nil) == nil {
+			return notFound
+		}
+	}
+	return nil
+}
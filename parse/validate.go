@@ -22,6 +22,10 @@ func ValidateMethod(request *types.APIContext) error {
 		return nil
 	}
 
+	if request.Method == http.MethodOptions {
+		return nil
+	}
+
 	if !supportedMethods[request.Method] {
 		return httperror.NewAPIError(httperror.MethodNotAllowed, fmt.Sprintf("Method %s not supported", request.Method))
 	}
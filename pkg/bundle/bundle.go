@@ -0,0 +1,172 @@
+package bundle
+
+import (
+	"fmt"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/parse"
+	"github.com/rancher/norman/parse/builder"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+)
+
+type ConflictStrategy string
+
+const (
+	// Fail rejects the whole import, before anything is written, if any resource already exists.
+	Fail ConflictStrategy = "fail"
+	// Skip leaves existing resources untouched.
+	Skip ConflictStrategy = "skip"
+	// Overwrite updates existing resources with the values in the bundle.
+	Overwrite ConflictStrategy = "overwrite"
+)
+
+// Bundle is a set of resources of any type. Every resource has its type and id.
+type Bundle struct {
+	Resources []map[string]interface{} `json:"resources"`
+}
+
+type ImportResult struct {
+	Created []string `json:"created,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Export lists every page of the resources of schemas, applying the filters of the request, into a bundle. Schemas
+// the user can not list are left out.
+func Export(apiContext *types.APIContext, schemas []*types.Schema) (*Bundle, error) {
+	bundle := &Bundle{
+		Resources: []map[string]interface{}{},
+	}
+
+	for _, schema := range schemas {
+		if schema.Store == nil || apiContext.AccessControl.CanList(apiContext, schema) != nil {
+			continue
+		}
+
+		// every page is exported, from the first one, with the page size of the request
+		opts := parse.QueryOptions(apiContext, schema)
+		pagination := types.Pagination{}
+		if opts.Pagination != nil {
+			pagination.Limit = opts.Pagination.Limit
+		}
+		opts.Pagination = &pagination
+
+		for {
+			pagination.Next = ""
+			data, err := schema.Store.List(apiContext, schema, &opts)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range apiContext.AccessControl.FilterList(apiContext, schema, data, nil) {
+				resource := map[string]interface{}{}
+				for k, v := range item {
+					if k == "links" || k == "actions" {
+						continue
+					}
+					resource[k] = v
+				}
+				resource["type"] = schema.ID
+				bundle.Resources = append(bundle.Resources, resource)
+			}
+
+			if pagination.Next == "" || pagination.Next == pagination.Marker {
+				break
+			}
+			pagination.Marker = pagination.Next
+		}
+	}
+
+	return bundle, nil
+}
+
+// Import creates the resources of bundle, or handles the ones that exist according to strategy.
+func Import(apiContext *types.APIContext, bundle *Bundle, strategy ConflictStrategy) (*ImportResult, error) {
+	switch strategy {
+	case Fail, Skip, Overwrite:
+	default:
+		return nil, httperror.NewAPIError(httperror.InvalidOption, fmt.Sprintf("invalid conflict strategy %q", strategy))
+	}
+
+	type entry struct {
+		schema   *types.Schema
+		id       string
+		data     map[string]interface{}
+		existing map[string]interface{}
+	}
+
+	var entries []entry
+	for i, resource := range bundle.Resources {
+		typeName := convert.ToString(resource["type"])
+		schema := apiContext.Schemas.Schema(apiContext.Version, typeName)
+		if schema == nil || schema.Store == nil {
+			return nil, httperror.NewAPIError(httperror.InvalidType, fmt.Sprintf("resource %d has unknown type %q", i, typeName))
+		}
+
+		id := convert.ToString(resource["id"])
+		var existing map[string]interface{}
+		if id != "" {
+			obj, err := schema.Store.ByID(apiContext, schema, id)
+			if err != nil && !httperror.IsNotFound(err) {
+				return nil, err
+			}
+			existing = obj
+		}
+
+		// access is checked against every target before anything is written
+		switch {
+		case existing != nil && strategy == Fail:
+			return nil, httperror.NewAPIError(httperror.Conflict, fmt.Sprintf("%s %s already exists", typeName, id))
+		case existing != nil && strategy == Overwrite:
+			if err := apiContext.AccessControl.CanUpdate(apiContext, existing, schema); err != nil {
+				return nil, err
+			}
+		case existing == nil:
+			if err := apiContext.AccessControl.CanCreate(apiContext, schema); err != nil {
+				return nil, err
+			}
+		}
+
+		entries = append(entries, entry{
+			schema:   schema,
+			id:       id,
+			data:     resource,
+			existing: existing,
+		})
+	}
+
+	result := &ImportResult{}
+	b := builder.NewBuilder(apiContext)
+	for _, e := range entries {
+		name := e.schema.ID + ":" + e.id
+		if e.existing != nil && strategy == Skip {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+
+		if e.existing != nil {
+			data, err := b.Construct(e.schema, e.data, builder.Update)
+			if err != nil {
+				return result, err
+			}
+			if _, err := e.schema.Store.Update(apiContext, e.schema, data, e.id); err != nil {
+				return result, err
+			}
+			result.Updated = append(result.Updated, name)
+			continue
+		}
+
+		data, err := b.Construct(e.schema, e.data, builder.Create)
+		if err != nil {
+			return result, err
+		}
+		created, err := e.schema.Store.Create(apiContext, e.schema, data)
+		if err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, e.schema.ID+":"+convert.ToString(created["id"]))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package bundle
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rancher/norman/api"
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Thing struct {
+	types.Resource
+	Name string `json:"name"`
+}
+
+type memoryStore struct {
+	empty.Store
+	data map[string]map[string]interface{}
+}
+
+func (m *memoryStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return m.data[id], nil
+}
+
+func (m *memoryStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, item := range m.data {
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	data["id"] = strconv.Itoa(len(m.data) + 1)
+	m.data[data["id"].(string)] = data
+	return data, nil
+}
+
+func (m *memoryStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	data["id"] = id
+	m.data[id] = data
+	return data, nil
+}
+
+// denyUpdate denies updates of the object with the id
+type denyUpdate struct {
+	authorization.AllAccess
+	id string
+}
+
+func (d *denyUpdate) CanUpdate(apiContext *types.APIContext, obj map[string]interface{}, schema *types.Schema) error {
+	if obj["id"] == d.id {
+		return httperror.NewAPIError(httperror.PermissionDenied, "can not update "+d.id)
+	}
+	return nil
+}
+
+func newServer(t *testing.T, store *memoryStore) *api.Server {
+	version := types.APIVersion{Group: "example.com", Version: "v1", Path: "/v1"}
+	schemas := types.NewSchemas()
+	schemas.MustImportAndCustomize(&version, Thing{}, func(schema *types.Schema) {
+		schema.Store = store
+		schema.CollectionMethods = []string{http.MethodGet, http.MethodPost}
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
+	})
+	Register(&version, schemas)
+
+	srv := api.NewAPIServer()
+	require.NoError(t, srv.AddSchemas(schemas))
+	return srv
+}
+
+func TestExportImport(t *testing.T) {
+	store := &memoryStore{data: map[string]map[string]interface{}{
+		"1": {"id": "1", "name": "one"},
+	}}
+	srv := newServer(t, store)
+
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/v1/bundles?resourceTypes=thing", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	bundle := &Bundle{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), bundle))
+	require.Len(t, bundle.Resources, 1)
+	assert.Equal(t, "thing", bundle.Resources[0]["type"])
+	assert.Equal(t, "one", bundle.Resources[0]["name"])
+
+	body := `{"resources":[{"type":"thing","id":"1","name":"changed"},{"type":"thing","name":"two"}]}`
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://localhost/v1/bundles?action=import", strings.NewReader(body)))
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Len(t, store.data, 1)
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://localhost/v1/bundles?action=import&conflict=skip", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, resp.Code)
+	result := &ImportResult{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), result))
+	assert.Equal(t, []string{"thing:1"}, result.Skipped)
+	assert.Equal(t, []string{"thing:2"}, result.Created)
+	assert.Equal(t, "one", store.data["1"]["name"])
+
+	resp = httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://localhost/v1/bundles?action=import&conflict=overwrite", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "changed", store.data["1"]["name"])
+}
+
+func TestExportPages(t *testing.T) {
+	store := &memoryStore{data: map[string]map[string]interface{}{
+		"1": {"id": "1", "name": "one"},
+		"2": {"id": "2", "name": "two"},
+		"3": {"id": "3", "name": "three"},
+	}}
+	srv := newServer(t, store)
+
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost/v1/bundles?resourceTypes=thing&limit=1", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	bundle := &Bundle{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), bundle))
+	require.Len(t, bundle.Resources, 3)
+	for i, name := range []string{"one", "two", "three"} {
+		assert.Equal(t, name, bundle.Resources[i]["name"])
+	}
+}
+
+func TestImportChecksAccessPerObject(t *testing.T) {
+	store := &memoryStore{data: map[string]map[string]interface{}{
+		"1": {"id": "1", "name": "one"},
+		"2": {"id": "2", "name": "two"},
+	}}
+	srv := newServer(t, store)
+	srv.AccessControl = &denyUpdate{id: "2"}
+
+	body := `{"resources":[{"type":"thing","id":"1","name":"changed"},{"type":"thing","id":"2","name":"changed"}]}`
+	resp := httptest.NewRecorder()
+	srv.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "http://localhost/v1/bundles?action=import&conflict=overwrite", strings.NewReader(body)))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Equal(t, "one", store.data["1"]["name"], "nothing is written when a target is denied")
+	assert.Equal(t, "two", store.data["2"]["name"])
+}
@@ -0,0 +1,86 @@
+package bundle
+
+import (
+	"net/http"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/parse"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/slice"
+)
+
+const ImportAction = "import"
+
+// Register adds the bundle type to version. GET exports the resources of the types in the resourceTypes
+// query parameter, or of all types, as JSON or YAML. The import action takes a bundle and a conflict query
+// parameter, one of fail, skip or overwrite.
+func Register(version *types.APIVersion, schemas *types.Schemas) {
+	schemas.MustImportAndCustomize(version, Bundle{}, func(schema *types.Schema) {
+		schema.CollectionMethods = []string{http.MethodGet}
+		schema.ResourceMethods = []string{}
+		schema.CollectionActions = map[string]types.Action{
+			ImportAction: {},
+		}
+		schema.ListHandler = exportHandler
+		schema.ActionHandler = importHandler
+	})
+}
+
+func exportHandler(apiContext *types.APIContext, _ types.RequestHandler) error {
+	resourceTypes := apiContext.Query["resourceTypes"]
+
+	var schemas []*types.Schema
+	for _, schema := range apiContext.Schemas.SchemasForVersion(*apiContext.Version) {
+		if schema.ID == apiContext.Schema.ID {
+			continue
+		}
+		if len(resourceTypes) == 0 || slice.ContainsString(resourceTypes, schema.ID) {
+			schemas = append(schemas, schema)
+		}
+	}
+
+	bundle, err := Export(apiContext, schemas)
+	if err != nil {
+		return err
+	}
+
+	return write(apiContext, bundle)
+}
+
+func importHandler(actionName string, action *types.Action, apiContext *types.APIContext) error {
+	if actionName != ImportAction {
+		return httperror.NewAPIError(httperror.NotFound, "action not found")
+	}
+
+	data, err := parse.ReadBody(apiContext.Request)
+	if err != nil {
+		return err
+	}
+	bundle := &Bundle{}
+	if err := convert.ToObj(data, bundle); err != nil {
+		return httperror.WrapAPIError(err, httperror.InvalidBodyContent, "invalid bundle")
+	}
+
+	strategy := ConflictStrategy(apiContext.Query.Get("conflict"))
+	if strategy == "" {
+		strategy = Fail
+	}
+
+	result, err := Import(apiContext, bundle, strategy)
+	if err != nil {
+		return err
+	}
+	return write(apiContext, result)
+}
+
+// write encodes obj as is, the response writers only handle resources.
+func write(apiContext *types.APIContext, obj interface{}) error {
+	encoder, contentType := types.JSONEncoder, "application/json"
+	if apiContext.ResponseFormat == "yaml" {
+		encoder, contentType = types.YAMLEncoder, "application/yaml"
+	}
+	apiContext.Response.Header().Set("Content-Type", contentType)
+	apiContext.Response.WriteHeader(http.StatusOK)
+	return encoder(apiContext.Response, obj)
+}
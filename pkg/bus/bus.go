@@ -0,0 +1,87 @@
+package bus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Verb string
+
+const (
+	Create Verb = "create"
+	Update Verb = "update"
+	Delete Verb = "delete"
+)
+
+// Event is a change of an object. Type is the schema ID of the object, Key is the ID of the objects published by
+// stores and the namespace/name of the objects published by object clients.
+type Event struct {
+	Type   string
+	Verb   Verb
+	Key    string
+	Object map[string]interface{}
+}
+
+// Bus delivers the change events published in the process to its subscribers.
+type Bus struct {
+	lock sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+type subscription struct {
+	types  map[string]bool
+	events chan Event
+}
+
+// Default is the bus used by the store wrapper, object clients and subscriptions unless told otherwise.
+var Default = New()
+
+func New() *Bus {
+	return &Bus{
+		subs: map[*subscription]struct{}{},
+	}
+}
+
+// Publish never blocks, events are dropped for subscribers that are not keeping up.
+func (b *Bus) Publish(event Event) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	for sub := range b.subs {
+		if len(sub.types) > 0 && !sub.types[event.Type] {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			logrus.Debugf("dropping %s event of %s %s for a slow subscriber", event.Verb, event.Type, event.Key)
+		}
+	}
+}
+
+// Subscribe returns the events of objects of types, or of all objects if none are given, until ctx is done.
+func (b *Bus) Subscribe(ctx context.Context, types ...string) <-chan Event {
+	sub := &subscription{
+		types:  map[string]bool{},
+		events: make(chan Event, 100),
+	}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+
+	b.lock.Lock()
+	b.subs[sub] = struct{}{}
+	b.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.lock.Lock()
+		delete(b.subs, sub)
+		close(sub.events)
+		b.lock.Unlock()
+	}()
+
+	return sub.events
+}
@@ -0,0 +1,76 @@
+package bus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishFiltersByType(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := New()
+	events := b.Subscribe(ctx, "pod")
+
+	b.Publish(Event{Type: "node", Verb: Create, Key: "n1"})
+	b.Publish(Event{Type: "pod", Verb: Update, Key: "default/p1"})
+
+	event := <-events
+	assert.Equal(t, Event{Type: "pod", Verb: Update, Key: "default/p1"}, event)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestPublishDropsForSlowSubscribers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := New()
+	events := b.Subscribe(ctx)
+
+	for i := 0; i < 200; i++ {
+		b.Publish(Event{Type: "pod", Verb: Create})
+	}
+	assert.Len(t, events, 100)
+}
+
+type createStore struct {
+	empty.Store
+}
+
+func (*createStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "w1"}, nil
+}
+
+func TestStorePublishes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := New()
+	events := b.Subscribe(ctx, "widget")
+
+	store := NewStore(&createStore{}, b)
+	_, err := store.Create(&types.APIContext{}, &types.Schema{ID: "widget"}, map[string]interface{}{})
+	require.NoError(t, err)
+
+	event := <-events
+	assert.Equal(t, Create, event.Verb)
+	assert.Equal(t, "w1", event.Key)
+	assert.Equal(t, map[string]interface{}{"id": "w1"}, event.Object)
+}
+
+func TestStoreSkipsDryRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := New()
+	events := b.Subscribe(ctx, "widget")
+
+	store := NewStore(&createStore{}, b)
+	_, err := store.Create(&types.APIContext{DryRun: true}, &types.Schema{ID: "widget"}, map[string]interface{}{})
+	require.NoError(t, err)
+
+	assert.Len(t, events, 0)
+}
@@ -0,0 +1,57 @@
+package bus
+
+import (
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+)
+
+// Store publishes the objects created, updated and deleted through it, with the schema ID as type. Dry runs are not
+// published.
+type Store struct {
+	types.Store
+	bus *Bus
+}
+
+// NewStore publishes the changes made through store on bus, Default if nil.
+func NewStore(store types.Store, bus *Bus) *Store {
+	if bus == nil {
+		bus = Default
+	}
+	return &Store{
+		Store: store,
+		bus:   bus,
+	}
+}
+
+func (s *Store) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	result, err := s.Store.Create(apiContext, schema, data)
+	if err == nil && !apiContext.DryRun {
+		s.publish(schema, Create, convert.ToString(result["id"]), result)
+	}
+	return result, err
+}
+
+func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	result, err := s.Store.Update(apiContext, schema, data, id)
+	if err == nil && !apiContext.DryRun {
+		s.publish(schema, Update, id, result)
+	}
+	return result, err
+}
+
+func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	result, err := s.Store.Delete(apiContext, schema, id)
+	if err == nil && !apiContext.DryRun {
+		s.publish(schema, Delete, id, result)
+	}
+	return result, err
+}
+
+func (s *Store) publish(schema *types.Schema, verb Verb, id string, data map[string]interface{}) {
+	s.bus.Publish(Event{
+		Type:   schema.ID,
+		Verb:   verb,
+		Key:    id,
+		Object: data,
+	})
+}
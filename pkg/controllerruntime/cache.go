@@ -0,0 +1,143 @@
+package controllerruntime
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rancher/lasso/pkg/cache"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	crcache "sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+type sharedCacheFactory struct {
+	cache.SharedCacheFactory
+
+	ctx       context.Context
+	informers crcache.Informers
+
+	lock   sync.Mutex
+	shared map[schema.GroupVersionKind]toolscache.SharedIndexInformer
+}
+
+// NewSharedCacheFactory returns a cache factory that serves informers from the cache of a controller-runtime
+// manager, normally mgr.GetCache(), so that norman controllers and reconcilers in the same process share a single
+// cache per GVK. Kinds the manager cache can not provide fall back to delegate. Shared informers are started by
+// the manager, not by Start.
+func NewSharedCacheFactory(ctx context.Context, informers crcache.Informers, delegate cache.SharedCacheFactory) cache.SharedCacheFactory {
+	return &sharedCacheFactory{
+		SharedCacheFactory: delegate,
+		ctx:                ctx,
+		informers:          informers,
+		shared:             map[schema.GroupVersionKind]toolscache.SharedIndexInformer{},
+	}
+}
+
+// NewSharedControllerFactory builds a controller factory, suitable for the generated NewFromControllerFactory
+// functions, whose informers are shared with a controller-runtime manager cache.
+func NewSharedControllerFactory(ctx context.Context, informers crcache.Informers, config *rest.Config, scheme *runtime.Scheme,
+	opts *controller.SharedControllerFactoryOptions) (controller.SharedControllerFactory, error) {
+	clientFactory, err := client.NewSharedClientFactory(config, &client.SharedClientFactoryOptions{
+		Scheme: scheme,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheOpts *cache.SharedCacheFactoryOptions
+	if opts != nil {
+		cacheOpts = opts.CacheOptions
+	}
+
+	cacheFactory := NewSharedCacheFactory(ctx, informers, cache.NewSharedCachedFactory(clientFactory, cacheOpts))
+	return controller.NewSharedControllerFactory(cacheFactory, opts), nil
+}
+
+func (s *sharedCacheFactory) ForObject(obj runtime.Object) (toolscache.SharedIndexInformer, error) {
+	return s.ForKind(obj.GetObjectKind().GroupVersionKind())
+}
+
+func (s *sharedCacheFactory) ForResource(gvr schema.GroupVersionResource, namespaced bool) (toolscache.SharedIndexInformer, error) {
+	return s.ForResourceKind(gvr, "", namespaced)
+}
+
+func (s *sharedCacheFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) (toolscache.SharedIndexInformer, error) {
+	if kind == "" {
+		gvk, err := s.SharedClientFactory().GVKForResource(gvr)
+		if err != nil {
+			return nil, err
+		}
+		kind = gvk.Kind
+	}
+
+	if informer, ok := s.sharedInformer(gvr.GroupVersion().WithKind(kind)); ok {
+		return informer, nil
+	}
+	return s.SharedCacheFactory.ForResourceKind(gvr, kind, namespaced)
+}
+
+func (s *sharedCacheFactory) ForKind(gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, error) {
+	if informer, ok := s.sharedInformer(gvk); ok {
+		return informer, nil
+	}
+	return s.SharedCacheFactory.ForKind(gvk)
+}
+
+func (s *sharedCacheFactory) sharedInformer(gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if informer, ok := s.shared[gvk]; ok {
+		return informer, true
+	}
+
+	informer, err := s.informers.GetInformerForKind(s.ctx, gvk, crcache.BlockUntilSynced(false))
+	if err != nil {
+		logrus.Debugf("controller-runtime cache can not provide %v, using a separate informer: %v", gvk, err)
+		return nil, false
+	}
+
+	sharedInformer, ok := informer.(toolscache.SharedIndexInformer)
+	if !ok {
+		logrus.Debugf("controller-runtime informer for %v is not a shared index informer, using a separate informer", gvk)
+		return nil, false
+	}
+
+	s.shared[gvk] = sharedInformer
+	return sharedInformer, true
+}
+
+func (s *sharedCacheFactory) isShared(gvk schema.GroupVersionKind) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, ok := s.shared[gvk]
+	return ok
+}
+
+func (s *sharedCacheFactory) StartGVK(ctx context.Context, gvk schema.GroupVersionKind) error {
+	if s.isShared(gvk) {
+		return nil
+	}
+	return s.SharedCacheFactory.StartGVK(ctx, gvk)
+}
+
+func (s *sharedCacheFactory) WaitForCacheSync(ctx context.Context) map[schema.GroupVersionKind]bool {
+	result := s.SharedCacheFactory.WaitForCacheSync(ctx)
+
+	s.lock.Lock()
+	shared := make(map[schema.GroupVersionKind]toolscache.SharedIndexInformer, len(s.shared))
+	for gvk, informer := range s.shared {
+		shared[gvk] = informer
+	}
+	s.lock.Unlock()
+
+	for gvk, informer := range shared {
+		result[gvk] = toolscache.WaitForCacheSync(ctx.Done(), informer.HasSynced)
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package controllerruntime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rancher/lasso/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	crcache "sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+type fakeInformers struct {
+	crcache.Informers
+	informers map[schema.GroupVersionKind]toolscache.SharedIndexInformer
+	gets      int
+}
+
+func (f *fakeInformers) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind, opts ...crcache.InformerGetOption) (crcache.Informer, error) {
+	f.gets++
+	informer, ok := f.informers[gvk]
+	if !ok {
+		return nil, errors.New("no informer for " + gvk.String())
+	}
+	return informer, nil
+}
+
+type fakeCacheFactory struct {
+	cache.SharedCacheFactory
+	informer toolscache.SharedIndexInformer
+	started  []schema.GroupVersionKind
+}
+
+func (f *fakeCacheFactory) ForKind(gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, error) {
+	return f.informer, nil
+}
+
+func (f *fakeCacheFactory) StartGVK(ctx context.Context, gvk schema.GroupVersionKind) error {
+	f.started = append(f.started, gvk)
+	return nil
+}
+
+func (f *fakeCacheFactory) WaitForCacheSync(ctx context.Context) map[schema.GroupVersionKind]bool {
+	result := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range f.started {
+		result[gvk] = true
+	}
+	return result
+}
+
+func newInformer() toolscache.SharedIndexInformer {
+	return toolscache.NewSharedIndexInformer(&toolscache.ListWatch{}, &corev1.ConfigMap{}, 0, toolscache.Indexers{})
+}
+
+func TestSharedCacheFactory(t *testing.T) {
+	configMaps := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	secrets := corev1.SchemeGroupVersion.WithKind("Secret")
+	managerInformer, separateInformer := newInformer(), newInformer()
+	informers := &fakeInformers{informers: map[schema.GroupVersionKind]toolscache.SharedIndexInformer{
+		configMaps: managerInformer,
+	}}
+	delegate := &fakeCacheFactory{informer: separateInformer}
+	factory := NewSharedCacheFactory(context.Background(), informers, delegate)
+
+	tests := []struct {
+		name     string
+		gvk      schema.GroupVersionKind
+		informer toolscache.SharedIndexInformer
+		started  []schema.GroupVersionKind
+	}{
+		{name: "kind of the manager cache", gvk: configMaps, informer: managerInformer},
+		{name: "kind the manager cache can not provide", gvk: secrets, informer: separateInformer,
+			started: []schema.GroupVersionKind{secrets}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delegate.started = nil
+			informer, err := factory.ForKind(test.gvk)
+			require.NoError(t, err)
+			assert.Same(t, test.informer, informer)
+
+			require.NoError(t, factory.StartGVK(context.Background(), test.gvk))
+			assert.Equal(t, test.started, delegate.started)
+		})
+	}
+
+	// informers of the manager cache are only requested once
+	gets := informers.gets
+	_, err := factory.ForKind(configMaps)
+	require.NoError(t, err)
+	assert.Equal(t, gets, informers.gets)
+
+	// the manager informers are waited for next to the ones of the delegate
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, map[schema.GroupVersionKind]bool{configMaps: false, secrets: true}, factory.WaitForCacheSync(ctx))
+}
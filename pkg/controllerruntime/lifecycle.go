@@ -0,0 +1,100 @@
+package controllerruntime
+
+import (
+	"context"
+
+	normancontroller "github.com/rancher/norman/controller"
+	"github.com/rancher/norman/lifecycle"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type lifecycleReconciler struct {
+	name          string
+	clusterScoped bool
+	lifecycle     lifecycle.ObjectLifecycle
+	client        client.Client
+	newObject     func() client.Object
+}
+
+// NewLifecycleReconciler runs an ObjectLifecycle as a controller-runtime reconciler. The create annotation and
+// finalizer are the same as the ones used by the generated lifecycle adapters, so a handler can move between the
+// frameworks without objects being created or finalized twice. newObject returns an empty object of the
+// reconciled type.
+func NewLifecycleReconciler(name string, clusterScoped bool, objLifecycle lifecycle.ObjectLifecycle, c client.Client,
+	newObject func() client.Object) reconcile.Reconciler {
+	return &lifecycleReconciler{
+		name:          name,
+		clusterScoped: clusterScoped,
+		lifecycle:     objLifecycle,
+		client:        c,
+		newObject:     newObject,
+	}
+}
+
+func (l *lifecycleReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	obj := l.newObject()
+	if err := l.client.Get(ctx, req.NamespacedName, obj); apierrors.IsNotFound(err) {
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	updater := &clientUpdater{
+		ctx:       ctx,
+		client:    l.client,
+		newObject: l.newObject,
+	}
+	handler := lifecycle.NewObjectLifecycleAdapterForUpdater(l.name, l.clusterScoped, l.lifecycle, updater)
+	_, err := handler(req.String(), obj)
+	return reconcile.Result{}, err
+}
+
+type clientUpdater struct {
+	ctx       context.Context
+	client    client.Client
+	newObject func() client.Object
+}
+
+func (c *clientUpdater) GetNamespaced(namespace, name string, opts metav1.GetOptions) (runtime.Object, error) {
+	obj := c.newObject()
+	return obj, c.client.Get(c.ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj)
+}
+
+func (c *clientUpdater) Update(name string, o runtime.Object) (runtime.Object, error) {
+	obj, ok := o.(client.Object)
+	if !ok {
+		return o, apierrors.NewBadRequest("object does not have metadata")
+	}
+	return obj, c.client.Update(c.ctx, obj)
+}
+
+// NewReconcilerHandler runs a controller-runtime reconciler as a norman handler on c. Requeue requests in the
+// reconcile result are turned into calls to Enqueue and EnqueueAfter.
+func NewReconcilerHandler(ctx context.Context, c normancontroller.GenericController, r reconcile.Reconciler) normancontroller.HandlerFunc {
+	return func(key string, obj interface{}) (interface{}, error) {
+		namespace, name, err := toolscache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return obj, err
+		}
+
+		result, err := r.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: namespace, Name: name},
+		})
+		if err != nil {
+			return obj, err
+		}
+
+		if result.RequeueAfter > 0 {
+			c.EnqueueAfter(namespace, name, result.RequeueAfter)
+		} else if result.Requeue {
+			c.Enqueue(namespace, name)
+		}
+		return obj, nil
+	}
+}
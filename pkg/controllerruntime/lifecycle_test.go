@@ -0,0 +1,167 @@
+package controllerruntime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	normancontroller "github.com/rancher/norman/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type recordingLifecycle struct {
+	created, finalized, updated int
+}
+
+func (r *recordingLifecycle) Create(obj runtime.Object) (runtime.Object, error) {
+	r.created++
+	return obj, nil
+}
+
+func (r *recordingLifecycle) Finalize(obj runtime.Object) (runtime.Object, error) {
+	r.finalized++
+	return obj, nil
+}
+
+func (r *recordingLifecycle) Updated(obj runtime.Object) (runtime.Object, error) {
+	r.updated++
+	return obj, nil
+}
+
+func TestLifecycleReconciler(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name       string
+		objects    []client.Object
+		lifecycle  recordingLifecycle
+		finalizers []string
+		deleted    bool
+	}{
+		{
+			name:       "new object",
+			objects:    []client.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"}}},
+			lifecycle:  recordingLifecycle{created: 1},
+			finalizers: []string{"controller.cattle.io/test"},
+		},
+		{
+			name: "created object",
+			objects: []client.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:        "cm",
+				Namespace:   "default",
+				Annotations: map[string]string{"lifecycle.cattle.io/create.test": "true"},
+				Finalizers:  []string{"controller.cattle.io/test"},
+			}}},
+			lifecycle:  recordingLifecycle{updated: 1},
+			finalizers: []string{"controller.cattle.io/test"},
+		},
+		{
+			name: "deleted object",
+			objects: []client.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:              "cm",
+				Namespace:         "default",
+				Annotations:       map[string]string{"lifecycle.cattle.io/create.test": "true"},
+				Finalizers:        []string{"controller.cattle.io/test"},
+				DeletionTimestamp: &now,
+			}}},
+			lifecycle: recordingLifecycle{finalized: 1},
+			deleted:   true,
+		},
+		{
+			name: "missing object",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(test.objects...).Build()
+			objLifecycle := &recordingLifecycle{}
+			reconciler := NewLifecycleReconciler("test", false, objLifecycle, c, func() client.Object {
+				return &corev1.ConfigMap{}
+			})
+
+			key := types.NamespacedName{Namespace: "default", Name: "cm"}
+			result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+			require.NoError(t, err)
+			assert.Equal(t, reconcile.Result{}, result)
+			assert.Equal(t, test.lifecycle, *objLifecycle)
+			if len(test.objects) == 0 {
+				return
+			}
+
+			cm := &corev1.ConfigMap{}
+			err = c.Get(context.Background(), key, cm)
+			if test.deleted {
+				assert.True(t, apierrors.IsNotFound(err), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.finalizers, cm.Finalizers)
+			assert.Equal(t, "true", cm.Annotations["lifecycle.cattle.io/create.test"])
+		})
+	}
+}
+
+type enqueueRecorder struct {
+	normancontroller.GenericController
+	enqueued []string
+	after    []time.Duration
+}
+
+func (e *enqueueRecorder) Enqueue(namespace, name string) {
+	e.enqueued = append(e.enqueued, namespace+"/"+name)
+}
+
+func (e *enqueueRecorder) EnqueueAfter(namespace, name string, after time.Duration) {
+	e.enqueued = append(e.enqueued, namespace+"/"+name)
+	e.after = append(e.after, after)
+}
+
+type fixedReconciler struct {
+	result reconcile.Result
+	err    error
+	req    reconcile.Request
+}
+
+func (f *fixedReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	f.req = req
+	return f.result, f.err
+}
+
+func TestReconcilerHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   reconcile.Result
+		err      error
+		enqueued []string
+		after    []time.Duration
+	}{
+		{name: "done"},
+		{name: "requeue", result: reconcile.Result{Requeue: true}, enqueued: []string{"default/cm"}},
+		{name: "requeue after", result: reconcile.Result{RequeueAfter: time.Minute}, enqueued: []string{"default/cm"},
+			after: []time.Duration{time.Minute}},
+		{name: "error", result: reconcile.Result{Requeue: true}, err: errors.New("failed")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &enqueueRecorder{}
+			reconciler := &fixedReconciler{result: test.result, err: test.err}
+			handler := NewReconcilerHandler(context.Background(), recorder, reconciler)
+
+			_, err := handler("default/cm", nil)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, types.NamespacedName{Namespace: "default", Name: "cm"}, reconciler.req.NamespacedName)
+			assert.Equal(t, test.enqueued, recorder.enqueued)
+			assert.Equal(t, test.after, recorder.after)
+		})
+	}
+}
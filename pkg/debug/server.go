@@ -0,0 +1,226 @@
+package debug
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	rdebug "runtime/debug"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/lasso/pkg/cache"
+	"github.com/sirupsen/logrus"
+)
+
+const maxProfileDuration = 5 * time.Minute
+
+type Options struct {
+	// Token must be sent as a bearer token by every request, it is required
+	Token string
+	// Caches, if set, has its started informer caches reported by /debug/caches
+	Caches cache.SharedCacheFactory
+}
+
+// NewHandler returns the runtime debug endpoints:
+//
+//	/debug/pprof/                 the names of the profiles
+//	/debug/pprof/profile          a CPU profile of the seconds query parameter, 30 by default
+//	/debug/pprof/trace            an execution trace of the seconds query parameter, 1 by default
+//	/debug/pprof/<name>           the heap, goroutine, allocs... profile, in text if the debug query parameter is set
+//	/debug/goroutines             the stacks of every goroutine
+//	/debug/gc                     the memory and GC stats, a POST runs a GC and returns memory to the OS first
+//	/debug/caches                 whether the informer caches synced and their number of objects, by GVK
+//
+// Profiles are served with runtime/pprof, so nothing is registered on http.DefaultServeMux.
+func NewHandler(opts Options) (http.Handler, error) {
+	if opts.Token == "" {
+		return nil, errors.New("debug endpoints require a token")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", profile)
+	mux.HandleFunc("/debug/goroutines", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = pprof.Lookup("goroutine").WriteTo(rw, 2)
+	})
+	mux.HandleFunc("/debug/gc", gcStats)
+	mux.HandleFunc("/debug/caches", func(rw http.ResponseWriter, req *http.Request) {
+		writeJSON(rw, cacheSizes(req.Context(), opts.Caches))
+	})
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		auth, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(opts.Token)) != 1 {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(rw, req)
+	}), nil
+}
+
+// ListenAndServe serves NewHandler on address until ctx is done.
+func ListenAndServe(ctx context.Context, address string, opts Options) error {
+	handler, err := NewHandler(opts)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	logrus.Infof("serving debug endpoints on %s", address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func profile(rw http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/debug/pprof/")
+	switch name {
+	case "":
+		var names []string
+		for _, p := range pprof.Profiles() {
+			names = append(names, p.Name())
+		}
+		sort.Strings(names)
+		writeJSON(rw, append(names, "profile", "trace"))
+	case "profile":
+		duration, err := seconds(req, 30)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/octet-stream")
+		if err := pprof.StartCPUProfile(rw); err != nil {
+			http.Error(rw, err.Error(), http.StatusConflict)
+			return
+		}
+		sleep(req.Context(), duration)
+		pprof.StopCPUProfile()
+	case "trace":
+		duration, err := seconds(req, 1)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/octet-stream")
+		if err := trace.Start(rw); err != nil {
+			http.Error(rw, err.Error(), http.StatusConflict)
+			return
+		}
+		sleep(req.Context(), duration)
+		trace.Stop()
+	default:
+		p := pprof.Lookup(name)
+		if p == nil {
+			http.Error(rw, "unknown profile "+name, http.StatusNotFound)
+			return
+		}
+		debug, _ := strconv.Atoi(req.URL.Query().Get("debug"))
+		if debug > 0 {
+			rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			rw.Header().Set("Content-Type", "application/octet-stream")
+		}
+		if name == "heap" && req.URL.Query().Get("gc") != "" {
+			runtime.GC()
+		}
+		_ = p.WriteTo(rw, debug)
+	}
+}
+
+func seconds(req *http.Request, defaultSeconds int) (time.Duration, error) {
+	value := req.URL.Query().Get("seconds")
+	if value == "" {
+		return time.Duration(defaultSeconds) * time.Second, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid seconds %q", value)
+	}
+	if duration := time.Duration(n) * time.Second; duration <= maxProfileDuration {
+		return duration, nil
+	}
+	return maxProfileDuration, nil
+}
+
+func sleep(ctx context.Context, duration time.Duration) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+type gcReport struct {
+	Goroutines int              `json:"goroutines"`
+	NumGC      int64            `json:"numGC"`
+	LastGC     time.Time        `json:"lastGC"`
+	PauseTotal time.Duration    `json:"pauseTotal"`
+	MemStats   runtime.MemStats `json:"memStats"`
+}
+
+func gcStats(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		rdebug.FreeOSMemory()
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var stats rdebug.GCStats
+	rdebug.ReadGCStats(&stats)
+	report := gcReport{
+		Goroutines: runtime.NumGoroutine(),
+		NumGC:      stats.NumGC,
+		LastGC:     stats.LastGC,
+		PauseTotal: stats.PauseTotal,
+	}
+	runtime.ReadMemStats(&report.MemStats)
+	writeJSON(rw, report)
+}
+
+type cacheSize struct {
+	Synced  bool `json:"synced"`
+	Objects int  `json:"objects"`
+}
+
+func cacheSizes(ctx context.Context, caches cache.SharedCacheFactory) map[string]cacheSize {
+	result := map[string]cacheSize{}
+	if caches == nil {
+		return result
+	}
+	// the caches are only listed, not waited for
+	done, cancel := context.WithCancel(ctx)
+	cancel()
+	for gvk, synced := range caches.WaitForCacheSync(done) {
+		size := cacheSize{Synced: synced}
+		if informer, err := caches.ForKind(gvk); err == nil {
+			size.Objects = len(informer.GetStore().ListKeys())
+		}
+		result[gvk.String()] = size
+	}
+	return result
+}
+
+func writeJSON(rw http.ResponseWriter, obj interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(obj)
+}
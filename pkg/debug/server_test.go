@@ -0,0 +1,59 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandlerRequiresToken(t *testing.T) {
+	_, err := NewHandler(Options{})
+	assert.Error(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	handler, err := NewHandler(Options{Token: "secret"})
+	require.NoError(t, err)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/debug/gc", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, get("/debug/gc", "wrong").Code)
+
+	// the token must be sent as a bearer token
+	req := httptest.NewRequest(http.MethodGet, "/debug/gc", nil)
+	req.Header.Set("Authorization", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = get("/debug/gc", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report gcReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Positive(t, report.Goroutines)
+
+	rec = get("/debug/goroutines", "secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = get("/debug/pprof/heap?debug=1", "secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusNotFound, get("/debug/pprof/missing", "secret").Code)
+
+	rec = get("/debug/caches", "secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "{}", rec.Body.String())
+}
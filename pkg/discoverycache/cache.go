@@ -0,0 +1,140 @@
+// Package discoverycache shares the discovery of the API server, and a RESTMapper built on it, between the
+// components of a process, refreshing them after a TTL or when CRDs change.
+package discoverycache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// Cache is a discovery client whose results are cached until Invalidate, and a RESTMapper using them.
+type Cache struct {
+	discovery.CachedDiscoveryInterface
+
+	ttl    time.Duration
+	mapper *restmapper.DeferredDiscoveryRESTMapper
+
+	lock      sync.Mutex
+	listeners []func()
+}
+
+// New caches the discovery of client, for ttl if it is positive, until the cache is invalidated otherwise.
+func New(client discovery.DiscoveryInterface, ttl time.Duration) *Cache {
+	cached := memory.NewMemCacheClient(client)
+	return &Cache{
+		CachedDiscoveryInterface: cached,
+		ttl:                      ttl,
+		mapper:                   restmapper.NewDeferredDiscoveryRESTMapper(cached),
+	}
+}
+
+func NewForConfig(config *rest.Config, ttl time.Duration) (*Cache, error) {
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, ttl), nil
+}
+
+// RESTMapper returns a RESTMapper using the cache, it is refreshed with the cache.
+func (c *Cache) RESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+// ClientFactoryOptions returns options for the lasso client factories of controllers and object clients, so that
+// they map kinds to resources with the cache instead of their own discovery.
+func (c *Cache) ClientFactoryOptions(scheme *runtime.Scheme) *client.SharedClientFactoryOptions {
+	return &client.SharedClientFactoryOptions{
+		Mapper: c.mapper,
+		Scheme: scheme,
+	}
+}
+
+// OnInvalidate calls f each time the cache is invalidated, to register dynamic schemas again for instance.
+func (c *Cache) OnInvalidate(f func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.listeners = append(c.listeners, f)
+}
+
+// Invalidate drops the cached discovery, the next calls discover the API server again.
+func (c *Cache) Invalidate() {
+	// resetting the mapper invalidates the discovery client as well
+	c.mapper.Reset()
+
+	c.lock.Lock()
+	listeners := append([]func(){}, c.listeners...)
+	c.lock.Unlock()
+	for _, f := range listeners {
+		f()
+	}
+}
+
+// Start invalidates the cache every TTL until ctx is done.
+func (c *Cache) Start(ctx context.Context) {
+	if c.ttl <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(c.ttl)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Invalidate()
+			}
+		}
+	}()
+}
+
+// InvalidateOnChange invalidates the cache when objects of informer, usually CRDs or APIServices, are added,
+// changed or removed. Resyncs don't invalidate the cache.
+func (c *Cache) InvalidateOnChange(informer toolscache.SharedIndexInformer) error {
+	_, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			// the objects listed on start are already discovered
+			if informer.HasSynced() {
+				c.invalidateFor("added", obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, err := meta.Accessor(oldObj)
+			if err != nil {
+				return
+			}
+			newMeta, err := meta.Accessor(newObj)
+			if err != nil || oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+				return
+			}
+			c.invalidateFor("changed", newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.invalidateFor("removed", obj)
+		},
+	})
+	return err
+}
+
+func (c *Cache) invalidateFor(change string, obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if o, ok := obj.(metav1.Object); ok {
+		logrus.Debugf("invalidating discovery, %s %s", o.GetName(), change)
+	}
+	c.Invalidate()
+}
@@ -0,0 +1,48 @@
+package discoverycache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestInvalidate(t *testing.T) {
+	fake := &k8stesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "example.cattle.io/v3",
+				APIResources: []metav1.APIResource{
+					{Name: "widgets", Kind: "Widget", Namespaced: true},
+				},
+			},
+		},
+	}
+	c := New(&fakediscovery.FakeDiscovery{Fake: fake}, 0)
+
+	invalidated := 0
+	c.OnInvalidate(func() {
+		invalidated++
+	})
+
+	widget := schema.GroupKind{Group: "example.cattle.io", Kind: "Widget"}
+	gadget := schema.GroupKind{Group: "example.cattle.io", Kind: "Gadget"}
+	mapping, err := c.RESTMapper().RESTMapping(widget, "v3")
+	require.NoError(t, err)
+	assert.Equal(t, "widgets", mapping.Resource.Resource)
+
+	fake.Resources[0].APIResources = append(fake.Resources[0].APIResources,
+		metav1.APIResource{Name: "gadgets", Kind: "Gadget"})
+	_, err = c.RESTMapper().RESTMapping(gadget, "v3")
+	assert.Error(t, err, "discovery is cached")
+
+	c.Invalidate()
+	assert.Equal(t, 1, invalidated)
+	mapping, err = c.RESTMapper().RESTMapping(gadget, "v3")
+	require.NoError(t, err)
+	assert.Equal(t, "gadgets", mapping.Resource.Resource)
+}
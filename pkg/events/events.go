@@ -0,0 +1,154 @@
+package events
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/rancher/norman/types"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	Link         = "events"
+	InlineField  = "recentEvents"
+	DefaultLimit = 10
+)
+
+// Event is the API representation of a Kubernetes Event.
+type Event struct {
+	Type           string      `json:"type,omitempty"`
+	Reason         string      `json:"reason,omitempty"`
+	Message        string      `json:"message,omitempty"`
+	Count          int32       `json:"count,omitempty"`
+	Source         string      `json:"source,omitempty"`
+	FirstTimestamp metav1.Time `json:"firstTimestamp,omitempty"`
+	LastTimestamp  metav1.Time `json:"lastTimestamp,omitempty"`
+}
+
+// Lister finds the most recent events of the resources of one Kubernetes kind.
+type Lister struct {
+	client kubernetes.Interface
+	kind   string
+	limit  int
+}
+
+// NewLister lists the events whose involved object is of kind. At most limit events are returned, DefaultLimit
+// if limit is not positive.
+func NewLister(client kubernetes.Interface, kind string, limit int) *Lister {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Lister{
+		client: client,
+		kind:   kind,
+		limit:  limit,
+	}
+}
+
+// AddLink serves the events of a resource, newest first, at its events sub-resource.
+func (l *Lister) AddLink(schema *types.Schema) {
+	if schema.SubResources == nil {
+		schema.SubResources = map[string]types.SubResource{}
+	}
+	schema.SubResources[Link] = types.SubResource{
+		Handler: l.handler,
+	}
+}
+
+// AddInline adds the events of a resource to the recentEvents field when it is read by ID. Collections are left
+// alone, they would need a query per row.
+func (l *Lister) AddInline(schema *types.Schema) {
+	formatter := schema.Formatter
+	schema.Formatter = func(apiContext *types.APIContext, resource *types.RawResource) {
+		if formatter != nil {
+			formatter(apiContext, resource)
+		}
+		if apiContext.ID == "" || apiContext.ID != resource.ID || apiContext.Link != "" {
+			return
+		}
+
+		events, err := l.List(apiContext, resource.ID)
+		if err != nil {
+			logrus.Debugf("failed to list events of %s %s: %v", l.kind, resource.ID, err)
+			return
+		}
+		resource.Values[InlineField] = events
+	}
+}
+
+// List returns the events of the resource with the norman ID id, parsed by the IDStrategy of the schema of the
+// request.
+func (l *Lister) List(apiContext *types.APIContext, id string) ([]Event, error) {
+	namespace, name, err := apiContext.Schema.IDs().ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	selector := fields.Set{
+		"involvedObject.kind": l.kind,
+		"involvedObject.name": name,
+	}
+	if namespace != "" {
+		selector["involvedObject.namespace"] = namespace
+	}
+
+	list, err := l.client.CoreV1().Events(namespace).List(apiContext.Request.Context(), metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		return lastSeen(&items[j]).Time.Before(lastSeen(&items[i]).Time)
+	})
+	if len(items) > l.limit {
+		items = items[:l.limit]
+	}
+
+	result := make([]Event, 0, len(items))
+	for _, event := range items {
+		result = append(result, Event{
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			Count:          event.Count,
+			Source:         event.Source.Component,
+			FirstTimestamp: event.FirstTimestamp,
+			LastTimestamp:  lastSeen(&event),
+		})
+	}
+	return result, nil
+}
+
+func (l *Lister) handler(apiContext *types.APIContext, _ types.RequestHandler) error {
+	events, err := l.List(apiContext, apiContext.ID)
+	if err != nil {
+		return err
+	}
+
+	encoder, contentType := types.JSONEncoder, "application/json"
+	if apiContext.ResponseFormat == "yaml" {
+		encoder, contentType = types.YAMLEncoder, "application/yaml"
+	}
+	apiContext.Response.Header().Set("Content-Type", contentType)
+	apiContext.Response.WriteHeader(http.StatusOK)
+	return encoder(apiContext.Response, map[string]interface{}{
+		"data": events,
+	})
+}
+
+func lastSeen(event *v1.Event) metav1.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp
+	case !event.EventTime.IsZero():
+		return metav1.NewTime(event.EventTime.Time)
+	default:
+		return event.CreationTimestamp
+	}
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func event(name string, age time.Duration) *v1.Event {
+	return &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      "ConfigMap",
+			Namespace: "default",
+			Name:      "config",
+		},
+		Reason:        name,
+		LastTimestamp: metav1.NewTime(time.Now().Add(-age)),
+	}
+}
+
+func TestList(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		event("old", time.Hour),
+		event("newest", time.Second),
+		event("new", time.Minute),
+	)
+	apiContext := &types.APIContext{
+		Request: httptest.NewRequest("GET", "/v3/configmaps/default:config", nil),
+	}
+
+	events, err := NewLister(client, "ConfigMap", 2).List(apiContext, "default:config")
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "newest", events[0].Reason)
+		assert.Equal(t, "new", events[1].Reason)
+	}
+}
@@ -0,0 +1,97 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/rancher/norman/api"
+	"github.com/rancher/wrangler/v3/pkg/leader"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// StandbyHeader is set to true on the responses served while standing by.
+const StandbyHeader = "X-API-Standby"
+
+const standbyMessage = "standing by, not the leader"
+
+type StandbyOptions struct {
+	// WarmCaches starts the informer caches while waiting to lead, like the Start of a SharedCacheFactory, so that
+	// failover doesn't wait for them to list every object
+	WarmCaches func(ctx context.Context) error
+	// Server, if set, is read-only while standing by
+	Server *api.Server
+}
+
+// Standby runs the controllers of the leader, and keeps the caches warm and serves reads while not leading.
+type Standby struct {
+	opts    StandbyOptions
+	leading atomic.Bool
+}
+
+func NewStandby(opts StandbyOptions) *Standby {
+	return &Standby{opts: opts}
+}
+
+// Leading returns whether this process is the leader.
+func (s *Standby) Leading() bool {
+	return s.leading.Load()
+}
+
+// Run warms the caches, puts the server in read-only mode and calls leading once elected leader of name in
+// namespace. Like leader.RunOrDie, it exits the process if leadership is lost.
+func (s *Standby) Run(ctx context.Context, namespace, name string, client kubernetes.Interface, leading func(ctx context.Context)) error {
+	if err := s.standby(ctx); err != nil {
+		return err
+	}
+	leader.RunOrDie(ctx, namespace, name, client, func(ctx context.Context) {
+		s.lead(ctx, leading)
+	})
+	return nil
+}
+
+func (s *Standby) standby(ctx context.Context) error {
+	if s.opts.Server != nil {
+		s.opts.Server.SetStandby(standbyMessage)
+	}
+	if s.opts.WarmCaches != nil {
+		if err := s.opts.WarmCaches(ctx); err != nil {
+			return err
+		}
+	}
+	logrus.Info("standing by until elected leader")
+	return nil
+}
+
+func (s *Standby) lead(ctx context.Context, leading func(ctx context.Context)) {
+	logrus.Info("elected leader, leaving standby")
+	s.leading.Store(true)
+	if s.opts.Server != nil {
+		s.opts.Server.SetStandby("")
+	}
+	leading(ctx)
+}
+
+// Wrap sets the StandbyHeader on the responses of next while standing by.
+func (s *Standby) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !s.Leading() {
+			rw.Header().Set(StandbyHeader, "true")
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// Handler serves whether this process is leading or standing by as JSON, it is meant to be mounted on an internal
+// endpoint.
+func (s *Standby) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]bool{
+			"leader":  s.Leading(),
+			"standby": !s.Leading(),
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package leader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/norman/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandby(t *testing.T) {
+	server := api.NewAPIServer()
+	warmed := false
+	standby := NewStandby(StandbyOptions{
+		WarmCaches: func(ctx context.Context) error {
+			warmed = true
+			return nil
+		},
+		Server: server,
+	})
+	handler := standby.Wrap(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+
+	require.NoError(t, standby.standby(context.Background()))
+	assert.True(t, warmed)
+	assert.Equal(t, api.ReadOnlyStatus{Standby: standbyMessage}, server.ReadOnly())
+	server.SetReadOnly(true, "frozen")
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "true", resp.Header().Get(StandbyHeader))
+
+	led := false
+	standby.lead(context.Background(), func(ctx context.Context) {
+		led = true
+	})
+	assert.True(t, led)
+	assert.True(t, standby.Leading())
+	assert.Equal(t, api.ReadOnlyStatus{Enabled: true, Message: "frozen"}, server.ReadOnly())
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, resp.Header().Get(StandbyHeader))
+
+	resp = httptest.NewRecorder()
+	standby.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/leader", nil))
+	assert.JSONEq(t, `{"leader":true,"standby":false}`, resp.Body.String())
+}
@@ -0,0 +1,222 @@
+package metadatacache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rancher/lasso/pkg/cache"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+type sharedCacheFactory struct {
+	cache.SharedCacheFactory
+
+	client metadata.Interface
+	opts   *cache.SharedCacheFactoryOptions
+	kinds  map[schema.GroupVersionKind]bool
+
+	lock     sync.Mutex
+	informer map[schema.GroupVersionKind]toolscache.SharedIndexInformer
+	started  map[schema.GroupVersionKind]bool
+}
+
+// NewSharedCacheFactory returns a cache factory serving metadata-only informers for kinds, which cache
+// *metav1.PartialObjectMetadata with the names, labels, annotations and owner references of the objects but not
+// their spec or status, and the informers of delegate for the other kinds. The objects of kinds are passed to
+// handlers as *metav1.PartialObjectMetadata, without their managed fields, so they must be handled by generic
+// controllers, the typed generated controllers panic when they are built for kinds. opts, which may be nil, sets the namespace, resync and list options of the
+// metadata informers like it does for the informers of delegate.
+func NewSharedCacheFactory(config *rest.Config, delegate cache.SharedCacheFactory, opts *cache.SharedCacheFactoryOptions,
+	kinds ...schema.GroupVersionKind) (cache.SharedCacheFactory, error) {
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return newSharedCacheFactory(metadataClient, delegate, opts, kinds...), nil
+}
+
+func newSharedCacheFactory(metadataClient metadata.Interface, delegate cache.SharedCacheFactory, opts *cache.SharedCacheFactoryOptions,
+	kinds ...schema.GroupVersionKind) *sharedCacheFactory {
+	if opts == nil {
+		opts = &cache.SharedCacheFactoryOptions{}
+	}
+	factory := &sharedCacheFactory{
+		SharedCacheFactory: delegate,
+		client:             metadataClient,
+		opts:               opts,
+		kinds:              map[schema.GroupVersionKind]bool{},
+		informer:           map[schema.GroupVersionKind]toolscache.SharedIndexInformer{},
+		started:            map[schema.GroupVersionKind]bool{},
+	}
+	for _, gvk := range kinds {
+		factory.kinds[gvk] = true
+	}
+	return factory
+}
+
+// NewSharedControllerFactory builds a controller factory, suitable for the generated NewFromControllerFactory
+// functions, caching only the metadata of kinds.
+func NewSharedControllerFactory(config *rest.Config, scheme *runtime.Scheme, opts *controller.SharedControllerFactoryOptions,
+	kinds ...schema.GroupVersionKind) (controller.SharedControllerFactory, error) {
+	clientFactory, err := client.NewSharedClientFactory(config, &client.SharedClientFactoryOptions{
+		Scheme: scheme,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheOpts *cache.SharedCacheFactoryOptions
+	if opts != nil {
+		cacheOpts = opts.CacheOptions
+	}
+
+	cacheFactory, err := NewSharedCacheFactory(config, cache.NewSharedCachedFactory(clientFactory, cacheOpts), cacheOpts, kinds...)
+	if err != nil {
+		return nil, err
+	}
+	return controller.NewSharedControllerFactory(cacheFactory, opts), nil
+}
+
+func (s *sharedCacheFactory) ForObject(obj runtime.Object) (toolscache.SharedIndexInformer, error) {
+	return s.ForKind(obj.GetObjectKind().GroupVersionKind())
+}
+
+func (s *sharedCacheFactory) ForResource(gvr schema.GroupVersionResource, namespaced bool) (toolscache.SharedIndexInformer, error) {
+	return s.ForResourceKind(gvr, "", namespaced)
+}
+
+func (s *sharedCacheFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) (toolscache.SharedIndexInformer, error) {
+	if kind == "" {
+		gvk, err := s.SharedClientFactory().GVKForResource(gvr)
+		if err != nil {
+			return nil, err
+		}
+		kind = gvk.Kind
+	}
+
+	gvk := gvr.GroupVersion().WithKind(kind)
+	if !s.kinds[gvk] {
+		return s.SharedCacheFactory.ForResourceKind(gvr, kind, namespaced)
+	}
+	return s.metadataInformer(gvk, gvr, namespaced), nil
+}
+
+func (s *sharedCacheFactory) ForKind(gvk schema.GroupVersionKind) (toolscache.SharedIndexInformer, error) {
+	if !s.kinds[gvk] {
+		return s.SharedCacheFactory.ForKind(gvk)
+	}
+	gvr, namespaced, err := s.SharedClientFactory().ResourceForGVK(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return s.metadataInformer(gvk, gvr, namespaced), nil
+}
+
+func (s *sharedCacheFactory) metadataInformer(gvk schema.GroupVersionKind, gvr schema.GroupVersionResource, namespaced bool) toolscache.SharedIndexInformer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if informer, ok := s.informer[gvk]; ok {
+		return informer
+	}
+
+	resync, ok := s.opts.KindResync[gvk]
+	if !ok {
+		resync = s.opts.DefaultResync
+	}
+	namespace, ok := s.opts.KindNamespace[gvk]
+	if !ok {
+		namespace = s.opts.DefaultNamespace
+	}
+	if !namespaced {
+		namespace = metav1.NamespaceAll
+	}
+	tweakList, ok := s.opts.KindTweakList[gvk]
+	if !ok {
+		tweakList = s.opts.DefaultTweakList
+	}
+
+	informer := &metadataInformer{metadatainformer.NewFilteredMetadataInformer(s.client, gvr, namespace, resync,
+		toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc},
+		metadatainformer.TweakListOptionsFunc(tweakList)).Informer()}
+	if err := informer.SetTransform(stripManagedFields); err != nil {
+		logrus.Errorf("failed to strip the managed fields of %s: %v", gvk, err)
+	}
+	s.informer[gvk] = informer
+	return informer
+}
+
+// metadataInformer is a metadata-only informer, see controller.RequireFullObjects.
+type metadataInformer struct {
+	toolscache.SharedIndexInformer
+}
+
+func (*metadataInformer) MetadataOnly() bool {
+	return true
+}
+
+// stripManagedFields drops the managed fields of the cached objects, they are often larger than the rest of the
+// metadata and handlers do not need them.
+func stripManagedFields(obj interface{}) (interface{}, error) {
+	if m, ok := obj.(*metav1.PartialObjectMetadata); ok {
+		m.ManagedFields = nil
+	}
+	return obj, nil
+}
+
+func (s *sharedCacheFactory) Start(ctx context.Context) error {
+	if err := s.SharedCacheFactory.Start(ctx); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for gvk, informer := range s.informer {
+		if !s.started[gvk] {
+			go informer.Run(ctx.Done())
+			s.started[gvk] = true
+		}
+	}
+	return nil
+}
+
+func (s *sharedCacheFactory) StartGVK(ctx context.Context, gvk schema.GroupVersionKind) error {
+	if !s.kinds[gvk] {
+		return s.SharedCacheFactory.StartGVK(ctx, gvk)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if informer, ok := s.informer[gvk]; ok && !s.started[gvk] {
+		go informer.Run(ctx.Done())
+		s.started[gvk] = true
+	}
+	return nil
+}
+
+func (s *sharedCacheFactory) WaitForCacheSync(ctx context.Context) map[schema.GroupVersionKind]bool {
+	result := s.SharedCacheFactory.WaitForCacheSync(ctx)
+
+	s.lock.Lock()
+	started := map[schema.GroupVersionKind]toolscache.SharedIndexInformer{}
+	for gvk, informer := range s.informer {
+		if s.started[gvk] {
+			started[gvk] = informer
+		}
+	}
+	s.lock.Unlock()
+
+	for gvk, informer := range started {
+		result[gvk] = toolscache.WaitForCacheSync(ctx.Done(), informer.HasSynced)
+	}
+	return result
+}
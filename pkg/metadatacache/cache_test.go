@@ -0,0 +1,69 @@
+package metadatacache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/lasso/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata/fake"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+type delegateFactory struct {
+	cache.SharedCacheFactory
+	kinds []string
+}
+
+func (d *delegateFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) (toolscache.SharedIndexInformer, error) {
+	d.kinds = append(d.kinds, kind)
+	return nil, nil
+}
+
+func (d *delegateFactory) Start(ctx context.Context) error {
+	return nil
+}
+
+func (d *delegateFactory) WaitForCacheSync(ctx context.Context) map[schema.GroupVersionKind]bool {
+	return map[schema.GroupVersionKind]bool{}
+}
+
+func TestMetadataInformer(t *testing.T) {
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	pod := &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a", Labels: map[string]string{"app": "x"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}},
+	}
+	scheme := fake.NewTestScheme()
+	require.NoError(t, metav1.AddMetaToScheme(scheme))
+	delegate := &delegateFactory{}
+	factory := newSharedCacheFactory(fake.NewSimpleMetadataClient(scheme, pod), delegate, nil, podGVK)
+
+	_, err := factory.ForResourceKind(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ConfigMap", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap"}, delegate.kinds)
+
+	informer, err := factory.ForResourceKind(schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "Pod", true)
+	require.NoError(t, err)
+	again, err := factory.ForResourceKind(schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "Pod", true)
+	require.NoError(t, err)
+	assert.Same(t, informer, again)
+	assert.Equal(t, []string{"ConfigMap"}, delegate.kinds)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, factory.Start(ctx))
+	assert.Equal(t, map[schema.GroupVersionKind]bool{podGVK: true}, factory.WaitForCacheSync(ctx))
+
+	obj, exists, err := informer.GetIndexer().GetByKey("default/a")
+	require.NoError(t, err)
+	require.True(t, exists)
+	cached := obj.(*metav1.PartialObjectMetadata)
+	assert.Equal(t, map[string]string{"app": "x"}, cached.Labels)
+	assert.Nil(t, cached.ManagedFields)
+	assert.True(t, informer.(*metadataInformer).MetadataOnly())
+}
@@ -0,0 +1,161 @@
+package podproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	stdinChannel byte = iota
+	stdoutChannel
+	stderrChannel
+	errorChannel
+	resizeChannel
+)
+
+// conn is the websocket of a client, the gorilla connection doesn't support concurrent writes.
+type conn struct {
+	ws         *websocket.Conn
+	writeLock  sync.Mutex
+	lastActive atomic.Int64
+}
+
+func newConn(ws *websocket.Conn) *conn {
+	c := &conn{ws: ws}
+	c.touch()
+	return c
+}
+
+func (c *conn) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+func (c *conn) write(channel byte, data []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	c.touch()
+	return c.ws.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, data...))
+}
+
+func (c *conn) read() (byte, []byte, error) {
+	for {
+		_, message, err := c.ws.ReadMessage()
+		if err != nil {
+			return 0, nil, err
+		}
+		c.touch()
+		if len(message) > 0 {
+			return message[0], message[1:], nil
+		}
+	}
+}
+
+func (c *conn) close() {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+	_ = c.ws.Close()
+}
+
+// closeIdle cancels the session when nothing was read or written for timeout.
+func (c *conn) closeIdle(ctx context.Context, cancel context.CancelFunc, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			idle := time.Since(time.Unix(0, c.lastActive.Load()))
+			if idle >= timeout {
+				_ = c.write(errorChannel, []byte("idle timeout"))
+				cancel()
+				c.close()
+				return
+			}
+			timer.Reset(timeout - idle)
+		}
+	}
+}
+
+func (c *conn) writer(channel byte) io.Writer {
+	return channelWriter{conn: c, channel: channel}
+}
+
+type channelWriter struct {
+	conn    *conn
+	channel byte
+}
+
+func (w channelWriter) Write(p []byte) (int, error) {
+	if err := w.conn.write(w.channel, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type terminalStreams struct {
+	stdin *io.PipeReader
+	sizes sizeQueue
+}
+
+// streams reads stdin and terminal resizes from the client until the websocket is closed, which cancels the
+// session.
+func (c *conn) streams(ctx context.Context, cancel context.CancelFunc) terminalStreams {
+	stdin, stdinWriter := io.Pipe()
+	sizes := make(sizeQueue)
+
+	go func() {
+		defer close(sizes)
+		defer cancel()
+		for {
+			channel, data, err := c.read()
+			if err != nil {
+				_ = stdinWriter.CloseWithError(io.EOF)
+				return
+			}
+
+			switch channel {
+			case stdinChannel:
+				if _, err := stdinWriter.Write(data); err != nil {
+					return
+				}
+			case resizeChannel:
+				var size remotecommand.TerminalSize
+				if err := json.Unmarshal(data, &size); err != nil {
+					continue
+				}
+				select {
+				case sizes <- size:
+				case <-ctx.Done():
+					_ = stdinWriter.CloseWithError(errors.New("session closed"))
+					return
+				}
+			}
+		}
+	}()
+
+	return terminalStreams{
+		stdin: stdin,
+		sizes: sizes,
+	}
+}
+
+type sizeQueue chan remotecommand.TerminalSize
+
+func (s sizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-s
+	if !ok {
+		return nil
+	}
+	return &size
+}
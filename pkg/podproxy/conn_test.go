@@ -0,0 +1,79 @@
+package podproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+func serve(t *testing.T, handler func(c *conn)) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ws, err := upgrader.Upgrade(rw, req, nil)
+		require.NoError(t, err)
+		handler(newConn(ws))
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestStreams(t *testing.T) {
+	sizes := make(chan *remotecommand.TerminalSize, 1)
+	stdin := make(chan string, 1)
+	client := serve(t, func(c *conn) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		streams := c.streams(ctx, cancel)
+
+		sizes <- streams.sizes.Next()
+		buf := make([]byte, 5)
+		_, _ = io.ReadFull(streams.stdin, buf)
+		stdin <- string(buf)
+		_, _ = c.writer(stdoutChannel).Write([]byte("world"))
+		<-ctx.Done()
+	})
+
+	require.NoError(t, client.WriteMessage(websocket.BinaryMessage, append([]byte{resizeChannel}, `{"width":80,"height":24}`...)))
+	require.NoError(t, client.WriteMessage(websocket.BinaryMessage, append([]byte{stdinChannel}, "hello"...)))
+
+	assert.Equal(t, &remotecommand.TerminalSize{Width: 80, Height: 24}, <-sizes)
+	assert.Equal(t, "hello", <-stdin)
+
+	_, message, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{stdoutChannel}, "world"...), message)
+}
+
+func TestCloseIdle(t *testing.T) {
+	client := serve(t, func(c *conn) {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.closeIdle(ctx, cancel, 50*time.Millisecond)
+	})
+
+	_, message, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{errorChannel}, "idle timeout"...), message)
+
+	_, _, err = client.ReadMessage()
+	assert.Error(t, err)
+}
+
+func TestSizeQueueClosed(t *testing.T) {
+	sizes := make(sizeQueue, 1)
+	sizes <- remotecommand.TerminalSize{Width: 1, Height: 2}
+	close(sizes)
+	assert.Equal(t, &remotecommand.TerminalSize{Width: 1, Height: 2}, sizes.Next())
+	assert.Nil(t, sizes.Next())
+}
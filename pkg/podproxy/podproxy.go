@@ -0,0 +1,275 @@
+package podproxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	ExecLink        = "exec"
+	AttachLink      = "attach"
+	PortForwardLink = "portforward"
+)
+
+var upgrader = websocket.Upgrader{}
+
+// Session describes a proxied connection to a pod.
+type Session struct {
+	// Verb is exec, attach or portforward
+	Verb      string
+	User      string
+	Namespace string
+	Pod       string
+	Container string
+	Command   []string
+	Ports     []string
+	TTY       bool
+	Start     time.Time
+
+	// config impersonates the user of the session
+	config *rest.Config
+}
+
+type Options struct {
+	// IdleTimeout closes sessions without traffic in either direction, defaults to 30 minutes
+	IdleTimeout time.Duration
+	// OnStart is called before a session connects to the pod, an error rejects the session
+	OnStart func(apiContext *types.APIContext, session *Session) error
+	// OnEnd is called when a session is closed, with the error that closed it if any
+	OnEnd func(apiContext *types.APIContext, session *Session, err error)
+	// Pod returns the pod of a request, defaults to the ID of the resource, [namespace:]name
+	Pod func(apiContext *types.APIContext) (namespace, name string, err error)
+}
+
+// Proxy serves pod exec, attach and port-forward over websockets. Messages are binary with the channel as first
+// byte: stdin (0), stdout (1), stderr (2), error (3) and resize (4), a JSON {"width":..., "height":...}, for exec
+// and attach; data (2i) and error (2i+1) of the i-th port for port-forward.
+type Proxy struct {
+	config *rest.Config
+	client rest.Interface
+	opts   Options
+}
+
+func New(config *rest.Config, opts Options) (*Proxy, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 30 * time.Minute
+	}
+	if opts.Pod == nil {
+		opts.Pod = podFromID
+	}
+	return &Proxy{
+		config: config,
+		client: client.CoreV1().RESTClient(),
+		opts:   opts,
+	}, nil
+}
+
+// AddSubResources serves exec, attach and portforward as sub-resources of schema. Users need to be allowed to
+// update the resource to use them, and sessions connect to the pod impersonating the user, so the API server
+// checks that the user may create pods/exec, pods/attach or pods/portforward on the pod as well.
+func (p *Proxy) AddSubResources(schema *types.Schema) {
+	if schema.SubResources == nil {
+		schema.SubResources = map[string]types.SubResource{}
+	}
+	schema.SubResources[ExecLink] = types.SubResource{Handler: p.Exec}
+	schema.SubResources[AttachLink] = types.SubResource{Handler: p.Attach}
+	schema.SubResources[PortForwardLink] = types.SubResource{Handler: p.PortForward}
+}
+
+// Exec runs the command query parameters in the container of the container query parameter, with a terminal if
+// tty is true.
+func (p *Proxy) Exec(apiContext *types.APIContext, _ types.RequestHandler) error {
+	return p.stream(apiContext, ExecLink)
+}
+
+// Attach attaches to the container of the container query parameter.
+func (p *Proxy) Attach(apiContext *types.APIContext, _ types.RequestHandler) error {
+	return p.stream(apiContext, AttachLink)
+}
+
+func (p *Proxy) session(apiContext *types.APIContext, verb string) (*Session, error) {
+	if err := apiContext.AccessControl.CanUpdate(apiContext, nil, apiContext.Schema); err != nil {
+		return nil, err
+	}
+
+	namespace, name, err := p.opts.Pod(apiContext)
+	if err != nil {
+		return nil, err
+	}
+
+	query := apiContext.Request.URL.Query()
+	session := &Session{
+		Verb:      verb,
+		Namespace: namespace,
+		Pod:       name,
+		Container: query.Get("container"),
+		Command:   query["command"],
+		Ports:     query["port"],
+		TTY:       convert.ToBool(query.Get("tty")),
+		Start:     time.Now(),
+	}
+	session.config, err = p.impersonate(apiContext, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if verb == ExecLink && len(session.Command) == 0 {
+		return nil, httperror.NewAPIError(httperror.MissingRequired, "command is required")
+	}
+	if verb == PortForwardLink && len(session.Ports) == 0 {
+		return nil, httperror.NewAPIError(httperror.MissingRequired, "port is required")
+	}
+	if len(session.Ports) > 127 {
+		return nil, httperror.NewAPIError(httperror.InvalidOption, "too many ports")
+	}
+	for _, port := range session.Ports {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return nil, httperror.NewAPIError(httperror.InvalidOption, "invalid port "+port)
+		}
+	}
+
+	if p.opts.OnStart != nil {
+		if err := p.opts.OnStart(apiContext, session); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// impersonate returns the config of the proxy impersonating the user of the request, from its authenticated user or
+// its impersonation headers. Requests without a user are rejected rather than run with the credentials of the
+// server.
+func (p *Proxy) impersonate(apiContext *types.APIContext, session *Session) (*rest.Config, error) {
+	impersonate := rest.ImpersonationConfig{}
+	if apiContext.User != nil && apiContext.User.Name != "" {
+		impersonate.UserName = apiContext.User.Name
+		impersonate.Groups = apiContext.User.Groups
+	} else if user := apiContext.Request.Header.Get("Impersonate-User"); user != "" {
+		impersonate.UserName = user
+		impersonate.Groups = apiContext.Request.Header.Values("Impersonate-Group")
+	} else {
+		return nil, httperror.NewAPIError(httperror.PermissionDenied, "pod sessions require an authenticated user")
+	}
+	for header, values := range apiContext.Request.Header {
+		if key, ok := strings.CutPrefix(header, "Impersonate-Extra-"); ok {
+			if impersonate.Extra == nil {
+				impersonate.Extra = map[string][]string{}
+			}
+			impersonate.Extra[strings.ToLower(key)] = values
+		}
+	}
+
+	session.User = impersonate.UserName
+	config := rest.CopyConfig(p.config)
+	config.Impersonate = impersonate
+	return config, nil
+}
+
+func (p *Proxy) stream(apiContext *types.APIContext, verb string) error {
+	session, err := p.session(apiContext, verb)
+	if err != nil {
+		return err
+	}
+
+	ws, err := upgrader.Upgrade(apiContext.Response, apiContext.Request, nil)
+	if err != nil {
+		return err
+	}
+
+	err = p.runStream(apiContext.Request.Context(), newConn(ws), session)
+	p.end(apiContext, session, err)
+	return nil
+}
+
+func (p *Proxy) runStream(ctx context.Context, c *conn, session *Session) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer c.close()
+	go c.closeIdle(ctx, cancel, p.opts.IdleTimeout)
+
+	request := p.client.Post().
+		Namespace(session.Namespace).
+		Resource("pods").
+		Name(session.Pod).
+		SubResource(session.Verb)
+	if session.Verb == ExecLink {
+		request = request.VersionedParams(&corev1.PodExecOptions{
+			Container: session.Container,
+			Command:   session.Command,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    !session.TTY,
+			TTY:       session.TTY,
+		}, scheme.ParameterCodec)
+	} else {
+		request = request.VersionedParams(&corev1.PodAttachOptions{
+			Container: session.Container,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    !session.TTY,
+			TTY:       session.TTY,
+		}, scheme.ParameterCodec)
+	}
+
+	executor, err := remotecommand.NewSPDYExecutor(session.config, http.MethodPost, request.URL())
+	if err != nil {
+		return err
+	}
+
+	streams := c.streams(ctx, cancel)
+	defer streams.stdin.Close()
+	options := remotecommand.StreamOptions{
+		Stdin:  streams.stdin,
+		Stdout: c.writer(stdoutChannel),
+	}
+	if session.TTY {
+		options.Tty = true
+		options.TerminalSizeQueue = streams.sizes
+	} else {
+		options.Stderr = c.writer(stderrChannel)
+	}
+
+	err = executor.StreamWithContext(ctx, options)
+	if err != nil && ctx.Err() == nil {
+		_ = c.write(errorChannel, []byte(err.Error()))
+	}
+	return err
+}
+
+func (p *Proxy) end(apiContext *types.APIContext, session *Session, err error) {
+	if err != nil {
+		logrus.Debugf("%s session to pod %s/%s ended: %v", session.Verb, session.Namespace, session.Pod, err)
+	}
+	if p.opts.OnEnd != nil {
+		p.opts.OnEnd(apiContext, session, err)
+	}
+}
+
+func podFromID(apiContext *types.APIContext) (string, string, error) {
+	if apiContext.ID == "" {
+		return "", "", httperror.NewAPIError(httperror.NotFound, "no pod")
+	}
+	namespace, name, err := apiContext.Schema.IDs().ParseID(apiContext.ID)
+	if err != nil {
+		return "", "", httperror.NewAPIError(httperror.NotFound, err.Error())
+	}
+	return namespace, name, nil
+}
@@ -0,0 +1,54 @@
+package podproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestSessionImpersonates(t *testing.T) {
+	p := &Proxy{
+		config: &rest.Config{Host: "https://localhost"},
+		opts: Options{
+			Pod: func(apiContext *types.APIContext) (string, string, error) {
+				return "default", "pod", nil
+			},
+		},
+	}
+	apiContext := func(user *types.User, header http.Header) *types.APIContext {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/v1/pods/default:pod/exec?command=sh", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		return &types.APIContext{
+			Request:       req,
+			User:          user,
+			AccessControl: &authorization.AllAccess{},
+			Schema:        &types.Schema{ResourceMethods: []string{http.MethodPut}},
+		}
+	}
+
+	session, err := p.session(apiContext(&types.User{Name: "alice", Groups: []string{"devs"}}, nil), ExecLink)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", session.User)
+	assert.Equal(t, rest.ImpersonationConfig{UserName: "alice", Groups: []string{"devs"}}, session.config.Impersonate)
+	assert.Empty(t, p.config.Impersonate.UserName)
+
+	session, err = p.session(apiContext(nil, http.Header{
+		"Impersonate-User":         {"bob"},
+		"Impersonate-Extra-Tenant": {"a"},
+	}), ExecLink)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", session.config.Impersonate.UserName)
+	assert.Equal(t, map[string][]string{"tenant": {"a"}}, session.config.Impersonate.Extra)
+
+	_, err = p.session(apiContext(nil, nil), ExecLink)
+	assert.Equal(t, httperror.PermissionDenied, err.(*httperror.APIError).Code)
+}
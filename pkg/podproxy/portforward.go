@@ -0,0 +1,118 @@
+package podproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/rancher/norman/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForward forwards the ports of the port query parameters of the pod, one connection per port.
+func (p *Proxy) PortForward(apiContext *types.APIContext, _ types.RequestHandler) error {
+	session, err := p.session(apiContext, PortForwardLink)
+	if err != nil {
+		return err
+	}
+
+	ws, err := upgrader.Upgrade(apiContext.Response, apiContext.Request, nil)
+	if err != nil {
+		return err
+	}
+
+	err = p.runPortForward(apiContext.Request.Context(), newConn(ws), session)
+	p.end(apiContext, session, err)
+	return nil
+}
+
+func (p *Proxy) runPortForward(ctx context.Context, c *conn, session *Session) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer c.close()
+	go c.closeIdle(ctx, cancel, p.opts.IdleTimeout)
+
+	transport, upgrader, err := spdy.RoundTripperFor(session.config)
+	if err != nil {
+		return err
+	}
+	url := p.client.Post().
+		Namespace(session.Namespace).
+		Resource("pods").
+		Name(session.Pod).
+		SubResource(PortForwardLink).
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return err
+	}
+	defer streamConn.Close()
+
+	var (
+		wg          sync.WaitGroup
+		dataStreams []httpstream.Stream
+	)
+	for i, port := range session.Ports {
+		headers := http.Header{}
+		headers.Set(corev1.StreamType, corev1.StreamTypeError)
+		headers.Set(corev1.PortHeader, port)
+		headers.Set(corev1.PortForwardRequestIDHeader, strconv.Itoa(i))
+		errorStream, err := streamConn.CreateStream(headers)
+		if err != nil {
+			return err
+		}
+		_ = errorStream.Close()
+
+		headers.Set(corev1.StreamType, corev1.StreamTypeData)
+		dataStream, err := streamConn.CreateStream(headers)
+		if err != nil {
+			return err
+		}
+		dataStreams = append(dataStreams, dataStream)
+
+		channel := byte(2 * i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			message, err := io.ReadAll(errorStream)
+			if err == nil && len(message) > 0 {
+				_ = c.write(channel+1, message)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			defer cancel()
+			_, _ = io.Copy(c.writer(channel), dataStream)
+		}()
+	}
+
+	go func() {
+		defer cancel()
+		for {
+			channel, data, err := c.read()
+			if err != nil {
+				return
+			}
+			if channel%2 != 0 || int(channel/2) >= len(dataStreams) {
+				continue
+			}
+			if _, err := dataStreams[channel/2].Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	<-ctx.Done()
+	for _, dataStream := range dataStreams {
+		_ = dataStream.Close()
+	}
+	streamConn.Close()
+	wg.Wait()
+	return nil
+}
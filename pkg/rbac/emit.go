@@ -0,0 +1,55 @@
+package rbac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Emit writes the YAML of the Default recorder, with roles named name, to path every interval and once more when ctx
+// is done, for the rules used by a test or staging run to be collected from the file.
+func Emit(ctx context.Context, path, name string, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				if err := Default.WriteFile(path, name); err != nil {
+					logrus.Errorf("failed to write RBAC rules to %s: %v", path, err)
+				}
+				return
+			}
+			if err := Default.WriteFile(path, name); err != nil {
+				logrus.Errorf("failed to write RBAC rules to %s: %v", path, err)
+			}
+		}
+	}()
+}
+
+// WriteFile writes the YAML of the recorder to path, replacing it at once so readers never see a partial file.
+func (r *Recorder) WriteFile(path, name string) error {
+	data, err := r.YAML(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
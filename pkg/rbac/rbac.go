@@ -0,0 +1,196 @@
+// Package rbac records the verbs and resources used by controllers and object clients at runtime, to generate
+// the minimal ClusterRole and Roles a deployment needs. Emit writes them to a file, the generator writes the rules
+// of the generated controllers next to them.
+package rbac
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Default is the recorder object clients and controllers record to.
+var Default = NewRecorder()
+
+// Record records verbs on resource, which may be resource/subresource, of group in namespace, "" for all
+// namespaces, to the Default recorder.
+func Record(gvr schema.GroupVersionResource, namespace string, verbs ...string) {
+	Default.Record(gvr, namespace, verbs...)
+}
+
+type ruleKey struct {
+	namespace string
+	group     string
+	resource  string
+}
+
+// ruleVerbs are the verbs recorded for a key, locked apart from the other keys for the concurrent requests of
+// different resources not to contend.
+type ruleVerbs struct {
+	lock  sync.Mutex
+	verbs map[string]bool
+}
+
+type Recorder struct {
+	verbs sync.Map
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Record(gvr schema.GroupVersionResource, namespace string, verbs ...string) {
+	if gvr.Resource == "" || len(verbs) == 0 {
+		return
+	}
+
+	key := ruleKey{
+		namespace: namespace,
+		group:     gvr.Group,
+		resource:  gvr.Resource,
+	}
+	value, ok := r.verbs.Load(key)
+	if !ok {
+		value, _ = r.verbs.LoadOrStore(key, &ruleVerbs{verbs: map[string]bool{}})
+	}
+	rule := value.(*ruleVerbs)
+
+	rule.lock.Lock()
+	defer rule.lock.Unlock()
+	for _, verb := range verbs {
+		rule.verbs[verb] = true
+	}
+}
+
+// snapshot returns a copy of the recorded verbs by key.
+func (r *Recorder) snapshot() map[ruleKey]map[string]bool {
+	result := map[ruleKey]map[string]bool{}
+	r.verbs.Range(func(key, value interface{}) bool {
+		rule := value.(*ruleVerbs)
+		rule.lock.Lock()
+		verbs := make(map[string]bool, len(rule.verbs))
+		for verb := range rule.verbs {
+			verbs[verb] = true
+		}
+		rule.lock.Unlock()
+		result[key.(ruleKey)] = verbs
+		return true
+	})
+	return result
+}
+
+// Rules returns the recorded rules by namespace, "" holds the rules of all namespaces and cluster scoped
+// resources. Resources of a group with the same verbs share a rule, verbs already granted in all namespaces are
+// omitted from the namespaces.
+func (r *Recorder) Rules() map[string][]rbacv1.PolicyRule {
+	recorded := r.snapshot()
+
+	type ruleGroup struct {
+		namespace string
+		group     string
+		verbs     string
+	}
+	resources := map[ruleGroup][]string{}
+	for key, verbs := range recorded {
+		var names []string
+		for verb := range verbs {
+			if key.namespace != "" && recorded[ruleKey{group: key.group, resource: key.resource}][verb] {
+				continue
+			}
+			names = append(names, verb)
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		group := ruleGroup{
+			namespace: key.namespace,
+			group:     key.group,
+			verbs:     strings.Join(names, ","),
+		}
+		resources[group] = append(resources[group], key.resource)
+	}
+
+	result := map[string][]rbacv1.PolicyRule{}
+	for group, names := range resources {
+		sort.Strings(names)
+		result[group.namespace] = append(result[group.namespace], rbacv1.PolicyRule{
+			APIGroups: []string{group.group},
+			Resources: names,
+			Verbs:     strings.Split(group.verbs, ","),
+		})
+	}
+	for _, rules := range result {
+		sort.Slice(rules, func(i, j int) bool {
+			if rules[i].APIGroups[0] != rules[j].APIGroups[0] {
+				return rules[i].APIGroups[0] < rules[j].APIGroups[0]
+			}
+			return rules[i].Resources[0] < rules[j].Resources[0]
+		})
+	}
+	return result
+}
+
+// Objects returns a ClusterRole with the rules of all namespaces and a Role per namespace with its rules, all named
+// name.
+func (r *Recorder) Objects(name string) []runtime.Object {
+	rules := r.Rules()
+
+	var namespaces []string
+	for namespace := range rules {
+		if namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	result := []runtime.Object{
+		&rbacv1.ClusterRole{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "ClusterRole",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Rules: rules[""],
+		},
+	}
+	for _, namespace := range namespaces {
+		result = append(result, &rbacv1.Role{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "Role",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Rules: rules[namespace],
+		})
+	}
+	return result
+}
+
+// YAML returns the Objects of the recorder as a multi document YAML.
+func (r *Recorder) YAML(name string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	for i, obj := range r.Objects(name) {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buffer.WriteString("---\n")
+		}
+		buffer.Write(data)
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRules(t *testing.T) {
+	r := NewRecorder()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	r.Record(pods, "", "get", "list", "watch")
+	r.Record(secrets, "", "watch", "list", "get")
+	r.Record(deployments, "", "update")
+	r.Record(secrets, "app", "create", "get")
+
+	rules := r.Rules()
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods", "secrets"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"update"}},
+	}, rules[""])
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"create"}},
+	}, rules["app"])
+
+	data, err := r.YAML("controller")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "kind: ClusterRole\n")
+	assert.Contains(t, string(data), "---\n")
+	assert.Contains(t, string(data), "namespace: app\n")
+}
+
+func TestWriteFile(t *testing.T) {
+	r := NewRecorder()
+	r.Record(schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "", "get")
+
+	path := filepath.Join(t.TempDir(), "rbac.yaml")
+	require.NoError(t, r.WriteFile(path, "controller"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	expected, err := r.YAML("controller")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(data))
+}
+
+func TestRecordConcurrently(t *testing.T) {
+	r := NewRecorder()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	var wg sync.WaitGroup
+	for _, verb := range []string{"get", "list", "watch", "create", "update", "patch", "delete"} {
+		wg.Add(1)
+		go func(verb string) {
+			defer wg.Done()
+			r.Record(pods, "", verb)
+			r.Rules()
+		}(verb)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create", "delete", "get", "list", "patch", "update", "watch"}},
+	}, r.Rules()[""])
+}
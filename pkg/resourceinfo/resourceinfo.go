@@ -0,0 +1,57 @@
+// Package resourceinfo resolves the scope and names of schemas from the discovery API of the API server, for
+// aggregated APIs and CRDs whose scope or plural differ from the ones guessed from their Go types.
+package resourceinfo
+
+import (
+	"strings"
+
+	"github.com/rancher/norman/types"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+)
+
+type Resolver struct {
+	client discovery.CachedDiscoveryInterface
+}
+
+// New returns a Resolver whose ResourceInfo is meant to be set as the ResourceInfo of types.Schemas before importing
+// types. Clients that don't cache discovery, unlike a discoverycache.Cache, are cached until Reset.
+func New(client discovery.DiscoveryInterface) *Resolver {
+	cached, ok := client.(discovery.CachedDiscoveryInterface)
+	if !ok {
+		cached = memory.NewMemCacheClient(client)
+	}
+	return &Resolver{
+		client: cached,
+	}
+}
+
+// ResourceInfo returns how the API server serves kind, false if it doesn't or discovery failed, in which case the
+// schema keeps the scope and names guessed from its type.
+func (r *Resolver) ResourceInfo(version *types.APIVersion, kind string) (types.ResourceInfo, bool) {
+	gv := schema.GroupVersion{Group: version.Group, Version: version.Version}
+	list, err := r.client.ServerResourcesForGroupVersion(gv.String())
+	if err != nil {
+		logrus.Debugf("failed to discover resources of %s: %v", gv, err)
+		return types.ResourceInfo{}, false
+	}
+
+	for _, resource := range list.APIResources {
+		if resource.Kind != kind || strings.Contains(resource.Name, "/") {
+			continue
+		}
+		return types.ResourceInfo{
+			Plural:     resource.Name,
+			Namespaced: resource.Namespaced,
+			ShortNames: resource.ShortNames,
+		}, true
+	}
+	return types.ResourceInfo{}, false
+}
+
+// Reset forgets the discovered resources, for instance after CRDs are created.
+func (r *Resolver) Reset() {
+	r.client.Invalidate()
+}
@@ -0,0 +1,60 @@
+package resourceinfo
+
+import (
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type NetworkPolicy struct {
+	types.Namespaced
+	Name string
+}
+
+type ClusterRoleBinding struct {
+	Name string
+}
+
+func TestResourceInfo(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{
+		Fake: &k8stesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "example.cattle.io/v3",
+					APIResources: []metav1.APIResource{
+						{Name: "networkpolicies", Kind: "NetworkPolicy", Namespaced: false, ShortNames: []string{"netpol"}},
+						{Name: "networkpolicies/status", Kind: "NetworkPolicy"},
+						{Name: "clusterrolebindings", Kind: "ClusterRoleBinding", Namespaced: true},
+					},
+				},
+			},
+		},
+	}
+	version := types.APIVersion{
+		Group:   "example.cattle.io",
+		Version: "v3",
+		Path:    "/v3",
+	}
+
+	schemas := types.NewSchemas()
+	schemas.ResourceInfo = New(client).ResourceInfo
+	schemas.MustImport(&version, NetworkPolicy{})
+	schemas.MustImport(&version, ClusterRoleBinding{})
+	require.NoError(t, schemas.Err())
+
+	networkPolicy := schemas.Schema(&version, "networkPolicy")
+	require.NotNil(t, networkPolicy)
+	assert.Equal(t, types.TypeScope(""), networkPolicy.Scope)
+	assert.Equal(t, "networkPolicies", networkPolicy.PluralName)
+	assert.Equal(t, []string{"netpol"}, networkPolicy.ShortNames)
+
+	binding := schemas.Schema(&version, "clusterRoleBinding")
+	require.NotNil(t, binding)
+	assert.Equal(t, types.NamespaceScope, binding.Scope)
+	assert.Equal(t, "clusterRoleBindings", binding.PluralName)
+}
@@ -0,0 +1,40 @@
+package subscribe
+
+import (
+	"sync"
+	"time"
+)
+
+// RecheckInterval is how often the access of active subscriptions to their types is checked again.
+var RecheckInterval = time.Minute
+
+var (
+	listenersLock sync.Mutex
+	listeners     = map[chan struct{}]struct{}{}
+)
+
+// NotifyAccessChanged makes active subscriptions check their access immediately, for example after an RBAC
+// change, instead of waiting for RecheckInterval.
+func NotifyAccessChanged() {
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+	for c := range listeners {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func listenAccessChanged() (chan struct{}, func()) {
+	c := make(chan struct{}, 1)
+	listenersLock.Lock()
+	listeners[c] = struct{}{}
+	listenersLock.Unlock()
+
+	return c, func() {
+		listenersLock.Lock()
+		delete(listeners, c)
+		listenersLock.Unlock()
+	}
+}
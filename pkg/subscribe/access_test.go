@@ -0,0 +1,101 @@
+package subscribe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type denyList struct {
+	authorization.AllAccess
+	denied map[string]bool
+}
+
+func (d *denyList) CanList(apiContext *types.APIContext, schema *types.Schema) error {
+	if d.denied[schema.ID] {
+		return httperror.NewAPIError(httperror.PermissionDenied, "can not list "+schema.ID)
+	}
+	return nil
+}
+
+func TestRecheckAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		denied   map[string]bool
+		messages []string
+		stopped  []string
+	}{
+		{
+			name: "access kept",
+		},
+		{
+			name:     "access revoked",
+			denied:   map[string]bool{"secret": true},
+			messages: []string{`{"name":"resource.stop","data":{"type":"secret"}}`},
+			stopped:  []string{"secret"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stopped []string
+			streams := map[*types.Schema]context.CancelFunc{}
+			for _, id := range []string{"configMap", "secret"} {
+				streams[&types.Schema{ID: id}] = func() { stopped = append(stopped, id) }
+			}
+			revoked := map[string]bool{}
+			apiContext := &types.APIContext{AccessControl: &denyList{denied: test.denied}}
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				c, err := upgrader.Upgrade(rw, req, nil)
+				require.NoError(t, err)
+				defer c.Close()
+				recheckAccess(apiContext, c, streams, revoked, func() {})
+				_ = writeData(c, `{"name":"done","data":`, []byte("{}"))
+			}))
+			defer server.Close()
+
+			c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			require.NoError(t, err)
+			defer c.Close()
+
+			var messages []string
+			for {
+				_, data, err := c.ReadMessage()
+				require.NoError(t, err)
+				if strings.HasPrefix(string(data), `{"name":"done"`) {
+					break
+				}
+				messages = append(messages, string(data))
+			}
+
+			assert.Equal(t, test.messages, messages)
+			assert.Equal(t, test.stopped, stopped)
+			assert.Len(t, streams, 2-len(test.stopped))
+			for _, id := range test.stopped {
+				assert.True(t, revoked[id])
+			}
+		})
+	}
+}
+
+func TestNotifyAccessChanged(t *testing.T) {
+	changed, stop := listenAccessChanged()
+
+	NotifyAccessChanged()
+	NotifyAccessChanged()
+	assert.Len(t, changed, 1, "notifications are coalesced")
+	<-changed
+
+	stop()
+	NotifyAccessChanged()
+	assert.Len(t, changed, 0)
+}
@@ -3,6 +3,7 @@ package subscribe
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/rancher/norman/api/writer"
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/parse"
+	"github.com/rancher/norman/pkg/bus"
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/types/convert"
 	"github.com/rancher/norman/types/slice"
@@ -23,6 +25,8 @@ type Subscribe struct {
 	ResourceTypes []string
 	APIVersions   []string
 	ProjectID     string `norman:"type=reference[/v3/schemas/project]"`
+	// Snapshot sends the current objects of each type, then a resource.snapshot event, before their changes
+	Snapshot bool
 }
 
 func Handler(apiContext *types.APIContext, _ types.RequestHandler) error {
@@ -54,11 +58,22 @@ type Expire interface {
 }
 
 func handler(apiContext *types.APIContext) error {
-	schemas := getMatchingSchemas(apiContext)
+	var schemas []*types.Schema
+	for _, schema := range getMatchingSchemas(apiContext) {
+		if apiContext.AccessControl.CanList(apiContext, schema) == nil {
+			schemas = append(schemas, schema)
+		}
+	}
 	if len(schemas) == 0 {
 		return httperror.NewAPIError(httperror.NotFound, "no resources types matched")
 	}
 
+	release, err := acquireWatch(apiContext)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	c, err := upgrader.Upgrade(apiContext.Response, apiContext.Request, nil)
 	if err != nil {
 		return err
@@ -80,9 +95,11 @@ func handler(apiContext *types.APIContext) error {
 	}()
 
 	events := make(chan map[string]interface{})
+	streams := map[*types.Schema]context.CancelFunc{}
 	for _, schema := range schemas {
-		streamStore(ctx, readerGroup, apiContext, schema, events)
+		streams[schema] = streamStore(ctx, readerGroup, apiContext, schema, events)
 	}
+	revoked := map[string]bool{}
 
 	go func() {
 		_ = readerGroup.Wait()
@@ -95,6 +112,10 @@ func handler(apiContext *types.APIContext) error {
 	}
 	t := time.NewTicker(5 * time.Second)
 	defer t.Stop()
+	recheck := time.NewTicker(RecheckInterval)
+	defer recheck.Stop()
+	accessChanged, stopListening := listenAccessChanged()
+	defer stopListening()
 
 	done := false
 	for !done {
@@ -105,12 +126,20 @@ func handler(apiContext *types.APIContext) error {
 				break
 			}
 
+			if item[snapshotKey] == true {
+				data, _ := json.Marshal(map[string]interface{}{"type": item["type"]})
+				if err := writeData(c, `{"name":"resource.snapshot","data":`, data); err != nil {
+					cancel()
+				}
+				continue
+			}
+
 			header := `{"name":"resource.change","data":`
 			if item[".removed"] == true {
 				header = `{"name":"resource.remove","data":`
 			}
 			schema := apiContext.Schemas.Schema(apiContext.Version, convert.ToString(item["type"]))
-			if schema != nil {
+			if schema != nil && !revoked[schema.ID] {
 				buffer := &bytes.Buffer{}
 
 				if err := jsonWriter.VersionBody(apiContext, &schema.Version, buffer, item); err != nil {
@@ -126,6 +155,10 @@ func handler(apiContext *types.APIContext) error {
 			if err := writeData(c, `{"name":"ping","data":`, []byte("{}")); err != nil {
 				cancel()
 			}
+		case <-recheck.C:
+			recheckAccess(apiContext, c, streams, revoked, cancel)
+		case <-accessChanged:
+			recheckAccess(apiContext, c, streams, revoked, cancel)
 		}
 	}
 
@@ -133,6 +166,25 @@ func handler(apiContext *types.APIContext) error {
 	return nil
 }
 
+// recheckAccess stops the streams of the types the user can no longer list.
+func recheckAccess(apiContext *types.APIContext, c *websocket.Conn, streams map[*types.Schema]context.CancelFunc, revoked map[string]bool, cancel context.CancelFunc) {
+	for schema, stop := range streams {
+		if err := apiContext.AccessControl.CanList(apiContext, schema); err == nil {
+			continue
+		}
+
+		logrus.Debugf("subscription lost access to %s", schema.ID)
+		stop()
+		delete(streams, schema)
+		revoked[schema.ID] = true
+
+		data, _ := json.Marshal(map[string]string{"type": schema.ID})
+		if err := writeData(c, `{"name":"resource.stop","data":`, data); err != nil {
+			cancel()
+		}
+	}
+}
+
 func writeData(c *websocket.Conn, header string, buf []byte) error {
 	messageWriter, err := c.NextWriter(websocket.TextMessage)
 	if err != nil {
@@ -151,15 +203,28 @@ func writeData(c *websocket.Conn, header string, buf []byte) error {
 	return messageWriter.Close()
 }
 
-func streamStore(ctx context.Context, eg *errgroup.Group, apiContext *types.APIContext, schema *types.Schema, result chan map[string]interface{}) {
+func streamStore(ctx context.Context, eg *errgroup.Group, apiContext *types.APIContext, schema *types.Schema, result chan map[string]interface{}) context.CancelFunc {
+	streamCtx, cancel := context.WithCancel(ctx)
+	streamContext := *apiContext
+	streamContext.Request = apiContext.Request.WithContext(streamCtx)
+
 	eg.Go(func() error {
-		opts := parse.QueryOptions(apiContext, schema)
-		events, err := schema.Store.Watch(apiContext, schema, &opts)
-		if err != nil || events == nil {
+		opts := parse.QueryOptions(&streamContext, schema)
+		events, err := schema.Store.Watch(&streamContext, schema, &opts)
+		if err != nil {
+			logrus.Errorf("failed on subscribe %s: %v", schema.ID, err)
+			return err
+		}
+		if events == nil {
+			// stores that can't watch may still publish their changes on the bus
+			events = busEvents(streamCtx, &streamContext, schema)
+		}
+		if convert.ToBool(apiContext.Request.URL.Query().Get("snapshot")) {
+			events, err = snapshotEvents(streamCtx, &streamContext, schema, &opts, events)
 			if err != nil {
-				logrus.Errorf("failed on subscribe %s: %v", schema.ID, err)
+				logrus.Errorf("failed on subscribe snapshot %s: %v", schema.ID, err)
+				return err
 			}
-			return err
 		}
 
 		logrus.Tracef("watching %s", schema.ID)
@@ -168,8 +233,41 @@ func streamStore(ctx context.Context, eg *errgroup.Group, apiContext *types.APIC
 			result <- e
 		}
 
+		if ctx.Err() == nil && streamCtx.Err() != nil {
+			// stopped by recheckAccess, the other streams carry on
+			return nil
+		}
 		return errors.New("disconnect")
 	})
+
+	return cancel
+}
+
+func busEvents(ctx context.Context, apiContext *types.APIContext, schema *types.Schema) chan map[string]interface{} {
+	result := make(chan map[string]interface{})
+	go func() {
+		defer close(result)
+		for event := range bus.Default.Subscribe(ctx, schema.ID) {
+			if event.Object == nil {
+				continue
+			}
+			data := map[string]interface{}{}
+			for k, v := range event.Object {
+				data[k] = v
+			}
+			if event.Verb == bus.Delete {
+				data[".removed"] = true
+			}
+			if data = apiContext.AccessControl.Filter(apiContext, schema, data, nil); data == nil {
+				continue
+			}
+			select {
+			case result <- data:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return result
 }
 
 func matches(items []string, item string) bool {
@@ -0,0 +1,80 @@
+package subscribe
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/metrics"
+	"github.com/rancher/norman/types"
+)
+
+// watchLimitRetryAfter is the delay suggested to clients whose subscription was rejected by the user limit.
+const watchLimitRetryAfter = 10 * time.Second
+
+var (
+	maxWatchesPerUser atomic.Int64
+	activeWatches     = watchCounts{
+		users: map[watchUser]int64{},
+	}
+)
+
+// SetMaxWatchesPerUser limits the concurrent subscriptions of each authenticated user to max, further subscriptions
+// fail with a TooManyRequests error. Anonymous subscriptions are limited by remote address. 0 removes the limit.
+func SetMaxWatchesPerUser(max int) {
+	maxWatchesPerUser.Store(int64(max))
+}
+
+type watchCounts struct {
+	lock  sync.Mutex
+	users map[watchUser]int64
+}
+
+// watchUser is the user subscriptions are counted by, the remote address of the request for anonymous users so that
+// they do not share a single limit.
+type watchUser struct {
+	name    string
+	address string
+}
+
+// acquireWatch counts a subscription of the user of apiContext until release is called.
+func acquireWatch(apiContext *types.APIContext) (func(), error) {
+	user := watchUserOf(apiContext)
+
+	activeWatches.lock.Lock()
+	defer activeWatches.lock.Unlock()
+	if max := maxWatchesPerUser.Load(); max > 0 && activeWatches.users[user] >= max {
+		metrics.IncAPIWatchLimited()
+		return nil, httperror.NewRetryableAPIError(httperror.TooManyRequests,
+			fmt.Sprintf("too many active subscriptions, at most %d are allowed per user", max), watchLimitRetryAfter)
+	}
+	activeWatches.users[user]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			activeWatches.lock.Lock()
+			defer activeWatches.lock.Unlock()
+			if activeWatches.users[user]--; activeWatches.users[user] <= 0 {
+				delete(activeWatches.users, user)
+			}
+		})
+	}, nil
+}
+
+func watchUserOf(apiContext *types.APIContext) watchUser {
+	if apiContext.User != nil && apiContext.User.Name != "" {
+		return watchUser{name: apiContext.User.Name}
+	}
+	if apiContext.Request == nil {
+		return watchUser{}
+	}
+	host, _, err := net.SplitHostPort(apiContext.Request.RemoteAddr)
+	if err != nil {
+		host = apiContext.Request.RemoteAddr
+	}
+	return watchUser{address: host}
+}
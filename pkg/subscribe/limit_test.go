@@ -0,0 +1,68 @@
+package subscribe
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetWatches() {
+	activeWatches.lock.Lock()
+	defer activeWatches.lock.Unlock()
+	activeWatches.users = map[watchUser]int64{}
+}
+
+func TestAcquireWatch(t *testing.T) {
+	SetMaxWatchesPerUser(2)
+	defer SetMaxWatchesPerUser(0)
+	defer resetWatches()
+
+	alice := &types.APIContext{User: &types.User{Name: "alice"}}
+	first, err := acquireWatch(alice)
+	require.NoError(t, err)
+	_, err = acquireWatch(alice)
+	require.NoError(t, err)
+
+	_, err = acquireWatch(alice)
+	var apiError *httperror.APIError
+	require.True(t, errors.As(err, &apiError))
+	assert.Equal(t, httperror.TooManyRequests, apiError.Code)
+	assert.True(t, apiError.Retryable)
+
+	_, err = acquireWatch(&types.APIContext{User: &types.User{Name: "bob"}})
+	assert.NoError(t, err, "limits are per user")
+
+	first()
+	first()
+	_, err = acquireWatch(alice)
+	assert.NoError(t, err, "released once")
+	_, err = acquireWatch(alice)
+	assert.Error(t, err)
+}
+
+func TestAcquireWatchAnonymous(t *testing.T) {
+	SetMaxWatchesPerUser(1)
+	defer SetMaxWatchesPerUser(0)
+	defer resetWatches()
+
+	anonymous := func(remoteAddr string) *types.APIContext {
+		req := httptest.NewRequest(http.MethodGet, "/v3/subscribe", nil)
+		req.RemoteAddr = remoteAddr
+		return &types.APIContext{Request: req}
+	}
+
+	_, err := acquireWatch(anonymous("10.0.0.1:1234"))
+	require.NoError(t, err)
+	_, err = acquireWatch(anonymous("10.0.0.1:5678"))
+	assert.Error(t, err, "limits are per address")
+	_, err = acquireWatch(anonymous("10.0.0.2:1234"))
+	assert.NoError(t, err, "anonymous users do not share a limit")
+	_, err = acquireWatch(&types.APIContext{User: &types.User{Name: "10.0.0.2"}, Request: anonymous("10.0.0.3:1").Request})
+	assert.NoError(t, err, "addresses do not collide with user names")
+}
@@ -0,0 +1,116 @@
+package subscribe
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/values"
+)
+
+// snapshotKey marks the item sent once the snapshot of a type is complete.
+const snapshotKey = ".snapshot"
+
+// snapshotEvents sends the objects of schema listed after events started, then a snapshotKey item, then the events
+// that are newer than the listed objects. Events received while listing are buffered, so that the snapshot and the
+// events have no gap between them.
+func snapshotEvents(ctx context.Context, apiContext *types.APIContext, schema *types.Schema, opts *types.QueryOptions,
+	events chan map[string]interface{}) (chan map[string]interface{}, error) {
+	var (
+		buffered  []map[string]interface{}
+		listed    = make(chan struct{})
+		bufferEnd = make(chan []map[string]interface{})
+	)
+	go func() {
+		for {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					bufferEnd <- buffered
+					return
+				}
+				buffered = append(buffered, e)
+			case <-listed:
+				bufferEnd <- buffered
+				return
+			}
+		}
+	}()
+
+	items, err := schema.Store.List(apiContext, schema, opts)
+	close(listed)
+	pending := <-bufferEnd
+	if err != nil {
+		go drain(events)
+		return nil, err
+	}
+	items = apiContext.AccessControl.FilterList(apiContext, schema, items, nil)
+
+	revisions := map[string]uint64{}
+	for _, item := range items {
+		if revision, ok := revisionOf(item); ok {
+			revisions[convert.ToString(item["id"])] = revision
+		}
+	}
+
+	result := make(chan map[string]interface{})
+	go func() {
+		defer close(result)
+		send := func(item map[string]interface{}) bool {
+			select {
+			case result <- item:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, item := range items {
+			if !send(item) {
+				return
+			}
+		}
+		if !send(map[string]interface{}{"type": schema.ID, snapshotKey: true}) {
+			return
+		}
+		for _, e := range pending {
+			if isListed(revisions, e) {
+				continue
+			}
+			if !send(e) {
+				return
+			}
+		}
+		for e := range events {
+			if !send(e) {
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
+// isListed returns whether the change of e is already in the listed revision of its object.
+func isListed(revisions map[string]uint64, e map[string]interface{}) bool {
+	listedRevision, ok := revisions[convert.ToString(e["id"])]
+	if !ok {
+		return false
+	}
+	revision, ok := revisionOf(e)
+	return ok && revision <= listedRevision
+}
+
+func revisionOf(item map[string]interface{}) (uint64, bool) {
+	version := convert.ToString(item["resourceVersion"])
+	if version == "" {
+		version = convert.ToString(values.GetValueN(item, "metadata", "resourceVersion"))
+	}
+	revision, err := strconv.ParseUint(version, 10, 64)
+	return revision, err == nil
+}
+
+func drain(events chan map[string]interface{}) {
+	for range events {
+	}
+}
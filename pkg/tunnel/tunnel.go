@@ -0,0 +1,192 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rancher/norman/metrics"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// DialFunc dials connections through the tunnel of a downstream cluster, like the dialers of remotedialer.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Limits bound the requests proxied to a downstream cluster, so that one cluster can't saturate the tunnels.
+type Limits struct {
+	// MaxConcurrent is the number of requests in flight at once, unlimited if 0. Streams, such as watches, followed
+	// logs and upgraded connections for exec or port forwarding, aren't counted as they stay open.
+	MaxConcurrent int
+	// QPS and Burst rate limit the requests, unlimited if QPS is 0
+	QPS   float32
+	Burst int
+}
+
+type limiter struct {
+	slots chan struct{}
+	rate  flowcontrol.RateLimiter
+}
+
+var limiters sync.Map
+
+// SetLimits limits the requests to cluster of all the clients configured by Configure, zero Limits remove the
+// limits. Requests in flight keep the limits they started with.
+func SetLimits(cluster string, limits Limits) {
+	if limits == (Limits{}) {
+		limiters.Delete(cluster)
+		return
+	}
+	l := &limiter{}
+	if limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	if limits.QPS > 0 {
+		burst := limits.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		l.rate = flowcontrol.NewTokenBucketRateLimiter(limits.QPS, burst)
+	}
+	limiters.Store(cluster, l)
+}
+
+// Configure makes the clients built from config measure their connections to cluster, if config dials through a
+// tunnel, and apply the Limits of cluster to their requests.
+func Configure(config *rest.Config, cluster string) {
+	if config.Dial != nil {
+		config.Dial = WrapDial(cluster, config.Dial)
+	}
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return WrapTransport(cluster, rt)
+	})
+}
+
+// WrapDial measures the connections dialed to cluster by dial.
+func WrapDial(cluster string, dial DialFunc) DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		start := time.Now()
+		conn, err := dial(ctx, network, address)
+		metrics.ObserveTunnelDial(cluster, time.Since(start), err)
+		if err != nil {
+			return nil, err
+		}
+		metrics.AddTunnelConnections(cluster, 1)
+		return &countingConn{Conn: conn, cluster: cluster}, nil
+	}
+}
+
+type countingConn struct {
+	net.Conn
+	cluster string
+	closed  atomic.Bool
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	metrics.AddTunnelBytes(c.cluster, "received", n)
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	metrics.AddTunnelBytes(c.cluster, "sent", n)
+	return n, err
+}
+
+func (c *countingConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		metrics.AddTunnelConnections(c.cluster, -1)
+	}
+	return c.Conn.Close()
+}
+
+// WrapTransport applies the Limits of cluster to the requests of next.
+func WrapTransport(cluster string, next http.RoundTripper) http.RoundTripper {
+	return &limitedTransport{
+		cluster: cluster,
+		next:    next,
+	}
+}
+
+type limitedTransport struct {
+	cluster string
+	next    http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release := func() {}
+	if value, ok := limiters.Load(t.cluster); ok {
+		var err error
+		if release, err = value.(*limiter).acquire(req, t.cluster); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics.AddTunnelRequests(t.cluster, 1)
+	done := func() {
+		metrics.AddTunnelRequests(t.cluster, -1)
+		release()
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		done()
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		// the body is the upgraded connection, which isn't a request in flight anymore
+		release()
+		release = func() {}
+	}
+	// the request is in flight until its body is read
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: done}
+	return resp, nil
+}
+
+func (l *limiter) acquire(req *http.Request, cluster string) (func(), error) {
+	ctx := req.Context()
+	if l.rate != nil && !l.rate.TryAccept() {
+		metrics.IncTunnelRequestsDelayed(cluster, "rate")
+		if err := l.rate.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if l.slots == nil || streaming(req) {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+	default:
+		metrics.IncTunnelRequestsDelayed(cluster, "concurrency")
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func() {
+		<-l.slots
+	}, nil
+}
+
+// streaming returns whether the response to req stays open until the client closes it.
+func streaming(req *http.Request) bool {
+	query := req.URL.Query()
+	return query.Get("watch") == "true" || query.Get("follow") == "true" || req.Header.Get("Upgrade") != ""
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releasingBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,79 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r(req)
+}
+
+func TestLimits(t *testing.T) {
+	defer SetLimits("c-1", Limits{})
+	SetLimits("c-1", Limits{MaxConcurrent: 1})
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+	transport := WrapTransport("c-1", next)
+	request := func(url string, timeout time.Duration) (*http.Response, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return transport.RoundTrip(httptest.NewRequest(http.MethodGet, url, nil).WithContext(ctx))
+	}
+
+	first, err := request("https://c-1/api/v1/pods", time.Second)
+	require.NoError(t, err)
+	_, err = request("https://c-1/api/v1/pods", 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	watch, err := request("https://c-1/api/v1/pods?watch=true", 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, watch.Body.Close())
+
+	logs, err := request("https://c-1/api/v1/namespaces/default/pods/p/log?follow=true", 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, logs.Body.Close())
+
+	upgrade := httptest.NewRequest(http.MethodPost, "https://c-1/api/v1/namespaces/default/pods/p/exec", nil)
+	upgrade.Header.Set("Connection", "Upgrade")
+	upgrade.Header.Set("Upgrade", "SPDY/3.1")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	exec, err := transport.RoundTrip(upgrade.WithContext(ctx))
+	require.NoError(t, err)
+	require.NoError(t, exec.Body.Close())
+
+	require.NoError(t, first.Body.Close())
+	require.NoError(t, first.Body.Close())
+	second, err := request("https://c-1/api/v1/pods", 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, second.Body.Close())
+
+	// upgraded connections release their slot with the response headers
+	upgraded := WrapTransport("c-1", roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusSwitchingProtocols, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+	conn, err := upgraded.RoundTrip(httptest.NewRequest(http.MethodGet, "https://c-1/api/v1/pods", nil))
+	require.NoError(t, err)
+	third, err := request("https://c-1/api/v1/pods", 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, third.Body.Close())
+	require.NoError(t, conn.Body.Close())
+
+	other := WrapTransport("c-2", next)
+	for i := 0; i < 3; i++ {
+		_, err := other.RoundTrip(httptest.NewRequest(http.MethodGet, "https://c-2/api/v1/pods", nil))
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,147 @@
+package cascade
+
+import (
+	"fmt"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+)
+
+type Policy string
+
+const (
+	// PolicyDelete deletes the objects referencing a deleted object
+	PolicyDelete = Policy("delete")
+	// PolicyOrphan clears the reference field of the objects referencing a deleted object
+	PolicyOrphan = Policy("orphan")
+)
+
+// Store deletes or orphans the objects referencing an object, through the reference fields of their schemas,
+// before deleting it. The dependents of deleted dependents are handled first, so objects are removed in dependency
+// order. A dry run delete reports the dependents, see types.DryRunDependents, without changing anything.
+//
+// The dependents are not deleted atomically: when one of them fails the ones already handled stay deleted or
+// orphaned and the object is kept, deleting it again resumes with the remaining dependents.
+type Store struct {
+	types.Store
+	// Policies by "<schema ID>.<field>" of the referencing field, Default for the others
+	Policies map[string]Policy
+	Default  Policy
+}
+
+func NewCascadeStore(store types.Store, policy Policy, policies map[string]Policy) *Store {
+	return &Store{
+		Store:    store,
+		Policies: policies,
+		Default:  policy,
+	}
+}
+
+// Wrap cascades the deletes of schema with policy, or the policy of the referencing field in policies. Dry runs are
+// left to the wrapped store, see Schema.DryRun.
+func Wrap(schema *types.Schema, policy Policy, policies map[string]Policy) *Store {
+	store := NewCascadeStore(schema.Store, policy, policies)
+	schema.Store = store
+	return store
+}
+
+type dependent struct {
+	types.Dependent
+	schema *types.Schema
+}
+
+func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	dependents, err := s.dependents(apiContext, schema, id, map[string]bool{schema.ID + "/" + id: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if apiContext.DryRun {
+		obj, err := s.Store.ByID(apiContext, schema, id)
+		if err != nil {
+			return nil, err
+		}
+		report := types.DryRunDependents.Value(apiContext)
+		for _, dependent := range dependents {
+			report = append(report, dependent.Dependent)
+		}
+		types.DryRunDependents.Set(apiContext, report)
+		return obj, nil
+	}
+
+	for i, dependent := range dependents {
+		if dependent.Action == string(PolicyOrphan) {
+			_, err = dependent.schema.Store.Update(apiContext, dependent.schema, map[string]interface{}{dependent.Field: nil}, dependent.ID)
+		} else {
+			_, err = dependent.schema.Store.Delete(apiContext, dependent.schema, dependent.ID)
+		}
+		if err != nil && !httperror.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to %s %s %s referencing %s %s, after handling %d of %d dependents: %w",
+				dependent.Action, dependent.Type, dependent.ID, schema.ID, id, i, len(dependents), err)
+		}
+	}
+
+	return s.Store.Delete(apiContext, schema, id)
+}
+
+func (s *Store) policy(backRef types.BackReference) Policy {
+	if policy, ok := s.Policies[backRef.Schema.ID+"."+backRef.FieldName]; ok {
+		return policy
+	}
+	return s.Default
+}
+
+// dependents returns the objects referencing the object id of schema, with their own dependents before them. The
+// user must be allowed to delete or update all of them.
+func (s *Store) dependents(apiContext *types.APIContext, schema *types.Schema, id string, seen map[string]bool) ([]dependent, error) {
+	var result []dependent
+	for _, backRef := range apiContext.Schemas.References(schema) {
+		refSchema := backRef.Schema
+		if refSchema.Store == nil {
+			continue
+		}
+
+		objs, err := refSchema.Store.List(apiContext, refSchema, &types.QueryOptions{
+			Conditions: []*types.QueryCondition{types.EQ(backRef.FieldName, id)},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		policy := s.policy(backRef)
+		for _, obj := range objs {
+			objID := convert.ToString(obj["id"])
+			if objID == "" || convert.ToString(obj[backRef.FieldName]) != id || seen[refSchema.ID+"/"+objID] {
+				continue
+			}
+			seen[refSchema.ID+"/"+objID] = true
+
+			if policy == PolicyOrphan {
+				if err := apiContext.AccessControl.CanUpdate(apiContext, obj, refSchema); err != nil {
+					return nil, err
+				}
+			} else {
+				if err := apiContext.AccessControl.CanDelete(apiContext, obj, refSchema); err != nil {
+					return nil, err
+				}
+				nested, err := s.dependents(apiContext, refSchema, objID, seen)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, nested...)
+			}
+
+			result = append(result, dependent{
+				Dependent: types.Dependent{
+					Type:   refSchema.ID,
+					ID:     objID,
+					Field:  backRef.FieldName,
+					Action: string(policy),
+				},
+				schema: refSchema,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package cascade
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	empty.Store
+	data    map[string]map[string]interface{}
+	deletes *[]string
+	fail    string
+}
+
+func (m *memoryStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return m.data[id], nil
+}
+
+func (m *memoryStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, item := range m.data {
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	for k, v := range data {
+		m.data[id][k] = v
+	}
+	return m.data[id], nil
+}
+
+func (m *memoryStore) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	if id == m.fail {
+		return nil, errors.New("failed")
+	}
+	obj := m.data[id]
+	delete(m.data, id)
+	*m.deletes = append(*m.deletes, schema.ID+"/"+id)
+	return obj, nil
+}
+
+func TestCascadeDelete(t *testing.T) {
+	version := types.APIVersion{Version: "v1", Path: "/v1"}
+	schemas := types.NewSchemas().
+		AddSchema(types.Schema{ID: "cluster", Version: version}).
+		AddSchema(types.Schema{ID: "node", Version: version, ResourceFields: map[string]types.Field{
+			"clusterId": {Type: "reference[cluster]"},
+		}}).
+		AddSchema(types.Schema{ID: "pod", Version: version, ResourceFields: map[string]types.Field{
+			"nodeId": {Type: "reference[node]"},
+		}}).
+		AddSchema(types.Schema{ID: "token", Version: version, ResourceFields: map[string]types.Field{
+			"clusterId": {Type: "reference[cluster]"},
+		}})
+	require.NoError(t, schemas.Err())
+
+	var deletes []string
+	data := map[string]map[string]map[string]interface{}{
+		"cluster": {"c1": {"id": "c1"}},
+		"node":    {"n1": {"id": "n1", "clusterId": "c1"}, "n2": {"id": "n2", "clusterId": "c2"}},
+		"pod":     {"p1": {"id": "p1", "nodeId": "n1"}},
+		"token":   {"t1": {"id": "t1", "clusterId": "c1"}},
+	}
+	for id, objs := range data {
+		schema := schemas.Schema(&version, id)
+		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut, http.MethodDelete}
+		schema.Store = &memoryStore{data: objs, deletes: &deletes}
+	}
+	cluster := schemas.Schema(&version, "cluster")
+	store := Wrap(cluster, PolicyDelete, map[string]Policy{"token.clusterId": PolicyOrphan})
+	assert.False(t, cluster.DryRun)
+
+	apiContext := &types.APIContext{
+		Schemas:       schemas,
+		AccessControl: &authorization.AllAccess{},
+		DryRun:        true,
+	}
+	obj, err := store.Delete(apiContext, cluster, "c1")
+	require.NoError(t, err)
+	assert.Equal(t, "c1", obj["id"])
+	assert.Empty(t, deletes)
+	assert.ElementsMatch(t, []types.Dependent{
+		{Type: "pod", ID: "p1", Field: "nodeId", Action: "delete"},
+		{Type: "node", ID: "n1", Field: "clusterId", Action: "delete"},
+		{Type: "token", ID: "t1", Field: "clusterId", Action: "orphan"},
+	}, types.DryRunDependents.Value(apiContext))
+
+	apiContext.DryRun = false
+	_, err = store.Delete(apiContext, cluster, "c1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pod/p1", "node/n1", "cluster/c1"}, deletes)
+	assert.Contains(t, data["node"], "n2")
+	assert.Equal(t, map[string]interface{}{"id": "t1", "clusterId": nil}, data["token"]["t1"])
+}
+
+func TestCascadeDeleteResumes(t *testing.T) {
+	version := types.APIVersion{Version: "v1", Path: "/v1"}
+	schemas := types.NewSchemas().
+		AddSchema(types.Schema{ID: "cluster", Version: version}).
+		AddSchema(types.Schema{ID: "node", Version: version, ResourceFields: map[string]types.Field{
+			"clusterId": {Type: "reference[cluster]"},
+		}})
+
+	var deletes []string
+	clusters := &memoryStore{data: map[string]map[string]interface{}{"c1": {"id": "c1"}}, deletes: &deletes}
+	nodes := &memoryStore{data: map[string]map[string]interface{}{
+		"n1": {"id": "n1", "clusterId": "c1"},
+		"n2": {"id": "n2", "clusterId": "c1"},
+	}, deletes: &deletes}
+	node := schemas.Schema(&version, "node")
+	node.ResourceMethods = []string{http.MethodGet, http.MethodDelete}
+	node.Store = nodes
+	cluster := schemas.Schema(&version, "cluster")
+	cluster.Store = clusters
+	store := Wrap(cluster, PolicyDelete, nil)
+
+	apiContext := &types.APIContext{
+		Schemas:       schemas,
+		AccessControl: &authorization.AllAccess{},
+	}
+	for id := range nodes.data {
+		nodes.fail = id
+		break
+	}
+	_, err := store.Delete(apiContext, cluster, "c1")
+	assert.Error(t, err)
+	assert.Contains(t, clusters.data, "c1")
+
+	nodes.fail = ""
+	_, err = store.Delete(apiContext, cluster, "c1")
+	require.NoError(t, err)
+	assert.Empty(t, nodes.data)
+	assert.NotContains(t, clusters.data, "c1")
+}
@@ -65,6 +65,8 @@ func (f *Factory) BatchCreateCRDs(ctx context.Context, storageContext types.Stor
 	})
 }
 
+// AssignStores assigns proxy stores to schemas. Schemas may allow dry runs, see Schema.DryRun, once every store
+// wrapping the proxy store honors APIContext.DryRun.
 func (f *Factory) AssignStores(ctx context.Context, storageContext types.StorageContext, typer proxy.StoreTyper, schemas ...*types.Schema) error {
 	schemaStatus, err := f.CreateCRDs(ctx, storageContext, schemas...)
 	if err != nil {
@@ -202,6 +204,12 @@ func (f *Factory) createCRD(ctx context.Context, apiClient clientset.Interface,
 		},
 	}
 
+	if schema.StatusSubresource {
+		crd.Spec.Versions[0].Subresources = &apiext.CustomResourceSubresources{
+			Status: &apiext.CustomResourceSubresourceStatus{},
+		}
+	}
+
 	if schema.Scope == types.NamespaceScope {
 		crd.Spec.Scope = apiext.NamespaceScoped
 	} else {
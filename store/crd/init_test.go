@@ -0,0 +1,47 @@
+package crd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+)
+
+func TestCreateCRDStatusSubresource(t *testing.T) {
+	tests := []struct {
+		name              string
+		statusSubresource bool
+		subresources      *apiext.CustomResourceSubresources
+	}{
+		{
+			name: "no status subresource",
+		},
+		{
+			name:              "status subresource",
+			statusSubresource: true,
+			subresources: &apiext.CustomResourceSubresources{
+				Status: &apiext.CustomResourceSubresourceStatus{},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema := &types.Schema{
+				CodeName:          "Widget",
+				PluralName:        "widgets",
+				Scope:             types.NamespaceScope,
+				Version:           types.APIVersion{Group: "test.cattle.io", Version: "v1"},
+				StatusSubresource: test.statusSubresource,
+			}
+
+			crd, err := (&Factory{}).createCRD(context.Background(), fake.NewSimpleClientset(), schema, map[string]*apiext.CustomResourceDefinition{})
+			require.NoError(t, err)
+			assert.Equal(t, "widgets.test.cattle.io", crd.Name)
+			assert.Equal(t, test.subresources, crd.Spec.Versions[0].Subresources)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/definition"
+	"github.com/rancher/norman/types/values"
+	"github.com/sirupsen/logrus"
+)
+
+// Verbs of the exported objects.
+const (
+	Create = "create"
+	Update = "update"
+	Delete = "delete"
+)
+
+// removedFields are the paths of the fields left out of exported objects, they are set by the server.
+var removedFields = [][]string{
+	{"created"},
+	{"createdTS"},
+	{"uuid"},
+	{"state"},
+	{"status"},
+	{"links"},
+	{"actions"},
+	{"actionLinks"},
+	{"resourceVersion"},
+	{"managedFields"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"transitioning"},
+	{"transitioningMessage"},
+}
+
+// Object is a write of a resource through a store.
+type Object struct {
+	SchemaID string
+	ID       string
+	Verb     string
+	// User is the name of the user who made the write, "" if unknown
+	User string
+	Time time.Time
+	// YAML is the resource without the fields set by the server, passwords and the fields with ReadRoles, empty for
+	// deletes
+	YAML []byte
+}
+
+// Sink receives the objects written through the stores wrapped by Wrap, like a git repository or a bucket.
+type Sink interface {
+	Export(ctx context.Context, object Object) error
+}
+
+// SinkFunc is a Sink function.
+type SinkFunc func(ctx context.Context, object Object) error
+
+func (s SinkFunc) Export(ctx context.Context, object Object) error {
+	return s(ctx, object)
+}
+
+type Options struct {
+	// RemoveFields are the paths of other fields left out of exported objects
+	RemoveFields [][]string
+	// QueueSize is the number of objects waiting for the sink, defaults to 1000. Objects are dropped when the queue
+	// is full.
+	QueueSize int
+}
+
+// Store exports the resources successfully created, updated and deleted through it to a Sink. The objects are
+// queued and exported in order in the background, so that writes don't wait on the sink. Sink errors are logged
+// and don't fail writes.
+type Store struct {
+	types.Store
+	sink  Sink
+	opts  Options
+	queue chan Object
+}
+
+// Wrap exports the writes of the store of schema to sink until ctx is done.
+func Wrap(ctx context.Context, schema *types.Schema, sink Sink, opts Options) *Store {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+	store := &Store{
+		Store: schema.Store,
+		sink:  sink,
+		opts:  opts,
+		queue: make(chan Object, opts.QueueSize),
+	}
+	schema.Store = store
+	go store.run(ctx)
+	return store
+}
+
+func (s *Store) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case object := <-s.queue:
+			if err := s.sink.Export(ctx, object); err != nil {
+				logrus.Errorf("failed to export %s of %s %s: %v", object.Verb, object.SchemaID, object.ID, err)
+			}
+		}
+	}
+}
+
+func (s *Store) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	result, err := s.Store.Create(apiContext, schema, data)
+	if err == nil && result != nil && !apiContext.DryRun {
+		s.export(apiContext, schema, Create, convert.ToString(result["id"]), result)
+	}
+	return result, err
+}
+
+func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	result, err := s.Store.Update(apiContext, schema, data, id)
+	if err == nil && result != nil && !apiContext.DryRun {
+		s.export(apiContext, schema, Update, id, result)
+	}
+	return result, err
+}
+
+func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	result, err := s.Store.Delete(apiContext, schema, id)
+	if err == nil && !apiContext.DryRun {
+		s.export(apiContext, schema, Delete, id, nil)
+	}
+	return result, err
+}
+
+func (s *Store) export(apiContext *types.APIContext, schema *types.Schema, verb, id string, data map[string]interface{}) {
+	object := Object{
+		SchemaID: schema.ID,
+		ID:       id,
+		Verb:     verb,
+		Time:     time.Now().UTC(),
+	}
+	if apiContext.User != nil {
+		object.User = apiContext.User.Name
+	}
+	if data != nil {
+		content, err := s.render(apiContext, schema, data)
+		if err != nil {
+			logrus.Errorf("failed to render %s %s for export: %v", schema.ID, id, err)
+			return
+		}
+		object.YAML = content
+	}
+
+	select {
+	case s.queue <- object:
+	default:
+		logrus.Errorf("failed to export %s of %s %s: export queue is full", verb, schema.ID, id)
+	}
+}
+
+// render returns the YAML of a copy of data without the removed fields and the sensitive fields of schema.
+func (s *Store) render(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) ([]byte, error) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var copied map[string]interface{}
+	if err := json.Unmarshal(content, &copied); err != nil {
+		return nil, err
+	}
+	for _, field := range removedFields {
+		values.RemoveValue(copied, field...)
+	}
+	for _, field := range s.opts.RemoveFields {
+		values.RemoveValue(copied, field...)
+	}
+	removeSensitive(apiContext.Schemas, schema, copied)
+	return yaml.Marshal(copied)
+}
+
+// removeSensitive removes the passwords and the fields restricted by ReadRoles of schema from data, and from the
+// values of its fields whose type is a schema.
+func removeSensitive(schemas *types.Schemas, schema *types.Schema, data map[string]interface{}) {
+	for name, field := range schema.ResourceFields {
+		value, ok := data[name]
+		if !ok {
+			continue
+		}
+		if field.Type == "password" || len(field.ReadRoles) > 0 {
+			delete(data, name)
+			continue
+		}
+		if schemas != nil {
+			removeSensitiveValue(schemas, &schema.Version, field.Type, value)
+		}
+	}
+}
+
+func removeSensitiveValue(schemas *types.Schemas, version *types.APIVersion, fieldType string, value interface{}) {
+	switch {
+	case definition.IsArrayType(fieldType):
+		for _, item := range convert.ToInterfaceSlice(value) {
+			removeSensitiveValue(schemas, version, definition.SubType(fieldType), item)
+		}
+	case definition.IsMapType(fieldType):
+		for _, item := range convert.ToMapInterface(value) {
+			removeSensitiveValue(schemas, version, definition.SubType(fieldType), item)
+		}
+	default:
+		if schema := schemas.Schema(version, fieldType); schema != nil {
+			if data, ok := value.(map[string]interface{}); ok {
+				removeSensitive(schemas, schema, data)
+			}
+		}
+	}
+}
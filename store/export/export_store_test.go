@@ -0,0 +1,118 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoStore struct {
+	empty.Store
+}
+
+func (e *echoStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	if data["fail"] != nil {
+		return nil, errors.New("failed")
+	}
+	return data, nil
+}
+
+func TestExport(t *testing.T) {
+	schemas := types.NewSchemas()
+	schemas.AddSchema(types.Schema{
+		ID: "credential",
+		ResourceFields: map[string]types.Field{
+			"user":     {Type: "string"},
+			"password": {Type: "password"},
+		},
+	})
+	schema := &types.Schema{
+		ID:    "thing",
+		Store: &echoStore{},
+		ResourceFields: map[string]types.Field{
+			"value":       {Type: "string"},
+			"secret":      {Type: "string", ReadRoles: []string{"admin"}},
+			"credentials": {Type: "array[credential]"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exported := make(chan Object, 10)
+	store := Wrap(ctx, schema, SinkFunc(func(ctx context.Context, object Object) error {
+		exported <- object
+		return nil
+	}), Options{RemoveFields: [][]string{{"extra"}}})
+	require.Equal(t, store, schema.Store)
+
+	apiContext := &types.APIContext{User: &types.User{Name: "admin"}, Schemas: schemas}
+	_, err := store.Create(apiContext, schema, map[string]interface{}{
+		"id":          "a",
+		"value":       "1",
+		"secret":      "s",
+		"credentials": []interface{}{map[string]interface{}{"user": "u", "password": "p"}},
+		"extra":       "e",
+		"uuid":        "123",
+		"created":     "2020-01-01T00:00:00Z",
+		"createdTS":   1577836800000,
+		"state":       "active",
+		"status":      map[string]interface{}{"state": "active"},
+		"links":       map[string]interface{}{"self": "http://localhost/v1/things/a"},
+	})
+	require.NoError(t, err)
+
+	_, err = store.Create(apiContext, schema, map[string]interface{}{"id": "b", "fail": true})
+	require.Error(t, err)
+
+	_, err = store.Delete(apiContext, schema, "a")
+	require.NoError(t, err)
+
+	created := <-exported
+	assert.Equal(t, "thing", created.SchemaID)
+	assert.Equal(t, Create, created.Verb)
+	assert.Equal(t, "admin", created.User)
+	assert.Equal(t, "credentials:\n- user: u\nid: a\nvalue: \"1\"\n", string(created.YAML))
+	deleted := <-exported
+	assert.Equal(t, Delete, deleted.Verb)
+	assert.Equal(t, "a", deleted.ID)
+	assert.Empty(t, deleted.YAML)
+	assert.Empty(t, exported)
+}
+
+func TestExportQueueFull(t *testing.T) {
+	schema := &types.Schema{ID: "thing", Store: &echoStore{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exporting := make(chan struct{})
+	unblock := make(chan struct{})
+	exported := make(chan string, 3)
+	store := Wrap(ctx, schema, SinkFunc(func(ctx context.Context, object Object) error {
+		if object.ID == "a" {
+			close(exporting)
+			<-unblock
+		}
+		exported <- object.ID
+		return nil
+	}), Options{QueueSize: 1})
+
+	apiContext := &types.APIContext{}
+	for _, id := range []string{"a", "b", "c"} {
+		if id == "b" {
+			<-exporting
+		}
+		_, err := store.Create(apiContext, schema, map[string]interface{}{"id": id})
+		require.NoError(t, err)
+	}
+	// c is dropped as b fills the queue while a is exported
+	close(unblock)
+	assert.Equal(t, "a", <-exported)
+	assert.Equal(t, "b", <-exported)
+	assert.Empty(t, store.queue)
+	assert.Empty(t, exported)
+}
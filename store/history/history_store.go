@@ -0,0 +1,264 @@
+package history
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/parse"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RevisionType    = "revision"
+	RevisionsLink   = "revisions"
+	RollbackAction  = "rollback"
+	defaultMaxCount = 10
+)
+
+// Revision is a snapshot of a resource, stored by the store of the revision schema.
+type Revision struct {
+	types.Resource
+	ResourceType string                 `json:"resourceType"`
+	ResourceID   string                 `json:"resourceId"`
+	Revision     int64                  `json:"revision"`
+	Created      string                 `json:"created"`
+	Data         map[string]interface{} `json:"data"`
+}
+
+type Options struct {
+	// MaxRevisions is the number of revisions kept per resource, 10 if not set.
+	MaxRevisions int
+	// TTL removes older revisions, the latest revision is always kept.
+	TTL time.Duration
+}
+
+// Store records a revision each time a resource is created or updated.
+type Store struct {
+	types.Store
+	revisions      types.Store
+	revisionSchema *types.Schema
+	opts           Options
+}
+
+// Wrap records the revisions of schema in revisions, and adds the revisions link and the rollback action to its
+// resources. The revision schema is added to schemas, in the version of schema, if it does not exist yet.
+func Wrap(schemas *types.Schemas, schema *types.Schema, revisions types.Store, opts Options) *Store {
+	if opts.MaxRevisions <= 0 {
+		opts.MaxRevisions = defaultMaxCount
+	}
+
+	revisionSchema := schemas.Schema(&schema.Version, RevisionType)
+	if revisionSchema == nil {
+		schemas.MustImportAndCustomize(&schema.Version, Revision{}, func(s *types.Schema) {
+			s.CollectionMethods = []string{}
+			s.ResourceMethods = []string{}
+		})
+		revisionSchema = schemas.Schema(&schema.Version, RevisionType)
+	}
+
+	store := &Store{
+		Store:          schema.Store,
+		revisions:      revisions,
+		revisionSchema: revisionSchema,
+		opts:           opts,
+	}
+	schema.Store = store
+
+	if schema.ResourceActions == nil {
+		schema.ResourceActions = map[string]types.Action{}
+	}
+	schema.ResourceActions[RollbackAction] = types.Action{
+		Output: schema.ID,
+	}
+
+	formatter := schema.Formatter
+	schema.Formatter = func(apiContext *types.APIContext, resource *types.RawResource) {
+		if formatter != nil {
+			formatter(apiContext, resource)
+		}
+		resource.Links[RevisionsLink] = apiContext.URLBuilder.Link(RevisionsLink, resource)
+		resource.AddAction(apiContext, RollbackAction)
+	}
+
+	linkHandler := schema.LinkHandler
+	schema.LinkHandler = func(apiContext *types.APIContext, next types.RequestHandler) error {
+		if apiContext.Link != RevisionsLink {
+			if linkHandler == nil {
+				return httperror.NewAPIError(httperror.NotFound, "Link not found")
+			}
+			return linkHandler(apiContext, next)
+		}
+
+		revisions, err := store.Revisions(apiContext, apiContext.Schema, apiContext.ID)
+		if err != nil {
+			return err
+		}
+		apiContext.WriteResponse(http.StatusOK, revisions)
+		return nil
+	}
+
+	actionHandler := schema.ActionHandler
+	schema.ActionHandler = func(actionName string, action *types.Action, apiContext *types.APIContext) error {
+		if actionName != RollbackAction {
+			if actionHandler == nil {
+				return httperror.NewAPIError(httperror.NotFound, "action not found")
+			}
+			return actionHandler(actionName, action, apiContext)
+		}
+
+		input, err := parse.ReadBody(apiContext.Request)
+		if err != nil {
+			return err
+		}
+		revision, err := convert.ToNumber(input["revision"])
+		if err != nil {
+			return httperror.NewFieldAPIError(httperror.InvalidFormat, "revision", "revision must be a number")
+		}
+
+		data, err := store.Rollback(apiContext, apiContext.Schema, apiContext.ID, revision)
+		if err != nil {
+			return err
+		}
+		apiContext.WriteResponse(http.StatusOK, data)
+		return nil
+	}
+
+	return store
+}
+
+func (s *Store) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	result, err := s.Store.Create(apiContext, schema, data)
+	if err == nil && result != nil && !apiContext.DryRun {
+		s.record(apiContext, schema, result)
+	}
+	return result, err
+}
+
+func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	result, err := s.Store.Update(apiContext, schema, data, id)
+	if err == nil && result != nil && !apiContext.DryRun {
+		s.record(apiContext, schema, result)
+	}
+	return result, err
+}
+
+// Revisions returns the revisions of a resource, oldest first.
+func (s *Store) Revisions(apiContext *types.APIContext, schema *types.Schema, id string) ([]map[string]interface{}, error) {
+	data, err := s.revisions.List(apiContext, s.revisionSchema, &types.QueryOptions{
+		Conditions: []*types.QueryCondition{
+			types.NewConditionFromString("resourceType", types.ModifierEQ, schema.ID),
+			types.NewConditionFromString("resourceId", types.ModifierEQ, id),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	for _, item := range data {
+		if convert.ToString(item["resourceType"]) == schema.ID && convert.ToString(item["resourceId"]) == id {
+			result = append(result, item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return revisionNumber(result[i]) < revisionNumber(result[j])
+	})
+	return result, nil
+}
+
+// Rollback updates a resource to the data of one of its revisions.
+func (s *Store) Rollback(apiContext *types.APIContext, schema *types.Schema, id string, revision int64) (map[string]interface{}, error) {
+	revisions, err := s.Revisions(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range revisions {
+		if revisionNumber(item) == revision {
+			return s.Update(apiContext, schema, rollbackData(convert.ToMapInterface(item["data"])), id)
+		}
+	}
+	return nil, httperror.NewAPIError(httperror.NotFound, "revision "+strconv.FormatInt(revision, 10)+" not found")
+}
+
+func (s *Store) record(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) {
+	id := convert.ToString(data["id"])
+	revisions, err := s.Revisions(apiContext, schema, id)
+	if err != nil {
+		logrus.Errorf("failed to list revisions of %s %s: %v", schema.ID, id, err)
+		return
+	}
+
+	var next int64 = 1
+	if len(revisions) > 0 {
+		next = revisionNumber(revisions[len(revisions)-1]) + 1
+	}
+
+	revision, err := s.revisions.Create(apiContext, s.revisionSchema, map[string]interface{}{
+		"type":         RevisionType,
+		"resourceType": schema.ID,
+		"resourceId":   id,
+		"revision":     next,
+		"created":      time.Now().UTC().Format(time.RFC3339),
+		"data":         copyData(data),
+	})
+	if err != nil {
+		logrus.Errorf("failed to record revision %d of %s %s: %v", next, schema.ID, id, err)
+		return
+	}
+
+	s.prune(apiContext, append(revisions, revision))
+}
+
+func (s *Store) prune(apiContext *types.APIContext, revisions []map[string]interface{}) {
+	for i, revision := range revisions[:len(revisions)-1] {
+		expired := false
+		if s.opts.TTL > 0 {
+			created, err := time.Parse(time.RFC3339, convert.ToString(revision["created"]))
+			expired = err == nil && time.Since(created) > s.opts.TTL
+		}
+		if len(revisions)-i <= s.opts.MaxRevisions && !expired {
+			continue
+		}
+
+		if _, err := s.revisions.Delete(apiContext, s.revisionSchema, convert.ToString(revision["id"])); err != nil {
+			logrus.Errorf("failed to remove revision %v: %v", revision["id"], err)
+		}
+	}
+}
+
+func revisionNumber(data map[string]interface{}) int64 {
+	n, _ := convert.ToNumber(data["revision"])
+	return n
+}
+
+func copyData(data map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range data {
+		switch k {
+		case "links", "actions", "actionLinks":
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// rollbackData returns the data of a revision without its version, the update applies over the current version
+// instead of failing on the old one.
+func rollbackData(data map[string]interface{}) map[string]interface{} {
+	result := copyData(data)
+	delete(result, "version")
+	delete(result, "resourceVersion")
+	if metadata, ok := result["metadata"].(map[string]interface{}); ok {
+		metadataCopy := copyData(metadata)
+		delete(metadataCopy, "resourceVersion")
+		result["metadata"] = metadataCopy
+	}
+	return result
+}
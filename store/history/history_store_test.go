@@ -0,0 +1,82 @@
+package history
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	empty.Store
+	next int
+	data map[string]map[string]interface{}
+}
+
+func (m *memoryStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, item := range m.data {
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	if data["id"] == nil {
+		m.next++
+		data["id"] = strconv.Itoa(m.next)
+	}
+	m.data[data["id"].(string)] = data
+	return data, nil
+}
+
+func (m *memoryStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	m.data[id] = data
+	return data, nil
+}
+
+func (m *memoryStore) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	delete(m.data, id)
+	return nil, nil
+}
+
+func TestHistory(t *testing.T) {
+	version := types.APIVersion{Version: "v1", Path: "/v1"}
+	schemas := types.NewSchemas()
+	schemas.AddSchema(types.Schema{ID: "thing", Version: version})
+	schema := schemas.Schema(&version, "thing")
+	schema.Store = &memoryStore{data: map[string]map[string]interface{}{}}
+
+	revisions := &memoryStore{data: map[string]map[string]interface{}{}}
+	store := Wrap(schemas, schema, revisions, Options{MaxRevisions: 2})
+	require.NotNil(t, schemas.Schema(&version, RevisionType))
+
+	apiContext := &types.APIContext{}
+	_, err := store.Create(apiContext, schema, map[string]interface{}{"id": "a", "value": "1"})
+	require.NoError(t, err)
+	_, err = store.Update(apiContext, schema, map[string]interface{}{"id": "a", "value": "2", "resourceVersion": "2",
+		"metadata": map[string]interface{}{"resourceVersion": "2"}}, "a")
+	require.NoError(t, err)
+	_, err = store.Update(apiContext, schema, map[string]interface{}{"id": "a", "value": "3"}, "a")
+	require.NoError(t, err)
+
+	list, err := store.Revisions(apiContext, schema, "a")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, int64(2), list[0]["revision"])
+	assert.Equal(t, int64(3), list[1]["revision"])
+
+	data, err := store.Rollback(apiContext, schema, "a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "2", data["value"])
+	assert.NotContains(t, data, "resourceVersion")
+	assert.Equal(t, map[string]interface{}{}, data["metadata"])
+
+	list, err = store.Revisions(apiContext, schema, "a")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, int64(4), list[1]["revision"])
+}
@@ -0,0 +1,321 @@
+package overlay
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/values"
+)
+
+// SessionHeader identifies the sessions of a user, the writes of a user are shared by its requests without it.
+// Sessions never span users, requests without a user aren't overlaid.
+const SessionHeader = "X-API-Session-Id"
+
+// DefaultTTL is how long a write is overlaid if reads and watches don't confirm it.
+const DefaultTTL = 30 * time.Second
+
+type write struct {
+	obj             map[string]interface{}
+	resourceVersion string
+	deleted         bool
+	expires         time.Time
+}
+
+// Store overlays the objects created, updated and deleted through it on the reads of the same session, until the
+// wrapped store returns them or a watch through the store sends them, so that a list right after a create shows
+// the created object even if the wrapped store reads from a cache that didn't see it yet.
+type Store struct {
+	types.Store
+	ttl time.Duration
+
+	lock sync.Mutex
+	// writes holds the pending writes by session and ID
+	writes map[string]map[string]*write
+	// swept is when the expired writes of every session were last removed
+	swept time.Time
+}
+
+func NewOverlayStore(store types.Store, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{
+		Store:  store,
+		ttl:    ttl,
+		writes: map[string]map[string]*write{},
+	}
+}
+
+// Wrap overlays the writes through the store of schema on its reads.
+func Wrap(schema *types.Schema, ttl time.Duration) *Store {
+	store := NewOverlayStore(schema.Store, ttl)
+	schema.Store = store
+	return store
+}
+
+func (s *Store) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	result, err := s.Store.Create(apiContext, schema, data)
+	if err == nil && result != nil && !apiContext.DryRun {
+		s.written(apiContext, convert.ToString(result["id"]), result, false)
+	}
+	return result, err
+}
+
+func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	result, err := s.Store.Update(apiContext, schema, data, id)
+	if err == nil && result != nil && !apiContext.DryRun {
+		s.written(apiContext, id, result, false)
+	}
+	return result, err
+}
+
+func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	result, err := s.Store.Delete(apiContext, schema, id)
+	if err == nil && !apiContext.DryRun {
+		s.written(apiContext, id, result, true)
+	}
+	return result, err
+}
+
+func (s *Store) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	result, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil && !httperror.IsNotFound(err) {
+		return result, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pending := s.pending(apiContext)[id]
+	if pending == nil || s.confirm(id, pending, result) {
+		return result, err
+	}
+	if pending.deleted {
+		return nil, httperror.NewAPIError(httperror.NotFound, "not found")
+	}
+	return pending.obj, nil
+}
+
+func (s *Store) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	s.lock.Lock()
+	hasPending := len(s.pending(apiContext)) > 0
+	s.lock.Unlock()
+	if !hasPending {
+		return s.Store.List(apiContext, schema, opt)
+	}
+
+	// the page is taken from the overlaid list by the outer store wrapper, so the wrapped store lists every object
+	var unpaged *types.QueryOptions
+	if opt != nil {
+		copied := *opt
+		copied.Pagination = nil
+		unpaged = &copied
+	}
+	result, err := s.Store.List(apiContext, schema, unpaged)
+	if err != nil {
+		return result, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	writes := s.pending(apiContext)
+
+	listed := map[string]bool{}
+	overlaid := make([]map[string]interface{}, 0, len(result))
+	for _, obj := range result {
+		id := convert.ToString(obj["id"])
+		listed[id] = true
+		pending := writes[id]
+		switch {
+		case pending == nil || s.confirm(id, pending, obj):
+			overlaid = append(overlaid, obj)
+		case !pending.deleted:
+			overlaid = append(overlaid, pending.obj)
+		}
+	}
+	for id, pending := range writes {
+		if listed[id] {
+			continue
+		}
+		if pending.deleted {
+			s.confirm(id, pending, nil)
+		} else if matches(schema, opt, pending.obj) {
+			overlaid = append(overlaid, pending.obj)
+		}
+	}
+	// the outer store wrapper sorts and pages the overlaid list
+	return overlaid, nil
+}
+
+func (s *Store) Watch(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) (chan map[string]interface{}, error) {
+	c, err := s.Store.Watch(apiContext, schema, opt)
+	if err != nil || c == nil {
+		return c, err
+	}
+
+	ctx := apiContext.Request.Context()
+	result := make(chan map[string]interface{})
+	go func() {
+		defer close(result)
+		for obj := range c {
+			s.watched(obj)
+			select {
+			case result <- obj:
+			case <-ctx.Done():
+				// the wrapped store closes c once it sees ctx is done
+				for range c {
+				}
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
+func (s *Store) written(apiContext *types.APIContext, id string, obj map[string]interface{}, deleted bool) {
+	session := session(apiContext)
+	if session == "" || id == "" {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sweep()
+	if s.writes[session] == nil {
+		s.writes[session] = map[string]*write{}
+	}
+	s.writes[session][id] = &write{
+		obj:             obj,
+		resourceVersion: resourceVersion(obj),
+		deleted:         deleted,
+		expires:         time.Now().Add(s.ttl),
+	}
+}
+
+// sweep removes the expired writes of every session, at most once per TTL, s.lock must be held. Sessions only
+// prune their own writes when they read, so writes of sessions that never read again are only removed here.
+func (s *Store) sweep() {
+	now := time.Now()
+	if now.Sub(s.swept) < s.ttl {
+		return
+	}
+	s.swept = now
+	for session, writes := range s.writes {
+		for id, pending := range writes {
+			if now.After(pending.expires) {
+				delete(writes, id)
+			}
+		}
+		if len(writes) == 0 {
+			delete(s.writes, session)
+		}
+	}
+}
+
+// watched confirms the writes of every session seen by obj, an event of a watch through the store.
+func (s *Store) watched(obj map[string]interface{}) {
+	id := convert.ToString(obj["id"])
+	removed := convert.ToBool(obj[".removed"])
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, writes := range s.writes {
+		if pending := writes[id]; pending != nil && pending.deleted == removed {
+			s.confirm(id, pending, obj)
+		}
+	}
+}
+
+// pending returns the unexpired writes of the session of apiContext, s.lock must be held.
+func (s *Store) pending(apiContext *types.APIContext) map[string]*write {
+	session := session(apiContext)
+	writes := s.writes[session]
+	now := time.Now()
+	for id, pending := range writes {
+		if now.After(pending.expires) {
+			delete(writes, id)
+		}
+	}
+	if len(writes) == 0 {
+		delete(s.writes, session)
+	}
+	return writes
+}
+
+// confirm removes the pending write of id from every session if obj, read from the wrapped store, is at least as
+// recent, s.lock must be held.
+func (s *Store) confirm(id string, pending *write, obj map[string]interface{}) bool {
+	if pending.deleted {
+		if obj != nil && !convert.ToBool(obj[".removed"]) {
+			return false
+		}
+	} else if obj == nil || !newer(resourceVersion(obj), pending.resourceVersion) {
+		return false
+	}
+
+	for session, writes := range s.writes {
+		if writes[id] == pending {
+			delete(writes, id)
+		}
+		if len(writes) == 0 {
+			delete(s.writes, session)
+		}
+	}
+	return true
+}
+
+// session returns the key of the writes of the user of apiContext and its session header, "" without a user.
+func session(apiContext *types.APIContext) string {
+	if apiContext.User == nil || apiContext.User.Name == "" {
+		return ""
+	}
+	key := apiContext.User.Name + "\x00"
+	if apiContext.Request != nil {
+		key += apiContext.Request.Header.Get(SessionHeader)
+	}
+	return key
+}
+
+func resourceVersion(obj map[string]interface{}) string {
+	if version := convert.ToString(values.GetValueN(obj, "metadata", "resourceVersion")); version != "" {
+		return version
+	}
+	return convert.ToString(obj["resourceVersion"])
+}
+
+// newer returns whether the resourceVersion read is at least the written one, resourceVersions are opaque but
+// the ones of etcd are increasing numbers.
+func newer(read, written string) bool {
+	if read == written {
+		return true
+	}
+	readVersion, readErr := strconv.ParseUint(read, 10, 64)
+	writtenVersion, writtenErr := strconv.ParseUint(written, 10, 64)
+	return readErr == nil && writtenErr == nil && readVersion > writtenVersion
+}
+
+func matches(schema *types.Schema, opt *types.QueryOptions, obj map[string]interface{}) bool {
+	if opt == nil {
+		return true
+	}
+	if opt.Namespaces != nil {
+		found := false
+		for _, namespace := range opt.Namespaces {
+			if namespace == convert.ToString(obj["namespaceId"]) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, condition := range opt.Conditions {
+		if !condition.Valid(schema, obj) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package overlay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/api/handler"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/parse"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/store/wrapper"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staleStore reads from a cache that only sees the writes once synced.
+type staleStore struct {
+	empty.Store
+	cache map[string]map[string]interface{}
+}
+
+func (s *staleStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	if obj, ok := s.cache[id]; ok {
+		return obj, nil
+	}
+	return nil, httperror.NewAPIError(httperror.NotFound, "not found")
+}
+
+func (s *staleStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, obj := range s.cache {
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+func (s *staleStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	return data, nil
+}
+
+func (s *staleStore) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return s.cache[id], nil
+}
+
+func TestOverlayStore(t *testing.T) {
+	stale := &staleStore{cache: map[string]map[string]interface{}{}}
+	store := NewOverlayStore(stale, time.Minute)
+	alice := &types.APIContext{User: &types.User{Name: "alice"}}
+	bob := &types.APIContext{User: &types.User{Name: "bob"}}
+
+	created := map[string]interface{}{"id": "c-1", "resourceVersion": "10"}
+	_, err := store.Create(alice, nil, created)
+	require.NoError(t, err)
+
+	list, err := store.List(alice, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{created}, list)
+	obj, err := store.ByID(alice, nil, "c-1")
+	require.NoError(t, err)
+	assert.Equal(t, created, obj)
+	list, err = store.List(bob, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	synced := map[string]interface{}{"id": "c-1", "resourceVersion": "11", "name": "synced"}
+	stale.cache["c-1"] = synced
+	list, err = store.List(alice, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{synced}, list)
+	assert.Empty(t, store.writes)
+
+	_, err = store.Delete(alice, nil, "c-1")
+	require.NoError(t, err)
+	_, err = store.ByID(alice, nil, "c-1")
+	assert.True(t, httperror.IsNotFound(err))
+	list, err = store.List(alice, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	store.watched(map[string]interface{}{"id": "c-1", ".removed": true})
+	assert.Empty(t, store.writes)
+}
+
+func withSession(apiContext *types.APIContext, session string) *types.APIContext {
+	apiContext.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	apiContext.Request.Header.Set(SessionHeader, session)
+	return apiContext
+}
+
+func TestOverlaySessionsOfUsers(t *testing.T) {
+	stale := &staleStore{cache: map[string]map[string]interface{}{}}
+	store := NewOverlayStore(stale, time.Minute)
+	alice := withSession(&types.APIContext{User: &types.User{Name: "alice"}}, "shared")
+	bob := withSession(&types.APIContext{User: &types.User{Name: "bob"}}, "shared")
+	anonymous := withSession(&types.APIContext{}, "shared")
+
+	_, err := store.Create(alice, nil, map[string]interface{}{"id": "c-1"})
+	require.NoError(t, err)
+	_, err = store.Create(anonymous, nil, map[string]interface{}{"id": "c-2"})
+	require.NoError(t, err)
+
+	list, err := store.List(bob, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = store.ByID(bob, nil, "c-1")
+	assert.True(t, httperror.IsNotFound(err))
+	list, err = store.List(anonymous, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	assert.Len(t, store.writes, 1)
+}
+
+func TestOverlayPagination(t *testing.T) {
+	stale := &staleStore{cache: map[string]map[string]interface{}{
+		"a": {"id": "a"},
+		"c": {"id": "c"},
+	}}
+	store := NewOverlayStore(stale, time.Minute)
+	alice := &types.APIContext{
+		User:                        &types.User{Name: "alice"},
+		QueryFilter:                 handler.QueryFilter,
+		SubContextAttributeProvider: &parse.DefaultSubContextAttributeProvider{},
+	}
+	_, err := store.Create(alice, nil, map[string]interface{}{"id": "b"})
+	require.NoError(t, err)
+
+	limit := int64(2)
+	opt := &types.QueryOptions{Pagination: &types.Pagination{Limit: &limit}}
+	list, err := wrapper.Wrap(store).List(alice, &types.Schema{}, opt)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": "a"}, {"id": "b"}}, list)
+	assert.Equal(t, "c", opt.Pagination.Next)
+	assert.Equal(t, int64(3), *opt.Pagination.Total)
+	assert.True(t, opt.Pagination.Partial)
+}
+
+func TestOverlaySweep(t *testing.T) {
+	store := NewOverlayStore(&staleStore{}, time.Millisecond)
+	_, err := store.Create(&types.APIContext{User: &types.User{Name: "alice"}}, nil, map[string]interface{}{"id": "c-1"})
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = store.Create(&types.APIContext{User: &types.User{Name: "bob"}}, nil, map[string]interface{}{"id": "c-2"})
+	require.NoError(t, err)
+	assert.Len(t, store.writes, 1)
+}
+
+type watchStore struct {
+	empty.Store
+	c chan map[string]interface{}
+}
+
+func (w *watchStore) Watch(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) (chan map[string]interface{}, error) {
+	return w.c, nil
+}
+
+func TestOverlayWatchDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	apiContext := &types.APIContext{Request: httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)}
+	upstream := make(chan map[string]interface{})
+	store := NewOverlayStore(&watchStore{c: upstream}, time.Minute)
+
+	c, err := store.Watch(apiContext, nil, nil)
+	require.NoError(t, err)
+	cancel()
+	// nothing reads c, the overlay stops sending once the request is done
+	upstream <- map[string]interface{}{"id": "c-1"}
+	upstream <- map[string]interface{}{"id": "c-2"}
+	close(upstream)
+
+	_, open := <-c
+	assert.False(t, open)
+}
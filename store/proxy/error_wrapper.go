@@ -46,7 +46,16 @@ func (e *errorStore) Watch(apiContext *types.APIContext, schema *types.Schema, o
 func translateError(err error) error {
 	if apiError, ok := err.(errors.APIStatus); ok {
 		status := apiError.Status()
-		return httperror.NewAPIErrorLong(int(status.Code), string(status.Reason), status.Message)
+		retryable, retryAfter := httperror.RetryInfo(err)
+		return &httperror.APIError{
+			Code: httperror.ErrorCode{
+				Code:   string(status.Reason),
+				Status: int(status.Code),
+			},
+			Message:    status.Message,
+			Retryable:  retryable,
+			RetryAfter: retryAfter,
+		}
 	}
 	return err
 }
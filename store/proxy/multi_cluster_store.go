@@ -0,0 +1,269 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ClusterIDField holds the cluster an object of a MultiClusterStore lives in. The IDs of those objects are
+// prefixed with "<cluster>:".
+const ClusterIDField = "clusterId"
+
+// ClusterStores returns the store of each cluster visible to a request, by cluster ID.
+type ClusterStores func(apiContext *types.APIContext, schema *types.Schema) (map[string]types.Store, error)
+
+// MultiClusterStore fans lists and watches out to the stores of several clusters and merges the results, and
+// routes reads and writes of a single object to the cluster that owns it. Clusters that fail to list or watch are
+// left out, so one unreachable cluster does not break the whole collection, and listed in a Warning header of the
+// response. Lists and watches fail with ClusterUnavailable if all clusters fail.
+type MultiClusterStore struct {
+	clusters ClusterStores
+}
+
+func NewMultiClusterStore(clusters ClusterStores) *MultiClusterStore {
+	return &MultiClusterStore{
+		clusters: clusters,
+	}
+}
+
+// NewClientGetterFromKubeconfig builds the ClientGetter of a downstream cluster from a stored kubeconfig.
+func NewClientGetterFromKubeconfig(kubeconfig []byte) (ClientGetter, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientGetterFromConfig(*config)
+}
+
+func (m *MultiClusterStore) Context() types.StorageContext {
+	return types.DefaultStorageContext
+}
+
+func (m *MultiClusterStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	clusterID, store, id, err := m.route(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := store.ByID(apiContext, schema, id)
+	return toMultiCluster(clusterID, data), err
+}
+
+func (m *MultiClusterStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	clusters, err := m.clusters(apiContext, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		lock    sync.Mutex
+		results = map[string][]map[string]interface{}{}
+		failed  = map[string]error{}
+		wg      sync.WaitGroup
+	)
+	for clusterID, store := range clusters {
+		clusterID, store := clusterID, store
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := store.List(apiContext, schema, opt)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				logrus.Warnf("failed to list %s in cluster %s: %v", schema.ID, clusterID, err)
+				failed[clusterID] = err
+				return
+			}
+			for i, item := range data {
+				data[i] = toMultiCluster(clusterID, item)
+			}
+			results[clusterID] = data
+		}()
+	}
+	wg.Wait()
+	if err := reportFailed(apiContext, schema, "list", failed, len(results)); err != nil {
+		return nil, err
+	}
+
+	clusterIDs := make([]string, 0, len(results))
+	for clusterID := range results {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	sort.Strings(clusterIDs)
+
+	var result []map[string]interface{}
+	for _, clusterID := range clusterIDs {
+		result = append(result, results[clusterID]...)
+	}
+	return result, nil
+}
+
+func (m *MultiClusterStore) Watch(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) (chan map[string]interface{}, error) {
+	clusters, err := m.clusters(apiContext, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	watches := map[string]chan map[string]interface{}{}
+	failed := map[string]error{}
+	for clusterID, store := range clusters {
+		c, err := store.Watch(apiContext, schema, opt)
+		if err != nil {
+			logrus.Warnf("failed to watch %s in cluster %s: %v", schema.ID, clusterID, err)
+			failed[clusterID] = err
+			continue
+		}
+		watches[clusterID] = c
+	}
+	if err := reportFailed(apiContext, schema, "watch", failed, len(watches)); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(apiContext.Request.Context())
+	result := make(chan map[string]interface{})
+	wg := sync.WaitGroup{}
+	for clusterID, c := range watches {
+		if c == nil {
+			continue
+		}
+
+		clusterID, c := clusterID, c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range c {
+				select {
+				case result <- toMultiCluster(clusterID, item):
+				case <-ctx.Done():
+					// drain so the cluster watch can finish
+					for range c {
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(result)
+	}()
+
+	return result, nil
+}
+
+// reportFailed lists the clusters that failed to list or watch in a Warning header of the response, or returns a
+// ClusterUnavailable error if no cluster succeeded.
+func reportFailed(apiContext *types.APIContext, schema *types.Schema, op string, failed map[string]error, succeeded int) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	clusterIDs := make([]string, 0, len(failed))
+	for clusterID := range failed {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	sort.Strings(clusterIDs)
+
+	if succeeded == 0 {
+		return httperror.WrapAPIError(failed[clusterIDs[0]], httperror.ClusterUnavailable,
+			fmt.Sprintf("failed to %s %s in clusters %s", op, schema.ID, strings.Join(clusterIDs, ", ")))
+	}
+	if apiContext != nil && apiContext.Response != nil {
+		apiContext.Response.Header().Add("Warning", fmt.Sprintf("299 - \"failed to %s %s in clusters %s, they are left out\"",
+			op, schema.ID, strings.Join(clusterIDs, ", ")))
+	}
+	return nil
+}
+
+func (m *MultiClusterStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	clusterID := convert.ToString(data[ClusterIDField])
+	if clusterID == "" {
+		return nil, httperror.NewFieldAPIError(httperror.MissingRequired, ClusterIDField, "")
+	}
+	store, err := m.store(apiContext, schema, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	data = copyWithout(data, ClusterIDField)
+	result, err := store.Create(apiContext, schema, data)
+	return toMultiCluster(clusterID, result), err
+}
+
+func (m *MultiClusterStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	clusterID, store, id, err := m.route(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data = copyWithout(data, ClusterIDField)
+	if _, ok := data["id"]; ok {
+		data["id"] = id
+	}
+	result, err := store.Update(apiContext, schema, data, id)
+	return toMultiCluster(clusterID, result), err
+}
+
+func (m *MultiClusterStore) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	clusterID, store, id, err := m.route(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := store.Delete(apiContext, schema, id)
+	return toMultiCluster(clusterID, result), err
+}
+
+// route splits the cluster off a multi-cluster ID and returns the store of that cluster with the ID it knows the
+// object by.
+func (m *MultiClusterStore) route(apiContext *types.APIContext, schema *types.Schema, id string) (string, types.Store, string, error) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", nil, "", httperror.NewAPIError(httperror.NotFound, "invalid id "+id)
+	}
+
+	store, err := m.store(apiContext, schema, parts[0])
+	return parts[0], store, parts[1], err
+}
+
+func (m *MultiClusterStore) store(apiContext *types.APIContext, schema *types.Schema, clusterID string) (types.Store, error) {
+	clusters, err := m.clusters(apiContext, schema)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := clusters[clusterID]
+	if !ok {
+		return nil, httperror.NewAPIError(httperror.NotFound, "no such cluster "+clusterID)
+	}
+	return store, nil
+}
+
+func toMultiCluster(clusterID string, data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	data = copyWithout(data, ClusterIDField)
+	data[ClusterIDField] = clusterID
+	if id := convert.ToString(data["id"]); id != "" {
+		data["id"] = clusterID + ":" + id
+	}
+	return data
+}
+
+func copyWithout(data map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type clusterStore struct {
+	empty.Store
+	data    map[string]map[string]interface{}
+	listErr error
+}
+
+func (c *clusterStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return c.data[id], nil
+}
+
+func (c *clusterStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, data := range c.data {
+		result = append(result, data)
+	}
+	return result, c.listErr
+}
+
+func (c *clusterStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	id := fmt.Sprint(data["name"])
+	data["id"] = id
+	c.data[id] = data
+	return data, nil
+}
+
+func TestMultiClusterStore(t *testing.T) {
+	local := &clusterStore{data: map[string]map[string]interface{}{
+		"default:a": {"id": "default:a"},
+	}}
+	remote := &clusterStore{data: map[string]map[string]interface{}{}}
+	broken := &clusterStore{listErr: fmt.Errorf("unreachable")}
+
+	store := NewMultiClusterStore(func(apiContext *types.APIContext, schema *types.Schema) (map[string]types.Store, error) {
+		return map[string]types.Store{
+			"local":  local,
+			"remote": remote,
+			"broken": broken,
+		}, nil
+	})
+	schema := &types.Schema{ID: "thing"}
+
+	created, err := store.Create(nil, schema, map[string]interface{}{
+		ClusterIDField: "remote",
+		"name":         "b",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "remote:b", created["id"])
+	assert.NotContains(t, remote.data["b"], ClusterIDField)
+
+	apiContext := &types.APIContext{Response: httptest.NewRecorder()}
+	list, err := store.List(apiContext, schema, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "local:default:a", ClusterIDField: "local"},
+		{"id": "remote:b", "name": "b", ClusterIDField: "remote"},
+	}, list)
+	assert.Equal(t, `299 - "failed to list thing in clusters broken, they are left out"`,
+		apiContext.Response.Header().Get("Warning"))
+
+	data, err := store.ByID(nil, schema, "local:default:a")
+	assert.NoError(t, err)
+	assert.Equal(t, "local:default:a", data["id"])
+	assert.Equal(t, "default:a", local.data["default:a"]["id"])
+
+	_, err = store.ByID(nil, schema, "missing:default:a")
+	assert.Error(t, err)
+
+	_, err = store.Create(nil, schema, map[string]interface{}{"name": "c"})
+	assert.Error(t, err)
+
+	brokenOnly := NewMultiClusterStore(func(apiContext *types.APIContext, schema *types.Schema) (map[string]types.Store, error) {
+		return map[string]types.Store{"broken": broken}, nil
+	})
+	_, err = brokenOnly.List(nil, schema, nil)
+	assert.Equal(t, httperror.ClusterUnavailable, err.(*httperror.APIError).Code)
+}
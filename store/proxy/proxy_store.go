@@ -3,6 +3,7 @@ package proxy
 import (
 	"context"
 	ejson "encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
@@ -20,6 +21,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -134,6 +136,23 @@ func NewProxyStore(ctx context.Context, clientGetter ClientGetter, storageContex
 	}
 }
 
+// NewProxyStoreForKind returns a proxy store for gvk, whose resource is found with mapper, a discoverycache.Cache
+// RESTMapper for instance.
+func NewProxyStoreForKind(ctx context.Context, clientGetter ClientGetter, storageContext types.StorageContext, typer StoreTyper,
+	mapper meta.RESTMapper, gvk schema.GroupVersionKind) (types.Store, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := []string{"apis"}
+	if gvk.Group == "" {
+		prefix = []string{"api"}
+	}
+	return NewProxyStore(ctx, clientGetter, storageContext, typer, prefix, gvk.Group, gvk.Version, gvk.Kind,
+		mapping.Resource.Resource), nil
+}
+
 func (s *Store) getUser(apiContext *types.APIContext) string {
 	return apiContext.Request.Header.Get(userAuthHeader)
 }
@@ -148,6 +167,11 @@ func (s *Store) doAuthed(apiContext *types.APIContext, request *rest.Request) re
 		request.SetHeader(header, apiContext.Request.Header[http.CanonicalHeaderKey(header)]...)
 	}
 
+	if apiContext.RequestID != "" {
+		request.SetHeader(types.RequestIDHeader, apiContext.RequestID)
+		request.SetHeader(types.AuditIDHeader, apiContext.RequestID)
+	}
+
 	//set extra info headers
 	for header := range apiContext.Request.Header {
 		if strings.HasPrefix(header, "Impersonate-Extra-") {
@@ -168,20 +192,11 @@ func (s *Store) ByID(apiContext *types.APIContext, schema *types.Schema, id stri
 }
 
 func (s *Store) byID(apiContext *types.APIContext, schema *types.Schema, id string, retry bool) (string, map[string]interface{}, error) {
-	splitted := strings.Split(strings.TrimSpace(id), ":")
-	validID := false
-	namespaced := schema.Scope == types.NamespaceScope
-	if namespaced {
-		validID = len(splitted) == 2 && len(strings.TrimSpace(splitted[0])) > 0 && len(strings.TrimSpace(splitted[1])) > 0
-	} else {
-		validID = len(splitted) == 1 && len(strings.TrimSpace(splitted[0])) > 0
-	}
-	if !validID {
-		return "", nil, httperror.NewAPIError(httperror.NotFound, "failed to find resource by id")
+	namespace, id, err := parseID(schema, id)
+	if err != nil {
+		return "", nil, err
 	}
 
-	namespace, id := splitID(id)
-
 	k8sClient, err := s.k8sClient(apiContext)
 	if err != nil {
 		return "", nil, err
@@ -269,6 +284,10 @@ func (s *Store) retryList(namespace string, apiContext *types.APIContext, result
 
 	for i := 0; i < 3; i++ {
 		req := s.common(namespace, k8sClient.Get())
+		if apiContext.RequestID != "" {
+			req.SetHeader(types.RequestIDHeader, apiContext.RequestID)
+			req.SetHeader(types.AuditIDHeader, apiContext.RequestID)
+		}
 		start := time.Now()
 		err = req.Do(apiContext.Request.Context()).Into(resultList)
 		logrus.Tracef("LIST: %v, %v", time.Since(start), s.resourcePlural)
@@ -417,6 +436,9 @@ func (s *Store) Create(apiContext *types.APIContext, schema *types.Schema, data
 		Body(&unstructured.Unstructured{
 			Object: data,
 		})
+	if apiContext.DryRun {
+		req.Param("dryRun", metav1.DryRunAll)
+	}
 
 	_, result, err := s.singleResult(apiContext, schema, req)
 	return result, err
@@ -449,10 +471,16 @@ func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data
 		return nil, err
 	}
 
-	namespace, id := splitID(id)
+	fullID := id
+	namespace, id, err := parseID(schema, id)
+	if err != nil {
+		return nil, err
+	}
 	if err := s.toInternal(schema.Mapper, data); err != nil {
 		return nil, err
 	}
+	// a client that sends the version it read gets a conflict instead of its changes being merged into newer ones
+	version := convert.ToString(values.GetValueN(data, "metadata", "resourceVersion"))
 
 	for i := 0; i < 5; i++ {
 		req := s.common(namespace, k8sClient.Get()).
@@ -465,6 +493,9 @@ func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data
 
 		existing = merge.APIUpdateMerge(schema.InternalSchema, apiContext.Schemas, existing, data, apiContext.Option("replace") == "true")
 
+		if version != "" {
+			resourceVersion = version
+		}
 		values.PutValue(existing, resourceVersion, "metadata", "resourceVersion")
 		values.PutValue(existing, namespace, "metadata", "namespace")
 		values.PutValue(existing, id, "metadata", "name")
@@ -474,8 +505,18 @@ func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data
 				Object: existing,
 			}).
 			Name(id)
+		if apiContext.DryRun {
+			req.Param("dryRun", metav1.DryRunAll)
+		}
 
 		_, result, err = s.singleResult(apiContext, schema, req)
+		if errors.IsConflict(err) && version != "" {
+			_, current, getErr := s.byID(apiContext, schema, fullID, false)
+			if getErr != nil {
+				current = nil
+			}
+			return nil, httperror.NewConflictAPIError(fmt.Sprintf("version %s of %s is out of date", version, fullID), current)
+		}
 		if errors.IsConflict(err) {
 			continue
 		}
@@ -491,7 +532,10 @@ func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id st
 		return nil, err
 	}
 
-	namespace, name := splitID(id)
+	namespace, name, err := parseID(schema, id)
+	if err != nil {
+		return nil, err
+	}
 	options, err := getDeleteOption(apiContext.Request)
 	if err != nil {
 		return nil, err
@@ -499,6 +543,9 @@ func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id st
 	req := s.common(namespace, k8sClient.Delete()).
 		Body(options).
 		Name(name)
+	if apiContext.DryRun {
+		req.Param("dryRun", metav1.DryRunAll)
+	}
 
 	err = s.doAuthed(apiContext, req).Error()
 	if err != nil {
@@ -531,15 +578,14 @@ func (s *Store) singleResultRaw(apiContext *types.APIContext, schema *types.Sche
 	return result.GetResourceVersion(), result.Object, nil
 }
 
-func splitID(id string) (string, string) {
-	namespace := ""
-	parts := strings.SplitN(id, ":", 2)
-	if len(parts) == 2 {
-		namespace = parts[0]
-		id = parts[1]
+// parseID returns the namespace and name of id, which must have a namespace only if the schema is namespaced.
+func parseID(schema *types.Schema, id string) (string, string, error) {
+	namespace, name, err := schema.IDs().ParseID(strings.TrimSpace(id))
+	namespace, name = strings.TrimSpace(namespace), strings.TrimSpace(name)
+	if err != nil || name == "" || (namespace != "") != (schema.Scope == types.NamespaceScope) {
+		return "", "", httperror.NewAPIError(httperror.NotFound, "failed to find resource by id")
 	}
-
-	return namespace, id
+	return namespace, name, nil
 }
 
 func getDeleteOption(req *http.Request) (*metav1.DeleteOptions, error) {
@@ -0,0 +1,321 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/values"
+)
+
+const (
+	UsageType         = "quotaUsage"
+	UsageLink         = "quota"
+	CreatorAnnotation = "quota.norman.rancher.io/creator"
+)
+
+// Usage is the number of objects of a type counted against the quota of one namespace or user.
+type Usage struct {
+	types.Resource
+	ResourceType string `json:"resourceType"`
+	Key          string `json:"key"`
+	Count        int    `json:"count"`
+	MaxCount     int    `json:"maxCount,omitempty"`
+	MaxSize      int    `json:"maxSize,omitempty"`
+}
+
+// KeyFunc returns the namespace, user or other tenant an object is counted against.
+type KeyFunc func(apiContext *types.APIContext, data map[string]interface{}) string
+
+// ByNamespace counts objects per namespace.
+func ByNamespace(apiContext *types.APIContext, data map[string]interface{}) string {
+	if namespace := convert.ToString(data["namespaceId"]); namespace != "" {
+		return namespace
+	}
+	namespace, _, _ := apiContext.Schema.IDs().ParseID(convert.ToString(data["id"]))
+	return namespace
+}
+
+// ByCreator counts objects per user that created them.
+func ByCreator(apiContext *types.APIContext, data map[string]interface{}) string {
+	return convert.ToString(values.GetValueN(data, "annotations", CreatorAnnotation))
+}
+
+type Options struct {
+	// MaxCount is the number of objects allowed per key, unlimited if not set.
+	MaxCount int
+	// MaxSize is the size in bytes of the JSON of a single object, unlimited if not set.
+	MaxSize int
+	// Key defaults to ByNamespace.
+	Key KeyFunc
+}
+
+// Store rejects creates and updates that exceed the quota of the namespace or user of the object with a
+// QuotaExceeded error. Objects are counted by listing the wrapped store without the identity of the requesting user,
+// so objects the user can not see count as well. Created objects are annotated with the name of the user that
+// created them, which users can not set or change.
+//
+// The quota is best effort: creates are counted, by listing all objects of the type, under a lock of this process
+// only, so replicas of the server creating objects at the same time can exceed it.
+type Store struct {
+	types.Store
+	opts Options
+	lock sync.Mutex
+}
+
+// Wrap enforces opts on schema, and adds the quota link to its collections. The link lists the usage of each
+// namespace or user from the quotaUsage schema, which is added to schemas, in the version of schema, if it does
+// not exist yet.
+func Wrap(schemas *types.Schemas, schema *types.Schema, opts Options) *Store {
+	if opts.Key == nil {
+		opts.Key = ByNamespace
+	}
+
+	usageSchema := schemas.Schema(&schema.Version, UsageType)
+	if usageSchema == nil {
+		schemas.TypeName(UsageType, Usage{}).MustImportAndCustomize(&schema.Version, Usage{}, func(s *types.Schema) {
+			s.CollectionMethods = []string{http.MethodGet}
+			s.ResourceMethods = []string{}
+			s.Store = &usageStore{
+				quotas: map[string]*quotaSchema{},
+			}
+		})
+		usageSchema = schemas.Schema(&schema.Version, UsageType)
+	}
+
+	store := &Store{
+		Store: schema.Store,
+		opts:  opts,
+	}
+	schema.Store = store
+
+	if usage, ok := usageSchema.Store.(*usageStore); ok {
+		usage.add(schema, store)
+	}
+
+	collectionFormatter := schema.CollectionFormatter
+	schema.CollectionFormatter = func(apiContext *types.APIContext, collection *types.GenericCollection) {
+		if collectionFormatter != nil {
+			collectionFormatter(apiContext, collection)
+		}
+		collection.Links[UsageLink] = apiContext.URLBuilder.Collection(usageSchema, nil) + "?resourceType=" + schema.ID
+	}
+
+	return store
+}
+
+func (s *Store) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	if user := creator(apiContext); user != "" {
+		values.PutValue(data, user, "annotations", CreatorAnnotation)
+	} else {
+		values.RemoveValue(data, "annotations", CreatorAnnotation)
+	}
+
+	if err := s.checkSize(data); err != nil {
+		return nil, err
+	}
+
+	if s.opts.MaxCount <= 0 {
+		return s.Store.Create(apiContext, schema, data)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := s.opts.Key(apiContext, data)
+	usage, err := s.Usage(apiContext, schema)
+	if err != nil {
+		return nil, err
+	}
+	if usage[key] >= s.opts.MaxCount {
+		return nil, httperror.NewAPIError(httperror.QuotaExceeded,
+			fmt.Sprintf("quota of %d %s exceeded for %s", s.opts.MaxCount, schema.PluralName, describe(key)))
+	}
+	return s.Store.Create(apiContext, schema, data)
+}
+
+func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	if err := s.checkSize(data); err != nil {
+		return nil, err
+	}
+	if err := s.keepCreator(apiContext, schema, data, id); err != nil {
+		return nil, err
+	}
+	return s.Store.Update(apiContext, schema, data, id)
+}
+
+// keepCreator replaces the creator annotation in the annotations of an update with the one of the existing object.
+func (s *Store) keepCreator(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) error {
+	if _, ok := data["annotations"]; !ok {
+		return nil
+	}
+	existing, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil {
+		return err
+	}
+	if user, ok := values.GetValue(existing, "annotations", CreatorAnnotation); ok {
+		values.PutValue(data, user, "annotations", CreatorAnnotation)
+	} else {
+		values.RemoveValue(data, "annotations", CreatorAnnotation)
+	}
+	return nil
+}
+
+// Usage returns the number of objects of schema per key, counting all objects whether or not the requesting user
+// can see them.
+func (s *Store) Usage(apiContext *types.APIContext, schema *types.Schema) (map[string]int, error) {
+	serverContext := withoutUser(apiContext)
+	serverContext.Schema = schema
+	data, err := s.Store.List(serverContext, schema, &types.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := map[string]int{}
+	for _, item := range data {
+		usage[s.opts.Key(serverContext, item)]++
+	}
+	return usage, nil
+}
+
+// visibleKeys returns the keys of the objects of schema the requesting user can list.
+func (s *Store) visibleKeys(apiContext *types.APIContext, schema *types.Schema) (map[string]bool, error) {
+	userContext := *apiContext
+	userContext.Schema = schema
+	data, err := s.Store.List(&userContext, schema, &types.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if apiContext.AccessControl != nil {
+		data = apiContext.AccessControl.FilterList(&userContext, schema, data, nil)
+	}
+
+	keys := map[string]bool{}
+	for _, item := range data {
+		keys[s.opts.Key(&userContext, item)] = true
+	}
+	return keys, nil
+}
+
+// withoutUser returns a copy of apiContext that lists as the server, without the user or impersonation headers of
+// the request and with access to all objects.
+func withoutUser(apiContext *types.APIContext) *types.APIContext {
+	serverContext := *apiContext
+	serverContext.User = nil
+	serverContext.AccessControl = &authorization.AllAccess{}
+	if apiContext.Request != nil {
+		serverContext.Request = apiContext.Request.Clone(apiContext.Request.Context())
+		for header := range serverContext.Request.Header {
+			if strings.HasPrefix(header, "Impersonate-") {
+				serverContext.Request.Header.Del(header)
+			}
+		}
+	}
+	return &serverContext
+}
+
+func (s *Store) checkSize(data map[string]interface{}) error {
+	if s.opts.MaxSize <= 0 {
+		return nil
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if len(content) > s.opts.MaxSize {
+		return httperror.NewAPIError(httperror.QuotaExceeded,
+			fmt.Sprintf("object of %d bytes exceeds the quota of %d bytes", len(content), s.opts.MaxSize))
+	}
+	return nil
+}
+
+func creator(apiContext *types.APIContext) string {
+	if apiContext.User != nil {
+		return apiContext.User.Name
+	}
+	return ""
+}
+
+func describe(key string) string {
+	if key == "" {
+		return "the cluster"
+	}
+	return key
+}
+
+type quotaSchema struct {
+	schema *types.Schema
+	store  *Store
+}
+
+// usageStore lists the usage of the quotas of all wrapped schemas of a version, or of the one in the resourceType
+// query parameter. Only the usage of the keys of objects the user can list is listed, the counts include all objects.
+type usageStore struct {
+	empty.Store
+	lock   sync.Mutex
+	quotas map[string]*quotaSchema
+}
+
+func (u *usageStore) add(schema *types.Schema, store *Store) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.quotas[schema.ID] = &quotaSchema{
+		schema: schema,
+		store:  store,
+	}
+}
+
+func (u *usageStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	resourceType := apiContext.Query.Get("resourceType")
+
+	u.lock.Lock()
+	var quotas []*quotaSchema
+	for id, quota := range u.quotas {
+		if resourceType == "" || resourceType == id {
+			quotas = append(quotas, quota)
+		}
+	}
+	u.lock.Unlock()
+
+	var result []map[string]interface{}
+	for _, quota := range quotas {
+		if err := apiContext.AccessControl.CanList(apiContext, quota.schema); err != nil {
+			continue
+		}
+		visible, err := quota.store.visibleKeys(apiContext, quota.schema)
+		if err != nil {
+			return nil, err
+		}
+		usage, err := quota.store.Usage(apiContext, quota.schema)
+		if err != nil {
+			return nil, err
+		}
+		for key, count := range usage {
+			if !visible[key] {
+				continue
+			}
+			result = append(result, map[string]interface{}{
+				"id":           quota.schema.ID + ":" + key,
+				"type":         UsageType,
+				"resourceType": quota.schema.ID,
+				"key":          key,
+				"count":        count,
+				"maxCount":     quota.store.opts.MaxCount,
+				"maxSize":      quota.store.opts.MaxSize,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return convert.ToString(result[i]["id"]) < convert.ToString(result[j]["id"])
+	})
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package quota
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/norman/authorization"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	empty.Store
+	data map[string]map[string]interface{}
+}
+
+// List lists only the objects created by the user of the request, as access control would.
+func (m *memoryStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, item := range m.data {
+		if apiContext.User != nil && ByCreator(apiContext, item) != apiContext.User.Name {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return m.data[id], nil
+}
+
+func (m *memoryStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	for k, v := range data {
+		m.data[id][k] = v
+	}
+	return m.data[id], nil
+}
+
+func (m *memoryStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	id := data["namespaceId"].(string) + ":" + data["name"].(string)
+	data["id"] = id
+	m.data[id] = data
+	return data, nil
+}
+
+func TestQuota(t *testing.T) {
+	backing := &memoryStore{data: map[string]map[string]interface{}{}}
+	store := &Store{
+		Store: backing,
+		opts: Options{
+			MaxCount: 2,
+			MaxSize:  200,
+			Key:      ByNamespace,
+		},
+	}
+	apiContext := &types.APIContext{
+		User:    &types.User{Name: "alice"},
+		Request: &http.Request{Header: http.Header{"Impersonate-User": []string{"mallory"}}},
+	}
+	bob := &types.APIContext{User: &types.User{Name: "bob"}}
+	schema := &types.Schema{ID: "thing", PluralName: "things"}
+
+	_, err := store.Create(bob, schema, map[string]interface{}{"namespaceId": "one", "name": "a"})
+	require.NoError(t, err)
+	_, err = store.Create(apiContext, schema, map[string]interface{}{
+		"namespaceId": "one",
+		"name":        "b",
+		"annotations": map[string]interface{}{CreatorAnnotation: "mallory"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", ByCreator(apiContext, backing.data["one:b"]))
+
+	_, err = store.Create(apiContext, schema, map[string]interface{}{"namespaceId": "one", "name": "c"})
+	require.Error(t, err)
+	assert.Equal(t, httperror.QuotaExceeded, err.(*httperror.APIError).Code)
+
+	_, err = store.Create(apiContext, schema, map[string]interface{}{"namespaceId": "two", "name": "c"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", ByCreator(apiContext, backing.data["two:c"]))
+
+	usage, err := store.Usage(apiContext, schema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"one": 2, "two": 1}, usage)
+
+	_, err = store.Update(apiContext, schema, map[string]interface{}{
+		"annotations": map[string]interface{}{CreatorAnnotation: "bob"},
+	}, "two:c")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", ByCreator(apiContext, backing.data["two:c"]))
+
+	_, err = store.Update(apiContext, schema, map[string]interface{}{"description": string(make([]byte, 300))}, "two:c")
+	require.Error(t, err)
+	assert.Equal(t, httperror.QuotaExceeded, err.(*httperror.APIError).Code)
+}
+
+func TestUsageListsVisibleKeys(t *testing.T) {
+	backing := &memoryStore{data: map[string]map[string]interface{}{}}
+	schemas := types.NewSchemas()
+	schema := &types.Schema{
+		ID:                "thing",
+		PluralName:        "things",
+		Version:           types.APIVersion{Group: "quota.cattle.io", Version: "v1", Path: "/v1"},
+		CollectionMethods: []string{http.MethodGet},
+	}
+	schema.Store = backing
+	store := Wrap(schemas, schema, Options{MaxCount: 5})
+	usageSchema := schemas.Schema(&schema.Version, UsageType)
+	require.NotNil(t, usageSchema)
+
+	newContext := func(user string) *types.APIContext {
+		return &types.APIContext{
+			User:          &types.User{Name: user},
+			AccessControl: &authorization.AllAccess{},
+			Query:         url.Values{},
+		}
+	}
+	for _, create := range []struct{ user, namespace, name string }{
+		{"bob", "one", "a"},
+		{"bob", "one", "b"},
+		{"alice", "two", "c"},
+	} {
+		_, err := store.Create(newContext(create.user), schema, map[string]interface{}{"namespaceId": create.namespace, "name": create.name})
+		require.NoError(t, err)
+	}
+
+	usage, err := usageSchema.Store.List(newContext("alice"), usageSchema, &types.QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, "two", usage[0]["key"])
+	assert.Equal(t, 1, usage[0]["count"])
+}
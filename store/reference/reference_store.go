@@ -0,0 +1,247 @@
+package reference
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/definition"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultRebuild = 5 * time.Minute
+
+// ListFunc returns every object of the schema, in the format of the store, to build the index. The index is shared
+// by all users, so it must not be limited to the objects a user can access.
+type ListFunc func() ([]map[string]interface{}, error)
+
+// Store indexes the objects of a schema by the IDs of their reference fields. Lists filtered on one referenced ID,
+// like the links of the referenced objects to the objects referencing them, add the objects of the index the
+// wrapped store didn't list yet, like the ones written through the store that a cache behind the wrapped store
+// didn't see yet. The index is only a hint, the wrapped store is always listed. It is kept up to date with the
+// writes through the store and rebuilt with Lister after Rebuild.
+type Store struct {
+	types.Store
+	Lister  ListFunc
+	Fields  []string
+	Rebuild time.Duration
+
+	lock    sync.Mutex
+	built   time.Time
+	index   map[string]map[string]map[string]bool
+	objects map[string]map[string]string
+}
+
+func NewReferenceStore(store types.Store, list ListFunc, rebuild time.Duration, fields ...string) *Store {
+	return &Store{
+		Store:   store,
+		Lister:  list,
+		Fields:  fields,
+		Rebuild: rebuild,
+	}
+}
+
+// Wrap indexes schema by fields, or by all its reference fields if none are given.
+func Wrap(schema *types.Schema, list ListFunc, fields ...string) *Store {
+	if len(fields) == 0 {
+		for name, field := range schema.ResourceFields {
+			if definition.IsReferenceType(field.Type) {
+				fields = append(fields, name)
+			}
+		}
+		sort.Strings(fields)
+	}
+	store := NewReferenceStore(schema.Store, list, DefaultRebuild, fields...)
+	schema.Store = store
+	return store
+}
+
+// Lookup returns the IDs of the objects with field referencing referent.
+func (s *Store) Lookup(field, referent string) ([]string, error) {
+	s.lock.Lock()
+	stale := s.index == nil || (s.Rebuild > 0 && time.Since(s.built) > s.Rebuild)
+	s.lock.Unlock()
+	if stale {
+		if err := s.rebuild(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var ids []string
+	for id := range s.index[field][referent] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// rebuild lists the objects without holding the lock, so that writes and lookups of a built index don't wait for
+// the list.
+func (s *Store) rebuild() error {
+	started := time.Now()
+	objs, err := s.Lister()
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.built.After(started) {
+		return nil
+	}
+	s.index = map[string]map[string]map[string]bool{}
+	s.objects = map[string]map[string]string{}
+	s.built = started
+	for _, obj := range objs {
+		s.add(obj)
+	}
+	return nil
+}
+
+func (s *Store) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	field, referent, ok := s.indexed(opt)
+	if !ok {
+		return s.Store.List(apiContext, schema, opt)
+	}
+
+	// the page is taken from the merged list by the outer store wrapper, so the wrapped store lists every object
+	unpaged := *opt
+	unpaged.Pagination = nil
+	result, err := s.Store.List(apiContext, schema, &unpaged)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.Lookup(field, referent)
+	if err != nil {
+		logrus.Warnf("failed to look up the %s objects with %s %s in the index: %v", schema.ID, field, referent, err)
+		return result, nil
+	}
+
+	listed := map[string]bool{}
+	for _, obj := range result {
+		listed[convert.ToString(obj["id"])] = true
+	}
+	for _, id := range ids {
+		if listed[id] {
+			continue
+		}
+		obj, err := s.Store.ByID(apiContext, schema, id)
+		if httperror.IsNotFound(err) || httperror.IsForbidden(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		// out of date entries of the index are dropped
+		if obj != nil && convert.ToString(obj[field]) == referent && inNamespaces(opt, obj) {
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
+
+func (s *Store) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	obj, err := s.Store.Create(apiContext, schema, data)
+	if !apiContext.DryRun {
+		s.update(obj, err)
+	}
+	return obj, err
+}
+
+func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	obj, err := s.Store.Update(apiContext, schema, data, id)
+	if !apiContext.DryRun {
+		s.update(obj, err)
+	}
+	return obj, err
+}
+
+func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	obj, err := s.Store.Delete(apiContext, schema, id)
+	if err == nil && !apiContext.DryRun {
+		s.lock.Lock()
+		s.remove(id)
+		s.lock.Unlock()
+	}
+	return obj, err
+}
+
+// indexed returns the field and referenced ID of the equality condition on an indexed field of opt.
+func (s *Store) indexed(opt *types.QueryOptions) (string, string, bool) {
+	if opt == nil {
+		return "", "", false
+	}
+	for _, condition := range opt.Conditions {
+		if condition.ToCondition().Modifier != types.ModifierEQ || condition.Value == "" {
+			continue
+		}
+		for _, field := range s.Fields {
+			if condition.Field == field {
+				return field, condition.Value, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func (s *Store) update(obj map[string]interface{}, err error) {
+	if err != nil || obj == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.index != nil {
+		s.remove(convert.ToString(obj["id"]))
+		s.add(obj)
+	}
+}
+
+func (s *Store) add(obj map[string]interface{}) {
+	id := convert.ToString(obj["id"])
+	if id == "" {
+		return
+	}
+	referents := map[string]string{}
+	for _, field := range s.Fields {
+		referent := convert.ToString(obj[field])
+		if referent == "" {
+			continue
+		}
+		if s.index[field] == nil {
+			s.index[field] = map[string]map[string]bool{}
+		}
+		if s.index[field][referent] == nil {
+			s.index[field][referent] = map[string]bool{}
+		}
+		s.index[field][referent][id] = true
+		referents[field] = referent
+	}
+	s.objects[id] = referents
+}
+
+func (s *Store) remove(id string) {
+	for field, referent := range s.objects[id] {
+		delete(s.index[field][referent], id)
+		if len(s.index[field][referent]) == 0 {
+			delete(s.index[field], referent)
+		}
+	}
+	delete(s.objects, id)
+}
+
+func inNamespaces(opt *types.QueryOptions, obj map[string]interface{}) bool {
+	if opt.Namespaces == nil {
+		return true
+	}
+	for _, namespace := range opt.Namespaces {
+		if namespace == convert.ToString(obj["namespaceId"]) {
+			return true
+		}
+	}
+	return false
+}
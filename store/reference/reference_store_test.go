@@ -0,0 +1,119 @@
+package reference
+
+import (
+	"testing"
+
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore lists from a cache that doesn't see the objects in unsynced yet.
+type memoryStore struct {
+	empty.Store
+	data     map[string]map[string]interface{}
+	unsynced map[string]bool
+	lists    int
+}
+
+func (m *memoryStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return m.data[id], nil
+}
+
+func (m *memoryStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	m.lists++
+	var result []map[string]interface{}
+	for id, item := range m.data {
+		if !m.unsynced[id] {
+			result = append(result, item)
+		}
+	}
+	return apiContext.FilterList(opt, schema, result), nil
+}
+
+func (m *memoryStore) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	id := data["id"].(string)
+	m.data[id] = data
+	m.unsynced[id] = true
+	return data, nil
+}
+
+func (m *memoryStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	for k, v := range data {
+		m.data[id][k] = v
+	}
+	return m.data[id], nil
+}
+
+func TestReferenceStore(t *testing.T) {
+	backing := &memoryStore{
+		data: map[string]map[string]interface{}{
+			"a": {"id": "a", "clusterId": "c1"},
+			"b": {"id": "b", "clusterId": "c2"},
+			"c": {"id": "c", "clusterId": "c1"},
+		},
+		unsynced: map[string]bool{},
+	}
+	apiContext := &types.APIContext{
+		QueryFilter: func(opts *types.QueryOptions, schema *types.Schema, data []map[string]interface{}) []map[string]interface{} {
+			var result []map[string]interface{}
+			for _, obj := range data {
+				if opts.Conditions[0].Valid(schema, obj) {
+					result = append(result, obj)
+				}
+			}
+			return result
+		},
+	}
+	schema := &types.Schema{
+		ID:             "node",
+		Store:          backing,
+		ResourceFields: map[string]types.Field{"clusterId": {Type: "reference[cluster]"}},
+	}
+	lists := 0
+	store := Wrap(schema, func() ([]map[string]interface{}, error) {
+		lists++
+		return []map[string]interface{}{backing.data["a"], backing.data["b"], backing.data["c"]}, nil
+	})
+	require.Equal(t, []string{"clusterId"}, store.Fields)
+
+	opt := &types.QueryOptions{Conditions: []*types.QueryCondition{types.EQ("clusterId", "c1")}}
+	list, err := store.List(apiContext, schema, opt)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []map[string]interface{}{backing.data["a"], backing.data["c"]}, list)
+
+	_, err = store.Update(apiContext, schema, map[string]interface{}{"clusterId": "c2"}, "a")
+	require.NoError(t, err)
+	ids, err := store.Lookup("clusterId", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids)
+	ids, err = store.Lookup("clusterId", "c2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	// dry runs don't change the index
+	dryRun := &types.APIContext{DryRun: true}
+	_, err = store.Delete(dryRun, schema, "b")
+	require.NoError(t, err)
+	ids, err = store.Lookup("clusterId", "c2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	// an out of date entry is dropped
+	backing.data["c"]["clusterId"] = "c3"
+	list, err = store.List(apiContext, schema, opt)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	// objects created through the store are listed before the wrapped store sees them, objects created by other
+	// clients are listed before the index sees them
+	_, err = store.Create(apiContext, schema, map[string]interface{}{"id": "d", "clusterId": "c1"})
+	require.NoError(t, err)
+	backing.data["e"] = map[string]interface{}{"id": "e", "clusterId": "c1"}
+	list, err = store.List(apiContext, schema, opt)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []map[string]interface{}{backing.data["d"], backing.data["e"]}, list)
+	assert.Equal(t, 1, lists)
+	assert.Equal(t, 3, backing.lists)
+}
@@ -0,0 +1,278 @@
+package shadow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/norman/types/values"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTimeout bounds the calls to the shadow store if Options.Timeout isn't set.
+	DefaultTimeout = 10 * time.Second
+	// maxInFlight is the number of calls to the shadow store running at once above which reads aren't mirrored.
+	maxInFlight = 32
+)
+
+// ignoredFields are the paths of the fields that differ between stores serving the same objects.
+var ignoredFields = [][]string{
+	{"links"},
+	{"actions"},
+	{"actionLinks"},
+}
+
+type Options struct {
+	// Percent is the percentage of the reads mirrored to the shadow store, from 0 to 100
+	Percent float64
+	// IgnoreFields are the paths of other fields left out of the comparisons
+	IgnoreFields [][]string
+	// OnDiff is called with the paths of the fields that differ in the responses of the stores, they are logged if
+	// not set
+	OnDiff func(schemaID, op string, diffs []string)
+	// Timeout bounds the calls to the shadow store, defaults to DefaultTimeout
+	Timeout time.Duration
+}
+
+// Store mirrors a percentage of the ByID and List calls of its store to a shadow store and compares the responses,
+// to try a new store implementation on real traffic. The responses of the shadow store are never returned, and the
+// shadow store is called in the background once the store responded, with a context that isn't canceled with the
+// request but times out after Timeout, so a slow shadow store doesn't slow down reads. Reads aren't mirrored while
+// too many calls to the shadow store are running. Writes and watches aren't mirrored.
+type Store struct {
+	types.Store
+	shadow types.Store
+	opts   Options
+
+	inFlight chan struct{}
+	// running tracks the calls to the shadow store, for tests to wait for them
+	running sync.WaitGroup
+}
+
+// Wrap mirrors the reads of the store of schema to shadow.
+func Wrap(schema *types.Schema, shadow types.Store, opts Options) *Store {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	store := &Store{
+		Store:    schema.Store,
+		shadow:   shadow,
+		opts:     opts,
+		inFlight: make(chan struct{}, maxInFlight),
+	}
+	schema.Store = store
+	return store
+}
+
+func (s *Store) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	result, err := s.Store.ByID(apiContext, schema, id)
+	if s.mirror() {
+		// the result is compared once the shadow store responded, after it was returned and maybe changed
+		expected := s.normalize(result)
+		s.background(apiContext, func(shadowContext *types.APIContext) {
+			shadowResult, shadowErr := s.shadow.ByID(shadowContext, schema, id)
+			diffs := diffErrors(err, shadowErr)
+			if err == nil && shadowErr == nil {
+				diffValues(id, expected, s.normalize(shadowResult), &diffs)
+			}
+			s.report(schema, "byID "+id, diffs)
+		})
+	}
+	return result, err
+}
+
+func (s *Store) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	// stores set the pagination of opt, the shadow store gets a copy so that it can't change the response
+	mirror := s.mirror()
+	var shadowOpt *types.QueryOptions
+	if mirror {
+		shadowOpt = copyOptions(opt)
+	}
+	result, err := s.Store.List(apiContext, schema, opt)
+	if mirror {
+		expected := make([]map[string]interface{}, 0, len(result))
+		for _, obj := range result {
+			if normalized, ok := s.normalize(obj).(map[string]interface{}); ok {
+				expected = append(expected, normalized)
+			}
+		}
+		s.background(apiContext, func(shadowContext *types.APIContext) {
+			shadowResult, shadowErr := s.shadow.List(shadowContext, schema, shadowOpt)
+			diffs := diffErrors(err, shadowErr)
+			if err == nil && shadowErr == nil {
+				diffs = s.diffList(expected, shadowResult)
+			}
+			s.report(schema, "list", diffs)
+		})
+	}
+	return result, err
+}
+
+// background calls f in a goroutine with a copy of apiContext whose request context is detached from the request
+// and times out after Timeout. f isn't called if too many calls are running.
+func (s *Store) background(apiContext *types.APIContext, f func(shadowContext *types.APIContext)) {
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		logrus.Debugf("shadow store is busy, not mirroring the read")
+		return
+	}
+
+	parent := context.Background()
+	var shadowContext types.APIContext
+	if apiContext != nil {
+		shadowContext = *apiContext
+		if apiContext.Request != nil {
+			parent = context.WithoutCancel(apiContext.Request.Context())
+		}
+	}
+	ctx, cancel := context.WithTimeout(parent, s.opts.Timeout)
+	if apiContext != nil && apiContext.Request != nil {
+		shadowContext.Request = apiContext.Request.WithContext(ctx)
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer func() { <-s.inFlight }()
+		defer cancel()
+		f(&shadowContext)
+	}()
+}
+
+func copyOptions(opt *types.QueryOptions) *types.QueryOptions {
+	if opt == nil {
+		return nil
+	}
+	copied := *opt
+	if opt.Pagination != nil {
+		pagination := *opt.Pagination
+		copied.Pagination = &pagination
+	}
+	copied.Conditions = append([]*types.QueryCondition(nil), opt.Conditions...)
+	if opt.Options != nil {
+		copied.Options = make(map[string]string, len(opt.Options))
+		for key, value := range opt.Options {
+			copied.Options[key] = value
+		}
+	}
+	if opt.Namespaces != nil {
+		copied.Namespaces = append([]string{}, opt.Namespaces...)
+	}
+	return &copied
+}
+
+func (s *Store) mirror() bool {
+	return s.opts.Percent > 0 && rand.Float64()*100 < s.opts.Percent
+}
+
+func (s *Store) report(schema *types.Schema, op string, diffs []string) {
+	if len(diffs) == 0 {
+		return
+	}
+	if s.opts.OnDiff != nil {
+		s.opts.OnDiff(schema.ID, op, diffs)
+		return
+	}
+	logrus.Warnf("shadow store of %s differs on %s: %s", schema.ID, op, strings.Join(diffs, "; "))
+}
+
+func diffErrors(err, shadowErr error) []string {
+	switch {
+	case err != nil && shadowErr == nil:
+		return []string{fmt.Sprintf("store failed with %v, shadow succeeded", err)}
+	case err == nil && shadowErr != nil:
+		return []string{fmt.Sprintf("shadow failed with %v, store succeeded", shadowErr)}
+	}
+	return nil
+}
+
+// diffList compares the objects of the stores by ID, regardless of their order.
+func (s *Store) diffList(objs, shadowObjs []map[string]interface{}) []string {
+	byID := map[string]map[string]interface{}{}
+	for _, obj := range shadowObjs {
+		byID[convert.ToString(obj["id"])] = obj
+	}
+
+	var diffs []string
+	for _, obj := range objs {
+		id := convert.ToString(obj["id"])
+		shadowObj, ok := byID[id]
+		if !ok {
+			diffs = append(diffs, id+": missing from shadow")
+			continue
+		}
+		delete(byID, id)
+		diffs = append(diffs, s.diff(id, obj, shadowObj)...)
+	}
+
+	var extra []string
+	for id := range byID {
+		extra = append(extra, id+": only in shadow")
+	}
+	sort.Strings(extra)
+	return append(diffs, extra...)
+}
+
+func (s *Store) diff(id string, obj, shadowObj map[string]interface{}) []string {
+	var diffs []string
+	diffValues(id, s.normalize(obj), s.normalize(shadowObj), &diffs)
+	return diffs
+}
+
+// normalize returns a JSON copy of obj without the ignored fields, so that numbers compare equal whatever their
+// type in each store.
+func (s *Store) normalize(obj map[string]interface{}) interface{} {
+	content, err := json.Marshal(obj)
+	if err != nil {
+		return obj
+	}
+	var copied map[string]interface{}
+	if err := json.Unmarshal(content, &copied); err != nil {
+		return obj
+	}
+	for _, field := range ignoredFields {
+		values.RemoveValue(copied, field...)
+	}
+	for _, field := range s.opts.IgnoreFields {
+		values.RemoveValue(copied, field...)
+	}
+	return copied
+}
+
+// diffValues records the paths of the fields that differ, not their values which may be secret.
+func diffValues(path string, value, shadowValue interface{}, diffs *[]string) {
+	valueMap, ok := value.(map[string]interface{})
+	shadowMap, shadowOk := shadowValue.(map[string]interface{})
+	if !ok || !shadowOk {
+		if !reflect.DeepEqual(value, shadowValue) {
+			*diffs = append(*diffs, path)
+		}
+		return
+	}
+
+	keys := map[string]bool{}
+	for key := range valueMap {
+		keys[key] = true
+	}
+	for key := range shadowMap {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		diffValues(path+"."+key, valueMap[key], shadowMap[key], diffs)
+	}
+}
@@ -0,0 +1,130 @@
+package shadow
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStore struct {
+	empty.Store
+	objs    []map[string]interface{}
+	err     error
+	partial bool
+}
+
+func (t *testStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	for _, obj := range t.objs {
+		if obj["id"] == id {
+			return obj, t.err
+		}
+	}
+	return nil, t.err
+}
+
+func (t *testStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	if opt != nil && opt.Pagination != nil {
+		opt.Pagination.Partial = t.partial
+	}
+	return t.objs, t.err
+}
+
+func TestShadowStore(t *testing.T) {
+	var lock sync.Mutex
+	reported := map[string][]string{}
+	schema := &types.Schema{
+		ID: "cluster",
+		Store: &testStore{objs: []map[string]interface{}{
+			{"id": "c-1", "name": "one", "replicas": 3, "links": map[string]interface{}{"self": "a"}},
+			{"id": "c-2", "name": "two", "spec": map[string]interface{}{"size": 1}},
+		}},
+	}
+	shadow := &testStore{objs: []map[string]interface{}{
+		{"id": "c-3", "name": "three"},
+		{"id": "c-1", "name": "one", "replicas": float64(3), "links": map[string]interface{}{"self": "b"}},
+		{"id": "c-2", "name": "two", "spec": map[string]interface{}{"size": 2}},
+	}}
+	store := Wrap(schema, shadow, Options{
+		Percent: 100,
+		OnDiff: func(schemaID, op string, diffs []string) {
+			lock.Lock()
+			defer lock.Unlock()
+			reported[op] = diffs
+		},
+	})
+
+	result, err := schema.Store.ByID(nil, schema, "c-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", result["name"])
+	_, err = schema.Store.ByID(nil, schema, "c-2")
+	assert.NoError(t, err)
+	opt := &types.QueryOptions{Pagination: &types.Pagination{}}
+	shadow.partial = true
+	objs, err := schema.Store.List(nil, schema, opt)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 2)
+	assert.False(t, opt.Pagination.Partial)
+	// the shadow store compares to the responses as returned
+	objs[1]["spec"] = nil
+
+	store.running.Wait()
+	assert.Equal(t, map[string][]string{
+		"byID c-2": {"c-2.spec.size"},
+		"list":     {"c-2.spec.size", "c-3: only in shadow"},
+	}, reported)
+
+	shadow.err = errors.New("not implemented")
+	_, err = schema.Store.ByID(nil, schema, "c-1")
+	assert.NoError(t, err)
+	store.running.Wait()
+	assert.Equal(t, []string{"shadow failed with not implemented, store succeeded"}, reported["byID c-1"])
+}
+
+// hangingStore only responds once the context of the request is done.
+type hangingStore struct {
+	empty.Store
+}
+
+func (h *hangingStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	<-apiContext.Request.Context().Done()
+	return nil, apiContext.Request.Context().Err()
+}
+
+func TestShadowStoreDoesNotWait(t *testing.T) {
+	reported := make(chan []string, 1)
+	schema := &types.Schema{
+		ID:    "cluster",
+		Store: &testStore{objs: []map[string]interface{}{{"id": "c-1"}}},
+	}
+	store := Wrap(schema, &hangingStore{}, Options{
+		Percent: 100,
+		Timeout: 50 * time.Millisecond,
+		OnDiff: func(schemaID, op string, diffs []string) {
+			reported <- diffs
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	apiContext := &types.APIContext{Request: httptest.NewRequest("GET", "/", nil).WithContext(ctx)}
+	result, err := schema.Store.ByID(apiContext, schema, "c-1")
+	require.NoError(t, err)
+	assert.Equal(t, "c-1", result["id"])
+	// the end of the request doesn't cancel the shadow call, the timeout does
+	cancel()
+
+	select {
+	case diffs := <-reported:
+		assert.Equal(t, []string{"shadow failed with " + context.DeadlineExceeded.Error() + ", store succeeded"}, diffs)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow call didn't time out")
+	}
+	store.running.Wait()
+}
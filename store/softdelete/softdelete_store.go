@@ -0,0 +1,224 @@
+package softdelete
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DeletedAnnotation = "norman.rancher.io/deleted-at"
+	RestoreAction     = "restore"
+	// IncludeDeletedQuery shows deleted objects in lists and watches when set to true.
+	IncludeDeletedQuery = "includeDeleted"
+)
+
+// Store turns deletes into soft deletes. A deleted object is annotated with the time it was deleted and hidden
+// until it is restored, deleted again, or purged after TTL.
+type Store struct {
+	types.Store
+	TTL time.Duration
+}
+
+func NewSoftDeleteStore(store types.Store, ttl time.Duration) *Store {
+	return &Store{
+		Store: store,
+		TTL:   ttl,
+	}
+}
+
+// Wrap enables soft deletes on schema, adding the restore action to deleted objects.
+func Wrap(schema *types.Schema, ttl time.Duration) *Store {
+	store := NewSoftDeleteStore(schema.Store, ttl)
+	schema.Store = store
+
+	if schema.ResourceActions == nil {
+		schema.ResourceActions = map[string]types.Action{}
+	}
+	schema.ResourceActions[RestoreAction] = types.Action{
+		Output: schema.ID,
+	}
+
+	formatter := schema.Formatter
+	schema.Formatter = func(apiContext *types.APIContext, resource *types.RawResource) {
+		if formatter != nil {
+			formatter(apiContext, resource)
+		}
+		if isDeleted(resource.Values) {
+			resource.AddAction(apiContext, RestoreAction)
+		}
+	}
+
+	actionHandler := schema.ActionHandler
+	schema.ActionHandler = func(actionName string, action *types.Action, apiContext *types.APIContext) error {
+		if actionName != RestoreAction {
+			if actionHandler == nil {
+				return httperror.NewAPIError(httperror.NotFound, "action not found")
+			}
+			return actionHandler(actionName, action, apiContext)
+		}
+
+		data, err := store.Restore(apiContext, apiContext.Schema, apiContext.ID)
+		if err != nil {
+			return err
+		}
+		apiContext.WriteResponse(http.StatusOK, data)
+		return nil
+	}
+
+	return store
+}
+
+func (s *Store) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	data, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if isDeleted(data) && !includeDeleted(apiContext) && apiContext.Action != RestoreAction {
+		return nil, httperror.NewAPIError(httperror.NotFound, "not found")
+	}
+	return data, nil
+}
+
+func (s *Store) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	data, err := s.Store.List(apiContext, schema, opt)
+	if err != nil || includeDeleted(apiContext) {
+		return data, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(data))
+	for _, item := range data {
+		if !isDeleted(item) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (s *Store) Watch(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) (chan map[string]interface{}, error) {
+	c, err := s.Store.Watch(apiContext, schema, opt)
+	if err != nil || includeDeleted(apiContext) {
+		return c, err
+	}
+
+	return convert.Chan(c, func(data map[string]interface{}) map[string]interface{} {
+		if isDeleted(data) {
+			// deleting an object removes it from the view of the subscriber
+			data[".removed"] = true
+		}
+		return data
+	}), nil
+}
+
+// Update rejects changes to deleted objects, they must be restored first. Restore and Delete update the deleted mark
+// through the wrapped store.
+func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	existing, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if isDeleted(existing) {
+		return nil, httperror.NewAPIError(httperror.InvalidState, schema.ID+" "+id+" is deleted, restore it first")
+	}
+	return s.Store.Update(apiContext, schema, data, id)
+}
+
+// Delete marks the object deleted. Deleting an object that is already deleted removes it.
+func (s *Store) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	data, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if isDeleted(data) {
+		return s.Store.Delete(apiContext, schema, id)
+	}
+
+	annotations := copyAnnotations(data)
+	annotations[DeletedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	return s.Store.Update(apiContext, schema, map[string]interface{}{
+		"annotations": annotations,
+	}, id)
+}
+
+// Restore clears the deleted mark of an object.
+func (s *Store) Restore(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	data, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isDeleted(data) {
+		return data, nil
+	}
+
+	annotations := copyAnnotations(data)
+	delete(annotations, DeletedAnnotation)
+	return s.Store.Update(apiContext, schema, map[string]interface{}{
+		"annotations": annotations,
+	}, id)
+}
+
+// Purge removes the objects of schema that were deleted more than TTL ago, every interval, until ctx is done.
+func (s *Store) Purge(ctx context.Context, schemas *types.Schemas, schema *types.Schema, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.purge(ctx, schemas, schema); err != nil {
+				logrus.Errorf("failed to purge deleted %s: %v", schema.ID, err)
+			}
+		}
+	}
+}
+
+func (s *Store) purge(ctx context.Context, schemas *types.Schemas, schema *types.Schema) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return err
+	}
+	apiContext := types.NewAPIContext(req, nil, schemas)
+
+	data, err := s.Store.List(apiContext, schema, &types.QueryOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range data {
+		deletedAt, err := time.Parse(time.RFC3339, deletedValue(item))
+		if err != nil || time.Since(deletedAt) < s.TTL {
+			continue
+		}
+		if _, err := s.Store.Delete(apiContext, schema, convert.ToString(item["id"])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func includeDeleted(apiContext *types.APIContext) bool {
+	return apiContext.Query.Get(IncludeDeletedQuery) == "true"
+}
+
+func deletedValue(data map[string]interface{}) string {
+	return convert.ToString(convert.ToMapInterface(data["annotations"])[DeletedAnnotation])
+}
+
+func isDeleted(data map[string]interface{}) bool {
+	return deletedValue(data) != ""
+}
+
+func copyAnnotations(data map[string]interface{}) map[string]interface{} {
+	annotations := map[string]interface{}{}
+	for k, v := range convert.ToMapInterface(data["annotations"]) {
+		annotations[k] = v
+	}
+	return annotations
+}
@@ -0,0 +1,90 @@
+package softdelete
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/store/empty"
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	empty.Store
+	data map[string]map[string]interface{}
+}
+
+func (m *memoryStore) ByID(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	return m.data[id], nil
+}
+
+func (m *memoryStore) List(apiContext *types.APIContext, schema *types.Schema, opt *types.QueryOptions) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, item := range m.data {
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	for k, v := range data {
+		m.data[id][k] = v
+	}
+	return m.data[id], nil
+}
+
+func (m *memoryStore) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	obj := m.data[id]
+	delete(m.data, id)
+	return obj, nil
+}
+
+func TestSoftDelete(t *testing.T) {
+	backing := &memoryStore{
+		data: map[string]map[string]interface{}{
+			"a": {"id": "a", "annotations": map[string]interface{}{"keep": "true"}},
+		},
+	}
+	store := NewSoftDeleteStore(backing, time.Hour)
+	apiContext := &types.APIContext{}
+	schema := &types.Schema{ID: "thing"}
+
+	_, err := store.Delete(apiContext, schema, "a")
+	require.NoError(t, err)
+	require.Contains(t, backing.data, "a")
+	assert.Equal(t, "true", backing.data["a"]["annotations"].(map[string]interface{})["keep"])
+
+	list, err := store.List(apiContext, schema, &types.QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = store.ByID(apiContext, schema, "a")
+	assert.Error(t, err)
+
+	apiContext.Query = url.Values{IncludeDeletedQuery: []string{"true"}}
+	list, err = store.List(apiContext, schema, &types.QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	_, err = store.Update(apiContext, schema, map[string]interface{}{"name": "changed"}, "a")
+	assert.True(t, httperror.IsAPIError(err) && err.(*httperror.APIError).Code == httperror.InvalidState, "%v", err)
+	assert.NotContains(t, backing.data["a"], "name")
+
+	_, err = store.Restore(apiContext, schema, "a")
+	require.NoError(t, err)
+	_, err = store.Update(apiContext, schema, map[string]interface{}{"name": "changed"}, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "changed", backing.data["a"]["name"])
+	apiContext.Query = nil
+	obj, err := store.ByID(apiContext, schema, "a")
+	require.NoError(t, err)
+	assert.False(t, isDeleted(obj))
+
+	_, err = store.Delete(apiContext, schema, "a")
+	require.NoError(t, err)
+	_, err = store.Delete(apiContext, schema, "a")
+	require.NoError(t, err)
+	assert.NotContains(t, backing.data, "a")
+}
@@ -0,0 +1,82 @@
+package types
+
+import "sync"
+
+// ContextKey is the key of a value of type T attached to an APIContext, so that middlewares can pass values like the
+// tenant or feature flags of a request to stores and formatters without string keys. Keys are compared by identity,
+// they are created once with NewContextKey and shared as package variables.
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey returns a new key, name is only used to describe it.
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+func (k *ContextKey[T]) String() string {
+	return k.name
+}
+
+// Set attaches value to apiContext, replacing the previous value of the key.
+func (k *ContextKey[T]) Set(apiContext *APIContext, value T) {
+	apiContext.contextValues().set(k, value)
+}
+
+// Get returns the value of the key attached to apiContext, and whether it was set.
+func (k *ContextKey[T]) Get(apiContext *APIContext) (T, bool) {
+	var zero T
+	if apiContext == nil || apiContext.values == nil {
+		return zero, false
+	}
+	value, ok := apiContext.values.get(k)
+	if !ok {
+		return zero, false
+	}
+	return value.(T), true
+}
+
+// Value returns the value of the key attached to apiContext, the zero value of T if it wasn't set.
+func (k *ContextKey[T]) Value(apiContext *APIContext) T {
+	value, _ := k.Get(apiContext)
+	return value
+}
+
+// Delete removes the value of the key from apiContext.
+func (k *ContextKey[T]) Delete(apiContext *APIContext) {
+	if apiContext.values != nil {
+		apiContext.values.delete(k)
+	}
+}
+
+// contextValues are the values attached to an APIContext, shared by its copies.
+type contextValues struct {
+	lock   sync.RWMutex
+	values map[any]any
+}
+
+func (r *APIContext) contextValues() *contextValues {
+	if r.values == nil {
+		r.values = &contextValues{values: map[any]any{}}
+	}
+	return r.values
+}
+
+func (c *contextValues) set(key, value any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values[key] = value
+}
+
+func (c *contextValues) get(key any) (any, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	value, ok := c.values[key]
+	return value, ok
+}
+
+func (c *contextValues) delete(key any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.values, key)
+}
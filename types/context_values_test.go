@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextKey(t *testing.T) {
+	tenant := NewContextKey[string]("tenant")
+	flags := NewContextKey[map[string]bool]("flags")
+	other := NewContextKey[string]("tenant")
+
+	apiContext := &APIContext{}
+	_, ok := tenant.Get(apiContext)
+	assert.False(t, ok)
+	assert.Equal(t, "", tenant.Value(nil))
+
+	tenant.Set(apiContext, "acme")
+	flags.Set(apiContext, map[string]bool{"beta": true})
+	value, ok := tenant.Get(apiContext)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", value)
+	assert.True(t, flags.Value(apiContext)["beta"])
+	_, ok = other.Get(apiContext)
+	assert.False(t, ok, "keys with the same name are different keys")
+
+	copied := *apiContext
+	assert.Equal(t, "acme", tenant.Value(&copied))
+
+	tenant.Delete(apiContext)
+	_, ok = tenant.Get(&copied)
+	assert.False(t, ok)
+	assert.Equal(t, "tenant", tenant.String())
+}
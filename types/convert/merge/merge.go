@@ -82,6 +82,17 @@ func merge(field, fieldType string, parentSchema, schema *types.Schema, schemas
 		return src
 	}
 
+	sl, slOk := src.([]interface{})
+	dl, dlOk := dest.([]interface{})
+	if slOk && schema != nil {
+		if f := schema.ResourceFields[field]; f.MergeStrategy == types.MergeStrategyMerge {
+			if dlOk && !replace {
+				return mergeList(f, schema, schemas, dl, sl)
+			}
+			return withoutDeletes(sl)
+		}
+	}
+
 	sm, smOk := src.(map[string]interface{})
 	dm, dmOk := dest.(map[string]interface{})
 	if smOk && dmOk {
@@ -91,6 +102,73 @@ func merge(field, fieldType string, parentSchema, schema *types.Schema, schemas
 	return src
 }
 
+// mergeList merges the elements of src into the elements of dest with the same key, and appends the others.
+// Elements of src with a delete PatchDirective remove the element of dest with their key.
+func mergeList(field types.Field, schema *types.Schema, schemas *types.Schemas, dest, src []interface{}) []interface{} {
+	subType := definition.SubType(field.Type)
+	elementSchema := schemas.Schema(&schema.Version, subType)
+	if elementSchema != nil && elementSchema.InternalSchema != nil {
+		elementSchema = elementSchema.InternalSchema
+	}
+
+	result := append([]interface{}{}, dest...)
+	index := map[string]int{}
+	for i, value := range dest {
+		if key := convert.ToString(convert.ToMapInterface(value)[field.MergeKey]); key != "" {
+			index[key] = i
+		}
+	}
+
+	deleted := map[int]bool{}
+	for _, value := range src {
+		sm, ok := value.(map[string]interface{})
+		key := convert.ToString(sm[field.MergeKey])
+		i, exists := index[key]
+		if ok && sm[types.PatchDirective] == types.PatchDelete {
+			if exists {
+				deleted[i] = true
+				delete(index, key)
+			}
+			continue
+		}
+		if !ok || key == "" || !exists {
+			result = append(result, value)
+			if ok && key != "" {
+				index[key] = len(result) - 1
+			}
+			continue
+		}
+		if dm, ok := result[i].(map[string]interface{}); ok {
+			result[i] = mergeMaps(subType, schema, elementSchema, schemas, false, dm, sm)
+		} else {
+			result[i] = value
+		}
+	}
+
+	if len(deleted) == 0 {
+		return result
+	}
+	kept := make([]interface{}, 0, len(result)-len(deleted))
+	for i, value := range result {
+		if !deleted[i] {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// withoutDeletes returns the elements of list without a delete PatchDirective, which replace it as a whole.
+func withoutDeletes(list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, value := range list {
+		if m, ok := value.(map[string]interface{}); ok && m[types.PatchDirective] == types.PatchDelete {
+			continue
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
 func getSchema(field, parentFieldType string, parentSchema, schema *types.Schema, schemas *types.Schemas) (string, *types.Schema) {
 	if schema == nil {
 		if definition.IsMapType(parentFieldType) && parentSchema != nil {
@@ -0,0 +1,78 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type EnvVar struct {
+	Name      string `json:"name,omitempty"`
+	Value     string `json:"value,omitempty"`
+	ValueFrom string `json:"valueFrom,omitempty"`
+}
+
+type Container struct {
+	Env  []EnvVar `json:"env,omitempty" norman:"mergeKey=name"`
+	Args []string `json:"args,omitempty"`
+}
+
+func TestUpdateMergeByKey(t *testing.T) {
+	version := types.APIVersion{Version: "v1", Group: "example.cattle.io", Path: "/v1"}
+	schemas := types.NewSchemas().MustImport(&version, Container{})
+	schema := schemas.Schema(&version, "container")
+
+	dest := map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": "A", "value": "1"},
+			map[string]interface{}{"name": "B", "valueFrom": "secret"},
+		},
+		"args": []interface{}{"a", "b"},
+	}
+	src := map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": "B", "value": "2"},
+			map[string]interface{}{"name": "C", "value": "3"},
+		},
+		"args": []interface{}{"c"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": "A", "value": "1"},
+			map[string]interface{}{"name": "B", "value": "2", "valueFrom": "secret"},
+			map[string]interface{}{"name": "C", "value": "3"},
+		},
+		"args": []interface{}{"c"},
+	}, UpdateMerge(schema, schemas, dest, src, false))
+
+	assert.Equal(t, src, UpdateMerge(schema, schemas, dest, src, true))
+}
+
+func TestUpdateMergeByKeyDelete(t *testing.T) {
+	version := types.APIVersion{Version: "v1", Group: "example.cattle.io", Path: "/v1"}
+	schemas := types.NewSchemas().MustImport(&version, Container{})
+	schema := schemas.Schema(&version, "container")
+
+	dest := map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": "A", "value": "1"},
+			map[string]interface{}{"name": "B", "value": "2"},
+		},
+	}
+	src := map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": "A", types.PatchDirective: types.PatchDelete},
+			map[string]interface{}{"name": "C", types.PatchDirective: types.PatchDelete},
+		},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": "B", "value": "2"},
+		},
+	}, UpdateMerge(schema, schemas, dest, src, false))
+
+	assert.Equal(t, map[string]interface{}{"env": []interface{}{}}, UpdateMerge(schema, schemas, dest, src, true))
+}
@@ -1,10 +1,37 @@
 package schemaconvert
 
 import (
+	"reflect"
+	"sync"
+
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/types/convert"
 )
 
+type conversionKey struct {
+	from, target reflect.Type
+}
+
+var (
+	conversionsLock sync.RWMutex
+	conversions     = map[conversionKey]func(from, target interface{}){}
+)
+
+// RegisterConversion makes ToInternal convert values of the type of from to the type of target with f instead of
+// mapping them through a JSON round trip. The generator registers the compiled conversions of the client types whose
+// schemas have no mappers and whose fields all map one to one to the backing type.
+func RegisterConversion(from, target interface{}, f func(from, target interface{})) {
+	conversionsLock.Lock()
+	defer conversionsLock.Unlock()
+	conversions[conversionKey{from: reflect.TypeOf(from), target: reflect.TypeOf(target)}] = f
+}
+
+func getConversion(from, target interface{}) func(from, target interface{}) {
+	conversionsLock.RLock()
+	defer conversionsLock.RUnlock()
+	return conversions[conversionKey{from: reflect.TypeOf(from), target: reflect.TypeOf(target)}]
+}
+
 func InternalToInternal(from interface{}, fromSchema *types.Schema, toSchema *types.Schema, target interface{}) error {
 	data, err := convert.EncodeToMap(from)
 	if err != nil {
@@ -18,6 +45,11 @@ func InternalToInternal(from interface{}, fromSchema *types.Schema, toSchema *ty
 }
 
 func ToInternal(from interface{}, schema *types.Schema, target interface{}) error {
+	if f := getConversion(from, target); f != nil {
+		f(from, target)
+		return nil
+	}
+
 	data, err := convert.EncodeToMap(from)
 	if err != nil {
 		return err
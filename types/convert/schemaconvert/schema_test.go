@@ -0,0 +1,54 @@
+package schemaconvert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rancher/norman/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type client struct {
+	Size int64 `json:"size"`
+}
+
+type backing struct {
+	Size int32 `json:"size"`
+}
+
+type mapperFunc func(data map[string]interface{})
+
+func (m mapperFunc) FromInternal(data map[string]interface{}) {}
+
+func (m mapperFunc) ToInternal(data map[string]interface{}) error {
+	m(data)
+	return nil
+}
+
+func (m mapperFunc) ModifySchema(schema *types.Schema, schemas *types.Schemas) error {
+	return nil
+}
+
+func TestToInternalRegisteredConversion(t *testing.T) {
+	schema := &types.Schema{Mapper: mapperFunc(func(data map[string]interface{}) {
+		data["size"] = 2
+	})}
+
+	out := &backing{}
+	require.NoError(t, ToInternal(&client{Size: 1}, schema, out))
+	assert.Equal(t, int32(2), out.Size, "without a registered conversion the mapper runs")
+
+	RegisterConversion(&client{}, &backing{}, func(from, target interface{}) {
+		target.(*backing).Size = int32(from.(*client).Size)
+	})
+	defer func() {
+		conversionsLock.Lock()
+		defer conversionsLock.Unlock()
+		delete(conversions, conversionKey{from: reflect.TypeOf(&client{}), target: reflect.TypeOf(&backing{})})
+	}()
+
+	out = &backing{}
+	require.NoError(t, ToInternal(&client{Size: 1}, schema, out))
+	assert.Equal(t, int32(1), out.Size)
+}
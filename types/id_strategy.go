@@ -0,0 +1,107 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// IDStrategy formats the IDs of the objects of a schema and parses them back to the namespace and name of the
+// object. Schemas without one use NamespacedIDs.
+type IDStrategy interface {
+	// FormatID returns the ID of the object named name in namespace, "" if cluster scoped, with uid.
+	FormatID(namespace, name, uid string) string
+	// ParseID returns the namespace and name of the object of id.
+	ParseID(id string) (namespace, name string, err error)
+}
+
+// NamespacedIDs formats IDs as namespace:name, or name for cluster scoped objects.
+var NamespacedIDs IDStrategy = namespacedIDs{}
+
+type namespacedIDs struct{}
+
+func (namespacedIDs) FormatID(namespace, name, uid string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + ":" + name
+}
+
+func (namespacedIDs) ParseID(id string) (string, string, error) {
+	if namespace, name, ok := strings.Cut(id, ":"); ok {
+		return namespace, name, nil
+	}
+	return "", id, nil
+}
+
+// PrefixedIDs prefixes the IDs of strategy with prefix.
+func PrefixedIDs(prefix string, strategy IDStrategy) IDStrategy {
+	return prefixedIDs{prefix: prefix, strategy: strategy}
+}
+
+type prefixedIDs struct {
+	prefix   string
+	strategy IDStrategy
+}
+
+func (p prefixedIDs) FormatID(namespace, name, uid string) string {
+	return p.prefix + p.strategy.FormatID(namespace, name, uid)
+}
+
+func (p prefixedIDs) ParseID(id string) (string, string, error) {
+	if !strings.HasPrefix(id, p.prefix) {
+		return "", "", fmt.Errorf("invalid id %s: missing prefix %s", id, p.prefix)
+	}
+	return p.strategy.ParseID(strings.TrimPrefix(id, p.prefix))
+}
+
+// SlugIDs formats IDs as opaque URL safe slugs of the namespace and name.
+var SlugIDs IDStrategy = slugIDs{}
+
+type slugIDs struct{}
+
+func (slugIDs) FormatID(namespace, name, uid string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(namespace + "/" + name))
+}
+
+func (slugIDs) ParseID(id string) (string, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid id %s", id)
+	}
+	namespace, name, ok := strings.Cut(string(data), "/")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid id %s", id)
+	}
+	return namespace, name, nil
+}
+
+// UIDIDs formats IDs as the UID of objects, lookup returns the namespace and name of the object with a UID, for
+// instance from an informer indexed by UID.
+func UIDIDs(lookup func(uid string) (namespace, name string, ok bool)) IDStrategy {
+	return uidIDs{lookup: lookup}
+}
+
+type uidIDs struct {
+	lookup func(uid string) (string, string, bool)
+}
+
+func (u uidIDs) FormatID(namespace, name, uid string) string {
+	return uid
+}
+
+func (u uidIDs) ParseID(id string) (string, string, error) {
+	namespace, name, ok := u.lookup(id)
+	if !ok {
+		return "", "", fmt.Errorf("no object with uid %s", id)
+	}
+	return namespace, name, nil
+}
+
+// IDs returns the IDStrategy of the schema.
+func (s *Schema) IDs() IDStrategy {
+	if s != nil && s.IDStrategy != nil {
+		return s.IDStrategy
+	}
+	return NamespacedIDs
+}
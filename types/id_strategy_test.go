@@ -0,0 +1,37 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDStrategies(t *testing.T) {
+	uids := map[string][2]string{"1234": {"ns", "foo"}}
+	strategies := map[string]IDStrategy{
+		"namespaced": NamespacedIDs,
+		"prefixed":   PrefixedIDs("c-", NamespacedIDs),
+		"slug":       SlugIDs,
+		"uid": UIDIDs(func(uid string) (string, string, bool) {
+			v, ok := uids[uid]
+			return v[0], v[1], ok
+		}),
+	}
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			id := strategy.FormatID("ns", "foo", "1234")
+			namespace, name, err := strategy.ParseID(id)
+			require.NoError(t, err)
+			assert.Equal(t, "ns", namespace)
+			assert.Equal(t, "foo", name)
+		})
+	}
+
+	assert.Equal(t, "ns:foo", NamespacedIDs.FormatID("ns", "foo", ""))
+	assert.Equal(t, "foo", NamespacedIDs.FormatID("", "foo", ""))
+	_, _, err := PrefixedIDs("c-", NamespacedIDs).ParseID("foo")
+	assert.Error(t, err)
+	_, _, err = SlugIDs.ParseID("not a slug")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/rancher/norman/types/convert"
 	"github.com/rancher/norman/types/definition"
@@ -46,17 +47,50 @@ type typeMapper struct {
 	subSchemas      map[string]*Schema
 	subArraySchemas map[string]*Schema
 	subMapSchemas   map[string]*Schema
+	schemas         *Schemas
+	version         APIVersion
+	schemaID        string
+
+	// pending holds fields whose type was still being imported when the schema was modified, as is the case
+	// for recursive types. They are resolved on first use.
+	pending     []pendingField
+	resolveOnce sync.Once
+}
+
+type pendingField struct {
+	name      string
+	fieldType string
+	target    map[string]*Schema
+	version   *APIVersion
+	schemas   *Schemas
+}
+
+func (t *typeMapper) resolve() {
+	t.resolveOnce.Do(func() {
+		for _, field := range t.pending {
+			if schema := field.schemas.Schema(field.version, field.fieldType); schema != nil {
+				field.target[field.name] = schema
+			}
+		}
+		t.pending = nil
+	})
 }
 
 func (t *typeMapper) FromInternal(data map[string]interface{}) {
+	t.resolve()
+
 	name, _ := values.GetValueN(data, "metadata", "name").(string)
 	namespace, _ := values.GetValueN(data, "metadata", "namespace").(string)
+	uid, _ := values.GetValueN(data, "metadata", "uid").(string)
 
 	for fieldName, schema := range t.subSchemas {
 		if schema.Mapper == nil {
 			continue
 		}
 		fieldData, _ := data[fieldName].(map[string]interface{})
+		if fieldData == nil {
+			continue
+		}
 		schema.Mapper.FromInternal(fieldData)
 	}
 
@@ -89,19 +123,11 @@ func (t *typeMapper) FromInternal(data map[string]interface{}) {
 	Mappers(t.Mappers).FromInternal(data)
 
 	if data != nil && t.root {
-		if _, ok := data["id"]; ok {
-			if namespace != "" {
-				id, _ := data["id"].(string)
-				data["id"] = namespace + ":" + id
-			}
-		} else {
-			if name != "" {
-				if namespace == "" {
-					data["id"] = name
-				} else {
-					data["id"] = namespace + ":" + name
-				}
-			}
+		if id, ok := data["id"].(string); ok {
+			name = id
+		}
+		if name != "" {
+			data["id"] = t.schemas.Schema(&t.version, t.schemaID).IDs().FormatID(namespace, name, uid)
 		}
 	}
 
@@ -113,6 +139,8 @@ func (t *typeMapper) FromInternal(data map[string]interface{}) {
 }
 
 func (t *typeMapper) ToInternal(data map[string]interface{}) error {
+	t.resolve()
+
 	errors := Errors{}
 	errors.Add(Mappers(t.Mappers).ToInternal(data))
 
@@ -141,6 +169,9 @@ func (t *typeMapper) ToInternal(data map[string]interface{}) error {
 			continue
 		}
 		fieldData, _ := data[fieldName].(map[string]interface{})
+		if fieldData == nil {
+			continue
+		}
 		errors.Add(schema.Mapper.ToInternal(fieldData))
 	}
 
@@ -152,6 +183,9 @@ func (t *typeMapper) ModifySchema(schema *Schema, schemas *Schemas) error {
 	t.subArraySchemas = map[string]*Schema{}
 	t.subMapSchemas = map[string]*Schema{}
 	t.typeName = fmt.Sprintf("%s/schemas/%s", schema.Version.Path, schema.ID)
+	t.schemas = schemas
+	t.version = schema.Version
+	t.schemaID = schema.ID
 
 	mapperSchema := schema
 	if schema.InternalSchema != nil {
@@ -168,9 +202,16 @@ func (t *typeMapper) ModifySchema(schema *Schema, schemas *Schemas) error {
 			targetMap = t.subMapSchemas
 		}
 
-		schema := schemas.Schema(&schema.Version, fieldType)
-		if schema != nil {
-			targetMap[name] = schema
+		if subSchema := schemas.Schema(&schema.Version, fieldType); subSchema != nil {
+			targetMap[name] = subSchema
+		} else if fieldType == schema.ID || schemas.isProcessing(fieldType) {
+			t.pending = append(t.pending, pendingField{
+				name:      name,
+				fieldType: fieldType,
+				target:    targetMap,
+				version:   &schema.Version,
+				schemas:   schemas,
+			})
 		}
 	}
 
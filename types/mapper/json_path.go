@@ -0,0 +1,366 @@
+package mapper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+)
+
+// The Path mappers take JSONPath expressions, like $.spec.containers[*].ports[0].name, made of field names, quoted
+// field names ['a.b'], indexes [0] and wildcards [*] matching all the elements of a list or all the values of a map.
+
+type pathPart struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parsePath(expr string) ([]pathPart, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	var parts []pathPart
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %s: empty field name", expr)
+			}
+			parts = append(parts, pathPart{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path %s: missing ]", expr)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				parts = append(parts, pathPart{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				parts = append(parts, pathPart{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid path %s: invalid index %s", expr, inner)
+				}
+				parts = append(parts, pathPart{index: index, isIndex: true})
+			}
+		default:
+			if len(parts) > 0 {
+				return nil, fmt.Errorf("invalid path %s: unexpected %q", expr, rest[0])
+			}
+			rest = "." + rest
+		}
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("invalid path %s: no field", expr)
+	}
+	return parts, nil
+}
+
+// parseFieldPath parses a path ending with a field name.
+func parseFieldPath(expr string) ([]pathPart, error) {
+	parts, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	if last := parts[len(parts)-1]; last.isIndex || last.wildcard {
+		return nil, fmt.Errorf("invalid path %s: must end with a field name", expr)
+	}
+	return parts, nil
+}
+
+// eachObject calls f with every object matched by parts. Missing fields are created if create is set, missing list
+// elements never are.
+func eachObject(data interface{}, parts []pathPart, create bool, f func(map[string]interface{})) {
+	if len(parts) == 0 {
+		if obj, ok := data.(map[string]interface{}); ok {
+			f(obj)
+		}
+		return
+	}
+
+	part, rest := parts[0], parts[1:]
+	switch {
+	case part.wildcard:
+		switch v := data.(type) {
+		case []interface{}:
+			for _, item := range v {
+				eachObject(item, rest, create, f)
+			}
+		case []map[string]interface{}:
+			for _, item := range v {
+				eachObject(item, rest, create, f)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				eachObject(item, rest, create, f)
+			}
+		}
+	case part.isIndex:
+		switch v := data.(type) {
+		case []interface{}:
+			if part.index < len(v) {
+				eachObject(v[part.index], rest, create, f)
+			}
+		case []map[string]interface{}:
+			if part.index < len(v) {
+				eachObject(v[part.index], rest, create, f)
+			}
+		}
+	default:
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+		next, ok := obj[part.key]
+		if !ok && create && (len(rest) == 0 || !rest[0].isIndex && !rest[0].wildcard) {
+			next = map[string]interface{}{}
+			obj[part.key] = next
+		}
+		eachObject(next, rest, create, f)
+	}
+}
+
+// splitPaths splits from and to after the parts they share, the values of from are moved or copied within each
+// object matched by the shared parts.
+func splitPaths(from, to string) ([]pathPart, []pathPart, []pathPart, error) {
+	fromParts, err := parseFieldPath(from)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	toParts, err := parseFieldPath(to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	shared := 0
+	for shared < len(fromParts)-1 && shared < len(toParts)-1 && fromParts[shared] == toParts[shared] {
+		shared++
+	}
+	for _, part := range fromParts[shared:] {
+		if part.wildcard {
+			return nil, nil, nil, fmt.Errorf("invalid paths %s and %s: wildcards must be shared", from, to)
+		}
+	}
+	for _, part := range toParts[shared:] {
+		if part.wildcard || part.isIndex {
+			return nil, nil, nil, fmt.Errorf("invalid paths %s and %s: indexes of %s must be shared", from, to, to)
+		}
+	}
+	return fromParts[:shared], fromParts[shared:], toParts[shared:], nil
+}
+
+func getPath(data map[string]interface{}, parts []pathPart, remove bool) (interface{}, bool) {
+	var (
+		result interface{}
+		found  bool
+	)
+	eachObject(data, parts[:len(parts)-1], false, func(obj map[string]interface{}) {
+		key := parts[len(parts)-1].key
+		if v, ok := obj[key]; ok && !found {
+			result, found = v, true
+			if remove {
+				delete(obj, key)
+			}
+		}
+	})
+	return result, found
+}
+
+func putPath(data map[string]interface{}, value interface{}, parts []pathPart, onlyIfMissing bool) {
+	eachObject(data, parts[:len(parts)-1], true, func(obj map[string]interface{}) {
+		key := parts[len(parts)-1].key
+		if _, ok := obj[key]; ok && onlyIfMissing {
+			return
+		}
+		obj[key] = value
+	})
+}
+
+func transferPath(data map[string]interface{}, from, to string, remove, onlyIfMissing bool) {
+	shared, fromParts, toParts, err := splitPaths(from, to)
+	if err != nil {
+		return
+	}
+	eachObject(data, shared, false, func(obj map[string]interface{}) {
+		if onlyIfMissing {
+			if _, ok := getPath(obj, toParts, false); ok {
+				return
+			}
+		}
+		if v, ok := getPath(obj, fromParts, remove); ok {
+			putPath(obj, v, toParts, false)
+		}
+	})
+}
+
+// fieldPath returns the field names of parts joined with /, as expected by getField.
+func fieldPath(parts []pathPart) string {
+	var names []string
+	for _, part := range parts {
+		if !part.isIndex && !part.wildcard {
+			names = append(names, part.key)
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+func getPathField(schema *types.Schema, schemas *types.Schemas, expr string) (*types.Schema, string, types.Field, bool, error) {
+	parts, err := parseFieldPath(expr)
+	if err != nil {
+		return nil, "", types.Field{}, false, err
+	}
+	return getField(schema, schemas, fieldPath(parts))
+}
+
+// PathMove moves the value of From to To, both JSONPath expressions. Wildcards and indexes must be in the part the
+// paths share, as in $.spec.containers[*].image to $.spec.containers[*].imageName.
+type PathMove struct {
+	From, To    string
+	DestDefined bool
+}
+
+func (m PathMove) FromInternal(data map[string]interface{}) {
+	transferPath(data, m.From, m.To, true, false)
+}
+
+func (m PathMove) ToInternal(data map[string]interface{}) error {
+	transferPath(data, m.To, m.From, true, false)
+	return nil
+}
+
+func (m PathMove) ModifySchema(s *types.Schema, schemas *types.Schemas) error {
+	if _, _, _, err := splitPaths(m.From, m.To); err != nil {
+		return err
+	}
+	fromSchema, fromName, fromField, ok, err := getPathField(s, schemas, m.From)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("failed to find field %s on schema %s", m.From, s.ID)
+	}
+	toSchema, toName, _, ok, err := getPathField(s, schemas, m.To)
+	if err != nil {
+		return err
+	}
+	if ok && !m.DestDefined {
+		return fmt.Errorf("field %s already exists on schema %s", m.To, s.ID)
+	}
+
+	delete(fromSchema.ResourceFields, fromName)
+	if !m.DestDefined {
+		fromField.CodeName = convert.Capitalize(toName)
+		toSchema.ResourceFields[toName] = fromField
+	}
+	return nil
+}
+
+// PathCopy copies the value of From to To, both JSONPath expressions, like Copy at any depth.
+type PathCopy struct {
+	From, To string
+}
+
+func (c PathCopy) FromInternal(data map[string]interface{}) {
+	transferPath(data, c.From, c.To, false, false)
+}
+
+func (c PathCopy) ToInternal(data map[string]interface{}) error {
+	transferPath(data, c.To, c.From, false, true)
+	return nil
+}
+
+func (c PathCopy) ModifySchema(s *types.Schema, schemas *types.Schemas) error {
+	if _, _, _, err := splitPaths(c.From, c.To); err != nil {
+		return err
+	}
+	_, _, fromField, ok, err := getPathField(s, schemas, c.From)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("field %s missing on schema %s", c.From, s.ID)
+	}
+	toSchema, toName, _, _, err := getPathField(s, schemas, c.To)
+	if err != nil {
+		return err
+	}
+	toSchema.ResourceFields[toName] = fromField
+	return nil
+}
+
+// PathDrop removes the fields matched by the JSONPath expression Path.
+type PathDrop struct {
+	Path             string
+	IgnoreDefinition bool
+}
+
+func (d PathDrop) FromInternal(data map[string]interface{}) {
+	parts, err := parseFieldPath(d.Path)
+	if err != nil {
+		return
+	}
+	eachObject(data, parts[:len(parts)-1], false, func(obj map[string]interface{}) {
+		delete(obj, parts[len(parts)-1].key)
+	})
+}
+
+func (d PathDrop) ToInternal(data map[string]interface{}) error {
+	return nil
+}
+
+func (d PathDrop) ModifySchema(s *types.Schema, schemas *types.Schemas) error {
+	schema, name, _, ok, err := getPathField(s, schemas, d.Path)
+	if err != nil {
+		return err
+	}
+	if !ok && !d.IgnoreDefinition {
+		return fmt.Errorf("can not drop missing field %s on %s", d.Path, s.ID)
+	}
+	delete(schema.ResourceFields, name)
+	return nil
+}
+
+// PathDefault sets the fields matched by the JSONPath expression Path to Value when they are missing.
+type PathDefault struct {
+	Path  string
+	Value interface{}
+}
+
+func (d PathDefault) FromInternal(data map[string]interface{}) {
+	d.setDefault(data)
+}
+
+func (d PathDefault) ToInternal(data map[string]interface{}) error {
+	d.setDefault(data)
+	return nil
+}
+
+func (d PathDefault) setDefault(data map[string]interface{}) {
+	if parts, err := parseFieldPath(d.Path); err == nil {
+		putPath(data, d.Value, parts, true)
+	}
+}
+
+func (d PathDefault) ModifySchema(s *types.Schema, schemas *types.Schemas) error {
+	schema, name, field, ok, err := getPathField(s, schemas, d.Path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("failed to find field %s on schema %s", d.Path, s.ID)
+	}
+	field.Default = d.Value
+	schema.ResourceFields[name] = field
+	return nil
+}
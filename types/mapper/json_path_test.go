@@ -0,0 +1,53 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	parts, err := parsePath("$.spec.containers[*]['a.b'][2]")
+	require.NoError(t, err)
+	assert.Equal(t, []pathPart{
+		{key: "spec"},
+		{key: "containers"},
+		{wildcard: true},
+		{key: "a.b"},
+		{index: 2, isIndex: true},
+	}, parts)
+
+	parts, err = parsePath("spec.name")
+	require.NoError(t, err)
+	assert.Equal(t, []pathPart{{key: "spec"}, {key: "name"}}, parts)
+
+	for _, expr := range []string{"$", "$.spec[", "$.spec[x]", "$..name"} {
+		_, err := parsePath(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestPathMappers(t *testing.T) {
+	data := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"image": "a", "ports": []interface{}{map[string]interface{}{"port": 80}}},
+				map[string]interface{}{"image": "b", "pull": "Always"},
+			},
+		},
+	}
+
+	PathMove{From: "$.spec.containers[*].image", To: "$.spec.containers[*].info.imageName"}.FromInternal(data)
+	PathCopy{From: "$.spec.containers[*].ports[0].port", To: "$.spec.containers[*].port"}.FromInternal(data)
+	PathDefault{Path: "$.spec.containers[*].pull", Value: "IfNotPresent"}.FromInternal(data)
+	PathDrop{Path: "$.spec.containers[*].ports"}.FromInternal(data)
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"info": map[string]interface{}{"imageName": "a"}, "port": 80, "pull": "IfNotPresent"},
+		map[string]interface{}{"info": map[string]interface{}{"imageName": "b"}, "pull": "Always"},
+	}, data["spec"].(map[string]interface{})["containers"])
+
+	require.NoError(t, PathMove{From: "$.spec.containers[*].image", To: "$.spec.containers[*].info.imageName"}.ToInternal(data))
+	assert.Equal(t, "b", data["spec"].(map[string]interface{})["containers"].([]interface{})[1].(map[string]interface{})["image"])
+}
@@ -9,7 +9,6 @@ func NewMetadataMapper() types.Mapper {
 		ChangeType{Field: "name", Type: "dnsLabel"},
 		Drop{Field: "generateName"},
 		Move{From: "uid", To: "uuid", CodeName: "UUID"},
-		Drop{Field: "resourceVersion"},
 		Drop{Field: "generation"},
 		Move{From: "creationTimestamp", To: "created"},
 		Move{From: "deletionTimestamp", To: "removed"},
@@ -18,10 +17,11 @@ func NewMetadataMapper() types.Mapper {
 		ReadOnly{Field: "*"},
 		Access{
 			Fields: map[string]string{
-				"name":        "c",
-				"namespace":   "c",
-				"labels":      "cu",
-				"annotations": "cu",
+				"name":            "c",
+				"namespace":       "c",
+				"labels":          "cu",
+				"annotations":     "cu",
+				"resourceVersion": "u",
 			},
 		},
 	}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// Middleware is called for the requests of every schema before they are routed to the handlers of the schema, it
+// calls next to continue handling the request or returns an error to stop it.
+type Middleware func(apiContext *APIContext, next func() error) error
+
+type middlewareEntry struct {
+	name       string
+	priority   int
+	middleware Middleware
+	exclude    []string
+}
+
+// Use adds middleware, named name, to the requests of the schemas. Middlewares run by increasing priority, and in
+// the order they were added for equal priorities. Requests whose URL path matches one of exclude skip the
+// middleware, a pattern ending with * matches the paths with that prefix. Adding a middleware with the name of
+// another replaces it.
+func (s *Schemas) Use(name string, priority int, middleware Middleware, exclude ...string) *Schemas {
+	s.Lock()
+	defer s.Unlock()
+
+	entry := middlewareEntry{
+		name:       name,
+		priority:   priority,
+		middleware: middleware,
+		exclude:    exclude,
+	}
+	for i, existing := range s.middlewares {
+		if existing.name == name {
+			s.middlewares = append(s.middlewares[:i:i], s.middlewares[i+1:]...)
+			break
+		}
+	}
+	s.middlewares = append(s.middlewares, entry)
+	sort.SliceStable(s.middlewares, func(i, j int) bool {
+		return s.middlewares[i].priority < s.middlewares[j].priority
+	})
+	return s
+}
+
+// UseMiddlewares adds the middlewares of other, replacing those with the same names.
+func (s *Schemas) UseMiddlewares(other *Schemas) *Schemas {
+	if other == s {
+		return s
+	}
+	other.Lock()
+	middlewares := other.middlewares
+	other.Unlock()
+
+	for _, entry := range middlewares {
+		s.Use(entry.name, entry.priority, entry.middleware, entry.exclude...)
+	}
+	return s
+}
+
+// Handle runs the middlewares of the schemas for apiContext, then handler.
+func (s *Schemas) Handle(apiContext *APIContext, handler func() error) error {
+	s.Lock()
+	middlewares := s.middlewares
+	s.Unlock()
+
+	path := ""
+	if apiContext.Request != nil {
+		path = apiContext.Request.URL.Path
+	}
+
+	var next func(i int) error
+	next = func(i int) error {
+		for ; i < len(middlewares); i++ {
+			if !excluded(middlewares[i].exclude, path) {
+				break
+			}
+		}
+		if i >= len(middlewares) {
+			return handler()
+		}
+		return middlewares[i].middleware(apiContext, func() error {
+			return next(i + 1)
+		})
+	}
+	return next(0)
+}
+
+func excluded(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(path, prefix) || pattern == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(apiContext *APIContext, next func() error) error {
+			calls = append(calls, name)
+			return next()
+		}
+	}
+
+	schemas := NewSchemas().
+		Use("late", 10, record("late")).
+		Use("first", 0, record("first")).
+		Use("second", 0, record("second"), "/v3/settings*").
+		Use("readonly", 5, func(apiContext *APIContext, next func() error) error {
+			if apiContext.Method != "GET" {
+				return errors.New("read only")
+			}
+			return next()
+		})
+
+	handled := false
+	handler := func() error {
+		handled = true
+		return nil
+	}
+
+	apiContext := &APIContext{
+		Method:  "GET",
+		Request: httptest.NewRequest("GET", "/v3/clusters", nil),
+	}
+	assert.NoError(t, schemas.Handle(apiContext, handler))
+	assert.True(t, handled)
+	assert.Equal(t, []string{"first", "second", "late"}, calls)
+
+	calls, handled = nil, false
+	apiContext.Request = httptest.NewRequest("GET", "/v3/settings/foo", nil)
+	assert.NoError(t, schemas.Handle(apiContext, handler))
+	assert.Equal(t, []string{"first", "late"}, calls)
+
+	calls, handled = nil, false
+	apiContext.Method = "POST"
+	assert.EqualError(t, schemas.Handle(apiContext, handler), "read only")
+	assert.False(t, handled)
+	assert.Equal(t, []string{"first"}, calls)
+
+	calls = nil
+	schemas.Use("first", 20, record("first"))
+	apiContext.Method = "GET"
+	assert.NoError(t, schemas.Handle(apiContext, handler))
+	assert.Equal(t, []string{"late", "first"}, calls)
+}
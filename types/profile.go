@@ -0,0 +1,118 @@
+package types
+
+import (
+	"net/http"
+)
+
+// Profile selects the schemas, fields and actions exposed by a server, like "public", "internal" or "readonly",
+// see Schemas.Profile.
+type Profile struct {
+	Name string
+	// Include are the IDs of the schemas exposed, every schema if empty, and Exclude the IDs of the schemas hidden.
+	// IDs ending with * match the IDs with that prefix.
+	Include []string
+	Exclude []string
+	// ExcludeFields and ExcludeActions are the fields and the resource and collection actions hidden, by schema ID
+	// or "*" for every schema
+	ExcludeFields  map[string][]string
+	ExcludeActions map[string][]string
+	// Defaults replace the defaults of fields, by schema ID or "*" for every schema, then field name
+	Defaults map[string]map[string]interface{}
+	// ReadOnly hides the create, update and delete methods and the actions of every schema
+	ReadOnly bool
+}
+
+func (p *Profile) includes(schemaID string) bool {
+	if len(p.Include) > 0 && !excluded(p.Include, schemaID) {
+		return false
+	}
+	return !excluded(p.Exclude, schemaID)
+}
+
+func (p *Profile) hides(excludes map[string][]string, name string, schemaIDs ...string) bool {
+	for _, schemaID := range append(schemaIDs, "*") {
+		for _, excluded := range excludes[schemaID] {
+			if excluded == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Profile returns new schemas with copies of the schemas selected by profile, to be served on their own listen
+// address or path prefix.
+func (s *Schemas) Profile(profile Profile) *Schemas {
+	result := NewSchemas()
+	s.Lock()
+	result.middlewares = s.middlewares
+	s.Unlock()
+
+	for _, schema := range s.Schemas() {
+		if !profile.includes(schema.ID) || schema.Embed && !profile.includes(schema.EmbedType) {
+			continue
+		}
+		result.AddSchema(profile.apply(*schema))
+	}
+	return result
+}
+
+func (p *Profile) apply(schema Schema) Schema {
+	// the fields of embedded schemas end up in the schema they embed into
+	schemaIDs := []string{schema.ID}
+	if schema.Embed {
+		schemaIDs = append(schemaIDs, schema.EmbedType)
+	}
+
+	fields := map[string]Field{}
+	for name, field := range schema.ResourceFields {
+		if p.hides(p.ExcludeFields, name, schemaIDs...) {
+			continue
+		}
+		if value, ok := p.fieldDefault(name, schemaIDs); ok {
+			field.Default = value
+		}
+		fields[name] = field
+	}
+	schema.ResourceFields = fields
+	schema.ResourceActions = p.actions(schema.ResourceActions, schemaIDs)
+	schema.CollectionActions = p.actions(schema.CollectionActions, schemaIDs)
+
+	if p.ReadOnly {
+		schema.ResourceMethods = readMethods(schema.ResourceMethods)
+		schema.CollectionMethods = readMethods(schema.CollectionMethods)
+	}
+	return schema
+}
+
+func (p *Profile) fieldDefault(name string, schemaIDs []string) (interface{}, bool) {
+	for _, schemaID := range append(schemaIDs, "*") {
+		if value, ok := p.Defaults[schemaID][name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (p *Profile) actions(actions map[string]Action, schemaIDs []string) map[string]Action {
+	if actions == nil || p.ReadOnly {
+		return nil
+	}
+	result := map[string]Action{}
+	for name, action := range actions {
+		if !p.hides(p.ExcludeActions, name, schemaIDs...) {
+			result[name] = action
+		}
+	}
+	return result
+}
+
+func readMethods(methods []string) []string {
+	var result []string
+	for _, method := range methods {
+		if method == http.MethodGet {
+			result = append(result, method)
+		}
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile(t *testing.T) {
+	version := APIVersion{Group: "management.cattle.io", Version: "v3", Path: "/v3"}
+	schemas := NewSchemas()
+	schemas.AddSchema(Schema{
+		ID:                "cluster",
+		Version:           version,
+		ResourceMethods:   []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+		CollectionMethods: []string{http.MethodGet, http.MethodPost},
+		ResourceFields: map[string]Field{
+			"name":   {Type: "string"},
+			"secret": {Type: "string"},
+		},
+		ResourceActions: map[string]Action{
+			"rotate": {},
+			"backup": {},
+		},
+	})
+	schemas.AddSchema(Schema{ID: "token", Version: version})
+	schemas.AddSchema(Schema{ID: "tokenReview", Version: version})
+
+	public := schemas.Profile(Profile{
+		Name:           "public",
+		Exclude:        []string{"token*"},
+		ExcludeFields:  map[string][]string{"*": {"secret"}},
+		ExcludeActions: map[string][]string{"cluster": {"rotate"}},
+	})
+	assert.Nil(t, public.Schema(&version, "token"))
+	assert.Nil(t, public.Schema(&version, "tokenReview"))
+	cluster := public.Schema(&version, "cluster")
+	assert.Contains(t, cluster.ResourceFields, "name")
+	assert.NotContains(t, cluster.ResourceFields, "secret")
+	assert.Equal(t, map[string]Action{"backup": {}}, cluster.ResourceActions)
+	assert.Contains(t, schemas.Schema(&version, "cluster").ResourceFields, "secret")
+
+	tenant := schemas.Profile(Profile{Name: "acme", Defaults: map[string]map[string]interface{}{"cluster": {"name": "acme"}}})
+	assert.Equal(t, "acme", tenant.Schema(&version, "cluster").ResourceFields["name"].Default)
+	assert.Nil(t, schemas.Schema(&version, "cluster").ResourceFields["name"].Default)
+
+	readOnly := schemas.Profile(Profile{Name: "readonly", Include: []string{"cluster"}, ReadOnly: true})
+	assert.Len(t, readOnly.Schemas(), 1)
+	cluster = readOnly.Schema(&version, "cluster")
+	assert.Equal(t, []string{http.MethodGet}, cluster.ResourceMethods)
+	assert.Equal(t, []string{http.MethodGet}, cluster.CollectionMethods)
+	assert.Empty(t, cluster.ResourceActions)
+}
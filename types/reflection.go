@@ -79,6 +79,7 @@ func (s *Schemas) newSchemaFromType(version *APIVersion, t reflect.Type, typeNam
 		Version:           *version,
 		CodeName:          t.Name(),
 		PkgName:           t.PkgPath(),
+		GoType:            t,
 		ResourceFields:    map[string]Field{},
 		ResourceActions:   map[string]Action{},
 		CollectionActions: map[string]Action{},
@@ -94,6 +95,15 @@ func (s *Schemas) newSchemaFromType(version *APIVersion, t reflect.Type, typeNam
 	return schema, nil
 }
 
+func (s *Schemas) isProcessing(id string) bool {
+	for _, schema := range s.processingTypes {
+		if schema.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Schemas) setupFilters(schema *Schema) {
 	if !slice.ContainsString(schema.CollectionMethods, http.MethodGet) {
 		return
@@ -105,6 +115,10 @@ func (s *Schemas) setupFilters(schema *Schema) {
 			mods = []ModifierType{ModifierEQ, ModifierNE, ModifierIn, ModifierNotIn}
 		case "date":
 			fallthrough
+		case "duration":
+			fallthrough
+		case "quantity":
+			fallthrough
 		case "dnsLabel":
 			fallthrough
 		case "hostname":
@@ -141,6 +155,10 @@ func (s *Schemas) MustCustomizeType(version *APIVersion, obj interface{}, f func
 
 	f(schema)
 
+	s.Lock()
+	s.hash = ""
+	s.Unlock()
+
 	return s
 }
 
@@ -163,6 +181,7 @@ func (s *Schemas) importType(version *APIVersion, t reflect.Type, overrides ...r
 	if err != nil {
 		return nil, err
 	}
+	s.applyResourceInfo(schema)
 
 	mappers := s.mapper(&schema.Version, schema.ID)
 	if s.DefaultMappers != nil {
@@ -179,6 +198,7 @@ func (s *Schemas) importType(version *APIVersion, t reflect.Type, overrides ...r
 		if err != nil {
 			return nil, err
 		}
+		s.applyResourceInfo(copy)
 		schema.InternalSchema = copy
 	}
 
@@ -219,7 +239,44 @@ func k8sObject(field reflect.StructField) bool {
 		strings.HasSuffix(field.Type.PkgPath(), "k8s.io/apimachinery/pkg/apis/meta/v1")
 }
 
+func inline(f reflect.StructField) bool {
+	for _, opt := range strings.Split(f.Tag.Get("json"), ",")[1:] {
+		if opt == "inline" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNormanTag(f reflect.StructField, tag string) bool {
+	for _, part := range strings.Split(f.Tag.Get("norman"), ",") {
+		if part == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten reports whether the fields of a struct field should be read into the parent schema. Anonymous fields
+// without a json name and fields tagged json:",inline" are flattened unless tagged norman:"nested", and any
+// struct field can opt in with norman:"flatten".
+func flatten(f reflect.StructField, jsonName string) bool {
+	if hasNormanTag(f, "nested") {
+		return false
+	}
+	if hasNormanTag(f, "flatten") {
+		return true
+	}
+	return (f.Anonymous && jsonName == "") || inline(f)
+}
+
 func (s *Schemas) readFields(schema *Schema, t reflect.Type) error {
+	return s.readEmbeddedFields(schema, t, map[string]int{}, 0)
+}
+
+// readEmbeddedFields reads the fields of t into schema. depths records the embedding depth each field was read at
+// so that, as with encoding/json, shallower fields win and fields colliding at the same depth are an error.
+func (s *Schemas) readEmbeddedFields(schema *Schema, t reflect.Type, depths map[string]int, depth int) error {
 	if t == resourceType {
 		schema.CollectionMethods = []string{"GET", "POST"}
 		schema.ResourceMethods = []string{"GET", "PUT", "DELETE"}
@@ -249,7 +306,7 @@ func (s *Schemas) readFields(schema *Schema, t reflect.Type) error {
 			hasMeta = true
 		}
 
-		if field.Anonymous && jsonName == "" {
+		if flatten(field, jsonName) {
 			t := field.Type
 			if t.Kind() == reflect.Ptr {
 				t = t.Elem()
@@ -258,11 +315,14 @@ func (s *Schemas) readFields(schema *Schema, t reflect.Type) error {
 				if t == namespacedType {
 					schema.Scope = NamespaceScope
 				}
-				if err := s.readFields(schema, t); err != nil {
+				if err := s.readEmbeddedFields(schema, t, depths, depth+1); err != nil {
 					return err
 				}
+				continue
+			}
+			if field.Anonymous && jsonName == "" {
+				continue
 			}
-			continue
 		}
 
 		fieldName := jsonName
@@ -278,6 +338,18 @@ func (s *Schemas) readFields(schema *Schema, t reflect.Type) error {
 			continue
 		}
 
+		if existing, ok := depths[fieldName]; ok {
+			if existing < depth {
+				logrus.Tracef("Ignoring shadowed field %s.%s for %v", schema.ID, fieldName, field)
+				continue
+			}
+			if existing == depth {
+				return fmt.Errorf("field %s of %s conflicts with another field of the same name in type %s",
+					fieldName, field.Name, t)
+			}
+		}
+		depths[fieldName] = depth
+
 		logrus.Tracef("Inspecting field %s.%s for %v", schema.ID, fieldName, field)
 
 		schemaField := Field{
@@ -305,9 +377,14 @@ func (s *Schemas) readFields(schema *Schema, t reflect.Type) error {
 			schemaField.Default = 0
 		}
 
+		implicitDefault := schemaField.Default
 		if err := applyTag(&field, &schemaField); err != nil {
 			return err
 		}
+		// a scalar tagged nullable has no value unless it is set, instead of its zero value
+		if schemaField.Nullable && implicitDefault != nil && schemaField.Default == implicitDefault {
+			schemaField.Default = nil
+		}
 
 		if schemaField.Type == "" {
 			inferedType, err := s.determineSchemaType(&schema.Version, fieldType)
@@ -388,6 +465,9 @@ func applyTag(structField *reflect.StructField, field *Field) error {
 			field.Options = split(value)
 			if field.Type == "" {
 				field.Type = "enum"
+				if deRef(structField.Type).Kind() == reflect.Slice {
+					field.Type = "array[enum]"
+				}
 			}
 		case "validChars":
 			field.ValidChars = value
@@ -395,6 +475,15 @@ func applyTag(structField *reflect.StructField, field *Field) error {
 			field.InvalidChars = value
 		case "pointer":
 			field.Pointer = true
+		case "readRoles":
+			field.ReadRoles = split(value)
+		case "writeRoles":
+			field.WriteRoles = split(value)
+		case "mergeKey":
+			field.MergeStrategy = MergeStrategyMerge
+			field.MergeKey = value
+		case "flatten", "nested":
+			// handled when reading the struct fields
 		default:
 			return fmt.Errorf("invalid tag %s on field %s", key, structField.Name)
 		}
@@ -496,7 +585,10 @@ func (s *Schemas) determineSchemaType(version *APIVersion, t reflect.Type) (stri
 			return "intOrString", nil
 		}
 		if t.Name() == "Quantity" {
-			return "string", nil
+			return "quantity", nil
+		}
+		if t.Name() == "Duration" {
+			return "duration", nil
 		}
 		schema, err := s.importType(version, t)
 		if err != nil {
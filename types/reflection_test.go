@@ -0,0 +1,115 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testVersion = APIVersion{
+	Group:   "meta.cattle.io",
+	Version: "v1",
+	Path:    "/shire",
+}
+
+type Address struct {
+	Street string `json:"street"`
+	Name   string `json:"name"`
+}
+
+type Contact struct {
+	Name    string  `json:"name"`
+	Inline  Address `json:",inline"`
+	Nested  Phone   `json:"nested" norman:"flatten"`
+	Address `json:",inline" norman:"nested"`
+}
+
+type Phone struct {
+	Name string `json:"name"`
+}
+
+type Conflicting struct {
+	Phone   Phone   `norman:"flatten"`
+	Address Address `norman:"flatten"`
+}
+
+func TestImportEmbedded(t *testing.T) {
+	schemas := NewSchemas()
+	schema, err := schemas.Import(&testVersion, Contact{})
+	require.NoError(t, err)
+
+	assert.Contains(t, schema.ResourceFields, "street")
+	assert.Contains(t, schema.ResourceFields, "name")
+	assert.Equal(t, "address", schema.ResourceFields["address"].Type)
+	assert.NotContains(t, schema.ResourceFields, "inline")
+	assert.NotContains(t, schema.ResourceFields, "nested")
+
+	_, err = NewSchemas().Import(&testVersion, Conflicting{})
+	assert.Error(t, err)
+}
+
+type Tree struct {
+	Name     string          `json:"name"`
+	Parent   *Tree           `json:"parent"`
+	Children map[string]Tree `json:"children"`
+}
+
+type upperMapper struct{}
+
+func (upperMapper) FromInternal(data map[string]interface{}) {
+	if name, ok := data["name"].(string); ok {
+		data["name"] = strings.ToUpper(name)
+	}
+}
+
+func (upperMapper) ToInternal(data map[string]interface{}) error {
+	return nil
+}
+
+func (upperMapper) ModifySchema(schema *Schema, schemas *Schemas) error {
+	return nil
+}
+
+func TestImportRecursive(t *testing.T) {
+	schemas := NewSchemas().AddMapperForType(&testVersion, Tree{}, upperMapper{})
+	schema, err := schemas.Import(&testVersion, Tree{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tree", schema.ResourceFields["parent"].Type)
+	assert.Equal(t, "map[tree]", schema.ResourceFields["children"].Type)
+
+	data := map[string]interface{}{
+		"name":   "root",
+		"parent": map[string]interface{}{"name": "parent"},
+		"children": map[string]interface{}{
+			"a": map[string]interface{}{"name": "child"},
+		},
+	}
+	schema.Mapper.FromInternal(data)
+	assert.Equal(t, "ROOT", data["name"])
+	assert.Equal(t, "PARENT", data["parent"].(map[string]interface{})["name"])
+	assert.Equal(t, "CHILD", data["children"].(map[string]interface{})["a"].(map[string]interface{})["name"])
+}
+
+type Toggles struct {
+	Enabled  *bool `json:"enabled,omitempty"`
+	Flag     bool  `json:"flag"`
+	Optional bool  `json:"optional" norman:"nullable"`
+	Count    int64 `json:"count" norman:"nullable,default=3"`
+}
+
+func TestImportNullable(t *testing.T) {
+	schemas := NewSchemas()
+	schema, err := schemas.Import(&testVersion, Toggles{})
+	require.NoError(t, err)
+
+	assert.True(t, schema.ResourceFields["enabled"].Nullable)
+	assert.Nil(t, schema.ResourceFields["enabled"].Default)
+	assert.False(t, schema.ResourceFields["flag"].Nullable)
+	assert.Equal(t, false, schema.ResourceFields["flag"].Default)
+	assert.True(t, schema.ResourceFields["optional"].Nullable)
+	assert.Nil(t, schema.ResourceFields["optional"].Default)
+	assert.Equal(t, int64(3), schema.ResourceFields["count"].Default)
+}
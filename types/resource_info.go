@@ -0,0 +1,61 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/rancher/wrangler/v3/pkg/name"
+)
+
+// ResourceInfo is how the API server serves a kind.
+type ResourceInfo struct {
+	Plural     string
+	Namespaced bool
+	ShortNames []string
+}
+
+// ResourceInfoFunc returns how the API server serves kind in the group and version of version, false if it
+// doesn't serve it.
+type ResourceInfoFunc func(version *APIVersion, kind string) (ResourceInfo, bool)
+
+// applyResourceInfo sets the scope, plural name and short names of schema from the API server, when it serves
+// the kind of schema, instead of guessing them from the Go type.
+func (s *Schemas) applyResourceInfo(schema *Schema) {
+	if s.ResourceInfo == nil {
+		return
+	}
+	info, ok := s.ResourceInfo(&schema.Version, schema.CodeName)
+	if !ok {
+		return
+	}
+
+	if info.Namespaced {
+		schema.Scope = NamespaceScope
+	} else {
+		schema.Scope = ""
+	}
+	if info.Plural != "" {
+		schema.PluralName = pluralName(schema, info.Plural)
+	}
+	schema.ShortNames = info.ShortNames
+}
+
+// pluralName keeps the camel case of the guessed plural name of schema for the letters it has in common with
+// plural, the lower case resource name.
+func pluralName(schema *Schema, plural string) string {
+	guess := schema.PluralName
+	if guess == "" {
+		guess = name.GuessPluralName(schema.ID)
+	}
+	if strings.EqualFold(guess, plural) {
+		return guess
+	}
+
+	result := []byte(plural)
+	for i := 0; i < len(result) && i < len(guess); i++ {
+		if strings.ToLower(guess[i:i+1]) != plural[i:i+1] {
+			break
+		}
+		result[i] = guess[i]
+	}
+	return string(result)
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/norman/types/convert"
+)
+
+// Rule is a validation constraint spanning several fields of a resource. On update a rule is only checked if
+// the input sets at least one of its fields.
+type Rule struct {
+	Fields  []string
+	Message string
+	Check   func(data map[string]interface{}) bool
+}
+
+// LessOrEqual requires the numeric value of field a to be no greater than field b when both are set.
+func LessOrEqual(a, b string) Rule {
+	return Rule{
+		Fields:  []string{a, b},
+		Message: fmt.Sprintf("%s must be less than or equal to %s", a, b),
+		Check: func(data map[string]interface{}) bool {
+			if data[a] == nil || data[b] == nil {
+				return true
+			}
+			aVal, aErr := convert.ToFloat(data[a])
+			bVal, bErr := convert.ToFloat(data[b])
+			return aErr != nil || bErr != nil || aVal <= bVal
+		},
+	}
+}
+
+// ExactlyOneOf requires exactly one of fields to be set.
+func ExactlyOneOf(fields ...string) Rule {
+	return Rule{
+		Fields:  fields,
+		Message: "exactly one of " + strings.Join(fields, ", ") + " must be set",
+		Check: func(data map[string]interface{}) bool {
+			return countSet(data, fields) == 1
+		},
+	}
+}
+
+// AtMostOneOf allows at most one of fields to be set.
+func AtMostOneOf(fields ...string) Rule {
+	return Rule{
+		Fields:  fields,
+		Message: "at most one of " + strings.Join(fields, ", ") + " can be set",
+		Check: func(data map[string]interface{}) bool {
+			return countSet(data, fields) <= 1
+		},
+	}
+}
+
+// RequiredWith requires all of fields to be set if field is set.
+func RequiredWith(field string, fields ...string) Rule {
+	return Rule{
+		Fields:  append([]string{field}, fields...),
+		Message: strings.Join(fields, ", ") + " must be set when " + field + " is set",
+		Check: func(data map[string]interface{}) bool {
+			if convert.IsAPIObjectEmpty(data[field]) {
+				return true
+			}
+			return countSet(data, fields) == len(fields)
+		},
+	}
+}
+
+func countSet(data map[string]interface{}, fields []string) int {
+	count := 0
+	for _, field := range fields {
+		if !convert.IsAPIObjectEmpty(data[field]) {
+			count++
+		}
+	}
+	return count
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/types/slice"
@@ -71,3 +72,11 @@ func (s *Schema) CanDelete(context *APIContext) error {
 	}
 	return context.AccessControl.CanDelete(context, nil, s)
 }
+
+// Timeout returns the time handlers have to respond to requests with method, 0 if they are not bounded.
+func (s *Schema) Timeout(method string) time.Duration {
+	if timeout, ok := s.Timeouts[method]; ok {
+		return timeout
+	}
+	return s.Timeouts["*"]
+}
@@ -2,6 +2,9 @@ package types
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"slices"
@@ -41,7 +44,12 @@ type Schemas struct {
 	versions           []APIVersion
 	schemas            []*Schema
 	AddHook            SchemaHook
-	errors             []error
+	// ResourceInfo, if set, is used to set the scope and names of the schemas imported from types
+	ResourceInfo ResourceInfoFunc
+	errors       []error
+	hash         string
+	generation   uint64
+	middlewares  []middlewareEntry
 }
 
 func NewSchemas() *Schemas {
@@ -77,6 +85,8 @@ func (s *Schemas) RemoveSchema(schema Schema) *Schemas {
 }
 
 func (s *Schemas) doRemoveSchema(schema Schema) *Schemas {
+	s.hash = ""
+	s.generation++
 	delete(s.schemasByPath[schema.Version.Path], schema.ID)
 
 	s.removeReferences(&schema)
@@ -122,7 +132,23 @@ func (s *Schemas) ForceAddSchema(schema Schema) *Schemas {
 	return s.doAddSchema(schema, true)
 }
 
+// ReplaceSchema adds schema in place of the schema with the same ID and version, which it returns, nil if there was
+// none. Unlike ForceAddSchema, the schema is replaced regardless of its DynamicSchemaVersion.
+func (s *Schemas) ReplaceSchema(schema Schema) *Schema {
+	s.Lock()
+	defer s.Unlock()
+
+	existing := s.schemasByPath[schema.Version.Path][schema.ID]
+	if existing != nil {
+		s.doRemoveSchema(*existing)
+	}
+	s.doAddSchema(schema, false)
+	return existing
+}
+
 func (s *Schemas) doAddSchema(schema Schema, replace bool) *Schemas {
+	s.hash = ""
+	s.generation++
 	s.setupDefaults(&schema)
 
 	if s.AddHook != nil {
@@ -276,6 +302,39 @@ func (s *Schemas) SchemasForVersion(version APIVersion) map[string]*Schema {
 	return s.schemasByPath[version.Path]
 }
 
+// Generation returns a number that increases whenever a schema is added, replaced or removed, unlike Hash even
+// when only its store or handlers change.
+func (s *Schemas) Generation() uint64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.generation
+}
+
+// Hash returns a digest of all registered schemas that changes whenever a schema is added, replaced or removed.
+func (s *Schemas) Hash() string {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.hash != "" {
+		return s.hash
+	}
+
+	schemas := slices.Clone(s.schemas)
+	slices.SortFunc(schemas, func(a, b *Schema) int {
+		return strings.Compare(a.Version.Path+"/"+a.ID, b.Version.Path+"/"+b.ID)
+	})
+
+	digest := sha256.New()
+	encoder := json.NewEncoder(digest)
+	for _, schema := range schemas {
+		if err := encoder.Encode(schema); err != nil {
+			fmt.Fprintf(digest, "%s/%s", schema.Version.Path, schema.ID)
+		}
+	}
+	s.hash = hex.EncodeToString(digest.Sum(nil))[:16]
+	return s.hash
+}
+
 func (s *Schemas) Versions() []APIVersion {
 	return s.versions
 }
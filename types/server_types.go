@@ -7,6 +7,13 @@ import (
 	"net/url"
 )
 
+// RequestIDHeader is the header used to receive and propagate the ID of an API request.
+const RequestIDHeader = "X-Request-Id"
+
+// AuditIDHeader sets the ID of the audit events of a request to the apiserver, requests to the apiserver send the
+// ID of the API request in it so that the audit log records it.
+const AuditIDHeader = "Audit-ID"
+
 type ValuesMap struct {
 	Foo map[string]interface{}
 }
@@ -100,6 +107,34 @@ type AccessControl interface {
 	FilterList(apiContext *APIContext, schema *Schema, obj []map[string]interface{}, context map[string]string) []map[string]interface{}
 }
 
+// ActionAccessControl is implemented by access controls that decide which actions are available to the user.
+type ActionAccessControl interface {
+	CanAction(apiContext *APIContext, obj map[string]interface{}, schema *Schema, action string) error
+}
+
+// Authenticator identifies the user making an API request. A nil user without an error is an anonymous request.
+type Authenticator interface {
+	Authenticate(req *http.Request) (*User, error)
+}
+
+// User is the authenticated user of an API request.
+type User struct {
+	Name   string
+	Groups []string
+	// Scopes limit the schemas and verbs the user can access. A user without scopes is not limited.
+	Scopes []TokenScope
+}
+
+// RoleResolver returns the roles of the user of an API request, which grant access to the fields with ReadRoles or
+// WriteRoles. Without a resolver the groups of the user are its roles.
+type RoleResolver func(apiContext *APIContext) []string
+
+// TokenScope allows Verbs, or all verbs if it is empty, on the schema with ID Schema, or on every schema for "*".
+type TokenScope struct {
+	Schema string   `json:"schema,omitempty"`
+	Verbs  []string `json:"verbs,omitempty"`
+}
+
 type APIContext struct {
 	Action                      string
 	ID                          string
@@ -120,9 +155,17 @@ type APIContext struct {
 	AccessControl               AccessControl
 	SubContext                  map[string]string
 	Pagination                  *Pagination
+	RequestID                   string
+	User                        *User
+	RoleResolver                RoleResolver
+	// DryRun is true for creates, updates and deletes with the dryRun query parameter, which must not be persisted
+	DryRun bool
 
 	Request  *http.Request
 	Response http.ResponseWriter
+
+	// values are attached with ContextKey
+	values *contextValues
 }
 
 type apiContextKey struct{}
@@ -142,6 +185,31 @@ func GetAPIContext(ctx context.Context) *APIContext {
 	return apiContext
 }
 
+// HasAnyRole returns whether the user of the request has one of roles, true if roles is empty.
+func (r *APIContext) HasAnyRole(roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	if r == nil {
+		return false
+	}
+
+	var userRoles []string
+	if r.RoleResolver != nil {
+		userRoles = r.RoleResolver(r)
+	} else if r.User != nil {
+		userRoles = r.User.Groups
+	}
+	for _, role := range userRoles {
+		for _, required := range roles {
+			if role == required {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (r *APIContext) Option(key string) string {
 	return r.Query.Get("_" + key)
 }
@@ -236,3 +304,14 @@ type Store interface {
 	Delete(apiContext *APIContext, schema *Schema, id string) (map[string]interface{}, error)
 	Watch(apiContext *APIContext, schema *Schema, opt *QueryOptions) (chan map[string]interface{}, error)
 }
+
+// Dependent is an object referencing a deleted object, which is deleted with it or orphaned by clearing Field.
+type Dependent struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Field  string `json:"field"`
+	Action string `json:"action"`
+}
+
+// DryRunDependents are the dependents a dry run delete would delete or orphan, reported with the deleted object.
+var DryRunDependents = NewContextKey[[]Dependent]("dryRunDependents")
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"reflect"
+	"time"
+)
+
 const (
 	ResourceFieldID = "id"
 )
@@ -85,6 +90,9 @@ type APIVersion struct {
 	Path             string `json:"path,omitempty"`
 	SubContext       bool   `json:"subContext,omitempty"`
 	SubContextSchema string `json:"filterField,omitempty"`
+	// Scopes lists the IDs of schemas whose resources can scope nested collections, as in
+	// <path>/namespaces/<name>/<type>. Requests to a nested collection are filtered by the parent.
+	Scopes []string `json:"scopes,omitempty"`
 }
 
 type Namespaced struct{}
@@ -94,27 +102,31 @@ var NamespaceScope TypeScope = "namespace"
 type TypeScope string
 
 type Schema struct {
-	ID                   string            `json:"id,omitempty"`
-	Embed                bool              `json:"embed,omitempty"`
-	EmbedType            string            `json:"embedType,omitempty"`
-	CodeName             string            `json:"-"`
-	CodeNamePlural       string            `json:"-"`
-	PkgName              string            `json:"-"`
-	Type                 string            `json:"type,omitempty"`
-	BaseType             string            `json:"baseType,omitempty"`
-	Links                map[string]string `json:"links"`
-	Version              APIVersion        `json:"version"`
-	PluralName           string            `json:"pluralName,omitempty"`
-	ResourceMethods      []string          `json:"resourceMethods,omitempty"`
-	ResourceFields       map[string]Field  `json:"resourceFields"`
-	ResourceActions      map[string]Action `json:"resourceActions,omitempty"`
-	CollectionMethods    []string          `json:"collectionMethods,omitempty"`
-	CollectionFields     map[string]Field  `json:"collectionFields,omitempty"`
-	CollectionActions    map[string]Action `json:"collectionActions,omitempty"`
-	CollectionFilters    map[string]Filter `json:"collectionFilters,omitempty"`
-	DynamicSchemaVersion string            `json:"dynamicSchemaVersion,omitempty"`
-	Scope                TypeScope         `json:"-"`
-	Enabled              func() bool       `json:"-"`
+	ID                   string                 `json:"id,omitempty"`
+	Embed                bool                   `json:"embed,omitempty"`
+	EmbedType            string                 `json:"embedType,omitempty"`
+	CodeName             string                 `json:"-"`
+	CodeNamePlural       string                 `json:"-"`
+	PkgName              string                 `json:"-"`
+	Type                 string                 `json:"type,omitempty"`
+	BaseType             string                 `json:"baseType,omitempty"`
+	Links                map[string]string      `json:"links"`
+	Version              APIVersion             `json:"version"`
+	PluralName           string                 `json:"pluralName,omitempty"`
+	ShortNames           []string               `json:"shortNames,omitempty"`
+	ResourceMethods      []string               `json:"resourceMethods,omitempty"`
+	ResourceFields       map[string]Field       `json:"resourceFields"`
+	ResourceActions      map[string]Action      `json:"resourceActions,omitempty"`
+	CollectionMethods    []string               `json:"collectionMethods,omitempty"`
+	CollectionFields     map[string]Field       `json:"collectionFields,omitempty"`
+	CollectionActions    map[string]Action      `json:"collectionActions,omitempty"`
+	CollectionFilters    map[string]Filter      `json:"collectionFilters,omitempty"`
+	SubResources         map[string]SubResource `json:"subResources,omitempty"`
+	DynamicSchemaVersion string                 `json:"dynamicSchemaVersion,omitempty"`
+	RequireVersion       bool                   `json:"requireVersion,omitempty"`
+	DryRun               bool                   `json:"dryRun,omitempty"`
+	Scope                TypeScope              `json:"-"`
+	Enabled              func() bool            `json:"-"`
 
 	InternalSchema      *Schema             `json:"-"`
 	Mapper              Mapper              `json:"-"`
@@ -129,7 +141,26 @@ type Schema struct {
 	CollectionFormatter CollectionFormatter `json:"-"`
 	ErrorHandler        ErrorHandler        `json:"-"`
 	Validator           Validator           `json:"-"`
+	Rules               []Rule              `json:"-"`
 	Store               Store               `json:"-"`
+	// IDStrategy formats and parses the IDs of the objects, NamespacedIDs if not set
+	IDStrategy IDStrategy `json:"-"`
+	// DefaultSort is the sort of collections that don't set the sort query parameter
+	DefaultSort *Sort `json:"-"`
+	// DefaultFilters are added to the filters of collections, unless the all query parameter is true or the field is
+	// filtered by the query, to hide system objects for instance
+	DefaultFilters []*QueryCondition `json:"-"`
+	// Timeouts bound the time handlers have to respond, by HTTP method or "*" for every method
+	Timeouts map[string]time.Duration `json:"-"`
+	// SummaryFields are the only fields of the objects in collection responses unless the view query parameter is
+	// full, to shrink the lists of heavy types; single objects are always complete. They are part of the schema
+	// resources for clients to know which fields collections return
+	SummaryFields []string `json:"summaryFields,omitempty"`
+	// StatusSubresource declares the status subresource in the CRD of the schema, the type must have a spec and a
+	// status and its generated client updates them apart with UpdateSpec and UpdateStatus
+	StatusSubresource bool `json:"-"`
+	// GoType is the type the schema was imported from, nil for schemas not imported from a Go type
+	GoType reflect.Type `json:"-"`
 }
 
 type Field struct {
@@ -151,11 +182,52 @@ type Field struct {
 	CodeName     string      `json:"-"`
 	DynamicField bool        `json:"dynamicField,omitempty"`
 	Pointer      bool        `json:"pointer,omitempty"`
+	// ReadRoles and WriteRoles restrict reading and writing the field to users with any of the roles
+	ReadRoles  []string `json:"readRoles,omitempty"`
+	WriteRoles []string `json:"writeRoles,omitempty"`
+	// MergeStrategy is how updates change an array field, replaced as a whole by default. Arrays merged by
+	// MergeKey keep the elements missing from the update, unless the replace query option is true.
+	MergeStrategy MergeStrategy `json:"mergeStrategy,omitempty"`
+	MergeKey      string        `json:"mergeKey,omitempty"`
+}
+
+type MergeStrategy string
+
+const (
+	// MergeStrategyReplace replaces the existing array with the array of the update
+	MergeStrategyReplace MergeStrategy = "replace"
+	// MergeStrategyMerge merges the elements of the update into the existing ones with the same MergeKey, and
+	// appends the others. Elements with the PatchDirective "delete" remove the existing ones with their key.
+	MergeStrategyMerge MergeStrategy = "merge"
+
+	// PatchDirective is the key of the directive of an element of an array merged by key, like
+	// {"name": "FOO", "$patch": "delete"}
+	PatchDirective = "$patch"
+	PatchDelete    = "delete"
+)
+
+// SubResource is served by Handler at <resource>/<name>. Handlers write the response themselves, so they can
+// stream it or upgrade the connection to a websocket. Methods defaults to GET.
+type SubResource struct {
+	Methods []string       `json:"methods,omitempty"`
+	Handler RequestHandler `json:"-"`
 }
 
 type Action struct {
 	Input  string `json:"input,omitempty"`
 	Output string `json:"output,omitempty"`
+	// Permissions are the Kubernetes verbs required to perform the action, checked by access controls like
+	// authorization.SARAccess instead of update on the resource of the schema
+	Permissions []ActionPermission `json:"-"`
+}
+
+// ActionPermission is a verb on a resource required to perform an action. An empty Resource is the resource of
+// the schema, checked on the object the action is performed on; other resources are checked in its namespace.
+type ActionPermission struct {
+	Verb        string
+	Group       string
+	Resource    string
+	Subresource string
 }
 
 type Filter struct {
@@ -100,7 +100,7 @@ func (u *urlBuilder) Link(linkName string, resource *types.RawResource) string {
 		return self + "/" + strings.ToLower(linkName)
 	}
 
-	return u.constructBasicURL(resource.Schema.Version, resource.Schema.PluralName, resource.ID, strings.ToLower(linkName))
+	return u.constructBasicURL(resource.Schema.Version, resource.Schema.PluralName, url.PathEscape(resource.ID), strings.ToLower(linkName))
 }
 
 func (u *urlBuilder) ResourceLink(resource *types.RawResource) string {
@@ -108,7 +108,7 @@ func (u *urlBuilder) ResourceLink(resource *types.RawResource) string {
 		return ""
 	}
 
-	return u.constructBasicURL(resource.Schema.Version, resource.Schema.PluralName, resource.ID)
+	return u.constructBasicURL(resource.Schema.Version, resource.Schema.PluralName, url.PathEscape(resource.ID))
 }
 
 func (u *urlBuilder) Marker(marker string) string {
@@ -177,7 +177,7 @@ func (u *urlBuilder) FilterLink(schema *types.Schema, fieldName string, value st
 }
 
 func (u *urlBuilder) ResourceLinkByID(schema *types.Schema, id string) string {
-	return u.constructBasicURL(schema.Version, schema.PluralName, id)
+	return u.constructBasicURL(schema.Version, schema.PluralName, url.PathEscape(id))
 }
 
 func (u *urlBuilder) constructBasicURL(version types.APIVersion, parts ...string) string {
@@ -231,7 +231,7 @@ func parseResponseURLBase(requestURL string, r *http.Request) (string, error) {
 }
 
 func (u *urlBuilder) Action(action string, resource *types.RawResource) string {
-	return u.constructBasicURL(resource.Schema.Version, resource.Schema.PluralName, resource.ID) + "?action=" + url.QueryEscape(action)
+	return u.constructBasicURL(resource.Schema.Version, resource.Schema.PluralName, url.PathEscape(resource.ID)) + "?action=" + url.QueryEscape(action)
 }
 
 func (u *urlBuilder) CollectionAction(schema *types.Schema, versionOverride *types.APIVersion, action string) string {
@@ -240,5 +240,5 @@ func (u *urlBuilder) CollectionAction(schema *types.Schema, versionOverride *typ
 }
 
 func (u *urlBuilder) ActionLinkByID(schema *types.Schema, id string, action string) string {
-	return u.constructBasicURL(schema.Version, schema.PluralName, id) + "?action=" + url.QueryEscape(action)
+	return u.constructBasicURL(schema.Version, schema.PluralName, url.PathEscape(id)) + "?action=" + url.QueryEscape(action)
 }